@@ -74,11 +74,11 @@ func main() {
 
 	defer db.GetDB().Close()
 
-	// Initialize S3 service (optional - only if AWS credentials are set)
-	if err := handlers.InitS3Service(); err != nil {
-		log.Printf("Warning: S3 service not initialized: %v (file uploads will not work)", err)
+	// Initialize file storage (S3 when configured, otherwise the local disk)
+	if err := handlers.InitBlobStore(); err != nil {
+		log.Printf("Warning: file storage not initialized: %v (file uploads will not work)", err)
 	} else {
-		log.Println("S3 service initialized successfully")
+		log.Println("File storage initialized successfully")
 	}
 
 	//initialize email service'
@@ -89,6 +89,21 @@ func main() {
 		log.Println("Email service initialized successfully")
 	}
 
+	// SMTP is the email fallback when SendGrid is not configured
+	if err := utils.InitSMTPService(); err != nil {
+		log.Printf("Note: SMTP service not initialized: %v", err)
+	} else {
+		log.Println("SMTP service initialized successfully")
+	}
+
+	// The in-app notification channel pushes over the chat hub, so it is created before the scheduler
+	hub := WS.NewHub()
+	go hub.Run()
+
+	services.InitNotifiers(hub)
+	services.InitWorkflows(hub)
+	services.InitStorageQuotas(hub)
+
 	//initialize gocron scheduler
 	scheduler, err := gocron.NewScheduler()
 	if err != nil {
@@ -101,6 +116,10 @@ func main() {
 		gocron.DurationJob(time.Minute),
 		gocron.NewTask(func() {
 			ctx := context.Background()
+			// Materialize the next occurrence of recurring tasks that were completed or are past due
+			if err := services.MaterializeRecurringTasks(ctx); err != nil {
+				log.Printf("Error materializing recurring tasks: %v", err)
+			}
 			// Check for due dates and reminders, create pending notifications
 			if err := services.CheckDueDatesAndReminders(ctx); err != nil {
 				log.Printf("Error checking due dates and reminders: %v", err)
@@ -117,6 +136,81 @@ func main() {
 
 	log.Printf("Notification sender job scheduled: %s", job.ID())
 
+	// Move invitations past their expiry to the expired status
+	invitationJob, err := scheduler.NewJob(
+		gocron.DurationJob(15*time.Minute),
+		gocron.NewTask(func() {
+			if err := services.ExpireStaleInvitations(context.Background()); err != nil {
+				log.Printf("Error expiring invitations: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Error scheduling invitation expiry job: %v", err)
+	}
+
+	log.Printf("Invitation expiry job scheduled: %s", invitationJob.ID())
+
+	// Drop sessions whose refresh token expired
+	sessionJob, err := scheduler.NewJob(
+		gocron.DurationJob(time.Hour),
+		gocron.NewTask(func() {
+			if err := services.CleanupExpiredSessions(context.Background()); err != nil {
+				log.Printf("Error cleaning up expired sessions: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Error scheduling session cleanup job: %v", err)
+	}
+
+	log.Printf("Session cleanup job scheduled: %s", sessionJob.ID())
+
+	// Discard the content of upload sessions that were never completed
+	uploadJob, err := scheduler.NewJob(
+		gocron.DurationJob(time.Hour),
+		gocron.NewTask(func() {
+			if err := services.CleanupExpiredUploads(context.Background()); err != nil {
+				log.Printf("Error cleaning up expired uploads: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Error scheduling upload cleanup job: %v", err)
+	}
+
+	log.Printf("Upload cleanup job scheduled: %s", uploadJob.ID())
+
+	// Purge what has been in the trash longer than the retention period
+	trashJob, err := scheduler.NewJob(
+		gocron.DurationJob(time.Hour),
+		gocron.NewTask(func() {
+			if err := services.PurgeExpiredTrash(context.Background()); err != nil {
+				log.Printf("Error purging trash: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Error scheduling trash purge job: %v", err)
+	}
+
+	log.Printf("Trash purge job scheduled: %s", trashJob.ID())
+
+	// Run task_overdue workflows for tasks that have been overdue long enough
+	workflowJob, err := scheduler.NewJob(
+		gocron.DurationJob(5*time.Minute),
+		gocron.NewTask(func() {
+			if err := services.RunOverdueWorkflows(context.Background()); err != nil {
+				log.Printf("Error running overdue workflows: %v", err)
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Error scheduling overdue workflow job: %v", err)
+	}
+
+	log.Printf("Overdue workflow job scheduled: %s", workflowJob.ID())
+
 	// Start the scheduler
 	scheduler.Start()
 
@@ -131,13 +225,14 @@ func main() {
 	// App Platform uses load balancers, so we need to trust all proxies
 	// This allows X-Forwarded-* headers to work correctly
 	server.SetTrustedProxies(nil) // Trust all proxies in App Platform
-	hub := WS.NewHub()
 	signalingHub := WS.NewSignalingHub()
 	wsHandler := handlers.NewHandler(hub)
 	signalingHandler := handlers.NewSignalingHandler(signalingHub)
+	canvasHub := WS.NewCanvasHub()
+	canvasHandler := handlers.NewCanvasHandler(canvasHub)
 
-	go hub.Run()
 	go signalingHub.Run()
+	go canvasHub.Run()
 
 	// Configure CORS middleware - allow multiple origins from environment
 	corsOrigins := os.Getenv("CORS_ORIGINS")
@@ -176,7 +271,7 @@ func main() {
 
 	server.Use(cors.New(corsConfig))
 
-	routes.SetupRoutes(server, wsHandler, signalingHandler)
+	routes.SetupRoutes(server, wsHandler, signalingHandler, canvasHandler)
 
 	// Check if HTTPS certificates are provided
 	certPath := os.Getenv("CERT_PATH")
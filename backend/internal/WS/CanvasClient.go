@@ -0,0 +1,103 @@
+package WS
+
+import (
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+// maxCanvasMessageSize bounds one incoming frame (a batch of operations)
+const maxCanvasMessageSize = 1 << 20
+
+type CanvasClient struct {
+	Conn     *websocket.Conn
+	Message  chan *CanvasMessage
+	ID       string `json:"id"`
+	CanvasID string `json:"canvasId"`
+	Username string `json:"username"`
+	ReadOnly bool   `json:"readOnly"` // Viewers follow the edits but cannot make any
+}
+
+// CanvasMessage is a frame of the canvas editing protocol.
+//
+// Client to server:
+//   - "op": Ops made on top of Version, identified by ClientOpID; refused for read-only clients
+//   - "sync": asks for everything after Version, e.g. after a reconnect
+//   - "cursor": ephemeral Data (pointer position, selection) relayed to the other editors
+//
+// Server to client:
+//   - "snapshot": the whole document in Data at Version, sent on join and when a sync reaches too far back
+//   - "op": Ops applied by another editor, producing Version
+//   - "ack": the sender's ClientOpID was applied as Version (Ops holds what actually applied)
+//   - "cursor", "peer_joined", "peer_left": presence of the other editors
+//   - "error": Data holds the reason; "canvas_deleted" ends the session
+type CanvasMessage struct {
+	Type       string            `json:"type"`
+	CanvasID   string            `json:"canvas_id"`
+	UserID     string            `json:"user_id"`
+	Username   string            `json:"username"`
+	Version    int64             `json:"version"`
+	ClientOpID string            `json:"client_op_id,omitempty"`
+	Ops        []models.CanvasOp `json:"ops,omitempty"`
+	Data       any               `json:"data,omitempty"`
+	CreatedAt  string            `json:"created_at,omitempty"`
+}
+
+func (c *CanvasClient) WriteMessage() {
+	defer c.Conn.Close()
+
+	ticker := time.NewTicker(54 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message, ok := <-c.Message:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.Conn.WriteJSON(message); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *CanvasClient) ReadMessage(h *CanvasHub) {
+	defer func() {
+		h.UnRegister <- c
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadLimit(maxCanvasMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		var msg CanvasMessage
+		if err := c.Conn.ReadJSON(&msg); err != nil {
+			break
+		}
+
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+		// Identity comes from the connection, never from the frame
+		msg.CanvasID = c.CanvasID
+		msg.UserID = c.ID
+		msg.Username = c.Username
+		msg.CreatedAt = time.Now().Format(time.RFC3339)
+
+		h.Incoming <- &canvasInput{client: c, message: &msg}
+	}
+}
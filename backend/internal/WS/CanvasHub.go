@@ -0,0 +1,468 @@
+package WS
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+const (
+	// canvasSnapshotInterval is how often edited canvases are written back to canvas_data
+	canvasSnapshotInterval = 10 * time.Second
+	// canvasIdleTimeout is how long a saved canvas nobody is editing stays in memory
+	canvasIdleTimeout = 2 * time.Minute
+	// canvasHistorySize is how many applied batches are kept to catch up reconnecting editors
+	canvasHistorySize = 500
+	// maxCanvasOpsPerMessage bounds one batch of operations
+	maxCanvasOpsPerMessage = 200
+)
+
+// CanvasRoom is the live state of a canvas being edited. It is only touched by CanvasHub.Run.
+type CanvasRoom struct {
+	ID       string
+	Clients  map[*CanvasClient]bool
+	Document *models.CanvasDocument
+	Version  int64
+	History  []canvasBatch // Latest batches, oldest first
+
+	savedVersion int64
+	saving       bool
+	emptySince   time.Time
+}
+
+// canvasBatch is one applied "op" message
+type canvasBatch struct {
+	Version  int64
+	UserID   string
+	Username string
+	Ops      []models.CanvasOp
+}
+
+type canvasInput struct {
+	client  *CanvasClient
+	message *CanvasMessage
+}
+
+type canvasLoadResult struct {
+	canvasID string
+	canvas   *models.Canvas
+	err      error
+}
+
+type canvasSaveResult struct {
+	canvasID string
+	version  int64
+	err      error
+}
+
+type canvasSnapshotRequest struct {
+	canvasID string
+	reply    chan *models.Canvas
+}
+
+// CanvasHub serializes the edits of every canvas: operations are applied in arrival order, each
+// batch gets the next version, and edited canvases are snapshotted to the database periodically.
+type CanvasHub struct {
+	Rooms      map[string]*CanvasRoom
+	Register   chan *CanvasClient
+	UnRegister chan *CanvasClient
+	Incoming   chan *canvasInput
+	Close      chan string // Ends the sessions of a deleted canvas
+
+	loading   map[string][]*CanvasClient // Clients waiting for their canvas to load, by canvas ID
+	loaded    chan canvasLoadResult
+	saved     chan canvasSaveResult
+	snapshots chan canvasSnapshotRequest
+}
+
+func NewCanvasHub() *CanvasHub {
+	return &CanvasHub{
+		Rooms:      make(map[string]*CanvasRoom),
+		Register:   make(chan *CanvasClient),
+		UnRegister: make(chan *CanvasClient),
+		Incoming:   make(chan *canvasInput, 256),
+		Close:      make(chan string),
+		loading:    make(map[string][]*CanvasClient),
+		loaded:     make(chan canvasLoadResult, 16),
+		saved:      make(chan canvasSaveResult, 16),
+		snapshots:  make(chan canvasSnapshotRequest),
+	}
+}
+
+// LiveCanvas returns the in-memory document and version of a canvas that is being edited, or nil
+// when nobody has it open. The returned canvas only carries ID, Data and Version.
+func (h *CanvasHub) LiveCanvas(canvasID string) *models.Canvas {
+	reply := make(chan *models.Canvas, 1)
+	h.snapshots <- canvasSnapshotRequest{canvasID: canvasID, reply: reply}
+	return <-reply
+}
+
+func (h *CanvasHub) Run() {
+	ticker := time.NewTicker(canvasSnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case client := <-h.Register:
+			if room, ok := h.Rooms[client.CanvasID]; ok {
+				h.join(room, client)
+				continue
+			}
+
+			// Load the canvas outside the hub so editors of other canvases do not wait on the database
+			waiting, loading := h.loading[client.CanvasID]
+			h.loading[client.CanvasID] = append(waiting, client)
+			if !loading {
+				go h.loadRoom(client.CanvasID)
+			}
+
+		case result := <-h.loaded:
+			waiting := h.loading[result.canvasID]
+			delete(h.loading, result.canvasID)
+			if result.err != nil {
+				log.Printf("Canvas: failed to open canvas %s: %v", result.canvasID, result.err)
+				for _, client := range waiting {
+					h.send(client, h.errorMessage(result.canvasID, "canvas could not be loaded"))
+					close(client.Message)
+				}
+				continue
+			}
+			// Everyone left, or the canvas was deleted, while it loaded
+			if len(waiting) == 0 {
+				continue
+			}
+
+			room := &CanvasRoom{
+				ID:           result.canvasID,
+				Clients:      make(map[*CanvasClient]bool),
+				Document:     result.canvas.Data,
+				Version:      result.canvas.Version,
+				savedVersion: result.canvas.Version,
+			}
+			h.Rooms[room.ID] = room
+			for _, client := range waiting {
+				h.join(room, client)
+			}
+
+		case client := <-h.UnRegister:
+			if waiting := h.loading[client.CanvasID]; slices.Contains(waiting, client) {
+				h.loading[client.CanvasID] = slices.DeleteFunc(waiting, func(c *CanvasClient) bool { return c == client })
+				close(client.Message)
+				continue
+			}
+
+			room, ok := h.Rooms[client.CanvasID]
+			if !ok || !room.Clients[client] {
+				continue
+			}
+
+			delete(room.Clients, client)
+			close(client.Message)
+
+			h.broadcast(room, nil, &CanvasMessage{
+				Type:      "peer_left",
+				CanvasID:  room.ID,
+				UserID:    client.ID,
+				Username:  client.Username,
+				Version:   room.Version,
+				CreatedAt: time.Now().Format(time.RFC3339),
+			})
+
+			// Persist right away when the last editor leaves
+			if len(room.Clients) == 0 {
+				room.emptySince = time.Now()
+				h.saveRoom(room)
+			}
+
+		case input := <-h.Incoming:
+			room, ok := h.Rooms[input.client.CanvasID]
+			if !ok || !room.Clients[input.client] {
+				continue
+			}
+			h.handleMessage(room, input.client, input.message)
+
+		case canvasID := <-h.Close:
+			for _, client := range h.loading[canvasID] {
+				h.send(client, &CanvasMessage{Type: "canvas_deleted", CanvasID: canvasID})
+				close(client.Message)
+			}
+			delete(h.loading, canvasID)
+
+			room, ok := h.Rooms[canvasID]
+			if !ok {
+				continue
+			}
+			for client := range room.Clients {
+				h.send(client, &CanvasMessage{Type: "canvas_deleted", CanvasID: canvasID, Version: room.Version})
+				close(client.Message)
+			}
+			delete(h.Rooms, canvasID)
+
+		case result := <-h.saved:
+			room, ok := h.Rooms[result.canvasID]
+			if !ok {
+				continue
+			}
+			room.saving = false
+			if result.err != nil {
+				log.Printf("Canvas: failed to save canvas %s at version %d: %v", result.canvasID, result.version, result.err)
+				continue
+			}
+			if result.version > room.savedVersion {
+				room.savedVersion = result.version
+			}
+
+		case request := <-h.snapshots:
+			room, ok := h.Rooms[request.canvasID]
+			if !ok {
+				request.reply <- nil
+				continue
+			}
+			document, err := copyCanvasDocument(room.Document)
+			if err != nil {
+				request.reply <- nil
+				continue
+			}
+			request.reply <- &models.Canvas{ID: room.ID, Data: document, Version: room.Version}
+
+		case <-ticker.C:
+			for id, room := range h.Rooms {
+				if room.Version > room.savedVersion {
+					h.saveRoom(room)
+					continue
+				}
+				// Saved and unused: drop it so the next editor loads it fresh from the database
+				if len(room.Clients) == 0 && !room.saving && time.Since(room.emptySince) > canvasIdleTimeout {
+					delete(h.Rooms, id)
+				}
+			}
+		}
+	}
+}
+
+// loadRoom reads the canvas from the database and hands it to Run, which opens its room
+func (h *CanvasHub) loadRoom(canvasID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	canvas := &models.Canvas{ID: canvasID}
+	err := canvas.Get(ctx)
+	h.loaded <- canvasLoadResult{canvasID: canvasID, canvas: canvas, err: err}
+}
+
+// join adds the client to the room, sends it the document and tells the other editors
+func (h *CanvasHub) join(room *CanvasRoom, client *CanvasClient) {
+	room.Clients[client] = true
+	h.send(client, h.snapshotMessage(room))
+	h.broadcast(room, client, &CanvasMessage{
+		Type:      "peer_joined",
+		CanvasID:  room.ID,
+		UserID:    client.ID,
+		Username:  client.Username,
+		Version:   room.Version,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	})
+}
+
+func (h *CanvasHub) handleMessage(room *CanvasRoom, client *CanvasClient, msg *CanvasMessage) {
+	switch msg.Type {
+	case "op":
+		if client.ReadOnly {
+			h.send(client, h.errorMessage(room.ID, "viewers cannot edit the canvas"))
+			return
+		}
+		h.applyOps(room, client, msg)
+
+	case "sync":
+		h.sync(room, client, msg.Version)
+
+	case "cursor":
+		msg.Version = room.Version
+		msg.Ops = nil
+		h.broadcast(room, client, msg)
+
+	default:
+		h.send(client, h.errorMessage(room.ID, fmt.Sprintf("unknown message type %q", msg.Type)))
+	}
+}
+
+// applyOps applies a batch on top of the current document. Batches made on an older version are
+// not rejected: operations merge per element and property, and the ones made obsolete by
+// concurrent edits (e.g. updates to a deleted element) are dropped. The sender gets an ack with
+// the operations that applied; everyone else gets them as an "op" message.
+func (h *CanvasHub) applyOps(room *CanvasRoom, client *CanvasClient, msg *CanvasMessage) {
+	if msg.Version > room.Version {
+		h.send(client, h.errorMessage(room.ID, "version is ahead of the canvas; sync first"))
+		return
+	}
+	if len(msg.Ops) == 0 || len(msg.Ops) > maxCanvasOpsPerMessage {
+		h.send(client, h.errorMessage(room.ID, fmt.Sprintf("a batch needs between 1 and %d ops", maxCanvasOpsPerMessage)))
+		return
+	}
+	for _, op := range msg.Ops {
+		if err := op.Validate(); err != nil {
+			h.send(client, h.errorMessage(room.ID, err.Error()))
+			return
+		}
+	}
+
+	applied := []models.CanvasOp{}
+	for _, op := range msg.Ops {
+		ok, err := room.Document.Apply(op)
+		if err != nil {
+			// Keep what already applied; the sender learns which ops made it from the ack
+			h.send(client, h.errorMessage(room.ID, err.Error()))
+			break
+		}
+		if ok {
+			applied = append(applied, op)
+		}
+	}
+
+	if len(applied) > 0 {
+		room.Version++
+		room.History = append(room.History, canvasBatch{
+			Version:  room.Version,
+			UserID:   client.ID,
+			Username: client.Username,
+			Ops:      applied,
+		})
+		if len(room.History) > canvasHistorySize {
+			room.History = room.History[len(room.History)-canvasHistorySize:]
+		}
+
+		h.broadcast(room, client, &CanvasMessage{
+			Type:      "op",
+			CanvasID:  room.ID,
+			UserID:    client.ID,
+			Username:  client.Username,
+			Version:   room.Version,
+			Ops:       applied,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+
+	h.send(client, &CanvasMessage{
+		Type:       "ack",
+		CanvasID:   room.ID,
+		UserID:     client.ID,
+		Username:   client.Username,
+		Version:    room.Version,
+		ClientOpID: msg.ClientOpID,
+		Ops:        applied,
+		CreatedAt:  msg.CreatedAt,
+	})
+}
+
+// sync replays the batches after version to the client, or sends a snapshot when they are no
+// longer in the history
+func (h *CanvasHub) sync(room *CanvasRoom, client *CanvasClient, version int64) {
+	if version > room.Version || version < 0 {
+		h.send(client, h.snapshotMessage(room))
+		return
+	}
+	if version == room.Version {
+		h.send(client, &CanvasMessage{Type: "ack", CanvasID: room.ID, Version: room.Version})
+		return
+	}
+
+	missing := room.Version - version
+	if missing > int64(len(room.History)) {
+		h.send(client, h.snapshotMessage(room))
+		return
+	}
+
+	for _, batch := range room.History[int64(len(room.History))-missing:] {
+		h.send(client, &CanvasMessage{
+			Type:     "op",
+			CanvasID: room.ID,
+			UserID:   batch.UserID,
+			Username: batch.Username,
+			Version:  batch.Version,
+			Ops:      batch.Ops,
+		})
+	}
+}
+
+// saveRoom writes the current document to the database in the background
+func (h *CanvasHub) saveRoom(room *CanvasRoom) {
+	if room.saving || room.Version <= room.savedVersion {
+		return
+	}
+
+	data, err := json.Marshal(room.Document)
+	if err != nil {
+		log.Printf("Canvas: failed to encode canvas %s: %v", room.ID, err)
+		return
+	}
+
+	room.saving = true
+	canvasID, version := room.ID, room.Version
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := models.SaveCanvasSnapshot(ctx, canvasID, data, version)
+		h.saved <- canvasSaveResult{canvasID: canvasID, version: version, err: err}
+	}()
+}
+
+func (h *CanvasHub) snapshotMessage(room *CanvasRoom) *CanvasMessage {
+	// Marshal now: the document keeps changing after the message is queued
+	data, err := json.Marshal(room.Document)
+	if err != nil {
+		return h.errorMessage(room.ID, "canvas could not be encoded")
+	}
+	return &CanvasMessage{
+		Type:      "snapshot",
+		CanvasID:  room.ID,
+		Version:   room.Version,
+		Data:      json.RawMessage(data),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+func (h *CanvasHub) errorMessage(canvasID string, reason string) *CanvasMessage {
+	return &CanvasMessage{
+		Type:      "error",
+		CanvasID:  canvasID,
+		Data:      map[string]string{"error": reason},
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// broadcast sends to every client of the room except the sender
+func (h *CanvasHub) broadcast(room *CanvasRoom, sender *CanvasClient, msg *CanvasMessage) {
+	for client := range room.Clients {
+		if client != sender {
+			h.send(client, msg)
+		}
+	}
+}
+
+// send never blocks the hub; a client too slow to keep up misses the message and has to sync
+func (h *CanvasHub) send(client *CanvasClient, msg *CanvasMessage) {
+	select {
+	case client.Message <- msg:
+	default:
+	}
+}
+
+// copyCanvasDocument deep-copies a document so it can leave the hub goroutine
+func copyCanvasDocument(document *models.CanvasDocument) (*models.CanvasDocument, error) {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var copied models.CanvasDocument
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	copied.Normalize()
+	return &copied, nil
+}
@@ -0,0 +1,37 @@
+package WS
+
+import (
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+func TestCanvasViewersCannotEdit(t *testing.T) {
+	hub := NewCanvasHub()
+	document := &models.CanvasDocument{}
+	document.Normalize()
+	room := &CanvasRoom{ID: "canvas", Clients: map[*CanvasClient]bool{}, Document: document}
+
+	viewer := &CanvasClient{ID: "viewer", CanvasID: room.ID, ReadOnly: true, Message: make(chan *CanvasMessage, 4)}
+	editor := &CanvasClient{ID: "editor", CanvasID: room.ID, Message: make(chan *CanvasMessage, 4)}
+	room.Clients[viewer] = true
+	room.Clients[editor] = true
+
+	hub.handleMessage(room, viewer, &CanvasMessage{Type: "op", Ops: []models.CanvasOp{{Op: "add", ID: "a"}}})
+	if reply := <-viewer.Message; reply.Type != "error" {
+		t.Errorf("viewer op answered with %q, want an error", reply.Type)
+	}
+	if room.Version != 0 || len(room.Document.Elements) != 0 || len(editor.Message) != 0 {
+		t.Fatalf("viewer op applied: version %d, elements %v", room.Version, room.Document.Elements)
+	}
+
+	// Viewers still follow along and share their cursor
+	hub.handleMessage(room, viewer, &CanvasMessage{Type: "cursor", UserID: viewer.ID})
+	if relayed := <-editor.Message; relayed.Type != "cursor" || relayed.UserID != viewer.ID {
+		t.Errorf("editor got %+v, want the viewer's cursor", relayed)
+	}
+	hub.handleMessage(room, editor, &CanvasMessage{Type: "op", Ops: []models.CanvasOp{{Op: "add", ID: "a"}}})
+	if relayed := <-viewer.Message; relayed.Type != "op" || relayed.Version != 1 {
+		t.Errorf("viewer got %+v, want the editor's op", relayed)
+	}
+}
@@ -0,0 +1,104 @@
+package WS
+
+import (
+	"context"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Chat events broadcast to a room. Every frame is a Message whose Type is one of these and whose
+// Data carries the payload.
+const (
+	EventMessageCreated  = "message.created"  // Data: models.Message
+	EventMessageEdited   = "message.edited"   // Data: models.Message
+	EventMessageDeleted  = "message.deleted"  // Data: MessageDeletedEvent
+	EventReactionAdded   = "reaction.added"   // Data: ReactionEvent
+	EventReactionRemoved = "reaction.removed" // Data: ReactionEvent
+	EventError           = "error"            // Sent to one client only; Content says what went wrong
+)
+
+// Frames clients send on a chat connection: {"type": ..., "data": {...}}. Frames that are not the
+// JSON envelope of a known action are posted as the content of a new message.
+const (
+	actionMessageCreate  = "message.create"  // Data: content, parent_id (optional)
+	actionMessageEdit    = "message.edit"    // Data: message_id, content
+	actionReactionAdd    = "reaction.add"    // Data: message_id, emoji
+	actionReactionRemove = "reaction.remove" // Data: message_id, emoji
+)
+
+type MessageDeletedEvent struct {
+	MessageID   string  `json:"message_id"`
+	ParentID    *string `json:"parent_id,omitempty"`
+	Placeholder bool    `json:"placeholder,omitempty"` // The message had replies; it stays, emptied, as the start of their thread
+}
+
+type ReactionEvent struct {
+	MessageID string `json:"message_id"`
+	UserID    string `json:"user_id"`
+	Emoji     string `json:"emoji"`
+}
+
+// NewMessageCreated wraps a chat message in a message.created frame. The message is also copied to
+// the flat fields, which clients from before typed frames read.
+func NewMessageCreated(msg *models.Message) *Message {
+	return &Message{
+		ID:        msg.ID,
+		RoomID:    msg.RoomID,
+		UserID:    msg.UserID,
+		Content:   msg.Content,
+		Username:  msg.Username,
+		CreatedAt: msg.CreatedAt.Format(time.RFC3339),
+		Type:      EventMessageCreated,
+		Data:      msg,
+	}
+}
+
+// EditMessage replaces the content of a message and tells the room. Callers check the editor wrote it.
+func (h *Hub) EditMessage(ctx context.Context, msg *models.Message, username string, content string) error {
+	if err := msg.Edit(ctx, content); err != nil {
+		return err
+	}
+	h.BroadcastEvent(msg.RoomID, msg.UserID, username, EventMessageEdited, msg)
+	return nil
+}
+
+// ReactToMessage adds or removes a member's reaction and tells the room when that changed anything.
+// It returns false when the member had (or, removing, had not) reacted with that emoji already.
+func (h *Hub) ReactToMessage(ctx context.Context, msg *models.Message, userID string, username string, emoji string, add bool) (bool, error) {
+	var changed bool
+	var err error
+	eventType := EventReactionAdded
+	if add {
+		changed, err = models.AddMessageReaction(ctx, msg.ID, userID, emoji)
+	} else {
+		changed, err = models.RemoveMessageReaction(ctx, msg.ID, userID, emoji)
+		eventType = EventReactionRemoved
+	}
+	if err != nil || !changed {
+		return changed, err
+	}
+
+	h.BroadcastEvent(msg.RoomID, userID, username, eventType, ReactionEvent{
+		MessageID: msg.ID,
+		UserID:    userID,
+		Emoji:     emoji,
+	})
+	return true, nil
+}
+
+// sendError tells one client its frame was refused; it is dropped when the client is not keeping up
+func (c *Client) sendError(text string) {
+	select {
+	case c.Message <- &Message{
+		ID:        uuid.New().String(),
+		RoomID:    c.RoomID,
+		UserID:    c.ID,
+		Content:   text,
+		Type:      EventError,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}:
+	default:
+	}
+}
@@ -2,6 +2,8 @@ package WS
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
@@ -15,6 +17,8 @@ type Client struct {
 	ID       string `json:"id"`
 	RoomID   string `json:"roomId"`
 	Username string `json:"username"`
+
+	away bool // Idle per the client's last presence.update frame; owned by Hub.Run
 }
 
 type Message struct {
@@ -24,6 +28,8 @@ type Message struct {
 	Content   string `json:"content"`
 	Username  string `json:"username"`
 	CreatedAt string `json:"created_at,omitempty"`
+	Type      string `json:"type,omitempty"` // A chat event such as "message.created" (see chat.go), "notification" on notification streams; empty for join/leave notices
+	Data      any    `json:"data,omitempty"`
 }
 
 func (c *Client) WriteMessage() {
@@ -88,35 +94,211 @@ func (c *Client) ReadMessage(h *Hub) {
 		// Reset read deadline after successful read
 		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 
-		now := time.Now()
-		msg := &models.Message{
-			ID:        uuid.New().String(),
-			RoomID:    c.RoomID,
-			UserID:    c.ID,
-			Content:   string(m),
-			Username:  c.Username,
-			CreatedAt: now,
-			UpdatedAt: now,
+		var frame struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(m, &frame); err != nil || !clientActions[frame.Type] {
+			// Plain text frames are new messages, and so is JSON that is not an action, e.g. a
+			// pasted snippet with a "type" field
+			c.postMessage(h, string(m), nil)
+			continue
 		}
+		c.handleFrame(h, frame.Type, frame.Data)
+	}
+}
 
-		// Persist message to database
+// clientActions are the frame types handleFrame carries out
+var clientActions = map[string]bool{
+	actionMessageCreate:  true,
+	actionMessageEdit:    true,
+	actionReactionAdd:    true,
+	actionReactionRemove: true,
+	actionTypingStart:    true,
+	actionTypingStop:     true,
+	actionReadUpdate:     true,
+	actionPresenceUpdate: true,
+}
+
+// handleFrame carries out a typed frame sent by the client; frameType is one of clientActions
+func (c *Client) handleFrame(h *Hub, frameType string, data json.RawMessage) {
+	var payload struct {
+		MessageID string  `json:"message_id"`
+		Content   string  `json:"content"`
+		ParentID  *string `json:"parent_id"`
+		Emoji     string  `json:"emoji"`
+		Status    string  `json:"status"`
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &payload); err != nil {
+			c.sendError("Invalid data for " + frameType)
+			return
+		}
+	}
+
+	switch frameType {
+	case actionMessageCreate:
+		c.postMessage(h, payload.Content, payload.ParentID)
+
+	case actionMessageEdit:
+		if strings.TrimSpace(payload.Content) == "" {
+			c.sendError("Message content is required")
+			return
+		}
+		msg, ok := c.getRoomMessage(payload.MessageID)
+		if !ok {
+			return
+		}
+		if msg.DeletedAt != nil {
+			c.sendError("Message not found")
+			return
+		}
+		if msg.UserID != c.ID {
+			c.sendError("You can only edit your own messages")
+			return
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		err = msg.SaveWithID(ctx)
-		cancel()
-		if err != nil {
-			// Still broadcast even if persistence fails
+		defer cancel()
+		if err := h.EditMessage(ctx, msg, c.Username, payload.Content); err != nil {
+			c.sendError("Failed to edit message")
+		}
+
+	case actionReactionAdd, actionReactionRemove:
+		if !models.ValidReactionEmoji(payload.Emoji) {
+			c.sendError("Invalid emoji")
+			return
+		}
+		msg, ok := c.getRoomMessage(payload.MessageID)
+		if !ok {
+			return
+		}
+		if frameType == actionReactionAdd && msg.DeletedAt != nil {
+			c.sendError("Message not found")
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := h.ReactToMessage(ctx, msg, c.ID, c.Username, payload.Emoji, frameType == actionReactionAdd); err != nil {
+			c.sendError("Failed to update reaction")
 		}
 
-		// Convert to WS.Message for broadcasting (CreatedAt as string)
-		wsMsg := &Message{
-			ID:        msg.ID,
-			RoomID:    msg.RoomID,
-			UserID:    msg.UserID,
-			Content:   msg.Content,
-			Username:  msg.Username,
-			CreatedAt: msg.CreatedAt.Format(time.RFC3339),
+	case actionTypingStart, actionTypingStop:
+		h.Typing(c.RoomID, c.ID, c.Username, payload.ParentID, frameType == actionTypingStart)
+
+	case actionReadUpdate:
+		msg, ok := c.getRoomMessage(payload.MessageID)
+		if !ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, _, err := h.MarkRead(ctx, msg, c.ID, c.Username); err != nil {
+			c.sendError("Failed to mark message read")
 		}
 
-		h.Broadcast <- wsMsg
+	case actionPresenceUpdate:
+		if !c.updatePresence(h, payload.Status) {
+			c.sendError("Invalid presence status")
+		}
+	}
+}
+
+// postMessage saves a new message, a reply when parentID is set, and broadcasts it to the room
+func (c *Client) postMessage(h *Hub, content string, parentID *string) {
+	if strings.TrimSpace(content) == "" {
+		c.sendError("Message content is required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if parentID != nil {
+		rootID, found, err := models.GetThreadRoot(ctx, c.RoomID, *parentID)
+		if err != nil || !found {
+			c.sendError("Parent message not found")
+			return
+		}
+		parentID = &rootID
+	}
+
+	now := time.Now()
+	msg := &models.Message{
+		ID:        uuid.New().String(),
+		RoomID:    c.RoomID,
+		UserID:    c.ID,
+		Content:   content,
+		Username:  c.Username,
+		ParentID:  parentID,
+		Reactions: []models.ReactionSummary{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Persist message to database
+	if err := msg.SaveWithID(ctx); err != nil {
+		// Still broadcast even if persistence fails
+	}
+
+	h.Broadcast <- NewMessageCreated(msg)
+}
+
+// getRoomMessage loads a message of the client's room, telling the client when there is none
+func (c *Client) getRoomMessage(messageID string) (*models.Message, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg := &models.Message{ID: messageID}
+	if _, err := uuid.Parse(messageID); err != nil || msg.GetByID(ctx) != nil || msg.RoomID != c.RoomID {
+		c.sendError("Message not found")
+		return nil, false
+	}
+	return msg, true
+}
+
+// updatePresence marks the connection away or back online; it returns false for other statuses
+func (c *Client) updatePresence(h *Hub, status string) bool {
+	switch status {
+	case PresenceOnline:
+		h.SetAway(c, false)
+	case PresenceAway:
+		h.SetAway(c, true)
+	default:
+		return false
+	}
+	return true
+}
+
+// ReadNotifications keeps a per-user notification stream open. The stream is server-to-client
+// except for presence.update frames; anything else is discarded. Reading is still needed to
+// process pongs and closes.
+func (c *Client) ReadNotifications(h *Hub) {
+	defer func() {
+		h.UnRegisterUser <- c
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		return nil
+	})
+
+	for {
+		_, m, err := c.Conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+
+		var frame struct {
+			Type string `json:"type"`
+			Data struct {
+				Status string `json:"status"`
+			} `json:"data"`
+		}
+		if json.Unmarshal(m, &frame) == nil && frame.Type == actionPresenceUpdate {
+			c.updatePresence(h, frame.Data.Status)
+		}
 	}
 }
@@ -2,15 +2,30 @@ package WS
 
 import (
 	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// broadcastTimeout bounds how long BroadcastEvent waits for Run to take an event
+const broadcastTimeout = 5 * time.Second
+
 type Room struct {
-	ID      string             `json:"id"`
-	Name    string             `json:"name"`
-	Clients map[string]*Client `json:"clients"`
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Clients map[*Client]bool `json:"-"` // Every open connection; a user has one per tab or device
+}
+
+// hasUser tells whether the user still has a connection to the room
+func (r *Room) hasUser(userID string) bool {
+	for cl := range r.Clients {
+		if cl.ID == userID {
+			return true
+		}
+	}
+	return false
 }
 
 type Hub struct {
@@ -19,19 +34,80 @@ type Hub struct {
 	UnRegister chan *Client
 	CreateRoom chan *Room
 	Broadcast  chan *Message
+
+	// Per-user notification streams; a user can have several connections (tabs, devices)
+	Users          map[string]map[*Client]bool
+	RegisterUser   chan *Client
+	UnRegisterUser chan *Client
+	SendToUser     chan *Message // Delivers to every notification stream of Message.UserID
+
+	// Status of users with open connections, read through Presence (see presence.go)
+	presenceMu      sync.RWMutex
+	presence        map[string]string
+	presenceUpdates chan presenceUpdate
+	presenceSeq     uint64 // Numbers the presence changes; owned by Run
+
+	// Presence changes waiting to be announced, oldest first (see announcePresenceLoop)
+	announceMu    sync.Mutex
+	announceQueue []PresenceEvent
+	announceReady chan struct{}
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		Rooms:      make(map[string]*Room),
-		Register:   make(chan *Client),
-		UnRegister: make(chan *Client),
-		CreateRoom: make(chan *Room),
-		Broadcast:  make(chan *Message),
+		Rooms:           make(map[string]*Room),
+		Register:        make(chan *Client),
+		UnRegister:      make(chan *Client),
+		CreateRoom:      make(chan *Room),
+		Broadcast:       make(chan *Message),
+		Users:           make(map[string]map[*Client]bool),
+		RegisterUser:    make(chan *Client),
+		UnRegisterUser:  make(chan *Client),
+		SendToUser:      make(chan *Message),
+		presence:        make(map[string]string),
+		presenceUpdates: make(chan presenceUpdate),
+		announceReady:   make(chan struct{}, 1),
+	}
+}
+
+// NotifyUser pushes an event (e.g. "notification", "invitation", "unread_count") to the user's
+// notification streams. Users without an open stream simply miss the push.
+func (h *Hub) NotifyUser(userID string, eventType string, data any) {
+	h.SendToUser <- &Message{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// BroadcastEvent pushes a typed event (e.g. "board_task_moved") to everyone in the room.
+// Rooms nobody has joined yet are skipped. The event is dropped when the hub does not take it
+// within broadcastTimeout, so callers never pile up.
+func (h *Hub) BroadcastEvent(roomID string, userID string, username string, eventType string, data any) {
+	message := &Message{
+		ID:        uuid.New().String(),
+		RoomID:    roomID,
+		UserID:    userID,
+		Username:  username,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	timer := time.NewTimer(broadcastTimeout)
+	defer timer.Stop()
+	select {
+	case h.Broadcast <- message:
+	case <-timer.C:
+		log.Printf("Dropped %s event for room %s: hub is busy", eventType, roomID)
 	}
 }
 
 func (h *Hub) Run() {
+	go h.announcePresenceLoop()
+
 	for {
 		select {
 		case room := <-h.CreateRoom:
@@ -40,50 +116,91 @@ func (h *Hub) Run() {
 			}
 
 		case cl := <-h.Register:
-			if _, ok := h.Rooms[cl.RoomID]; ok {
-				room := h.Rooms[cl.RoomID]
-
-				if _, ok := room.Clients[cl.ID]; !ok {
-					room.Clients[cl.ID] = cl
-				}
-			}
+			h.register(cl)
 
 		case cl := <-h.UnRegister:
-			if _, ok := h.Rooms[cl.RoomID]; ok {
-				room := h.Rooms[cl.RoomID]
-
-				message := &Message{
-					ID:        uuid.New().String(),
-					RoomID:    cl.RoomID,
-					UserID:    cl.ID,
-					Content:   fmt.Sprintf(`%s has left the chat`, cl.Username),
-					Username:  cl.Username,
-					CreatedAt: time.Now().Format(time.RFC3339),
-				}
+			h.unregister(cl)
 
-				if _, ok := room.Clients[cl.ID]; ok {
-					delete(room.Clients, cl.ID)
+		case m := <-h.Broadcast:
+			h.broadcast(m)
 
-					if len(h.Rooms[cl.RoomID].Clients) > 0 {
-						select {
-						case h.Broadcast <- message:
-						default:
-						}
-					}
+		case cl := <-h.RegisterUser:
+			if _, ok := h.Users[cl.ID]; !ok {
+				h.Users[cl.ID] = make(map[*Client]bool)
+			}
+			h.Users[cl.ID][cl] = true
+			h.refreshPresence(cl.ID)
 
+		case cl := <-h.UnRegisterUser:
+			if clients, ok := h.Users[cl.ID]; ok {
+				if _, ok := clients[cl]; ok {
+					delete(clients, cl)
 					close(cl.Message)
 				}
+				if len(clients) == 0 {
+					delete(h.Users, cl.ID)
+				}
+				h.refreshPresence(cl.ID)
 			}
 
-		case m := <-h.Broadcast:
-			if room, ok := h.Rooms[m.RoomID]; ok {
-				for _, cl := range room.Clients {
-					select {
-					case cl.Message <- m:
-					default:
-					}
+		case m := <-h.SendToUser:
+			for cl := range h.Users[m.UserID] {
+				select {
+				case cl.Message <- m:
+				default:
 				}
 			}
+
+		case update := <-h.presenceUpdates:
+			update.client.away = update.away
+			h.refreshPresence(update.client.ID)
+		}
+	}
+}
+
+// register adds a chat connection to its room. Only called from Run, like unregister and broadcast.
+func (h *Hub) register(cl *Client) {
+	room, ok := h.Rooms[cl.RoomID]
+	if !ok || room.Clients[cl] {
+		return
+	}
+	room.Clients[cl] = true
+	h.refreshPresence(cl.ID)
+}
+
+// unregister removes a chat connection from its room. The room is told the user left once their
+// last connection to it closes.
+func (h *Hub) unregister(cl *Client) {
+	room, ok := h.Rooms[cl.RoomID]
+	if !ok || !room.Clients[cl] {
+		return
+	}
+	delete(room.Clients, cl)
+	close(cl.Message)
+
+	if !room.hasUser(cl.ID) {
+		h.broadcast(&Message{
+			ID:        uuid.New().String(),
+			RoomID:    cl.RoomID,
+			UserID:    cl.ID,
+			Content:   fmt.Sprintf(`%s has left the chat`, cl.Username),
+			Username:  cl.Username,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		})
+	}
+	h.refreshPresence(cl.ID)
+}
+
+// broadcast sends a message to every connection in its room, skipping those that are not keeping up
+func (h *Hub) broadcast(m *Message) {
+	room, ok := h.Rooms[m.RoomID]
+	if !ok {
+		return
+	}
+	for cl := range room.Clients {
+		select {
+		case cl.Message <- m:
+		default:
 		}
 	}
 }
@@ -0,0 +1,80 @@
+package WS
+
+import "testing"
+
+func TestHubKeepsEveryTabOfAUser(t *testing.T) {
+	hub := NewHub()
+	hub.Rooms["group"] = &Room{ID: "group", Clients: map[*Client]bool{}}
+	connect := func(userID string) *Client {
+		cl := &Client{ID: userID, Username: userID, RoomID: "group", Message: make(chan *Message, 4)}
+		hub.register(cl)
+		return cl
+	}
+	firstTab := connect("ada")
+	secondTab := connect("ada")
+	other := connect("grace")
+
+	if len(hub.Rooms["group"].Clients) != 3 {
+		t.Fatalf("room has %d connections, want 3", len(hub.Rooms["group"].Clients))
+	}
+
+	// Closing one tab leaves the user in the room, without a leave notice
+	hub.unregister(firstTab)
+	if _, open := <-firstTab.Message; open {
+		t.Error("closed tab's channel is still open")
+	}
+	if status := hub.Presence([]string{"ada"})["ada"]; status != PresenceOnline {
+		t.Errorf("user with a tab open is %s, want %s", status, PresenceOnline)
+	}
+	if len(other.Message) != 0 {
+		t.Errorf("room told about a tab closing: %+v", <-other.Message)
+	}
+	hub.broadcast(&Message{RoomID: "group", Content: "hello"})
+	if m := <-secondTab.Message; m.Content != "hello" {
+		t.Errorf("open tab got %q, want the broadcast", m.Content)
+	}
+	<-other.Message
+
+	// Unregistering a connection twice changes nothing
+	hub.unregister(firstTab)
+	if !hub.Rooms["group"].Clients[secondTab] {
+		t.Fatal("second tab was removed along with the first")
+	}
+
+	hub.unregister(secondTab)
+	if status := hub.Presence([]string{"ada"})["ada"]; status != PresenceOffline {
+		t.Errorf("user without connections is %s, want %s", status, PresenceOffline)
+	}
+	if m := <-other.Message; m.UserID != "ada" || m.Type != "" {
+		t.Errorf("got %+v, want ada's leave notice", m)
+	}
+}
+
+func TestPresenceAwayNeedsEveryTabIdle(t *testing.T) {
+	hub := NewHub()
+	hub.Rooms["group"] = &Room{ID: "group", Clients: map[*Client]bool{}}
+	firstTab := &Client{ID: "ada", RoomID: "group", Message: make(chan *Message, 4)}
+	secondTab := &Client{ID: "ada", RoomID: "group", Message: make(chan *Message, 4)}
+	hub.register(firstTab)
+	hub.register(secondTab)
+
+	firstTab.away = true
+	hub.refreshPresence("ada")
+	if status := hub.Presence([]string{"ada"})["ada"]; status != PresenceOnline {
+		t.Errorf("one idle tab: %s, want %s", status, PresenceOnline)
+	}
+	secondTab.away = true
+	hub.refreshPresence("ada")
+	if status := hub.Presence([]string{"ada"})["ada"]; status != PresenceAway {
+		t.Errorf("every tab idle: %s, want %s", status, PresenceAway)
+	}
+
+	// Each change was queued for announcement once, in order
+	var statuses []string
+	for _, event := range hub.announceQueue {
+		statuses = append(statuses, event.Status)
+	}
+	if len(statuses) != 2 || statuses[0] != PresenceOnline || statuses[1] != PresenceAway {
+		t.Errorf("announced %v, want [online away]", statuses)
+	}
+}
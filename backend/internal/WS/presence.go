@@ -0,0 +1,186 @@
+package WS
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// Presence of a user, worked out from their open chat and notification connections: online while
+// any connection is active, away while all of them are idle, offline without connections.
+const (
+	PresenceOnline  = "online"
+	PresenceAway    = "away"
+	PresenceOffline = "offline"
+)
+
+// Presence, typing and read events broadcast to a room
+const (
+	EventPresenceChanged = "presence.changed" // Data: PresenceEvent, to every group of the user
+	EventTypingStarted   = "typing.started"   // Data: TypingEvent; clients repeat it while typing and expire it after a few seconds
+	EventTypingStopped   = "typing.stopped"   // Data: TypingEvent
+	EventReadUpdated     = "read.updated"     // Data: models.MessageRead
+)
+
+// Frames clients send besides the chat actions in chat.go
+const (
+	actionPresenceUpdate = "presence.update" // Data: status ("online" or "away"); also accepted on notification streams
+	actionTypingStart    = "typing.start"    // Data: parent_id (optional, typing in a thread)
+	actionTypingStop     = "typing.stop"     // Data: parent_id (optional)
+	actionReadUpdate     = "read.update"     // Data: message_id
+)
+
+// PresenceEvent is a change of a user's status. Changes are announced in the order they happened
+// and Seq increases with each of them, so clients can ignore an event older than one they applied.
+type PresenceEvent struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+	Seq    uint64 `json:"seq"`
+}
+
+type TypingEvent struct {
+	UserID   string  `json:"user_id"`
+	Username string  `json:"username"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+// presenceUpdate marks one connection idle or active again
+type presenceUpdate struct {
+	client *Client
+	away   bool
+}
+
+// SetAway marks the client's connection idle (or active again when away is false)
+func (h *Hub) SetAway(c *Client, away bool) {
+	h.presenceUpdates <- presenceUpdate{client: c, away: away}
+}
+
+// Presence returns the status of each user, offline for users without connections
+func (h *Hub) Presence(userIDs []string) map[string]string {
+	h.presenceMu.RLock()
+	defer h.presenceMu.RUnlock()
+
+	statuses := make(map[string]string, len(userIDs))
+	for _, userID := range userIDs {
+		status, ok := h.presence[userID]
+		if !ok {
+			status = PresenceOffline
+		}
+		statuses[userID] = status
+	}
+	return statuses
+}
+
+// refreshPresence works out the user's status from their connections and announces it when it
+// changed. Only called from Run, which owns the rooms and streams.
+func (h *Hub) refreshPresence(userID string) {
+	status := PresenceOffline
+	consider := func(cl *Client) {
+		if !cl.away {
+			status = PresenceOnline
+		} else if status == PresenceOffline {
+			status = PresenceAway
+		}
+	}
+	for _, room := range h.Rooms {
+		for cl := range room.Clients {
+			if cl.ID == userID {
+				consider(cl)
+			}
+		}
+	}
+	for cl := range h.Users[userID] {
+		consider(cl)
+	}
+
+	h.presenceMu.Lock()
+	previous, ok := h.presence[userID]
+	if !ok {
+		previous = PresenceOffline
+	}
+	if status == PresenceOffline {
+		delete(h.presence, userID)
+	} else {
+		h.presence[userID] = status
+	}
+	h.presenceMu.Unlock()
+
+	if status != previous {
+		h.presenceSeq++
+		h.queuePresence(PresenceEvent{UserID: userID, Status: status, Seq: h.presenceSeq})
+	}
+}
+
+// queuePresence hands a change to announcePresenceLoop without waiting for it, so Run is never
+// held up by the database
+func (h *Hub) queuePresence(event PresenceEvent) {
+	h.announceMu.Lock()
+	h.announceQueue = append(h.announceQueue, event)
+	h.announceMu.Unlock()
+
+	select {
+	case h.announceReady <- struct{}{}:
+	default: // Already signalled
+	}
+}
+
+// announcePresenceLoop announces the queued presence changes one at a time, in the order they
+// happened, so a user's groups never see an older status after a newer one
+func (h *Hub) announcePresenceLoop() {
+	for range h.announceReady {
+		for {
+			h.announceMu.Lock()
+			if len(h.announceQueue) == 0 {
+				h.announceMu.Unlock()
+				break
+			}
+			event := h.announceQueue[0]
+			h.announceQueue = h.announceQueue[1:]
+			h.announceMu.Unlock()
+
+			h.announcePresence(event)
+		}
+	}
+}
+
+// announcePresence tells every group of the user about their new status
+func (h *Hub) announcePresence(event PresenceEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	groupIDs, err := models.GetUserGroupIDs(ctx, event.UserID)
+	if err != nil {
+		log.Printf("Failed to announce presence of user %s: %v", event.UserID, err)
+		return
+	}
+	for _, groupID := range groupIDs {
+		h.BroadcastEvent(groupID, event.UserID, "", EventPresenceChanged, event)
+	}
+}
+
+// Typing tells the room a member started or stopped typing, in a thread when parentID is set.
+// Nothing is stored.
+func (h *Hub) Typing(roomID string, userID string, username string, parentID *string, typing bool) {
+	eventType := EventTypingStopped
+	if typing {
+		eventType = EventTypingStarted
+	}
+	h.BroadcastEvent(roomID, userID, username, eventType, TypingEvent{
+		UserID:   userID,
+		Username: username,
+		ParentID: parentID,
+	})
+}
+
+// MarkRead moves the member's read pointer up to the message and tells the room. It returns false,
+// with nothing broadcast, when the member had read further already.
+func (h *Hub) MarkRead(ctx context.Context, msg *models.Message, userID string, username string) (*models.MessageRead, bool, error) {
+	read, advanced, err := models.MarkMessageRead(ctx, userID, msg.RoomID, msg.ID)
+	if err != nil || !advanced {
+		return read, advanced, err
+	}
+	h.BroadcastEvent(msg.RoomID, userID, username, EventReadUpdated, read)
+	return read, true, nil
+}
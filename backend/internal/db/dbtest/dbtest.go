@@ -0,0 +1,53 @@
+// Package dbtest connects tests to a throwaway Postgres database. Tests using it are skipped unless
+// TEST_DATABASE_URL is set; the database is migrated to the latest schema on first use.
+package dbtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+)
+
+var (
+	setupOnce sync.Once
+	setupErr  error
+)
+
+// Setup points db.GetDB at the test database, skipping the test when none is configured
+func Setup(t testing.TB) {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	setupOnce.Do(func() {
+		if setupErr = db.InitDB(context.Background(), dbURL); setupErr != nil {
+			return
+		}
+
+		migrationDB, err := db.NewMigrationConnection(dbURL)
+		if err != nil {
+			setupErr = err
+			return
+		}
+		defer migrationDB.Close()
+
+		setupErr = db.RunMigrations(migrationDB, migrationsDir())
+	})
+	if setupErr != nil {
+		t.Fatalf("failed to set up test database: %v", setupErr)
+	}
+}
+
+// migrationsDir finds backend/migrations from this file, whatever package the test runs in
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations")
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPassword emails a password reset link. It answers the same way whether or not the email
+// belongs to an account, so it can't be used to find out who is registered.
+// POST /api/auth/forgot-password
+func ForgotPassword(ctx *gin.Context) {
+	var requestBody struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := &models.User{Email: requestBody.Email}
+	if err := user.GetByEmail(); err == nil {
+		go func(user models.User) {
+			emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			if err := services.SendPasswordResetEmail(emailCtx, &user); err != nil {
+				log.Printf("Error sending password reset email to user %s: %v", user.ID, err)
+			}
+		}(*user)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+// ResetPassword sets a new password using an emailed reset token and signs the user out everywhere
+// POST /api/auth/reset-password
+func ResetPassword(ctx *gin.Context) {
+	var requestBody struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reqCtx := ctx.Request.Context()
+
+	token, err := services.ConsumeAccountToken(reqCtx, services.PasswordResetTokenPurpose, requestBody.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := &models.User{ID: token.UserID}
+	err = user.UpdatePassword(reqCtx, requestBody.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if _, err := models.DeleteUserAuths(reqCtx, user.ID); err != nil {
+		log.Printf("Error revoking sessions of user %s after password reset: %v", user.ID, err)
+	}
+
+	// The reset link reached the inbox, which proves ownership of the address it was sent to
+	if err := user.MarkEmailVerified(reqCtx, token.Email); err != nil {
+		log.Printf("Email of user %s not marked verified after password reset: %v", user.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully. Please log in with your new password"})
+}
+
+// VerifyEmail confirms the user's email address with an emailed verification token
+// POST /api/auth/verify-email
+func VerifyEmail(ctx *gin.Context) {
+	var requestBody struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reqCtx := ctx.Request.Context()
+
+	token, err := services.ConsumeAccountToken(reqCtx, services.EmailVerificationTokenPurpose, requestBody.Token)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user := &models.User{ID: token.UserID}
+	err = user.MarkEmailVerified(reqCtx, token.Email)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified successfully", "email_verified_at": user.EmailVerifiedAt})
+}
+
+// ResendVerificationEmail sends a new verification link to the current user's address
+// POST /api/users/me/verify-email
+func ResendVerificationEmail(ctx *gin.Context) {
+	user := &models.User{ID: ctx.GetString("userID")}
+	err := user.Get()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if user.IsEmailVerified() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "email is already verified"})
+		return
+	}
+
+	err = services.SendVerificationEmail(ctx.Request.Context(), user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// sendVerificationEmailAsync emails a verification link without blocking the request
+func sendVerificationEmailAsync(user models.User) {
+	go func() {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := services.SendVerificationEmail(emailCtx, &user); err != nil {
+			log.Printf("Error sending verification email to user %s: %v", user.ID, err)
+		}
+	}()
+}
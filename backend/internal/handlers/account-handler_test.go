@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type sentEmail struct {
+	to   string
+	body string
+}
+
+// fakeEmailSender hands emails to the test instead of sending them; account emails are sent from
+// goroutines, hence the channel
+type fakeEmailSender struct {
+	sent chan sentEmail
+}
+
+func (f *fakeEmailSender) SendNotificationEmail(toEmail string, toName string, subject, content string) error {
+	f.sent <- sentEmail{to: toEmail, body: content}
+	return nil
+}
+
+// setupAccountTest connects to the test database and routes account emails to a fake sender
+func setupAccountTest(t *testing.T) (*gin.Engine, *fakeEmailSender) {
+	t.Helper()
+	dbtest.Setup(t)
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	sender := &fakeEmailSender{sent: make(chan sentEmail, 10)}
+	services.RegisterNotifier(services.NewEmailNotifier(sender))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/auth/forgot-password", ForgotPassword)
+	router.POST("/api/auth/reset-password", ResetPassword)
+	router.POST("/api/auth/verify-email", VerifyEmail)
+	return router, sender
+}
+
+func createTestUser(t *testing.T) *models.User {
+	t.Helper()
+	user := &models.User{
+		Name:     "Ada",
+		Email:    fmt.Sprintf("ada-%d@example.com", time.Now().UnixNano()),
+		Password: "old-password",
+	}
+	if err := user.Save(); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+func postJSON(router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+var linkTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// waitForToken returns the token in the link of the next email sent to the address
+func waitForToken(t *testing.T, sender *fakeEmailSender, email string) string {
+	t.Helper()
+	select {
+	case sent := <-sender.sent:
+		if sent.to != email {
+			t.Fatalf("email sent to %s, want %s", sent.to, email)
+		}
+		match := linkTokenPattern.FindStringSubmatch(sent.body)
+		if match == nil {
+			t.Fatalf("no link in email body %q", sent.body)
+		}
+		token, err := url.QueryUnescape(match[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was sent")
+		return ""
+	}
+}
+
+// requestResetToken asks for a password reset and returns the token from the email
+func requestResetToken(t *testing.T, router *gin.Engine, sender *fakeEmailSender, user *models.User) string {
+	t.Helper()
+	resp := postJSON(router, "/api/auth/forgot-password", gin.H{"email": user.Email})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("forgot-password: %d %s", resp.Code, resp.Body)
+	}
+	return waitForToken(t, sender, user.Email)
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	router, sender := setupAccountTest(t)
+
+	resp := postJSON(router, "/api/auth/forgot-password", gin.H{"email": "nobody-registered@example.com"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("forgot-password: %d %s", resp.Code, resp.Body)
+	}
+
+	select {
+	case sent := <-sender.sent:
+		t.Fatalf("email sent to %s for an unknown address", sent.to)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestResetPasswordSingleUse(t *testing.T) {
+	router, sender := setupAccountTest(t)
+	user := createTestUser(t)
+	token := requestResetToken(t, router, sender, user)
+
+	resp := postJSON(router, "/api/auth/reset-password", gin.H{"token": token, "password": "new-password"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("reset-password: %d %s", resp.Code, resp.Body)
+	}
+
+	login := &models.User{Email: user.Email, Password: "new-password"}
+	if err := login.ValidateCredentials(); err != nil {
+		t.Fatalf("new password does not work: %v", err)
+	}
+
+	resp = postJSON(router, "/api/auth/reset-password", gin.H{"token": token, "password": "another-password"})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("reusing the reset token: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
+
+func TestResetPasswordOnlyLatestTokenWorks(t *testing.T) {
+	router, sender := setupAccountTest(t)
+	user := createTestUser(t)
+	first := requestResetToken(t, router, sender, user)
+	second := requestResetToken(t, router, sender, user)
+
+	resp := postJSON(router, "/api/auth/reset-password", gin.H{"token": first, "password": "new-password"})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("superseded reset token: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	resp = postJSON(router, "/api/auth/reset-password", gin.H{"token": second, "password": "new-password"})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("latest reset token: %d %s", resp.Code, resp.Body)
+	}
+}
+
+func TestResetPasswordExpiredToken(t *testing.T) {
+	router, sender := setupAccountTest(t)
+	user := createTestUser(t)
+	token := requestResetToken(t, router, sender, user)
+
+	_, err := db.GetDB().Exec(context.Background(),
+		`UPDATE user_tokens SET expires_at = NOW() - INTERVAL '1 minute' WHERE user_id = $1`, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := postJSON(router, "/api/auth/reset-password", gin.H{"token": token, "password": "new-password"})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("expired reset token: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	login := &models.User{Email: user.Email, Password: "old-password"}
+	if err := login.ValidateCredentials(); err != nil {
+		t.Fatalf("password changed by an expired token: %v", err)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	router, sender := setupAccountTest(t)
+	user := createTestUser(t)
+
+	if err := services.SendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	token := waitForToken(t, sender, user.Email)
+
+	// Verification tokens are not reset tokens
+	resp := postJSON(router, "/api/auth/reset-password", gin.H{"token": token, "password": "new-password"})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("verification token used for reset: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+
+	resp = postJSON(router, "/api/auth/verify-email", gin.H{"token": token})
+	if resp.Code != http.StatusOK {
+		t.Fatalf("verify-email: %d %s", resp.Code, resp.Body)
+	}
+
+	verified := &models.User{ID: user.ID}
+	if err := verified.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if !verified.IsEmailVerified() {
+		t.Fatal("email not marked verified")
+	}
+
+	resp = postJSON(router, "/api/auth/verify-email", gin.H{"token": token})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("reusing the verification token: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVerifyEmailAfterAddressChange(t *testing.T) {
+	router, sender := setupAccountTest(t)
+	user := createTestUser(t)
+
+	if err := services.SendVerificationEmail(context.Background(), user); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+	token := waitForToken(t, sender, user.Email)
+
+	changed := &models.User{ID: user.ID, Email: "changed-" + user.Email}
+	if err := changed.Update(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := postJSON(router, "/api/auth/verify-email", gin.H{"token": token})
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("verifying an old address: got %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+}
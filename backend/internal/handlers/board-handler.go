@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type boardColumnRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Status string `json:"status" binding:"required"` // Task status the column maps onto
+}
+
+// CreateBoard adds a kanban board to the group; owners and admins only. Without columns the board
+// gets To Do / In Progress / Done mapped onto pending / active / completed.
+// POST /api/groups/:groupID/boards
+func CreateBoard(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	var requestBody struct {
+		Name    string               `json:"name" binding:"required"`
+		Columns []boardColumnRequest `json:"columns" binding:"dive"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board := models.Board{
+		GroupID:   ctx.Param("groupID"),
+		Name:      strings.TrimSpace(requestBody.Name),
+		CreatedBy: ctx.GetString("userID"),
+		Columns:   models.DefaultBoardColumns(),
+	}
+	if board.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "board name cannot be empty"})
+		return
+	}
+
+	if len(requestBody.Columns) > 0 {
+		board.Columns = []models.BoardColumn{}
+		for _, column := range requestBody.Columns {
+			name, ok := validateBoardColumn(ctx, column.Name, column.Status)
+			if !ok {
+				return
+			}
+			board.Columns = append(board.Columns, models.BoardColumn{Name: name, Status: column.Status})
+		}
+	}
+
+	err = board.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"board": board})
+}
+
+// GetBoards lists the group's boards with their columns
+// GET /api/groups/:groupID/boards
+func GetBoards(ctx *gin.Context) {
+	boards, err := models.GetGroupBoards(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"boards": boards})
+}
+
+// GetBoard returns a board with the group's tasks placed in its columns
+// GET /api/groups/:groupID/boards/:boardID
+func GetBoard(ctx *gin.Context) {
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	err := board.LoadTasks(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"board": board})
+}
+
+// UpdateBoard renames a board; owners and admins only
+// PATCH /api/groups/:groupID/boards/:boardID
+func UpdateBoard(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board.Name = strings.TrimSpace(requestBody.Name)
+	if board.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "board name cannot be empty"})
+		return
+	}
+
+	err = board.Update(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"board": board})
+}
+
+// DeleteBoard removes a board; the tasks stay. Owners and admins only.
+// DELETE /api/groups/:groupID/boards/:boardID
+func DeleteBoard(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	err := board.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Board deleted successfully"})
+}
+
+// CreateBoardColumn appends a column to a board; owners and admins only
+// POST /api/groups/:groupID/boards/:boardID/columns
+func CreateBoardColumn(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody boardColumnRequest
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name, ok := validateBoardColumn(ctx, requestBody.Name, requestBody.Status)
+	if !ok {
+		return
+	}
+
+	column := models.BoardColumn{
+		BoardID: board.ID,
+		Name:    name,
+		Status:  requestBody.Status,
+	}
+
+	err = column.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"column": column})
+}
+
+// UpdateBoardColumn renames, remaps or reorders a column; owners and admins only
+// PATCH /api/groups/:groupID/boards/:boardID/columns/:columnID
+func UpdateBoardColumn(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	column, ok := getBoardColumn(ctx, board, ctx.Param("columnID"))
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name     *string `json:"name"`
+		Status   *string `json:"status"`
+		Position *int    `json:"position"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Name != nil {
+		column.Name = *requestBody.Name
+	}
+	if requestBody.Status != nil {
+		column.Status = *requestBody.Status
+	}
+	if requestBody.Position != nil {
+		column.Position = *requestBody.Position
+	}
+
+	column.Name, ok = validateBoardColumn(ctx, column.Name, column.Status)
+	if !ok {
+		return
+	}
+
+	err = column.Update(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"column": column})
+}
+
+// DeleteBoardColumn removes a column; its tasks fall back to the first column of their status.
+// Owners and admins only.
+// DELETE /api/groups/:groupID/boards/:boardID/columns/:columnID
+func DeleteBoardColumn(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage boards") {
+		return
+	}
+
+	board, ok := getGroupBoard(ctx)
+	if !ok {
+		return
+	}
+
+	column, ok := getBoardColumn(ctx, board, ctx.Param("columnID"))
+	if !ok {
+		return
+	}
+
+	err := column.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Column deleted successfully"})
+}
+
+// MoveBoardTask moves a task to a column, right after after_task_id (at the top when omitted).
+// The task takes the column's status, subject to the same rules as UpdateTask, and the move is
+// broadcast to the group room as a "board_task_moved" event.
+// POST /api/groups/:groupID/boards/:boardID/tasks/:taskId/move
+func MoveBoardTask(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		groupID := ctx.Param("groupID")
+		taskID := ctx.Param("taskId")
+		userID := ctx.GetString("userID")
+		role := ctx.GetString("role")
+
+		board, ok := getGroupBoard(ctx)
+		if !ok {
+			return
+		}
+
+		task, ok := getTaskForUpdate(ctx, taskID, groupID)
+		if !ok {
+			return
+		}
+
+		var requestBody struct {
+			ColumnID    string `json:"column_id" binding:"required"`
+			AfterTaskID string `json:"after_task_id"` // Omit to move to the top of the column
+		}
+
+		err := ctx.ShouldBindJSON(&requestBody)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if requestBody.AfterTaskID == taskID {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "a task cannot be placed after itself"})
+			return
+		}
+
+		column, ok := getBoardColumn(ctx, board, requestBody.ColumnID)
+		if !ok {
+			return
+		}
+
+		previousStatus := task.Status
+		statusChanged := previousStatus != column.Status
+		if statusChanged {
+			// Same rule as UpdateTask: members can only send a task back to pending
+			if role != "owner" && role != "admin" && column.Status != "pending" {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins and owners can set task status to: " + column.Status})
+				return
+			}
+			if !checkStatusPreconditions(ctx, task, column.Status, task.RequireSubtasksComplete) {
+				return
+			}
+		}
+
+		rank, moved, err := models.MoveTaskOnBoard(ctx.Request.Context(), board, taskID, column, requestBody.AfterTaskID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !moved {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "after_task_id is not in the target column"})
+			return
+		}
+
+		if statusChanged {
+			recordTaskChange(ctx, taskID, "status_change", previousStatus, column.Status)
+
+			if column.Status == "pending" {
+				openApprovalRequest(ctx, task, "")
+			} else if previousStatus == "pending" {
+				closeApprovalRequest(ctx, taskID, column.Status)
+			}
+
+			runStatusWorkflowsAsync(taskID, previousStatus, column.Status)
+
+			if models.IsOpenTaskStatus(previousStatus) && !models.IsOpenTaskStatus(column.Status) {
+				notifyUnblockedDependentsAsync(taskID)
+			}
+			if column.Status == "completed" && task.SeriesID != nil {
+				generateNextOccurrenceAsync(*task.SeriesID)
+			}
+			if role == "owner" || role == "admin" {
+				notifyAssigneesAsync(taskID, "status_change")
+			}
+		}
+
+		move := gin.H{
+			"board_id":      board.ID,
+			"task_id":       taskID,
+			"column_id":     column.ID,
+			"after_task_id": requestBody.AfterTaskID,
+			"rank":          rank,
+			"status":        column.Status,
+		}
+
+		username := ctx.GetString("username")
+		go hub.BroadcastEvent(groupID, userID, username, "board_task_moved", move)
+
+		ctx.JSON(http.StatusOK, gin.H{"move": move})
+	}
+}
+
+// getGroupBoard loads the board from the URL with its columns and checks it belongs to the group,
+// writing the error response otherwise
+func getGroupBoard(ctx *gin.Context) (*models.Board, bool) {
+	board := &models.Board{ID: ctx.Param("boardID")}
+	err := board.Get(ctx.Request.Context())
+	if err != nil || board.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "board not found"})
+		return nil, false
+	}
+	return board, true
+}
+
+// getBoardColumn finds a column of the board, writing a 404 otherwise
+func getBoardColumn(ctx *gin.Context, board *models.Board, columnID string) (*models.BoardColumn, bool) {
+	index := slices.IndexFunc(board.Columns, func(c models.BoardColumn) bool { return c.ID == columnID })
+	if index < 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "column not found on this board"})
+		return nil, false
+	}
+	return &board.Columns[index], true
+}
+
+// validateBoardColumn trims the column name and checks the status exists, writing a 400 otherwise
+func validateBoardColumn(ctx *gin.Context, name string, status string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "column name cannot be empty"})
+		return "", false
+	}
+	if !slices.Contains(models.TaskStatuses, status) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid column status. Must be one of: " + strings.Join(models.TaskStatuses, ", ")})
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type CanvasHandler struct {
+	hub *WS.CanvasHub
+}
+
+func NewCanvasHandler(hub *WS.CanvasHub) *CanvasHandler {
+	return &CanvasHandler{
+		hub: hub,
+	}
+}
+
+func (h *CanvasHandler) GetHub() *WS.CanvasHub {
+	return h.hub
+}
+
+var canvasUpgrader = websocket.Upgrader{
+	ReadBufferSize:   1024,
+	WriteBufferSize:  1024,
+	HandshakeTimeout: 10 * time.Second,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// CreateCanvas adds a canvas to the group, empty or with initial canvas_data
+// POST /api/groups/:groupID/canvases
+func (h *CanvasHandler) CreateCanvas(ctx *gin.Context) {
+	var requestBody struct {
+		Name string                 `json:"name" binding:"required"`
+		Data *models.CanvasDocument `json:"canvas_data"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	canvas := models.Canvas{
+		GroupID:   ctx.Param("groupID"),
+		Name:      strings.TrimSpace(requestBody.Name),
+		Data:      requestBody.Data,
+		CreatedBy: ctx.GetString("userID"),
+	}
+	if canvas.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "canvas name cannot be empty"})
+		return
+	}
+	if canvas.Data != nil && len(canvas.Data.Elements) > models.MaxCanvasElements {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("a canvas can have at most %d elements", models.MaxCanvasElements)})
+		return
+	}
+
+	err = canvas.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"canvas": canvas})
+}
+
+// GetCanvases lists the group's canvases without their content
+// GET /api/groups/:groupID/canvases
+func (h *CanvasHandler) GetCanvases(ctx *gin.Context) {
+	canvases, err := models.GetGroupCanvases(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"canvases": canvases})
+}
+
+// GetCanvas returns a canvas with its content. While the canvas is being edited the content and
+// version are the live ones, which can be ahead of the last snapshot.
+// GET /api/groups/:groupID/canvases/:canvasID
+func (h *CanvasHandler) GetCanvas(ctx *gin.Context) {
+	canvas, ok := getGroupCanvas(ctx)
+	if !ok {
+		return
+	}
+
+	if live := h.hub.LiveCanvas(canvas.ID); live != nil && live.Version >= canvas.Version {
+		canvas.Data = live.Data
+		canvas.Version = live.Version
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"canvas": canvas})
+}
+
+// UpdateCanvas renames a canvas; its content only changes through the editing session
+// PATCH /api/groups/:groupID/canvases/:canvasID
+func (h *CanvasHandler) UpdateCanvas(ctx *gin.Context) {
+	canvas, ok := getGroupCanvas(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name string `json:"name" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(requestBody.Name)
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "canvas name cannot be empty"})
+		return
+	}
+
+	err = canvas.Rename(ctx.Request.Context(), name)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"canvas": canvas})
+}
+
+// DeleteCanvas deletes a canvas and ends its editing session; its creator, admins and owners only
+// DELETE /api/groups/:groupID/canvases/:canvasID
+func (h *CanvasHandler) DeleteCanvas(ctx *gin.Context) {
+	canvas, ok := getGroupCanvas(ctx)
+	if !ok {
+		return
+	}
+
+	role := ctx.GetString("role")
+	if canvas.CreatedBy != ctx.GetString("userID") && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the canvas creator, admins and owners can delete a canvas"})
+		return
+	}
+
+	err := canvas.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.hub.Close <- canvas.ID
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Canvas deleted successfully"})
+}
+
+// JoinCanvas opens the collaborative editing session of a canvas (see WS.CanvasMessage for the protocol).
+// Viewers join read-only.
+// GET /api/groups/:groupID/canvases/:canvasID/ws
+func (h *CanvasHandler) JoinCanvas(ctx *gin.Context) {
+	canvas, ok := getGroupCanvas(ctx)
+	if !ok {
+		return
+	}
+
+	conn, err := canvasUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		log.Printf("Canvas: WebSocket upgrade failed for user %s: %v", ctx.GetString("userID"), err)
+		return
+	}
+
+	client := &WS.CanvasClient{
+		Conn:     conn,
+		ID:       ctx.GetString("userID"),
+		Username: ctx.GetString("username"),
+		CanvasID: canvas.ID,
+		ReadOnly: ctx.GetString("role") == "viewer",
+		Message:  make(chan *WS.CanvasMessage, 64),
+	}
+
+	h.hub.Register <- client
+
+	go client.WriteMessage()
+	client.ReadMessage(h.hub)
+}
+
+// getGroupCanvas loads the canvas from the URL and checks it belongs to the group, writing the error response otherwise
+func getGroupCanvas(ctx *gin.Context) (*models.Canvas, bool) {
+	canvas := &models.Canvas{ID: ctx.Param("canvasID")}
+	err := canvas.Get(ctx.Request.Context())
+	if err != nil || canvas.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "canvas not found"})
+		return nil, false
+	}
+	return canvas, true
+}
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCustomField defines a typed field for the group's tasks; owners and admins only
+// POST /api/groups/:groupID/custom-fields
+func CreateCustomField(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage custom fields") {
+		return
+	}
+
+	var requestBody struct {
+		Name      string   `json:"name" binding:"required"`
+		FieldType string   `json:"field_type" binding:"required"` // text, number, date or select
+		Options   []string `json:"options"`                       // Required for select fields
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field := models.CustomField{
+		GroupID:   ctx.Param("groupID"),
+		Name:      strings.TrimSpace(requestBody.Name),
+		FieldType: requestBody.FieldType,
+		Options:   requestBody.Options,
+	}
+	if field.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "field name cannot be empty"})
+		return
+	}
+	if err := field.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = field.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"field": field})
+}
+
+// GetCustomFields lists the group's custom fields in display order
+// GET /api/groups/:groupID/custom-fields
+func GetCustomFields(ctx *gin.Context) {
+	fields, err := models.GetGroupCustomFields(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"fields": fields})
+}
+
+// UpdateCustomField renames, reorders or changes the options of a field; its type is fixed.
+// Owners and admins only.
+// PATCH /api/groups/:groupID/custom-fields/:fieldID
+func UpdateCustomField(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage custom fields") {
+		return
+	}
+
+	field, ok := getGroupCustomField(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name     *string   `json:"name"`
+		Options  *[]string `json:"options"`
+		Position *int      `json:"position"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Name != nil {
+		field.Name = strings.TrimSpace(*requestBody.Name)
+		if field.Name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "field name cannot be empty"})
+			return
+		}
+	}
+	if requestBody.Options != nil {
+		field.Options = *requestBody.Options
+	}
+	if requestBody.Position != nil {
+		field.Position = *requestBody.Position
+	}
+	if err := field.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = field.Update(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"field": field})
+}
+
+// DeleteCustomField removes a field and its values from every task; owners and admins only
+// DELETE /api/groups/:groupID/custom-fields/:fieldID
+func DeleteCustomField(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage custom fields") {
+		return
+	}
+
+	field, ok := getGroupCustomField(ctx)
+	if !ok {
+		return
+	}
+
+	err := field.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
+
+// SetTaskCustomFields sets or clears custom field values of a task. Values are keyed by field ID;
+// null clears the value and fields that are not listed keep theirs.
+// PATCH /api/groups/:groupID/tasks/:taskId/custom-fields
+func SetTaskCustomFields(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	if _, ok := getTaskForUpdate(ctx, taskID, groupID); !ok {
+		return
+	}
+
+	var requestBody struct {
+		Values map[string]any `json:"values" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fields, err := models.GetGroupCustomFields(ctx.Request.Context(), groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fieldsByID := map[string]*models.CustomField{}
+	for i := range fields {
+		fieldsByID[fields[i].ID] = &fields[i]
+	}
+
+	// Validate every value before storing any of them
+	values := map[string]*models.CustomFieldValue{}
+	for fieldID, raw := range requestBody.Values {
+		field, ok := fieldsByID[fieldID]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "custom field " + fieldID + " not found in this group"})
+			return
+		}
+		if raw == nil {
+			values[fieldID] = nil
+			continue
+		}
+		value, err := field.ParseValue(raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		values[fieldID] = &value
+	}
+
+	for fieldID, value := range values {
+		err := models.SetTaskCustomFieldValue(ctx.Request.Context(), taskID, fieldsByID[fieldID], value)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	task, err := models.GetTaskByID(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// getGroupCustomField loads the field from the URL and checks it belongs to the group, writing the error response otherwise
+func getGroupCustomField(ctx *gin.Context) (*models.CustomField, bool) {
+	field := &models.CustomField{ID: ctx.Param("fieldID")}
+	err := field.Get(ctx.Request.Context())
+	if err != nil || field.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "custom field not found"})
+		return nil, false
+	}
+	return field, true
+}
@@ -3,35 +3,40 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
 	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// Package-level S3 service
-var s3Service *utils.S3Service
+// Package-level file storage, S3 or the local disk
+var blobStore utils.BlobStore
 
-// InitS3Service initializes the S3 service for file uploads
-func InitS3Service() error {
-	service, err := utils.NewS3Service()
+// InitBlobStore initializes the file storage selected by STORAGE_BACKEND
+func InitBlobStore() error {
+	store, err := utils.NewBlobStore()
 	if err != nil {
-		return fmt.Errorf("failed to initialize S3 service: %w", err)
+		return fmt.Errorf("failed to initialize file storage: %w", err)
 	}
-	s3Service = service
+	blobStore = store
+	services.InitLinkPreviews(store)
+	services.InitUploads(store)
 	return nil
 }
 
-// UploadFile handles file uploads to S3
+// UploadFile handles file uploads to storage
 // POST /api/groups/:groupID/files
 func UploadFile(ctx *gin.Context) {
-	if s3Service == nil {
+	if blobStore == nil {
 		ctx.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "S3 service not initialized",
+			"error": "File storage not initialized",
 		})
 		return
 	}
@@ -49,6 +54,17 @@ func UploadFile(ctx *gin.Context) {
 		return
 	}
 
+	// Files can only be uploaded to a live folder of the group
+	if folderID != "" {
+		folder := &models.Folder{ID: folderID}
+		if _, err := uuid.Parse(folderID); err != nil || folder.GetByID(ctx) != nil || folder.GroupID == nil || *folder.GroupID != groupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+	}
+
 	// Get file from form
 	fileHeader, err := ctx.FormFile("file")
 	if err != nil {
@@ -58,6 +74,12 @@ func UploadFile(ctx *gin.Context) {
 		return
 	}
 
+	reservation, ok := reserveStorage(ctx, groupID, fileHeader.Size)
+	if !ok {
+		return
+	}
+	defer releaseStorage(reservation)
+
 	// Open file
 	src, err := fileHeader.Open()
 	if err != nil {
@@ -68,20 +90,21 @@ func UploadFile(ctx *gin.Context) {
 	}
 	defer src.Close()
 
-	// Generate S3 key
-	s3Key := utils.GenerateS3Key(groupID, userID, fileHeader.Filename)
+	// Generate the storage key; later versions of the file are stored under the same prefix
+	fileID := uuid.New().String()
+	s3Key := utils.GenerateFileVersionKey(groupID, fileID, fileHeader.Filename)
 
-	// Upload to S3
-	err = s3Service.UploadFile(ctx, s3Key, src, fileHeader.Header.Get("Content-Type"))
+	// Upload to storage
+	err = blobStore.Put(ctx, s3Key, src, fileHeader.Header.Get("Content-Type"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to upload file to S3: %v", err),
+			"error": fmt.Sprintf("Failed to upload file to storage: %v", err),
 		})
 		return
 	}
 
 	// Generate presigned URL (1 hour expiry)
-	presignedURL, err := s3Service.GetPresignedURL(ctx, s3Key, 1*time.Hour)
+	presignedURL, err := blobStore.PresignGet(ctx, s3Key, 1*time.Hour)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to generate presigned URL: %v", err),
@@ -91,11 +114,11 @@ func UploadFile(ctx *gin.Context) {
 
 	// Create file model
 	file := &models.File{
-		ID:        uuid.New().String(),
+		ID:        fileID,
 		Name:      fileHeader.Filename,
 		Size:      fileHeader.Size,
 		MimeType:  fileHeader.Header.Get("Content-Type"),
-		S3Bucket:  s3Service.GetBucket(),
+		S3Bucket:  blobStore.Bucket(),
 		S3Key:     s3Key,
 		GroupID:   &groupID,
 		CreatedBy: userID,
@@ -104,22 +127,22 @@ func UploadFile(ctx *gin.Context) {
 
 	// Set folder ID if provided
 	if folderID != "" {
-		if _, err := uuid.Parse(folderID); err == nil {
-			file.FolderID = &folderID
-		}
+		file.FolderID = &folderID
 	}
 
 	// Save to database
 	err = file.Save(ctx)
 	if err != nil {
-		// Try to delete from S3 if DB save fails
-		_ = s3Service.DeleteFile(ctx, s3Key)
+		// Try to delete from storage if DB save fails
+		_ = blobStore.Delete(ctx, s3Key)
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to save file metadata: %v", err),
 		})
 		return
 	}
 
+	checkStorageThresholdAsync(groupID)
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"message": "File uploaded successfully",
 		"file":    file,
@@ -162,9 +185,9 @@ func GetFiles(ctx *gin.Context) {
 	}
 
 	// Generate fresh presigned URLs for each file
-	if s3Service != nil {
+	if blobStore != nil {
 		for i := range files {
-			presignedURL, err := s3Service.GetPresignedURL(ctx, files[i].S3Key, 1*time.Hour)
+			presignedURL, err := blobStore.PresignGet(ctx, files[i].S3Key, 1*time.Hour)
 			if err == nil {
 				files[i].S3URL = &presignedURL
 			}
@@ -207,9 +230,9 @@ func GetFileInfo(ctx *gin.Context) {
 // GetFileDownloadURL generates a presigned URL for downloading a file
 // GET /api/groups/:groupID/files/:fileID/download
 func GetFileDownloadURL(ctx *gin.Context) {
-	if s3Service == nil {
+	if blobStore == nil {
 		ctx.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "S3 service not initialized",
+			"error": "File storage not initialized",
 		})
 		return
 	}
@@ -244,7 +267,7 @@ func GetFileDownloadURL(ctx *gin.Context) {
 	}
 
 	// Generate presigned URL
-	presignedURL, err := s3Service.GetPresignedURL(ctx, file.S3Key, expires)
+	presignedURL, err := blobStore.PresignGet(ctx, file.S3Key, expires)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to generate download URL: %v", err),
@@ -258,16 +281,9 @@ func GetFileDownloadURL(ctx *gin.Context) {
 	})
 }
 
-// DeleteFile deletes a file from S3 and database
+// DeleteFile moves a file to the trash; its versions are deleted from storage when the trash is purged
 // DELETE /api/groups/:groupID/files/:fileID
 func DeleteFile(ctx *gin.Context) {
-	if s3Service == nil {
-		ctx.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "S3 service not initialized",
-		})
-		return
-	}
-
 	fileID := ctx.Param("fileID")
 	userID := ctx.GetString("userID")
 
@@ -298,25 +314,344 @@ func DeleteFile(ctx *gin.Context) {
 		return
 	}
 
-	// Delete from S3 first
-	err = s3Service.DeleteFile(context.Background(), file.S3Key)
+	// The stored objects stay until the trash is purged, so the file still counts towards the quota
+	err = file.Trash(ctx, userID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to delete file from S3: %v", err),
+			"error": fmt.Sprintf("Failed to delete file: %v", err),
 		})
 		return
 	}
 
-	// Delete from database
-	err = file.Delete(ctx)
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "File moved to trash successfully",
+	})
+}
+
+// UpdateFile renames a file
+// PATCH /api/groups/:groupID/files/:fileID
+func UpdateFile(ctx *gin.Context) {
+	file, ok := getGroupFile(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	name := strings.TrimSpace(requestBody.Name)
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "File name cannot be empty or contain slashes",
+		})
+		return
+	}
+
+	moveFile(ctx, file, name, file.FolderID)
+}
+
+// MoveFile moves a file to another folder of the group, or to its root when folder_id is null
+// POST /api/groups/:groupID/files/:fileID/move
+func MoveFile(ctx *gin.Context) {
+	file, ok := getGroupFile(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		FolderID *string `json:"folder_id"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if requestBody.FolderID != nil {
+		folder := &models.Folder{ID: *requestBody.FolderID}
+		if _, err := uuid.Parse(folder.ID); err != nil || folder.GetByID(ctx) != nil || folder.GroupID == nil || *folder.GroupID != *file.GroupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+	}
+
+	moveFile(ctx, file, file.Name, requestBody.FolderID)
+}
+
+// UploadFileVersion uploads new content for an existing file. The upload becomes the current
+// version and versions beyond the retention (utils.FileVersionRetention) are pruned. Only the
+// file's creator and the group's owners and admins can upload versions.
+// POST /api/groups/:groupID/files/:fileID/versions
+func UploadFileVersion(ctx *gin.Context) {
+	if blobStore == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "File storage not initialized",
+		})
+		return
+	}
+
+	file, ok := getGroupFile(ctx)
+	if !ok || !canChangeFile(ctx, file) {
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "No file provided",
+		})
+		return
+	}
+
+	reservation, ok := reserveStorage(ctx, *file.GroupID, fileHeader.Size)
+	if !ok {
+		return
+	}
+	defer releaseStorage(reservation)
+
+	src, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to open file",
+		})
+		return
+	}
+	defer src.Close()
+
+	s3Key := utils.GenerateFileVersionKey(*file.GroupID, file.ID, fileHeader.Filename)
+
+	err = blobStore.Put(ctx, s3Key, src, fileHeader.Header.Get("Content-Type"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("Failed to delete file metadata: %v", err),
+			"error": fmt.Sprintf("Failed to upload file to storage: %v", err),
+		})
+		return
+	}
+
+	version := &models.FileVersion{
+		FileID:    file.ID,
+		Name:      fileHeader.Filename,
+		Size:      fileHeader.Size,
+		MimeType:  fileHeader.Header.Get("Content-Type"),
+		S3Bucket:  blobStore.Bucket(),
+		S3Key:     s3Key,
+		CreatedBy: ctx.GetString("userID"),
+	}
+
+	err = version.Append(ctx)
+	if err != nil {
+		_ = blobStore.Delete(ctx, s3Key)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to save file version: %v", err),
+		})
+		return
+	}
+
+	pruneFileVersions(ctx, file.ID)
+	checkStorageThresholdAsync(*file.GroupID)
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "File version uploaded successfully",
+		"version": version,
+	})
+}
+
+// GetFileVersions lists the versions of a file, newest first
+// GET /api/groups/:groupID/files/:fileID/versions
+func GetFileVersions(ctx *gin.Context) {
+	file, ok := getGroupFile(ctx)
+	if !ok {
+		return
+	}
+
+	versions, err := models.GetFileVersions(ctx, file.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to retrieve file versions: %v", err),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"current_version": file.CurrentVersion,
+		"versions":        versions,
+	})
+}
+
+// GetFileVersionDownloadURL generates a presigned URL for downloading one version of a file
+// GET /api/groups/:groupID/files/:fileID/versions/:version/download
+func GetFileVersionDownloadURL(ctx *gin.Context) {
+	if blobStore == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "File storage not initialized",
+		})
+		return
+	}
+
+	_, version, ok := getFileVersion(ctx)
+	if !ok {
+		return
+	}
+
+	presignedURL, err := blobStore.PresignGet(ctx, version.S3Key, 1*time.Hour)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to generate download URL: %v", err),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"download_url": presignedURL,
+		"expires_in":   3600,
+		"version":      version,
+	})
+}
+
+// RestoreFileVersion makes an earlier version current again. The restore is recorded as a new
+// version with the earlier content, so the history is kept. Only the file's creator and the
+// group's owners and admins can restore versions.
+// POST /api/groups/:groupID/files/:fileID/versions/:version/restore
+func RestoreFileVersion(ctx *gin.Context) {
+	if blobStore == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "File storage not initialized",
+		})
+		return
+	}
+
+	file, version, ok := getFileVersion(ctx)
+	if !ok || !canChangeFile(ctx, file) {
+		return
+	}
+
+	restored := &models.FileVersion{
+		FileID:       version.FileID,
+		Name:         version.Name,
+		Size:         version.Size,
+		MimeType:     version.MimeType,
+		S3Bucket:     version.S3Bucket,
+		S3Key:        version.S3Key,
+		RestoredFrom: &version.VersionNumber,
+		CreatedBy:    ctx.GetString("userID"),
+	}
+
+	err := restored.Append(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to restore file version: %v", err),
+		})
+		return
+	}
+
+	pruneFileVersions(ctx, version.FileID)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "File version restored successfully",
+		"version": restored,
+	})
+}
+
+// moveFile renames and moves the file, writing the response
+func moveFile(ctx *gin.Context, file *models.File, name string, folderID *string) {
+	if !canChangeFile(ctx, file) {
+		return
+	}
+
+	if err := file.Move(ctx, name, folderID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to move file: %v", err),
 		})
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "File deleted successfully",
+		"file": file,
 	})
 }
+
+// canChangeFile checks the current user created the file or is an owner or admin of the group,
+// writing the error response otherwise
+func canChangeFile(ctx *gin.Context, file *models.File) bool {
+	role := ctx.GetString("role")
+	if file.CreatedBy != ctx.GetString("userID") && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to change this file",
+		})
+		return false
+	}
+	return true
+}
+
+// getGroupFile loads the file from the URL and checks it belongs to the group, writing the error response otherwise
+func getGroupFile(ctx *gin.Context) (*models.File, bool) {
+	fileID := ctx.Param("fileID")
+	if _, err := uuid.Parse(fileID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid file ID",
+		})
+		return nil, false
+	}
+
+	file := &models.File{ID: fileID}
+	err := file.GetByID(ctx)
+	if err != nil || file.GroupID == nil || *file.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File not found",
+		})
+		return nil, false
+	}
+	return file, true
+}
+
+// getFileVersion loads the file from the URL and its version, checking the file belongs to the group
+// and writing the error response otherwise
+func getFileVersion(ctx *gin.Context) (*models.File, *models.FileVersion, bool) {
+	file, ok := getGroupFile(ctx)
+	if !ok {
+		return nil, nil, false
+	}
+
+	versionNumber, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || versionNumber < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid version number",
+		})
+		return nil, nil, false
+	}
+
+	version, err := models.GetFileVersion(ctx, file.ID, versionNumber)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "File version not found",
+		})
+		return nil, nil, false
+	}
+	return file, version, true
+}
+
+// pruneFileVersions drops the versions beyond the retention and deletes the stored objects no longer used.
+// Failures are only logged: the new version is already saved.
+func pruneFileVersions(ctx context.Context, fileID string) {
+	keys, err := models.PruneFileVersions(ctx, fileID, utils.FileVersionRetention())
+	if err != nil {
+		log.Printf("Error pruning versions of file %s: %v", fileID, err)
+		return
+	}
+
+	for _, key := range keys {
+		if err := blobStore.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting pruned file version %s: %v", key, err)
+		}
+	}
+}
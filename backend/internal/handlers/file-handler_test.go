@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCanChangeFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	file := &models.File{CreatedBy: "creator"}
+
+	tests := []struct {
+		userID  string
+		role    string
+		allowed bool
+	}{
+		{"creator", "member", true},
+		{"creator", "viewer", true},
+		{"someone", "owner", true},
+		{"someone", "admin", true},
+		{"someone", "member", false},
+		{"someone", "viewer", false},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Set("userID", test.userID)
+		ctx.Set("role", test.role)
+
+		if allowed := canChangeFile(ctx, file); allowed != test.allowed {
+			t.Errorf("%s as %s: allowed %v, want %v", test.userID, test.role, allowed, test.allowed)
+		}
+		if !test.allowed && recorder.Code != http.StatusForbidden {
+			t.Errorf("%s as %s: status %d, want %d", test.userID, test.role, recorder.Code, http.StatusForbidden)
+		}
+	}
+}
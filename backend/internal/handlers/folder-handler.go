@@ -44,6 +44,17 @@ func CreateFolder(ctx *gin.Context) {
 		return
 	}
 
+	// Folders can only be created under a live folder of the same group
+	if req.ParentID != nil {
+		parent := &models.Folder{ID: *req.ParentID}
+		if _, err := uuid.Parse(parent.ID); err != nil || parent.GetByID(ctx) != nil || parent.GroupID == nil || *parent.GroupID != groupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Parent folder not found",
+			})
+			return
+		}
+	}
+
 	// Create folder model
 	folder := &models.Folder{
 		Name:      folderName,
@@ -131,40 +142,100 @@ func GetFolder(ctx *gin.Context) {
 	})
 }
 
-// DeleteFolder deletes a folder
-// DELETE /api/groups/:groupID/folders/:folderID
-func DeleteFolder(ctx *gin.Context) {
-	folderID := ctx.Param("folderID")
-	userID := ctx.GetString("userID")
+// UpdateFolder renames a folder; the paths of its subfolders follow
+// PATCH /api/groups/:groupID/folders/:folderID
+func UpdateFolder(ctx *gin.Context) {
+	folder, ok := getGroupFolder(ctx)
+	if !ok {
+		return
+	}
 
-	// Validate folder ID
-	if _, err := uuid.Parse(folderID); err != nil {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
+			"error": "Invalid request body",
 		})
 		return
 	}
 
-	// Get folder from database
-	folder := &models.Folder{ID: folderID}
-	err := folder.GetByID(ctx.Request.Context())
-	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
-			"error": "Folder not found",
+	moveFolder(ctx, folder, req.Name, folder.ParentID)
+}
+
+// MoveFolder moves a folder with everything in it under another folder, or to the group's root
+// when parent_id is null
+// POST /api/groups/:groupID/folders/:folderID/move
+func MoveFolder(ctx *gin.Context) {
+	folder, ok := getGroupFolder(ctx)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ParentID *string `json:"parent_id"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
 		})
 		return
 	}
 
+	if req.ParentID != nil {
+		parent := &models.Folder{ID: *req.ParentID}
+		if _, err := uuid.Parse(parent.ID); err != nil || parent.GetByID(ctx) != nil || parent.GroupID == nil || *parent.GroupID != *folder.GroupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Parent folder not found",
+			})
+			return
+		}
+		if folder.Contains(parent) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "A folder cannot be moved into itself or one of its subfolders",
+			})
+			return
+		}
+	}
+
+	moveFolder(ctx, folder, folder.Name, req.ParentID)
+}
+
+// DeleteFolder moves a folder to the trash. Folders that are not empty are only deleted with
+// recursive=true, which also moves every subfolder and file in them to the trash.
+// DELETE /api/groups/:groupID/folders/:folderID
+func DeleteFolder(ctx *gin.Context) {
+	folder, ok := getGroupFolder(ctx)
+	if !ok {
+		return
+	}
+
 	// Check ownership
-	if folder.CreatedBy != userID {
+	role := ctx.GetString("role")
+	if folder.CreatedBy != ctx.GetString("userID") && role != "owner" && role != "admin" {
 		ctx.JSON(http.StatusForbidden, gin.H{
 			"error": "You don't have permission to delete this folder",
 		})
 		return
 	}
 
-	// Delete folder (CASCADE will handle child folders)
-	err = folder.Delete(ctx.Request.Context())
+	folderCount, fileCount, err := folder.CountContents(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if (folderCount > 0 || fileCount > 0) && ctx.Query("recursive") != "true" {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":   "Folder is not empty; delete it with recursive=true to delete its contents too",
+			"folders": folderCount,
+			"files":   fileCount,
+		})
+		return
+	}
+
+	err = folder.Trash(ctx, ctx.GetString("userID"))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to delete folder: %v", err),
@@ -173,6 +244,66 @@ func DeleteFolder(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Folder deleted successfully",
+		"message":         "Folder moved to trash successfully",
+		"deleted_folders": folderCount + 1,
+		"deleted_files":   fileCount,
+	})
+}
+
+// moveFolder renames and moves the folder, writing the response
+func moveFolder(ctx *gin.Context, folder *models.Folder, name string, parentID *string) {
+	role := ctx.GetString("role")
+	if folder.CreatedBy != ctx.GetString("userID") && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": "You don't have permission to change this folder",
+		})
+		return
+	}
+
+	name = models.SanitizeFolderName(name)
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Folder name cannot be empty",
+		})
+		return
+	}
+
+	moved, err := folder.Move(ctx, name, parentID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to move folder: %v", err),
+		})
+		return
+	}
+	if !moved {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": "A folder with that name already exists there",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"folder": folder,
 	})
 }
+
+// getGroupFolder loads the folder from the URL when it belongs to the group, writing the error response otherwise
+func getGroupFolder(ctx *gin.Context) (*models.Folder, bool) {
+	folderID := ctx.Param("folderID")
+	if _, err := uuid.Parse(folderID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid folder ID",
+		})
+		return nil, false
+	}
+
+	folder := &models.Folder{ID: folderID}
+	err := folder.GetByID(ctx)
+	if err != nil || folder.GroupID == nil || *folder.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Folder not found",
+		})
+		return nil, false
+	}
+	return folder, true
+}
@@ -45,7 +45,7 @@ func CreateGroup(wsHandler *WShandler) gin.HandlerFunc {
 		wsHandler.hub.CreateRoom <- &WS.Room{
 			ID:      group.ID,
 			Name:    group.Name,
-			Clients: make(map[string]*WS.Client),
+			Clients: make(map[*WS.Client]bool),
 		}
 
 		ctx.JSON(http.StatusCreated, gin.H{
@@ -71,6 +71,12 @@ func GetGroupByID(ctx *gin.Context) {
 	})
 }
 
+// GroupRes is a group with how many chat messages the user has not read in it
+type GroupRes struct {
+	models.Group
+	UnreadCount int `json:"unread_count"`
+}
+
 func GetGroups(ctx *gin.Context) {
 	userID := ctx.GetString("userID")
 
@@ -82,8 +88,21 @@ func GetGroups(ctx *gin.Context) {
 		return
 	}
 
+	unread, err := models.GetUnreadMessageCounts(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to fetch unread counts",
+		})
+		return
+	}
+
+	res := make([]GroupRes, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, GroupRes{Group: group, UnreadCount: unread[group.ID]})
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
-		"groups": groups,
+		"groups": res,
 	})
 }
 
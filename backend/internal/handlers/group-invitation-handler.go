@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -64,6 +68,47 @@ func GetInvitations(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"invitations": invitations})
 }
 
+// GetInvitationByToken shows what an emailed invitation link is for, so the signup page can
+// prefill the email and name the group. No authentication required.
+// GET /api/auth/invitations/:token
+func GetInvitationByToken(ctx *gin.Context) {
+	invitation, err := getInvitationForToken(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"invitation": gin.H{
+			"id":            invitation.ID,
+			"group_id":      invitation.GroupID,
+			"group_name":    invitation.GroupName,
+			"invitee_email": invitation.InviteeEmail,
+			"role":          invitation.Role,
+			"expires_at":    invitation.ExpiresAt,
+		},
+	})
+}
+
+// getInvitationForToken verifies an emailed accept token and returns its pending, unexpired invitation
+func getInvitationForToken(ctx context.Context, token string) (*models.GroupInvitation, error) {
+	tokenHash, err := utils.VerifySignedToken(services.InvitationTokenPurpose, token)
+	if err != nil {
+		return nil, errors.New("invalid invitation link")
+	}
+
+	invitation, err := models.GetInvitationByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, errors.New("invalid invitation link")
+	}
+
+	if invitation.Status != "pending" || invitation.IsExpired() {
+		return nil, errors.New("invitation link has already been used or has expired")
+	}
+
+	return invitation, nil
+}
+
 // AcceptInvitation accepts a group invitation
 func AcceptInvitation(ctx *gin.Context) {
 	invitationID := ctx.Param("invitationID")
@@ -100,12 +145,24 @@ func AcceptInvitation(ctx *gin.Context) {
 		return
 	}
 
+	// The expiry job may not have run yet
+	if invitation.IsExpired() {
+		_ = invitation.UpdateStatus(reqCtx, "expired")
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invitation has expired"})
+		return
+	}
+
 	// Verify the invitation is for this user (by email or user ID)
 	isForUser := false
 	if invitation.InviteeID != nil && *invitation.InviteeID == userID {
 		isForUser = true
 	}
 	if !isForUser && invitation.InviteeEmail == user.Email {
+		// Matching by email only counts once the user proved they own the address
+		if !user.IsEmailVerified() {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "verify your email address to accept this invitation"})
+			return
+		}
 		isForUser = true
 	}
 
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// asUser stands in for the auth and group member middleware
+func asUser(userID string, role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("userID", userID)
+		ctx.Set("role", role)
+		ctx.Next()
+	}
+}
+
+// inviteByEmail has the owner invite an address to the group and returns the invitation
+func inviteByEmail(t *testing.T, owner *models.User, groupID string, email string) *models.GroupInvitation {
+	t.Helper()
+	router := gin.New()
+	router.POST("/groups/:groupID/members", asUser(owner.ID, "owner"), AddGroupMember)
+
+	resp := postJSON(router, "/groups/"+groupID+"/members", gin.H{"email": email, "role": "member"})
+	if resp.Code != http.StatusCreated {
+		t.Fatalf("invite: %d %s", resp.Code, resp.Body)
+	}
+	var body struct {
+		Invitation models.GroupInvitation `json:"invitation"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	return &body.Invitation
+}
+
+func acceptInvitation(invitee *models.User, invitationID string) int {
+	router := gin.New()
+	router.POST("/invitations/:invitationID/accept", asUser(invitee.ID, ""), AcceptInvitation)
+	return postJSON(router, "/invitations/"+invitationID+"/accept", nil).Code
+}
+
+func TestAcceptInvitationRequiresVerifiedEmail(t *testing.T) {
+	setupAccountTest(t)
+	owner := createTestUser(t)
+	group := &models.Group{Name: "Invitations", CreatedBy: owner.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Registered with the invited address but never proved owning it
+	invitee := createTestUser(t)
+	invitation := inviteByEmail(t, owner, group.ID, invitee.Email)
+	if invitation.InviteeID != nil {
+		t.Errorf("invitation was tied to unverified account %s", *invitation.InviteeID)
+	}
+
+	if code := acceptInvitation(invitee, invitation.ID); code != http.StatusForbidden {
+		t.Fatalf("unverified user accepting: got %d, want %d", code, http.StatusForbidden)
+	}
+	member := &models.GroupMember{GroupID: group.ID, UserID: invitee.ID}
+	if isMember, err := member.IsMember(context.Background()); err != nil || isMember {
+		t.Fatalf("unverified user joined the group (member %v, err %v)", isMember, err)
+	}
+
+	if err := invitee.MarkEmailVerified(context.Background(), invitee.Email); err != nil {
+		t.Fatal(err)
+	}
+	if code := acceptInvitation(invitee, invitation.ID); code != http.StatusOK {
+		t.Fatalf("verified user accepting: got %d, want %d", code, http.StatusOK)
+	}
+}
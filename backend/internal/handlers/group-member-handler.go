@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -69,8 +74,11 @@ func AddGroupMember(ctx *gin.Context) {
 		}
 		err = user.GetByEmail()
 		if err == nil {
-			// User exists, set invitee_id
-			inviteeID = &user.ID
+			// User exists, set invitee_id. Only a verified address identifies the account: anyone
+			// can sign up with an address they do not own, and invitee_id alone lets them accept.
+			if user.IsEmailVerified() {
+				inviteeID = &user.ID
+			}
 			// Check if user is already a member
 			groupMember := &models.GroupMember{
 				GroupID: groupID,
@@ -100,6 +108,14 @@ func AddGroupMember(ctx *gin.Context) {
 		return
 	}
 
+	// The emailed accept link carries a signed single-use token; only its hash is stored
+	token, tokenHash, err := utils.GenerateSignedToken(services.InvitationTokenPurpose)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	expiresAt := time.Now().Add(models.InvitationTTL)
+
 	// Create the invitation
 	invitation := &models.GroupInvitation{
 		GroupID:      groupID,
@@ -108,7 +124,8 @@ func AddGroupMember(ctx *gin.Context) {
 		InviteeID:    inviteeID,
 		Role:         requestBody.Role,
 		Status:       "pending",
-		ExpiresAt:    nil, // Can be set later if needed
+		ExpiresAt:    &expiresAt,
+		TokenHash:    &tokenHash,
 	}
 
 	err = invitation.Save(reqCtx)
@@ -117,6 +134,22 @@ func AddGroupMember(ctx *gin.Context) {
 		return
 	}
 
+	// Email the invitee in the background so a slow mail server doesn't block the response
+	go func(invitation models.GroupInvitation) {
+		emailCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := services.SendInvitationEmail(emailCtx, &invitation, token); err != nil {
+			log.Printf("Error sending invitation email for invitation %s: %v", invitation.ID, err)
+		}
+	}(*invitation)
+
+	// Invitees who already have an account see the invitation in their notification stream
+	if inviteeID != nil {
+		services.PushToUser(*inviteeID, "invitation", gin.H{"invitation": invitation})
+		services.PushUnreadCounts(*inviteeID, requestBody.Email)
+	}
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"message":    "Invitation sent successfully",
 		"invitation": invitation,
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateLabel adds a label to the group; owners and admins only
+// POST /api/groups/:groupID/labels
+func CreateLabel(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage labels") {
+		return
+	}
+
+	var requestBody struct {
+		Name  string `json:"name" binding:"required"`
+		Color string `json:"color" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label := models.GroupLabel{
+		GroupID: ctx.Param("groupID"),
+		Name:    strings.TrimSpace(requestBody.Name),
+		Color:   requestBody.Color,
+	}
+	if label.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "label name cannot be empty"})
+		return
+	}
+	if err := models.ValidateLabelColor(label.Color); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = label.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"label": label})
+}
+
+// GetLabels lists the group's labels
+// GET /api/groups/:groupID/labels
+func GetLabels(ctx *gin.Context) {
+	labels, err := models.GetGroupLabels(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"labels": labels})
+}
+
+// UpdateLabel renames or recolors a label; owners and admins only
+// PATCH /api/groups/:groupID/labels/:labelID
+func UpdateLabel(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage labels") {
+		return
+	}
+
+	label, ok := getGroupLabel(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Name != nil {
+		label.Name = strings.TrimSpace(*requestBody.Name)
+		if label.Name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "label name cannot be empty"})
+			return
+		}
+	}
+	if requestBody.Color != nil {
+		if err := models.ValidateLabelColor(*requestBody.Color); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		label.Color = *requestBody.Color
+	}
+
+	err = label.Update(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"label": label})
+}
+
+// DeleteLabel removes a label from the group and its tasks; owners and admins only
+// DELETE /api/groups/:groupID/labels/:labelID
+func DeleteLabel(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage labels") {
+		return
+	}
+
+	label, ok := getGroupLabel(ctx)
+	if !ok {
+		return
+	}
+
+	err := label.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Label deleted successfully"})
+}
+
+// SetTaskLabels replaces the labels of a task
+// PUT /api/groups/:groupID/tasks/:taskId/labels
+func SetTaskLabels(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	if _, ok := getTaskForUpdate(ctx, taskID, groupID); !ok {
+		return
+	}
+
+	var requestBody struct {
+		LabelIDs []string `json:"label_ids" binding:"required"` // [] removes every label
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for _, labelID := range requestBody.LabelIDs {
+		if _, err := uuid.Parse(labelID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid label id " + labelID})
+			return
+		}
+	}
+
+	err = models.SetTaskLabels(ctx.Request.Context(), taskID, groupID, requestBody.LabelIDs)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := models.GetTaskByID(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// getGroupLabel loads the label from the URL and checks it belongs to the group, writing the error response otherwise
+func getGroupLabel(ctx *gin.Context) (*models.GroupLabel, bool) {
+	label := &models.GroupLabel{ID: ctx.Param("labelID")}
+	err := label.Get(ctx.Request.Context())
+	if err != nil || label.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "label not found"})
+		return nil, false
+	}
+	return label, true
+}
+
+// requireGroupAdmin checks the caller is an owner or admin of the group, writing a 403 with message otherwise
+func requireGroupAdmin(ctx *gin.Context, message string) bool {
+	role := ctx.GetString("role")
+	if role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": message})
+		return false
+	}
+	return true
+}
@@ -1,15 +1,21 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// CreateLink creates a new link
+// CreateLink creates a new link. Its preview (title, description and image) is fetched in the
+// background; the fields sent by the client are kept.
 // POST /api/groups/:groupID/links
 func CreateLink(ctx *gin.Context) {
 	groupID := ctx.Param("groupID")
@@ -37,6 +43,13 @@ func CreateLink(ctx *gin.Context) {
 		return
 	}
 
+	if _, err := utils.ValidateOutboundURL(req.URL); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
 	// Create link model
 	link := &models.Link{
 		URL:             req.URL,
@@ -56,6 +69,8 @@ func CreateLink(ctx *gin.Context) {
 		return
 	}
 
+	unfurlLinkAsync(*link)
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"link": link,
 	})
@@ -82,6 +97,10 @@ func GetLinks(ctx *gin.Context) {
 		return
 	}
 
+	for i := range links {
+		presignLinkPreview(ctx.Request.Context(), &links[i])
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"links": links,
 	})
@@ -109,12 +128,14 @@ func GetLink(ctx *gin.Context) {
 		return
 	}
 
+	presignLinkPreview(ctx.Request.Context(), link)
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"link": link,
 	})
 }
 
-// DeleteLink deletes a link
+// DeleteLink moves a link to the trash
 // DELETE /api/groups/:groupID/links/:linkID
 func DeleteLink(ctx *gin.Context) {
 	linkID := ctx.Param("linkID")
@@ -146,8 +167,8 @@ func DeleteLink(ctx *gin.Context) {
 		return
 	}
 
-	// Delete link
-	err = link.Delete(ctx.Request.Context())
+	// Move the link to the trash; its preview image is deleted when the trash is purged
+	err = link.Trash(ctx.Request.Context(), userID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("Failed to delete link: %v", err),
@@ -156,6 +177,69 @@ func DeleteLink(ctx *gin.Context) {
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Link deleted successfully",
+		"message": "Link moved to trash successfully",
+	})
+}
+
+// RefreshLinkPreview fetches the link's preview again, replacing the title, description and image
+// POST /api/groups/:groupID/links/:linkID/preview
+func RefreshLinkPreview(ctx *gin.Context) {
+	linkID := ctx.Param("linkID")
+
+	// Validate link ID
+	if _, err := uuid.Parse(linkID); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid link ID",
+		})
+		return
+	}
+
+	link := &models.Link{ID: linkID}
+	err := link.GetByID(ctx.Request.Context())
+	if err != nil || link.GroupID == nil || *link.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Link not found",
+		})
+		return
+	}
+
+	unfurlCtx, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	err = services.UnfurlLink(unfurlCtx, link, true)
+	presignLinkPreview(ctx.Request.Context(), link)
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{
+			"error": fmt.Sprintf("Failed to fetch link preview: %v", err),
+			"link":  link,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"link": link,
 	})
 }
+
+// unfurlLinkAsync fetches a new link's preview without blocking the response
+func unfurlLinkAsync(link models.Link) {
+	go func() {
+		unfurlCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := services.UnfurlLink(unfurlCtx, &link, false); err != nil {
+			log.Printf("Error fetching preview of link %s: %v", link.ID, err)
+		}
+	}()
+}
+
+// presignLinkPreview points the preview image at its stored copy when there is one
+func presignLinkPreview(ctx context.Context, link *models.Link) {
+	if link.PreviewImageS3Key == nil || blobStore == nil {
+		return
+	}
+	presignedURL, err := blobStore.PresignGet(ctx, *link.PreviewImageS3Key, 1*time.Hour)
+	if err == nil {
+		link.PreviewImageURL = &presignedURL
+	}
+}
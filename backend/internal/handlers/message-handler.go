@@ -16,12 +16,16 @@ import (
 // It broadcasts via WebSocket first for real-time display, then saves to DB in background
 func CreateMessage(hub *WS.Hub) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if !isGroupRoom(ctx) {
+			return
+		}
 		roomID := ctx.Param("roomId")
 		userID := ctx.GetString("userID")
 		username := ctx.GetString("username")
 
 		var requestBody struct {
-			Content string `json:"content" binding:"required"`
+			Content  string  `json:"content" binding:"required"`
+			ParentID *string `json:"parent_id"` // Reply in the thread of this message
 		}
 
 		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
@@ -29,6 +33,25 @@ func CreateMessage(hub *WS.Hub) gin.HandlerFunc {
 			return
 		}
 
+		// Replies to a reply go in the thread of its first message
+		var parentID *string
+		if requestBody.ParentID != nil {
+			if _, err := uuid.Parse(*requestBody.ParentID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+				return
+			}
+			rootID, found, err := models.GetThreadRoot(ctx.Request.Context(), roomID, *requestBody.ParentID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !found {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "parent message not found"})
+				return
+			}
+			parentID = &rootID
+		}
+
 		// Generate message ID and timestamp immediately
 		messageID := uuid.New().String()
 		now := time.Now()
@@ -40,23 +63,15 @@ func CreateMessage(hub *WS.Hub) gin.HandlerFunc {
 			UserID:    userID,
 			Username:  username,
 			Content:   requestBody.Content,
+			ParentID:  parentID,
+			Reactions: []models.ReactionSummary{},
 			CreatedAt: now,
 			UpdatedAt: now,
 		}
 
-		// Create WebSocket message for broadcast
-		wsMessage := &WS.Message{
-			ID:        messageID,
-			RoomID:    roomID,
-			UserID:    userID,
-			Username:  username,
-			Content:   requestBody.Content,
-			CreatedAt: now.Format(time.RFC3339),
-		}
-
 		// Broadcast via WebSocket immediately (non-blocking)
 		if _, ok := hub.Rooms[roomID]; ok {
-			hub.Broadcast <- wsMessage
+			hub.Broadcast <- WS.NewMessageCreated(&message)
 		}
 
 		// Save to database in background (non-blocking goroutine)
@@ -81,6 +96,9 @@ func CreateMessage(hub *WS.Hub) gin.HandlerFunc {
 
 // GetRoomMessages retrieves all messages for a room with pagination
 func GetRoomMessages(ctx *gin.Context) {
+	if !isGroupRoom(ctx) {
+		return
+	}
 	roomID := ctx.Param("roomId")
 	limit := 50 // default limit
 	offset := 0 // default offset
@@ -118,6 +136,9 @@ func GetRoomMessages(ctx *gin.Context) {
 
 // GetUserRoomMessages retrieves messages for a specific user in a room
 func GetUserRoomMessages(ctx *gin.Context) {
+	if !isGroupRoom(ctx) {
+		return
+	}
 	roomID := ctx.Param("roomId")
 	userID := ctx.Param("userId")
 	limit := 50
@@ -154,41 +175,201 @@ func GetUserRoomMessages(ctx *gin.Context) {
 	})
 }
 
-// DeleteMessage deletes a message by ID
-func DeleteMessage(ctx *gin.Context) {
-	messageID := ctx.Param("messageId")
-	userID := ctx.GetString("userID")
+// DeleteMessage deletes one of the user's messages by ID. A message that starts a thread stays as an
+// empty placeholder so the replies of other members are kept.
+func DeleteMessage(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID := ctx.GetString("userID")
 
-	if userID == "" {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found"})
-		return
+		if userID == "" {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user ID not found"})
+			return
+		}
+
+		msgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// First, get the message to check if the user is the creator
+		message, ok := getGroupMessage(ctx)
+		if !ok {
+			return
+		}
+
+		if message.DeletedAt != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		// Check if the current user is the creator of the message
+		if message.UserID != userID {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own messages"})
+			return
+		}
+
+		// Delete the message
+		placeholder, err := models.DeleteMessage(msgCtx, message.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		go hub.BroadcastEvent(message.RoomID, userID, ctx.GetString("username"), WS.EventMessageDeleted, WS.MessageDeletedEvent{
+			MessageID:   message.ID,
+			ParentID:    message.ParentID,
+			Placeholder: placeholder,
+		})
+
+		ctx.JSON(http.StatusOK, gin.H{"message": "message deleted successfully"})
 	}
+}
 
-	msgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// EditMessage replaces the content of one of the user's messages and marks it edited
+// PATCH /api/groups/:groupID/ws/messages/:messageId
+func EditMessage(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var requestBody struct {
+			Content string `json:"content" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		message, ok := getGroupMessage(ctx)
+		if !ok {
+			return
+		}
+		if message.DeletedAt != nil {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+		if message.UserID != ctx.GetString("userID") {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only edit your own messages"})
+			return
+		}
+
+		err := hub.EditMessage(ctx.Request.Context(), message, ctx.GetString("username"), requestBody.Content)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
-	// First, get the message to check if the user is the creator
-	message := &models.Message{
-		ID: messageID,
+		ctx.JSON(http.StatusOK, gin.H{"data": message})
 	}
-	err := message.GetByID(msgCtx)
+}
+
+// GetMessageThread retrieves a message with the replies in its thread, oldest first
+// GET /api/groups/:groupID/ws/messages/:messageId/thread
+func GetMessageThread(ctx *gin.Context) {
+	limit, offset, ok := getPagination(ctx, 50)
+	if !ok {
+		return
+	}
+
+	message, ok := getGroupMessage(ctx)
+	if !ok {
+		return
+	}
+	if message.ParentID != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "message is a reply; get the thread of its parent_id"})
+		return
+	}
+
+	replies, err := models.GetThreadMessages(ctx.Request.Context(), message.ID, int(limit), int(offset))
 	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if replies == nil {
+		replies = []models.Message{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"replies": replies,
+		"count":   len(replies),
+	})
+}
+
+// AddMessageReaction reacts to a message with an emoji
+// POST /api/groups/:groupID/ws/messages/:messageId/reactions
+func AddMessageReaction(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var requestBody struct {
+			Emoji string `json:"emoji" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		reactToMessage(ctx, hub, requestBody.Emoji, true)
+	}
+}
+
+// RemoveMessageReaction takes back the user's reaction to a message
+// DELETE /api/groups/:groupID/ws/messages/:messageId/reactions/:emoji
+func RemoveMessageReaction(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reactToMessage(ctx, hub, ctx.Param("emoji"), false)
+	}
+}
+
+// reactToMessage adds or removes the user's reaction, writing the response
+func reactToMessage(ctx *gin.Context, hub *WS.Hub, emoji string, add bool) {
+	if !models.ValidReactionEmoji(emoji) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid emoji"})
+		return
+	}
+
+	message, ok := getGroupMessage(ctx)
+	if !ok {
+		return
+	}
+	if add && message.DeletedAt != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
 		return
 	}
 
-	// Check if the current user is the creator of the message
-	if message.UserID != userID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only delete your own messages"})
+	changed, err := hub.ReactToMessage(ctx.Request.Context(), message, ctx.GetString("userID"), ctx.GetString("username"), emoji, add)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !add && !changed {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "reaction not found"})
 		return
 	}
 
-	// Delete the message
-	err = models.DeleteMessage(msgCtx, messageID)
+	reactions, err := models.GetMessageReactions(ctx.Request.Context(), []string{message.ID})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if reactions[message.ID] == nil {
+		reactions[message.ID] = []models.ReactionSummary{}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reactions": reactions[message.ID]})
+}
+
+// isGroupRoom tells whether the room of the URL is the group's own chat room, writing a 404 when
+// it is not. Membership is checked for the group only, so other rooms must not be reachable through it.
+func isGroupRoom(ctx *gin.Context) bool {
+	if ctx.Param("roomId") != ctx.Param("groupID") {
+		ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "room not found"})
+		return false
+	}
+	return true
+}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "message deleted successfully"})
+// getGroupMessage loads the message of the URL, writing a 404 when the group has no such message
+func getGroupMessage(ctx *gin.Context) (*models.Message, bool) {
+	messageID := ctx.Param("messageId")
+	message := &models.Message{ID: messageID}
+	if _, err := uuid.Parse(messageID); err != nil || message.GetByID(ctx.Request.Context()) != nil || message.RoomID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return nil, false
+	}
+	return message, true
 }
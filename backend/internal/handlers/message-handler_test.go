@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsGroupRoom(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		groupID string
+		roomID  string
+		allowed bool
+	}{
+		{"group-a", "group-a", true},
+		{"group-a", "group-b", false},
+		{"group-a", "", false},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Params = gin.Params{{Key: "groupID", Value: test.groupID}, {Key: "roomId", Value: test.roomID}}
+
+		if allowed := isGroupRoom(ctx); allowed != test.allowed {
+			t.Errorf("room %q of group %q: allowed %v, want %v", test.roomID, test.groupID, allowed, test.allowed)
+		}
+		if !test.allowed && (recorder.Code != http.StatusNotFound || !ctx.IsAborted()) {
+			t.Errorf("room %q of group %q: status %d, aborted %v", test.roomID, test.groupID, recorder.Code, ctx.IsAborted())
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MarkRoomRead moves the user's read pointer in the group chat up to a message and tells the room.
+// Pointers never move back, so marking an older message returns the pointer unchanged.
+// PUT /api/groups/:groupID/ws/reads
+func MarkRoomRead(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var requestBody struct {
+			MessageID string `json:"message_id" binding:"required"`
+		}
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		message := &models.Message{ID: requestBody.MessageID}
+		if _, err := uuid.Parse(message.ID); err != nil || message.GetByID(ctx.Request.Context()) != nil || message.RoomID != ctx.Param("groupID") {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		userID := ctx.GetString("userID")
+		read, advanced, err := hub.MarkRead(ctx.Request.Context(), message, userID, ctx.GetString("username"))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !advanced {
+			read, err = models.GetMessageRead(ctx.Request.Context(), userID, message.RoomID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"read": read, "advanced": advanced})
+	}
+}
+
+// GetRoomReads lists how far each member has read the group chat, most recent reader first
+// GET /api/groups/:groupID/ws/reads
+func GetRoomReads(ctx *gin.Context) {
+	reads, err := models.GetRoomReads(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reads": reads})
+}
+
+// SendTyping tells the group chat the user started or stopped typing, for clients without a chat
+// connection. Typing is not stored; clients expire typing.started after a few seconds.
+// POST /api/groups/:groupID/ws/typing
+func SendTyping(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var requestBody struct {
+			Typing   *bool   `json:"typing" binding:"required"`
+			ParentID *string `json:"parent_id"` // Typing in the thread of this message
+		}
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if requestBody.ParentID != nil {
+			if _, err := uuid.Parse(*requestBody.ParentID); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent_id"})
+				return
+			}
+		}
+
+		go hub.Typing(ctx.Param("groupID"), ctx.GetString("userID"), ctx.GetString("username"), requestBody.ParentID, *requestBody.Typing)
+
+		ctx.JSON(http.StatusOK, gin.H{"statusOk": true})
+	}
+}
+
+// GetGroupPresence returns whether each member of the group is online, away or offline
+// GET /api/groups/:groupID/presence
+func GetGroupPresence(hub *WS.Hub) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		memberIDs, err := models.GetGroupMemberIDs(ctx.Request.Context(), ctx.Param("groupID"), "owner", "admin", "member", "viewer")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"presence": hub.Presence(memberIDs)})
+	}
+}
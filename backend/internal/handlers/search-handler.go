@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxSearchLimit = 50
+
+// Search looks for tasks, messages, files, folders and links matching q in the groups the user
+// belongs to. It accepts types (comma separated), group_id, limit and the cursor of the previous
+// page; facets count the results of each type whatever the types filter.
+// GET /api/search
+func Search(ctx *gin.Context) {
+	text := strings.TrimSpace(ctx.Query("q"))
+	if text == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	query := models.SearchQuery{
+		UserID: ctx.GetString("userID"),
+		Text:   text,
+		Limit:  20,
+	}
+
+	if types := ctx.Query("types"); types != "" {
+		for _, resultType := range strings.Split(types, ",") {
+			resultType = strings.TrimSpace(resultType)
+			if !slices.Contains(models.SearchResultTypes, resultType) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid type " + resultType + ". Must be one of: " + strings.Join(models.SearchResultTypes, ", ")})
+				return
+			}
+			query.Types = append(query.Types, resultType)
+		}
+	}
+
+	if groupID := ctx.Query("group_id"); groupID != "" {
+		if _, err := uuid.Parse(groupID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_id"})
+			return
+		}
+		query.GroupID = &groupID
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxSearchLimit)})
+			return
+		}
+		query.Limit = parsed
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		after, err := models.DecodeSearchCursor(cursor)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query.After = after
+	}
+
+	results, facets, next, err := models.Search(ctx.Request.Context(), query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var nextCursor *string
+	if next != nil {
+		encoded := next.Encode()
+		nextCursor = &encoded
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"results":     results,
+		"facets":      facets,
+		"next_cursor": nextCursor, // null on the last page
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// createSession starts a session for the user on the requesting device and returns the token fields
+// sent to the client: a short-lived access token and a refresh token
+func createSession(ctx *gin.Context, userID string) (gin.H, error) {
+	refreshToken, refreshTokenHash, err := utils.GenerateSignedToken(utils.RefreshTokenPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := &models.Auth{
+		UserID:           userID,
+		RefreshTokenHash: refreshTokenHash,
+		UserAgent:        ctx.Request.UserAgent(),
+		IPAddress:        ctx.ClientIP(),
+		ExpiresAt:        time.Now().Add(utils.RefreshTokenTTL()),
+	}
+
+	auth, err = auth.Create()
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionTokens(auth, refreshToken)
+}
+
+// sessionTokens issues an access token for the session and pairs it with the refresh token
+func sessionTokens(auth *models.Auth, refreshToken string) (gin.H, error) {
+	token, err := utils.GenerateToken(utils.AuthDetails{
+		UserID:   auth.UserID,
+		AuthUuid: auth.AuthUUID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"token":                    token,
+		"expires_in":               int(utils.AccessTokenTTL().Seconds()),
+		"refresh_token":            refreshToken,
+		"refresh_token_expires_at": auth.ExpiresAt,
+	}, nil
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a refresh token that was already rotated out revokes the whole session.
+// POST /api/auth/refresh
+func RefreshToken(ctx *gin.Context) {
+	var requestBody struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokenHash, err := utils.VerifySignedToken(utils.RefreshTokenPurpose, requestBody.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	reqCtx := ctx.Request.Context()
+
+	auth, reused, err := models.FetchAuthByRefreshToken(reqCtx, tokenHash)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	if reused {
+		// Someone else holds a copy of this session's tokens
+		log.Printf("Refresh token reuse detected for session %d of user %s, revoking session", auth.ID, auth.UserID)
+		_, _ = models.DeleteAuthByID(reqCtx, auth.UserID, auth.ID)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has already been used"})
+		return
+	}
+
+	if !auth.ExpiresAt.After(time.Now()) {
+		_, _ = models.DeleteAuthByID(reqCtx, auth.UserID, auth.ID)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+		return
+	}
+
+	refreshToken, refreshTokenHash, err := utils.GenerateSignedToken(utils.RefreshTokenPurpose)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = auth.Rotate(reqCtx, refreshTokenHash, time.Now().Add(utils.RefreshTokenTTL()), ctx.Request.UserAgent(), ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := sessionTokens(auth, refreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// GetSessions lists the current user's active sessions (devices)
+// GET /api/sessions
+func GetSessions(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+	currentAuthUUID := ctx.GetString("authUUID")
+
+	auths, err := models.GetUserAuths(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	sessions := make([]gin.H, 0, len(auths))
+	for _, auth := range auths {
+		sessions = append(sessions, gin.H{
+			"id":           auth.ID,
+			"user_agent":   auth.UserAgent,
+			"ip_address":   auth.IPAddress,
+			"created_at":   auth.CreatedAt,
+			"last_used_at": auth.LastUsedAt,
+			"expires_at":   auth.ExpiresAt,
+			"current":      auth.AuthUUID == currentAuthUUID,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// DeleteSession logs out one of the current user's devices
+// DELETE /api/sessions/:sessionID
+func DeleteSession(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+
+	sessionID, err := strconv.ParseUint(ctx.Param("sessionID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	deleted, err := models.DeleteAuthByID(ctx.Request.Context(), userID, sessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// LogoutAllDevices revokes every session of the current user, including this one
+// POST /api/logout/all
+func LogoutAllDevices(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+
+	revoked, err := models.DeleteUserAuths(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices successfully", "sessions_revoked": revoked})
+}
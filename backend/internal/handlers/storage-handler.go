@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetStoredObject serves an object of the local file storage to the holder of a presigned URL.
+// Objects are user content served from the API's origin, so they are sent as sandboxed downloads
+// the browser must not sniff or render as a page.
+// GET /api/storage/*key
+func GetStoredObject(ctx *gin.Context) {
+	store, key, _, ok := verifyStorageRequest(ctx, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	object, err := store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Object not found",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to read object: %v", err),
+		})
+		return
+	}
+	defer object.Close()
+
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Header("Content-Security-Policy", "sandbox")
+
+	// Files support range requests; ServeContent sets the type from the file name
+	if seeker, ok := object.(io.ReadSeeker); ok {
+		http.ServeContent(ctx.Writer, ctx.Request, path.Base(key), time.Time{}, seeker)
+		return
+	}
+	ctx.DataFromReader(http.StatusOK, -1, "application/octet-stream", object, nil)
+}
+
+// PutStoredObject stores the body under the key of a presigned upload URL of the local file storage.
+// The body must have the size and SHA-256 the URL was signed for, and the upload session of the key
+// must still be pending.
+// PUT /api/storage/*key
+func PutStoredObject(ctx *gin.Context) {
+	store, key, content, ok := verifyStorageRequest(ctx, http.MethodPut)
+	if !ok {
+		return
+	}
+
+	pending, err := models.IsUploadPending(ctx, key)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !pending {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": "Upload is no longer pending",
+		})
+		return
+	}
+
+	if ctx.Request.ContentLength > content.Size {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Object is larger than the upload announced",
+		})
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, content.Size)
+	if err := store.PutVerified(ctx, key, body, *content); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": "Object is larger than the upload announced",
+			})
+			return
+		}
+		if errors.Is(err, utils.ErrContentMismatch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Content does not match the size and SHA-256 of the upload",
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to store object: %v", err),
+		})
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// verifyStorageRequest checks the signature of a presigned local storage URL and writes the error
+// response when it is not valid. Upload URLs also return the content they were signed for.
+func verifyStorageRequest(ctx *gin.Context, method string) (*utils.LocalBlobStore, string, *utils.UploadContent, bool) {
+	store, ok := blobStore.(*utils.LocalBlobStore)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Local file storage is not enabled",
+		})
+		return nil, "", nil, false
+	}
+
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	content, err := store.VerifySignedRequest(method, key, ctx.Request.URL.Query())
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+		})
+		return nil, "", nil, false
+	}
+	return store, key, content, true
+}
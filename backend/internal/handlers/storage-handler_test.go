@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetStoredObjectServesDownloads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LOCAL_STORAGE_PATH", t.TempDir())
+	t.Setenv("STORAGE_SIGNING_SECRET", "test-secret")
+	store, err := utils.NewLocalBlobStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := blobStore
+	blobStore = store
+	t.Cleanup(func() { blobStore = previous })
+
+	ctx := context.Background()
+	page := "<html><script>alert(document.cookie)</script></html>"
+	if err := store.Put(ctx, "group/file/page.html", strings.NewReader(page), "text/html"); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := store.PresignGet(ctx, "group/file/page.html", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedURL, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/api/storage/*key", GetStoredObject)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, signedURL.RequestURI(), nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != page {
+		t.Fatalf("got %d %q", recorder.Code, recorder.Body)
+	}
+	headers := map[string]string{
+		"Content-Disposition":     `attachment; filename=page.html`,
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "sandbox",
+	}
+	for name, want := range headers {
+		if got := recorder.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
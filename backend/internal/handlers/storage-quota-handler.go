@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetStorageUsage reports the group's storage against its quota, broken down by folder and mime
+// type, together with the requester's own usage and quota
+// GET /api/groups/:groupID/storage
+func GetStorageUsage(ctx *gin.Context) {
+	groupID := ctx.Param("groupID")
+	userID := ctx.GetString("userID")
+
+	usage, err := models.GetGroupStorageUsage(ctx, groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	quota, err := services.GroupStorageQuota(ctx, groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byFolder, err := models.GetStorageUsageByFolder(ctx, groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byMimeType, err := models.GetStorageUsageByMimeType(ctx, groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	memberUsage, err := models.GetMemberStorageUsage(ctx, groupID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	memberQuota, err := services.MemberStorageQuota(ctx, groupID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"usage":           usage,
+		"quota_bytes":     quota, // null when unlimited
+		"warning_percent": utils.StorageWarningPercent(),
+		"by_folder":       byFolder,
+		"by_mime_type":    byMimeType,
+		"member": gin.H{
+			"usage":       memberUsage,
+			"quota_bytes": memberQuota,
+		},
+	})
+}
+
+// UpdateGroupStorageQuota sets the group's quota in bytes; null goes back to the default
+// (GROUP_STORAGE_QUOTA). A group can set at most utils.MaxStorageQuota, or the configured default
+// when it is lower.
+// PUT /api/groups/:groupID/storage/quota
+func UpdateGroupStorageQuota(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "Only group admins can change the storage quota") {
+		return
+	}
+
+	var requestBody struct {
+		QuotaBytes *int64 `json:"quota_bytes" binding:"omitempty,min=0"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := utils.MaxStorageQuota()
+	if defaultQuota := utils.DefaultGroupStorageQuota(); defaultQuota != nil {
+		limit = min(limit, *defaultQuota)
+	}
+	if requestBody.QuotaBytes != nil && *requestBody.QuotaBytes > limit {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("The storage quota can be at most %s", utils.FormatBytes(limit)),
+		})
+		return
+	}
+
+	groupID := ctx.Param("groupID")
+	if err := models.SetGroupStorageQuota(ctx, groupID, requestBody.QuotaBytes); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	checkStorageThresholdAsync(groupID)
+
+	quota, err := services.GroupStorageQuota(ctx, groupID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Storage quota updated successfully",
+		"quota_bytes": quota,
+	})
+}
+
+// UpdateMemberStorageQuota sets how many bytes a member may upload to the group, at most
+// utils.MaxStorageQuota; null goes back to the default (USER_STORAGE_QUOTA)
+// PUT /api/groups/:groupID/members/:userId/storage-quota
+func UpdateMemberStorageQuota(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "Only group admins can change storage quotas") {
+		return
+	}
+
+	var requestBody struct {
+		QuotaBytes *int64 `json:"quota_bytes" binding:"omitempty,min=0"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if limit := utils.MaxStorageQuota(); requestBody.QuotaBytes != nil && *requestBody.QuotaBytes > limit {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("The storage quota can be at most %s", utils.FormatBytes(limit)),
+		})
+		return
+	}
+
+	groupID := ctx.Param("groupID")
+	userID := ctx.Param("userId")
+	updated, err := models.SetMemberStorageQuota(ctx, groupID, userID, requestBody.QuotaBytes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Group member not found"})
+		return
+	}
+
+	quota, err := services.MemberStorageQuota(ctx, groupID, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Storage quota updated successfully",
+		"quota_bytes": quota,
+	})
+}
+
+// reserveStorage holds the storage of an upload, refusing uploads that would take the group or the
+// uploader over their quota. The caller releases the reservation with releaseStorage.
+func reserveStorage(ctx *gin.Context, groupID string, size int64) (*models.StorageReservation, bool) {
+	reservation, reason, err := services.ReserveStorage(ctx, groupID, ctx.GetString("userID"), size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to check storage quota: %v", err),
+		})
+		return nil, false
+	}
+	if reason != "" {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Storage quota exceeded: " + reason,
+		})
+		return nil, false
+	}
+	return reservation, true
+}
+
+// releaseStorage gives reserved storage back once the upload is saved, when it counts as used, or failed
+func releaseStorage(reservation *models.StorageReservation) {
+	if err := reservation.Release(context.Background()); err != nil {
+		log.Printf("Error releasing storage reservation %s: %v", reservation.ID, err)
+	}
+}
+
+// checkStorageThresholdAsync warns the group's owners in the background when storage changed
+func checkStorageThresholdAsync(groupID string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		services.CheckStorageThreshold(ctx, groupID)
+	}()
+}
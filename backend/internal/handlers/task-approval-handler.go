@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetApprovalQueue lists the group's approval requests for owners and admins. It accepts status
+// (pending by default, or approved, rejected, cancelled), limit and offset.
+// GET /api/groups/:groupID/approvals
+func GetApprovalQueue(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can review approvals") {
+		return
+	}
+
+	status := ctx.DefaultQuery("status", "pending")
+	if !slices.Contains(models.TaskApprovalStatuses, status) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid status. Must be one of: " + strings.Join(models.TaskApprovalStatuses, ", ")})
+		return
+	}
+
+	limit, offset, ok := getPagination(ctx, 20)
+	if !ok {
+		return
+	}
+
+	approvals, total, err := models.GetGroupApprovals(ctx.Request.Context(), ctx.Param("groupID"), status, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"approvals": approvals, "total": total})
+}
+
+// ApproveTask approves a pending request and activates the task; owners and admins only
+// POST /api/groups/:groupID/approvals/:approvalID/approve
+func ApproveTask(ctx *gin.Context) {
+	decideApproval(ctx, "approved", "active")
+}
+
+// RejectTask rejects a pending request and marks the task rejected; owners and admins only.
+// A comment explaining the rejection is required.
+// POST /api/groups/:groupID/approvals/:approvalID/reject
+func RejectTask(ctx *gin.Context) {
+	decideApproval(ctx, "rejected", "rejected")
+}
+
+// GetTaskApprovals returns the approval history of a task
+// GET /api/groups/:groupID/tasks/:taskId/approvals
+func GetTaskApprovals(ctx *gin.Context) {
+	task, ok := getGroupTask(ctx, ctx.Param("taskId"), ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	approvals, err := models.GetTaskApprovals(ctx.Request.Context(), task.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"approvals": approvals})
+}
+
+// decideApproval settles the request from the URL with status, moving its task to taskStatus,
+// and notifies the requester
+func decideApproval(ctx *gin.Context, status string, taskStatus string) {
+	if !requireGroupAdmin(ctx, "only admins and owners can review approvals") {
+		return
+	}
+
+	var requestBody struct {
+		Comment string `json:"comment"`
+	}
+
+	// The body is optional when approving
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var comment *string
+	if trimmed := strings.TrimSpace(requestBody.Comment); trimmed != "" {
+		comment = &trimmed
+	}
+	if status == "rejected" && comment == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "a comment is required to reject a task"})
+		return
+	}
+
+	approval, task, ok := getGroupApproval(ctx)
+	if !ok {
+		return
+	}
+
+	if approval.Status != "pending" {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "approval request is already " + approval.Status})
+		return
+	}
+
+	if task.Status == "pending" && !checkStatusPreconditions(ctx, task, taskStatus, task.RequireSubtasksComplete) {
+		return
+	}
+
+	decided, err := approval.Decide(ctx.Request.Context(), ctx.GetString("userID"), status, comment, taskStatus)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !decided {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "approval request was already decided"})
+		return
+	}
+
+	if task.Status == "pending" {
+		recordTaskChange(ctx, task.ID, "status_change", task.Status, taskStatus)
+		runStatusWorkflowsAsync(task.ID, task.Status, taskStatus)
+
+		if taskStatus == "active" {
+			notifyAssigneesAsync(task.ID, "status_change")
+		} else {
+			notifyUnblockedDependentsAsync(task.ID)
+		}
+	}
+
+	notifyApprovalDecisionAsync(*approval)
+
+	ctx.JSON(http.StatusOK, gin.H{"approval": approval})
+}
+
+// getGroupApproval loads the approval request from the URL and its task, checking the task belongs
+// to the group and writing the error response otherwise
+func getGroupApproval(ctx *gin.Context) (*models.TaskApproval, *models.Task, bool) {
+	approval := &models.TaskApproval{ID: ctx.Param("approvalID")}
+	err := approval.Get(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "approval request not found"})
+		return nil, nil, false
+	}
+
+	task, err := models.GetTaskByID(ctx.Request.Context(), approval.TaskID)
+	if err != nil || task.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "approval request not found"})
+		return nil, nil, false
+	}
+
+	return approval, task, true
+}
+
+// openApprovalRequest queues a task that was put in pending by the current user and notifies the
+// group's approvers. Failures are logged; the task itself is already saved.
+func openApprovalRequest(ctx *gin.Context, task *models.Task, reason string) {
+	approval := models.TaskApproval{
+		TaskID:      task.ID,
+		RequestedBy: ctx.GetString("userID"),
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		approval.Reason = &reason
+	}
+
+	opened, err := approval.Save(ctx.Request.Context())
+	if err != nil {
+		log.Printf("Error opening approval request for task %s: %v", task.ID, err)
+		return
+	}
+	if !opened {
+		return
+	}
+
+	groupID := task.GroupID
+	go func() {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		services.NotifyApprovers(notificationCtx, groupID, &approval)
+	}()
+}
+
+// closeApprovalRequest settles the open request of a task the current user moved out of pending
+// directly: rejecting or cancelling the task rejects or cancels the request, any other status approves it
+func closeApprovalRequest(ctx *gin.Context, taskID string, status string) {
+	approvalStatus := "approved"
+	if status == "rejected" || status == "cancelled" {
+		approvalStatus = status
+	}
+
+	approval, err := models.CloseTaskApproval(ctx.Request.Context(), taskID, ctx.GetString("userID"), approvalStatus)
+	if err != nil {
+		log.Printf("Error closing approval request for task %s: %v", taskID, err)
+		return
+	}
+	if approval != nil {
+		notifyApprovalDecisionAsync(*approval)
+	}
+}
+
+// notifyApprovalDecisionAsync tells the requester about the decision without blocking the response
+func notifyApprovalDecisionAsync(approval models.TaskApproval) {
+	go func() {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		services.NotifyApprovalDecision(notificationCtx, &approval)
+	}()
+}
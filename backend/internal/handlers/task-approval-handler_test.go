@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// decideAs posts a decision on the approval request as a user with the given role
+func decideAs(userID string, role string, groupID string, approvalID string, decision string, body any) (int, *models.TaskApproval) {
+	router := gin.New()
+	router.POST("/groups/:groupID/approvals/:approvalID/approve", asUser(userID, role), ApproveTask)
+	router.POST("/groups/:groupID/approvals/:approvalID/reject", asUser(userID, role), RejectTask)
+
+	resp := postJSON(router, "/groups/"+groupID+"/approvals/"+approvalID+"/"+decision, body)
+	var decided struct {
+		Approval *models.TaskApproval `json:"approval"`
+	}
+	json.Unmarshal(resp.Body.Bytes(), &decided)
+	return resp.Code, decided.Approval
+}
+
+// requestApproval creates a pending task by the requester and opens its approval request
+func requestApproval(t *testing.T, requester *models.User, groupID string) (*models.Task, *models.TaskApproval) {
+	t.Helper()
+	task := &models.Task{
+		GroupID:     groupID,
+		Title:       "Needs approval",
+		Description: "Created by a member",
+		DueDate:     time.Now().Add(24 * time.Hour),
+		CreatedBy:   requester.ID,
+		Status:      "pending",
+	}
+	if err := task.CreateTask(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	reason := "please"
+	approval := &models.TaskApproval{TaskID: task.ID, RequestedBy: requester.ID, Reason: &reason}
+	if opened, err := approval.Save(context.Background()); err != nil || !opened {
+		t.Fatalf("opening approval request: opened %v, err %v", opened, err)
+	}
+	return task, approval
+}
+
+func TestDecideApprovalChecksRoleAndComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		role     string
+		decision string
+		body     any
+		code     int
+	}{
+		{"member approving", "member", "approve", nil, http.StatusForbidden},
+		{"viewer rejecting", "viewer", "reject", gin.H{"comment": "no"}, http.StatusForbidden},
+		{"rejecting without a comment", "admin", "reject", nil, http.StatusBadRequest},
+		{"rejecting with a blank comment", "owner", "reject", gin.H{"comment": "  "}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		code, _ := decideAs("user", test.role, "group", "approval", test.decision, test.body)
+		if code != test.code {
+			t.Errorf("%s: got %d, want %d", test.name, code, test.code)
+		}
+	}
+}
+
+func TestDecideApproval(t *testing.T) {
+	dbtest.Setup(t)
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	owner := createTestUser(t)
+	requester := createTestUser(t)
+	group := &models.Group{Name: "Approvals", CreatedBy: owner.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	approvedTask, approval := requestApproval(t, requester, group.ID)
+	again := &models.TaskApproval{TaskID: approvedTask.ID, RequestedBy: requester.ID}
+	if opened, err := again.Save(ctx); err != nil || opened {
+		t.Fatalf("second open request: opened %v, err %v", opened, err)
+	}
+
+	code, decided := decideAs(owner.ID, "owner", group.ID, approval.ID, "approve", gin.H{"comment": "looks good"})
+	if code != http.StatusOK {
+		t.Fatalf("approve: got %d", code)
+	}
+	if decided.Status != "approved" || decided.DecidedBy == nil || *decided.DecidedBy != owner.ID || decided.DecisionComment == nil || *decided.DecisionComment != "looks good" {
+		t.Errorf("approved request = %+v", decided)
+	}
+	if task, err := models.GetTaskByID(ctx, approvedTask.ID); err != nil || task.Status != "active" {
+		t.Fatalf("approved task: %+v, err %v", task, err)
+	}
+
+	// A decided request stays decided
+	if code, _ := decideAs(owner.ID, "owner", group.ID, approval.ID, "reject", gin.H{"comment": "changed my mind"}); code != http.StatusConflict {
+		t.Errorf("deciding twice: got %d, want %d", code, http.StatusConflict)
+	}
+	if task, _ := models.GetTaskByID(ctx, approvedTask.ID); task.Status != "active" {
+		t.Errorf("task moved to %s by a second decision", task.Status)
+	}
+
+	rejectedTask, rejection := requestApproval(t, requester, group.ID)
+	if code, _ := decideAs(owner.ID, "admin", "other-group", rejection.ID, "reject", gin.H{"comment": "no"}); code != http.StatusNotFound {
+		t.Errorf("deciding through another group: got %d, want %d", code, http.StatusNotFound)
+	}
+	if code, _ := decideAs(owner.ID, "admin", group.ID, rejection.ID, "reject", gin.H{"comment": "out of scope"}); code != http.StatusOK {
+		t.Fatalf("reject: got %d", code)
+	}
+	if task, err := models.GetTaskByID(ctx, rejectedTask.ID); err != nil || task.Status != "rejected" {
+		t.Fatalf("rejected task: %+v, err %v", task, err)
+	}
+
+	history, err := models.GetTaskApprovals(ctx, rejectedTask.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Status != "rejected" || *history[0].DecisionComment != "out of scope" || *history[0].Reason != "please" {
+		t.Errorf("approval history = %+v", history)
+	}
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -66,6 +69,26 @@ func AssignTask(ctx *gin.Context) {
 		return
 	}
 
+	recordTaskChange(ctx, taskID, "assigned", "", requestBody.UserID)
+
+	// Notify the new assignee (background, errors don't block the response)
+	go func(userID string) {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		notification := models.TaskNotification{
+			TaskID:           taskID,
+			UserID:           userID,
+			NotificationType: "assignment",
+			ScheduledAt:      time.Now(),
+			Status:           "pending",
+		}
+		if err := notification.Save(notificationCtx); err != nil {
+			return
+		}
+		services.DeliverNotification(notificationCtx, &notification)
+	}(requestBody.UserID)
+
 	ctx.JSON(http.StatusCreated, gin.H{
 		"message":    "Task assigned successfully",
 		"assignment": assignment,
@@ -154,6 +177,8 @@ func UnassignTask(ctx *gin.Context) {
 		return
 	}
 
+	recordTaskChange(ctx, taskID, "unassigned", requestBody.UserID, "")
+
 	ctx.JSON(http.StatusOK, gin.H{
 		"message": "Assignment removed successfully",
 	})
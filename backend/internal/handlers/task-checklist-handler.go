@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateChecklistItem appends an item to the task's checklist
+// POST /api/groups/:groupID/tasks/:taskId/checklist
+func CreateChecklistItem(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+
+	if _, ok := getTaskForUpdate(ctx, taskID, ctx.Param("groupID")); !ok {
+		return
+	}
+
+	var requestBody struct {
+		Title string `json:"title" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	title := strings.TrimSpace(requestBody.Title)
+	if title == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "checklist item title cannot be empty"})
+		return
+	}
+
+	item := models.TaskChecklistItem{
+		TaskID:    taskID,
+		Title:     title,
+		CreatedBy: ctx.GetString("userID"),
+	}
+
+	err = item.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"item": item})
+}
+
+// GetChecklist lists the task's checklist in display order
+// GET /api/groups/:groupID/tasks/:taskId/checklist
+func GetChecklist(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+
+	task, ok := getGroupTask(ctx, taskID, ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	items, err := models.GetTaskChecklist(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"checklist": items, "progress": task.Progress})
+}
+
+// UpdateChecklistItem renames, moves, checks or unchecks a checklist item
+// PATCH /api/groups/:groupID/tasks/:taskId/checklist/:itemId
+func UpdateChecklistItem(ctx *gin.Context) {
+	item, ok := getChecklistItem(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Title     *string `json:"title"`
+		Position  *int    `json:"position"`
+		Completed *bool   `json:"completed"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Title != nil {
+		title := strings.TrimSpace(*requestBody.Title)
+		if title == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "checklist item title cannot be empty"})
+			return
+		}
+		item.Title = title
+	}
+	if requestBody.Position != nil {
+		item.Position = *requestBody.Position
+	}
+	completed := item.CompletedAt != nil
+	if requestBody.Completed != nil {
+		completed = *requestBody.Completed
+	}
+
+	err = item.Update(ctx.Request.Context(), completed, ctx.GetString("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"item": item})
+}
+
+// DeleteChecklistItem removes an item from the task's checklist
+// DELETE /api/groups/:groupID/tasks/:taskId/checklist/:itemId
+func DeleteChecklistItem(ctx *gin.Context) {
+	item, ok := getChecklistItem(ctx)
+	if !ok {
+		return
+	}
+
+	err := item.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Checklist item deleted successfully"})
+}
+
+// getChecklistItem loads the checklist item from the URL after checking the caller may update its task,
+// writing the error response otherwise
+func getChecklistItem(ctx *gin.Context) (*models.TaskChecklistItem, bool) {
+	taskID := ctx.Param("taskId")
+
+	if _, ok := getTaskForUpdate(ctx, taskID, ctx.Param("groupID")); !ok {
+		return nil, false
+	}
+
+	item := &models.TaskChecklistItem{ID: ctx.Param("itemId")}
+	err := item.Get(ctx.Request.Context())
+	if err != nil || item.TaskID != taskID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "checklist item not found"})
+		return nil, false
+	}
+
+	return item, true
+}
@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTaskComment adds a comment to a task. Mentions are the IDs of the group members
+// mentioned in the body; each of them gets a "mention" notification.
+// POST /api/groups/:groupID/tasks/:taskId/comments
+func CreateTaskComment(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	if _, ok := getGroupTask(ctx, taskID, groupID); !ok {
+		return
+	}
+
+	var requestBody struct {
+		Body     string   `json:"body" binding:"required"`
+		Mentions []string `json:"mentions"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(requestBody.Body)
+	if body == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "comment body cannot be empty"})
+		return
+	}
+
+	mentions, ok := validateMentions(ctx, groupID, userID, requestBody.Mentions)
+	if !ok {
+		return
+	}
+
+	comment := models.TaskComment{
+		TaskID:   taskID,
+		UserID:   userID,
+		Body:     body,
+		Mentions: mentions,
+	}
+
+	err = comment.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyMentionsAsync(comment, mentions)
+
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Comment added successfully", "comment": comment})
+}
+
+// GetTaskComments lists a task's comments, oldest first
+// GET /api/groups/:groupID/tasks/:taskId/comments
+func GetTaskComments(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	if _, ok := getGroupTask(ctx, taskID, groupID); !ok {
+		return
+	}
+
+	limit, offset, ok := getPagination(ctx, 50)
+	if !ok {
+		return
+	}
+
+	comments, total, err := models.GetTaskComments(ctx.Request.Context(), taskID, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"comments": comments, "total": total})
+}
+
+// UpdateTaskComment edits a comment; only its author can. The previous body is kept in the edit
+// history and members newly mentioned by the edit are notified.
+// PATCH /api/groups/:groupID/tasks/:taskId/comments/:commentId
+func UpdateTaskComment(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+	groupID := ctx.Param("groupID")
+
+	comment, ok := getTaskComment(ctx, ctx.Param("commentId"), ctx.Param("taskId"), groupID)
+	if !ok {
+		return
+	}
+
+	if comment.UserID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the author can edit this comment"})
+		return
+	}
+
+	var requestBody struct {
+		Body     string    `json:"body" binding:"required"`
+		Mentions *[]string `json:"mentions"` // Omitted keeps the current mentions
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := strings.TrimSpace(requestBody.Body)
+	if body == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "comment body cannot be empty"})
+		return
+	}
+
+	mentions := comment.Mentions
+	if requestBody.Mentions != nil {
+		mentions, ok = validateMentions(ctx, groupID, userID, *requestBody.Mentions)
+		if !ok {
+			return
+		}
+	}
+
+	added, err := comment.Edit(ctx.Request.Context(), userID, body, mentions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyMentionsAsync(*comment, added)
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment updated successfully", "comment": comment})
+}
+
+// DeleteTaskComment deletes a comment; allowed for its author and for group owners and admins
+// DELETE /api/groups/:groupID/tasks/:taskId/comments/:commentId
+func DeleteTaskComment(ctx *gin.Context) {
+	comment, ok := getTaskComment(ctx, ctx.Param("commentId"), ctx.Param("taskId"), ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	role := ctx.GetString("role")
+	if comment.UserID != ctx.GetString("userID") && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "you do not have permission to delete this comment"})
+		return
+	}
+
+	err := comment.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// GetTaskCommentHistory lists the previous bodies of a comment, oldest first
+// GET /api/groups/:groupID/tasks/:taskId/comments/:commentId/history
+func GetTaskCommentHistory(ctx *gin.Context) {
+	comment, ok := getTaskComment(ctx, ctx.Param("commentId"), ctx.Param("taskId"), ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	edits, err := comment.GetCommentEdits(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"comment": comment, "edits": edits})
+}
+
+// GetTaskActivity returns the task's feed: comments interleaved with status changes,
+// (un)assignments and due date edits, newest first
+// GET /api/groups/:groupID/tasks/:taskId/activity
+func GetTaskActivity(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	if _, ok := getGroupTask(ctx, taskID, groupID); !ok {
+		return
+	}
+
+	limit, offset, ok := getPagination(ctx, 50)
+	if !ok {
+		return
+	}
+
+	items, total, err := models.GetTaskFeed(ctx.Request.Context(), taskID, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"activity": items, "total": total})
+}
+
+// getGroupTask loads the task and checks it belongs to the group from the URL, writing the error response otherwise
+func getGroupTask(ctx *gin.Context, taskID string, groupID string) (*models.Task, bool) {
+	task, err := models.GetTaskByID(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return nil, false
+	}
+
+	if task.GroupID != groupID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "task does not belong to this group"})
+		return nil, false
+	}
+
+	return task, true
+}
+
+// getTaskComment loads a comment of a task in the group from the URL, writing the error response otherwise
+func getTaskComment(ctx *gin.Context, commentID string, taskID string, groupID string) (*models.TaskComment, bool) {
+	if _, ok := getGroupTask(ctx, taskID, groupID); !ok {
+		return nil, false
+	}
+
+	comment := &models.TaskComment{ID: commentID}
+	err := comment.Get(ctx.Request.Context())
+	if err != nil || comment.TaskID != taskID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "comment not found"})
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// validateMentions de-duplicates the mentioned users, drops the author and checks that everyone
+// else is a member of the group
+func validateMentions(ctx *gin.Context, groupID string, authorID string, mentions []string) ([]string, bool) {
+	valid := []string{}
+	for _, mentionedID := range mentions {
+		if mentionedID == authorID || slices.Contains(valid, mentionedID) {
+			continue
+		}
+
+		groupMember := &models.GroupMember{
+			GroupID: groupID,
+			UserID:  mentionedID,
+		}
+		isMember, err := groupMember.IsMember(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		if !isMember {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "mentioned user " + mentionedID + " is not a member of this group"})
+			return nil, false
+		}
+
+		valid = append(valid, mentionedID)
+	}
+	return valid, true
+}
+
+// notifyMentionsAsync delivers mention notifications without blocking the response
+func notifyMentionsAsync(comment models.TaskComment, userIDs []string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	go func() {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		services.NotifyMentions(notificationCtx, &comment, userIDs)
+	}()
+}
+
+// maxPageLimit caps the limit of paginated lists so one request cannot read a whole table
+const maxPageLimit = 100
+
+// getPagination reads the limit and offset query parameters, writing the error response when they are invalid.
+// Limits above maxPageLimit are lowered to it.
+func getPagination(ctx *gin.Context, defaultLimit int64) (int64, int64, bool) {
+	limit := defaultLimit
+	var offset int64 = 0
+	var err error
+
+	limitStr, provided := ctx.GetQuery("limit")
+	if provided {
+		limit, err = strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit format. Use integer"})
+			return 0, 0, false
+		}
+		if limit < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "limit must not be negative"})
+			return 0, 0, false
+		}
+	}
+	limit = min(limit, maxPageLimit)
+
+	offsetStr, provided := ctx.GetQuery("offset")
+	if provided {
+		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset format. Use integer"})
+			return 0, 0, false
+		}
+		if offset < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return 0, 0, false
+		}
+	}
+
+	return limit, offset, true
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetPagination(t *testing.T) {
+	tests := []struct {
+		query  string
+		ok     bool
+		limit  int64
+		offset int64
+	}{
+		{"", true, 50, 0},
+		{"?limit=10&offset=20", true, 10, 20},
+		{"?limit=0", true, 0, 0},
+		{"?limit=1000000", true, maxPageLimit, 0},
+		{"?limit=-1", false, 0, 0},
+		{"?offset=-5", false, 0, 0},
+		{"?limit=ten", false, 0, 0},
+		{"?offset=1.5", false, 0, 0},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(recorder)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/comments"+test.query, nil)
+
+		limit, offset, ok := getPagination(ctx, 50)
+		if ok != test.ok {
+			t.Errorf("%q: ok = %v, want %v", test.query, ok, test.ok)
+			continue
+		}
+		if !ok {
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("%q: status %d, want %d", test.query, recorder.Code, http.StatusBadRequest)
+			}
+			continue
+		}
+		if limit != test.limit || offset != test.offset {
+			t.Errorf("%q: limit %d offset %d, want %d and %d", test.query, limit, offset, test.limit, test.offset)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AddTaskDependency makes the task wait for another task of the same group
+// POST /api/groups/:groupID/tasks/:taskId/dependencies
+func AddTaskDependency(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	task, ok := getTaskForUpdate(ctx, taskID, groupID)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		DependsOnID string `json:"depends_on_id" binding:"required"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.DependsOnID == taskID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "a task cannot depend on itself"})
+		return
+	}
+
+	blocker, err := models.GetTaskByID(ctx.Request.Context(), requestBody.DependsOnID)
+	if err != nil || blocker.GroupID != groupID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "blocking task not found in this group"})
+		return
+	}
+
+	dependency := models.TaskDependency{
+		TaskID:      taskID,
+		DependsOnID: blocker.ID,
+		CreatedBy:   ctx.GetString("userID"),
+	}
+
+	saved, err := dependency.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !saved {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "dependency would create a cycle: " + blocker.Title + " already depends on " + task.Title})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"dependency": dependency})
+}
+
+// RemoveTaskDependency stops the task from waiting for another task
+// DELETE /api/groups/:groupID/tasks/:taskId/dependencies/:dependsOnId
+func RemoveTaskDependency(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+
+	if _, ok := getTaskForUpdate(ctx, taskID, ctx.Param("groupID")); !ok {
+		return
+	}
+
+	dependency := models.TaskDependency{
+		TaskID:      taskID,
+		DependsOnID: ctx.Param("dependsOnId"),
+	}
+
+	deleted, err := dependency.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "dependency not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Dependency removed successfully"})
+}
+
+// GetTaskDependencies lists the tasks blocking this one and the tasks waiting on it
+// GET /api/groups/:groupID/tasks/:taskId/dependencies
+func GetTaskDependencies(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+
+	task, ok := getGroupTask(ctx, taskID, ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	blockers, err := models.GetTaskBlockers(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	dependents, err := models.GetTaskDependents(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"blocked_by":    blockers,
+		"blocking":      dependents,
+		"open_blockers": task.OpenBlockers,
+	})
+}
+
+// notifyUnblockedDependentsAsync notifies the assignees of tasks unblocked by closing taskID without blocking the response
+func notifyUnblockedDependentsAsync(taskID string) {
+	go func() {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		services.NotifyUnblockedDependents(notificationCtx, taskID)
+	}()
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// parseTaskFilter reads the task listing filters from the query string, writing the error response
+// when one is invalid. With groupID set, custom fields must belong to that group; without it, to
+// one of the current user's groups.
+func parseTaskFilter(ctx *gin.Context, groupID string) (models.TaskFilter, bool) {
+	filter := models.TaskFilter{
+		Status: ctx.Query("status"),
+		// "assignee" has always filtered by the task creator
+		CreatedBy: ctx.Query("assignee"),
+		SortBy:    ctx.DefaultQuery("sort", "created_at"),
+	}
+
+	if !models.IsValidTaskSort(filter.SortBy) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort. Must be one of: created_at, due_date, title, priority"})
+		return filter, false
+	}
+
+	switch ctx.DefaultQuery("order", "desc") {
+	case "asc":
+		filter.SortAsc = true
+	case "desc":
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid order. Must be asc or desc"})
+		return filter, false
+	}
+
+	for _, priority := range splitQueryList(ctx.Query("priority")) {
+		if !slices.Contains(models.TaskPriorities, priority) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid priority. Must be one of: " + strings.Join(models.TaskPriorities, ", ")})
+			return filter, false
+		}
+		filter.Priorities = append(filter.Priorities, priority)
+	}
+
+	for _, labelID := range splitQueryList(ctx.Query("labels")) {
+		parsed, err := uuid.Parse(labelID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid label id " + labelID})
+			return filter, false
+		}
+		filter.LabelIDs = append(filter.LabelIDs, parsed.String())
+	}
+	filter.LabelIDs = slices.Compact(slices.Sorted(slices.Values(filter.LabelIDs)))
+
+	// Custom field conditions: field.<fieldID>[.<op>]=<value>
+	fields := map[string]*models.CustomField{}
+	for key, values := range ctx.Request.URL.Query() {
+		if !strings.HasPrefix(key, "field.") || len(values) == 0 {
+			continue
+		}
+
+		fieldID, op, _ := strings.Cut(strings.TrimPrefix(key, "field."), ".")
+		if op == "" {
+			op = "eq"
+		}
+		if !slices.Contains(models.CustomFieldFilterOps, op) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field filter " + key + ". Use eq, gte, lte or contains"})
+			return filter, false
+		}
+
+		field, ok := fields[fieldID]
+		if !ok {
+			field = &models.CustomField{ID: fieldID}
+			if err := field.Get(ctx.Request.Context()); err != nil || !canFilterByField(ctx, field, groupID) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "custom field " + fieldID + " not found"})
+				return filter, false
+			}
+			fields[fieldID] = field
+		}
+
+		if op == "contains" && field.FieldType != "text" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "contains only applies to text fields"})
+			return filter, false
+		}
+		if (op == "gte" || op == "lte") && field.FieldType != "number" && field.FieldType != "date" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": op + " only applies to number and date fields"})
+			return filter, false
+		}
+
+		// contains matches any text, so select option checks don't apply to it
+		var value models.CustomFieldValue
+		if op == "contains" {
+			value.Text = &values[0]
+		} else {
+			parsed, err := field.ParseValue(values[0])
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return filter, false
+			}
+			value = parsed
+		}
+
+		filter.Fields = append(filter.Fields, models.CustomFieldFilter{Field: *field, Op: op, Value: value})
+	}
+
+	return filter, true
+}
+
+// canFilterByField reports whether the field may be used in this listing: it must belong to the
+// listed group, or across groups, to a group the current user is a member of
+func canFilterByField(ctx *gin.Context, field *models.CustomField, groupID string) bool {
+	if groupID != "" {
+		return field.GroupID == groupID
+	}
+
+	member := &models.GroupMember{GroupID: field.GroupID, UserID: ctx.GetString("userID")}
+	isMember, err := member.IsMember(ctx.Request.Context())
+	return err == nil && isMember
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty entries
+func splitQueryList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// filterContext is a request for the given query string made by userID
+func filterContext(query string, userID string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/tasks"+query, nil)
+	ctx.Set("userID", userID)
+	return ctx, recorder
+}
+
+func TestParseTaskFilterNormalizesLabels(t *testing.T) {
+	label := "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f"
+	ctx, _ := filterContext("?labels="+label+",6F1C2D3E-4B5A-4C7D-8E9F-0A1B2C3D4E5F,"+label, "")
+
+	filter, ok := parseTaskFilter(ctx, "group-1")
+	if !ok {
+		t.Fatal("valid labels were rejected")
+	}
+	if len(filter.LabelIDs) != 1 || filter.LabelIDs[0] != label {
+		t.Errorf("label IDs = %v, want [%s]", filter.LabelIDs, label)
+	}
+}
+
+func TestParseTaskFilterRejectsFieldsOfOtherGroups(t *testing.T) {
+	dbtest.Setup(t)
+	member := createTestUser(t)
+	outsider := createTestUser(t)
+	group := &models.Group{Name: "Fields", CreatedBy: member.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&models.GroupMember{GroupID: group.ID, UserID: member.ID, Role: "owner"}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	field := &models.CustomField{GroupID: group.ID, Name: "Customer", FieldType: "text"}
+	if err := field.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Listing tasks across groups (GetUserTasks) passes no group
+	query := "?field." + field.ID + "=Acme"
+	memberCtx, _ := filterContext(query, member.ID)
+	if _, ok := parseTaskFilter(memberCtx, ""); !ok {
+		t.Error("a member could not filter by their group's field")
+	}
+	outsiderCtx, recorder := filterContext(query, outsider.ID)
+	if _, ok := parseTaskFilter(outsiderCtx, ""); ok || recorder.Code != http.StatusBadRequest {
+		t.Errorf("a field of another group was accepted (status %d)", recorder.Code)
+	}
+}
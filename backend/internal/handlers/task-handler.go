@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"context"
+	"log"
 	"net/http"
-	"strconv"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/WS"
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -20,10 +24,18 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 		role := ctx.GetString("role")
 
 		var requestBody struct {
-			Title       string   `json:"title" binding:"required"`
-			Description string   `json:"description" binding:"required"`
-			DueDate     string   `json:"due_date" binding:"required"`
-			Assignees   []string `json:"assignees" binding:"required"`
+			Title              string   `json:"title" binding:"required"`
+			Description        string   `json:"description" binding:"required"`
+			DueDate            string   `json:"due_date" binding:"required"`
+			Assignees          []string `json:"assignees" binding:"required"`
+			RecurrenceRule     string   `json:"recurrence_rule"`     // Optional RFC 5545 RRULE, e.g. FREQ=WEEKLY;BYDAY=MO
+			RecurrenceTimezone string   `json:"recurrence_timezone"` // Optional IANA timezone, defaults to UTC
+			ReminderOffsets    *[]int   `json:"reminder_offsets"`    // Optional minutes before due date; omit to use assignee preferences
+			ParentID           *string  `json:"parent_id"`           // Optional parent task; makes this task a subtask
+			Priority           string   `json:"priority"`            // Optional: none (default), low, medium, high, urgent
+			ApprovalReason     string   `json:"approval_reason"`     // Optional; shown to approvers when the task is created pending
+			// Optional; when true the task cannot be completed while its subtasks are open
+			RequireSubtasksComplete bool `json:"require_subtasks_complete"`
 		}
 
 		err := ctx.ShouldBindJSON(&requestBody)
@@ -46,15 +58,56 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 		}
 
 		task := models.Task{
-			GroupID:     groupID,
-			Title:       requestBody.Title,
-			Description: requestBody.Description,
-			DueDate:     dueDate,
-			CreatedBy:   userID,
-			Status:      status,
+			GroupID:                 groupID,
+			Title:                   requestBody.Title,
+			Description:             requestBody.Description,
+			DueDate:                 dueDate,
+			CreatedBy:               userID,
+			Status:                  status,
+			RequireSubtasksComplete: requestBody.RequireSubtasksComplete,
+			Priority:                requestBody.Priority,
 		}
 
-		err = task.CreateTask(ctx.Request.Context())
+		if task.Priority != "" && !slices.Contains(models.TaskPriorities, task.Priority) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid priority. Must be one of: " + strings.Join(models.TaskPriorities, ", ")})
+			return
+		}
+
+		// Subtasks live in their parent's group; the database trigger enforces this too
+		if requestBody.ParentID != nil && *requestBody.ParentID != "" {
+			parent, err := models.GetTaskByID(ctx.Request.Context(), *requestBody.ParentID)
+			if err != nil || parent.GroupID != groupID {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "parent task not found in this group"})
+				return
+			}
+			if requestBody.RecurrenceRule != "" {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "subtasks cannot be recurring"})
+				return
+			}
+			task.ParentID = &parent.ID
+		}
+
+		if requestBody.ReminderOffsets != nil {
+			offsets, err := models.NormalizeReminderOffsets(*requestBody.ReminderOffsets)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			task.ReminderOffsets = offsets
+		}
+
+		// Recurring tasks get a series anchored at the first due date; this task is its first occurrence
+		if requestBody.RecurrenceRule != "" {
+			rule, timezone, err := validateRecurrence(requestBody.RecurrenceRule, requestBody.RecurrenceTimezone, dueDate)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			err = task.CreateWithSeries(ctx.Request.Context(), newTaskSeries(rule, timezone, dueDate))
+		} else {
+			err = task.CreateTask(ctx.Request.Context())
+		}
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -89,6 +142,13 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 			}
 		}
 
+		// Tasks from members and viewers wait in the group's approval queue
+		if task.Status == "pending" {
+			openApprovalRequest(ctx, &task, requestBody.ApprovalReason)
+		}
+
+		runTaskWorkflowsAsync(services.TaskEvent{Type: "task_created", TaskID: task.ID, ToStatus: task.Status})
+
 		// Create notifications for all assignees when task is created
 		go func() {
 			notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -109,7 +169,11 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 					ScheduledAt:      time.Now(), // Send immediately
 					Status:           "pending",
 				}
-				_ = notification.Save(notificationCtx) // Log errors but don't block
+				if err := notification.Save(notificationCtx); err != nil {
+					continue // Log errors but don't block
+				}
+				// Deliver right away instead of waiting for the scheduler
+				services.DeliverNotification(notificationCtx, &notification)
 			}
 		}()
 
@@ -139,14 +203,16 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 		cancel()
 
 		// Create WebSocket message
-		message := &WS.Message{
+		message := WS.NewMessageCreated(&models.Message{
 			ID:        messageID,
 			RoomID:    groupID,
 			UserID:    userID,
 			Username:  username,
 			Content:   messageContent,
-			CreatedAt: now.Format(time.RFC3339),
-		}
+			Reactions: []models.ReactionSummary{},
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
 
 		// Broadcast to room if it exists (even if persistence failed)
 		if _, ok := hub.Rooms[groupID]; ok {
@@ -157,40 +223,25 @@ func CreateTask(hub *WS.Hub) gin.HandlerFunc {
 	}
 }
 
+// GetGroupTasks lists the group's tasks. Besides status, assignee (the task creator), limit and offset it accepts:
+//   - priority: comma-separated priorities, e.g. high,urgent
+//   - labels: comma-separated label IDs the tasks must all carry
+//   - field.<fieldID>, field.<fieldID>.gte, field.<fieldID>.lte, field.<fieldID>.contains: custom field conditions
+//   - sort (created_at, due_date, title, priority) and order (asc, desc)
 func GetGroupTasks(ctx *gin.Context) {
 	groupID := ctx.Param("groupID")
-	var limit int64 = 10
-	var offset int64 = 0
-	var err error
-
-	status, provided := ctx.GetQuery("status")
-	if !provided {
-		status = ""
-	}
-	assignee, provided := ctx.GetQuery("assignee")
-	if !provided {
-		assignee = ""
-	}
 
-	limitStr, provided := ctx.GetQuery("limit")
-	if provided {
-		limit, err = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit format. Use integer"})
-			return
-		}
+	filter, ok := parseTaskFilter(ctx, groupID)
+	if !ok {
+		return
 	}
 
-	offsetStr, provided := ctx.GetQuery("offset")
-	if provided {
-		offset, err = strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset format. Use integer"})
-			return
-		}
+	limit, offset, ok := getPagination(ctx, 10)
+	if !ok {
+		return
 	}
 
-	tasks, total, err := models.GetTasksByGroupID(ctx.Request.Context(), groupID, status, assignee, limit, offset)
+	tasks, total, err := models.GetTasksByGroupID(ctx.Request.Context(), groupID, filter, limit, offset)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -200,41 +251,21 @@ func GetGroupTasks(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"tasks": tasks, "total": total})
 }
 
+// GetUserTasks lists the tasks assigned to the current user; it accepts the same filters as GetGroupTasks
 func GetUserTasks(ctx *gin.Context) {
 	user_id := ctx.GetString("userID")
 
-	var limit int64 = 10
-	var offset int64 = 0
-	var err error
-
-	status, provided := ctx.GetQuery("status")
-	if !provided {
-		status = ""
-	}
-	assignee, provided := ctx.GetQuery("assignee")
-	if !provided {
-		assignee = ""
-	}
-
-	limitStr, provided := ctx.GetQuery("limit")
-	if provided {
-		limit, err = strconv.ParseInt(limitStr, 10, 64)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit format. Use integer"})
-			return
-		}
+	filter, ok := parseTaskFilter(ctx, "")
+	if !ok {
+		return
 	}
 
-	offsetStr, provided := ctx.GetQuery("offset")
-	if provided {
-		offset, err = strconv.ParseInt(offsetStr, 10, 64)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset format. Use integer"})
-			return
-		}
+	limit, offset, ok := getPagination(ctx, 10)
+	if !ok {
+		return
 	}
 
-	tasks, total, err := models.GetTasksByUserID(ctx.Request.Context(), user_id, status, assignee, limit, offset)
+	tasks, total, err := models.GetTasksByUserID(ctx.Request.Context(), user_id, filter, limit, offset)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -284,7 +315,32 @@ func GetTaskByIDWithAssignees(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"task": task})
+	checklist, err := models.GetTaskChecklist(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"task": task, "checklist": checklist})
+}
+
+// GetSubtasks lists the direct subtasks of a task with their own progress
+// GET /api/groups/:groupID/tasks/:taskId/subtasks
+func GetSubtasks(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+
+	task, ok := getGroupTask(ctx, taskID, ctx.Param("groupID"))
+	if !ok {
+		return
+	}
+
+	subtasks, err := models.GetSubtasks(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"subtasks": subtasks, "progress": task.Progress})
 }
 
 func UpdateTask(ctx *gin.Context) {
@@ -322,10 +378,17 @@ func UpdateTask(ctx *gin.Context) {
 	}
 
 	var requestBody struct {
-		Title       string `json:"title" binding:"required"`
-		Description string `json:"description" binding:"required"`
-		DueDate     string `json:"due_date" binding:"required"`
-		Status      string `json:"status" binding:"required"`
+		Title              string  `json:"title" binding:"required"`
+		Description        string  `json:"description" binding:"required"`
+		DueDate            string  `json:"due_date" binding:"required"`
+		Status             string  `json:"status" binding:"required"`
+		Scope              string  `json:"scope"`               // Recurring tasks only: "this" (default), "following" or "all"
+		RecurrenceRule     *string `json:"recurrence_rule"`     // Empty string stops the recurrence
+		RecurrenceTimezone *string `json:"recurrence_timezone"` // IANA timezone, defaults to UTC
+		// Omit to keep the current setting; owners and admins only
+		RequireSubtasksComplete *bool   `json:"require_subtasks_complete"`
+		Priority                *string `json:"priority"`        // Omit to keep the current priority
+		ApprovalReason          string  `json:"approval_reason"` // Optional; shown to approvers when the task moves back to pending
 	}
 
 	err = ctx.ShouldBindJSON(&requestBody)
@@ -334,6 +397,46 @@ func UpdateTask(ctx *gin.Context) {
 		return
 	}
 
+	scope := requestBody.Scope
+	if scope == "" {
+		scope = "this"
+	}
+	if !validRecurrenceScopes[scope] {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope. Must be one of: this, following, all"})
+		return
+	}
+	if scope != "this" && taskCheck.SeriesID == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "scope " + scope + " only applies to recurring tasks"})
+		return
+	}
+
+	// Work out the recurrence the task should have after this update
+	currentRule, currentTimezone := "", "UTC"
+	if taskCheck.RecurrenceRule != nil {
+		currentRule = *taskCheck.RecurrenceRule
+	}
+	if taskCheck.RecurrenceTimezone != nil {
+		currentTimezone = *taskCheck.RecurrenceTimezone
+	}
+	newRule, newTimezone := currentRule, currentTimezone
+	if requestBody.RecurrenceRule != nil {
+		newRule = utils.NormalizeRecurrenceRule(*requestBody.RecurrenceRule)
+	}
+	if requestBody.RecurrenceTimezone != nil && *requestBody.RecurrenceTimezone != "" {
+		newTimezone = *requestBody.RecurrenceTimezone
+	}
+	recurrenceChanged := newRule != currentRule || (newRule != "" && newTimezone != currentTimezone)
+
+	// Series-wide edits and recurrence changes are reserved for owners and admins
+	if (scope != "this" || recurrenceChanged) && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins and owners can edit a recurring series"})
+		return
+	}
+	if recurrenceChanged && scope == "this" && taskCheck.SeriesID != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "recurrence can only be changed for following or all occurrences"})
+		return
+	}
+
 	// Validate status - only allow: pending, rejected, active, completed, cancelled
 	validStatuses := map[string]bool{
 		"pending":   true,
@@ -359,8 +462,26 @@ func UpdateTask(ctx *gin.Context) {
 		finalStatus = "pending"
 	}
 
+	if requestBody.Priority != nil && !slices.Contains(models.TaskPriorities, *requestBody.Priority) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid priority. Must be one of: " + strings.Join(models.TaskPriorities, ", ")})
+		return
+	}
+
+	requireSubtasksComplete := taskCheck.RequireSubtasksComplete
+	if requestBody.RequireSubtasksComplete != nil && *requestBody.RequireSubtasksComplete != requireSubtasksComplete {
+		if role != "owner" && role != "admin" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins and owners can change require_subtasks_complete"})
+			return
+		}
+		requireSubtasksComplete = *requestBody.RequireSubtasksComplete
+	}
+
 	// Check what fields are actually changing
 	statusChanged := taskCheck.Status != finalStatus
+
+	if statusChanged && !checkStatusPreconditions(ctx, taskCheck, finalStatus, requireSubtasksComplete) {
+		return
+	}
 	titleChanged := taskCheck.Title != requestBody.Title
 	descriptionChanged := taskCheck.Description != requestBody.Description
 
@@ -379,6 +500,14 @@ func UpdateTask(ctx *gin.Context) {
 	// Determine if any non-status field changed
 	otherFieldsChanged := titleChanged || descriptionChanged || dueDateChanged
 
+	if recurrenceChanged && newRule != "" {
+		newRule, newTimezone, err = validateRecurrence(newRule, newTimezone, newDueDate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Update task
 	err = models.UpdateTask(ctx.Request.Context(), taskID, requestBody.Title, requestBody.Description, requestBody.DueDate, finalStatus)
 	if err != nil {
@@ -386,39 +515,81 @@ func UpdateTask(ctx *gin.Context) {
 		return
 	}
 
-	// Create notifications if any field changed (only for admin/owner)
-	if (statusChanged || otherFieldsChanged) && (role == "owner" || role == "admin") {
-		go func() {
-			notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-			defer cancel()
+	if requireSubtasksComplete != taskCheck.RequireSubtasksComplete {
+		err = models.UpdateTaskRequireSubtasksComplete(ctx.Request.Context(), taskID, requireSubtasksComplete)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-			// Get all assignees for the task
-			assignments, err := models.GetTaskAssignments(notificationCtx, taskID)
-			if err != nil {
-				// Log error but don't block response
-				return
-			}
+	if requestBody.Priority != nil && *requestBody.Priority != taskCheck.Priority {
+		err = models.UpdateTaskPriority(ctx.Request.Context(), taskID, *requestBody.Priority)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-			// Determine notification type:
-			// - If only status changed (and nothing else) → "status_change"
-			// - If anything else changed (including status + other fields) → "update"
-			notificationType := "update"
-			if statusChanged && !otherFieldsChanged {
-				notificationType = "status_change"
-			}
+	if statusChanged {
+		recordTaskChange(ctx, taskID, "status_change", taskCheck.Status, finalStatus)
 
-			// Create notification for each assignee (scheduled for immediate send)
-			for _, assignment := range assignments {
-				notification := models.TaskNotification{
-					TaskID:           taskID,
-					UserID:           assignment.UserID,
-					NotificationType: notificationType,
-					ScheduledAt:      time.Now(), // Send immediately
-					Status:           "pending",
-				}
-				_ = notification.Save(notificationCtx) // Log errors but don't block
-			}
-		}()
+		// Moving a task back to pending asks for approval again; moving it out settles the open request
+		if finalStatus == "pending" {
+			openApprovalRequest(ctx, taskCheck, requestBody.ApprovalReason)
+		} else if taskCheck.Status == "pending" {
+			closeApprovalRequest(ctx, taskID, finalStatus)
+		}
+
+		runStatusWorkflowsAsync(taskID, taskCheck.Status, finalStatus)
+	}
+	if dueDateChanged {
+		recordTaskChange(ctx, taskID, "due_date_change", taskCheck.DueDate.UTC().Format(time.RFC3339), newDueDate.UTC().Format(time.RFC3339))
+	}
+
+	// Propagate the edit to the other occurrences in scope; status stays per occurrence
+	if scope != "this" {
+		var fromOccurrence *time.Time
+		if scope == "following" {
+			fromOccurrence = taskCheck.OccurrenceAt
+		}
+		dueShift := newDueDate.Sub(taskCheck.DueDate)
+		err = models.UpdateSeriesTasks(ctx.Request.Context(), *taskCheck.SeriesID, taskID, fromOccurrence, requestBody.Title, requestBody.Description, dueShift)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	seriesID := taskCheck.SeriesID
+	if recurrenceChanged {
+		seriesID, err = applyRecurrenceChange(ctx.Request.Context(), taskCheck, scope, newRule, newTimezone, newDueDate)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Closing a task may clear the last blocker of the tasks depending on it
+	if statusChanged && models.IsOpenTaskStatus(taskCheck.Status) && !models.IsOpenTaskStatus(finalStatus) {
+		notifyUnblockedDependentsAsync(taskID)
+	}
+
+	// Completing an occurrence materializes the next one right away instead of waiting for the scheduler
+	if statusChanged && finalStatus == "completed" && seriesID != nil {
+		generateNextOccurrenceAsync(*seriesID)
+	}
+
+	// Create notifications if any field changed (only for admin/owner)
+	if (statusChanged || otherFieldsChanged) && (role == "owner" || role == "admin") {
+		// Determine notification type:
+		// - If only status changed (and nothing else) → "status_change"
+		// - If anything else changed (including status + other fields) → "update"
+		notificationType := "update"
+		if statusChanged && !otherFieldsChanged {
+			notificationType = "status_change"
+		}
+		notifyAssigneesAsync(taskID, notificationType)
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Task updated successfully"})
@@ -441,9 +612,25 @@ func DeleteTask(ctx *gin.Context) {
 		return
 	}
 
+	// Recurring tasks: "this" (default), "following" or "all" occurrences
+	scope := ctx.DefaultQuery("scope", "this")
+	if !validRecurrenceScopes[scope] {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scope. Must be one of: this, following, all"})
+		return
+	}
+	if scope != "this" && taskCheck.SeriesID == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "scope " + scope + " only applies to recurring tasks"})
+		return
+	}
+
 	role := ctx.GetString("role")
 	// If user is not owner or admin, set status to cancelled instead of deleting
 	if role != "owner" && role != "admin" {
+		// Members can only cancel the occurrence they are assigned to
+		if scope != "this" {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins and owners can delete a recurring series"})
+			return
+		}
 		// Check if user is assigned to the task
 		taskAssignment := models.TaskAssignment{
 			TaskID: taskID,
@@ -468,16 +655,286 @@ func DeleteTask(ctx *gin.Context) {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		if task.Status != "cancelled" {
+			recordTaskChange(ctx, taskID, "status_change", task.Status, "cancelled")
+			runStatusWorkflowsAsync(taskID, task.Status, "cancelled")
+		}
+		if task.Status == "pending" {
+			closeApprovalRequest(ctx, taskID, "cancelled")
+		}
+		if models.IsOpenTaskStatus(task.Status) {
+			notifyUnblockedDependentsAsync(taskID)
+		}
 		ctx.JSON(http.StatusOK, gin.H{"message": "Task cancelled successfully"})
 		return
 	}
 
-	// Owner or admin can actually delete the task
-	err = models.DeleteTask(ctx.Request.Context(), taskID)
+	// Owner or admin can move the task to the trash, from where it can be restored until it is purged
+	userID := ctx.GetString("userID")
+	switch scope {
+	case "following", "all":
+		// Trash this and later occurrences (or all of them) and stop the series from generating more
+		var fromOccurrence *time.Time
+		if scope == "following" {
+			fromOccurrence = taskCheck.OccurrenceAt
+		}
+		err = models.TrashSeries(ctx.Request.Context(), *taskCheck.SeriesID, fromOccurrence, userID)
+	default:
+		// The series cursor is not moved back, so a deleted occurrence is skipped rather than regenerated
+		err = models.TrashTask(ctx.Request.Context(), taskID, userID)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Tasks waiting on the deleted one are no longer blocked by it
+	if models.IsOpenTaskStatus(taskCheck.Status) {
+		notifyUnblockedDependentsAsync(taskID)
+	}
+
+	if scope != "this" {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Tasks moved to trash successfully"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Task moved to trash successfully"})
+}
+
+// UpdateTaskReminders overrides the reminder offsets of a task for all of its assignees
+func UpdateTaskReminders(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	task, ok := getTaskForUpdate(ctx, taskID, groupID)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		ReminderOffsets []int `json:"reminder_offsets" binding:"required"` // Minutes before due date; [] disables reminders
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	offsets, err := models.NormalizeReminderOffsets(requestBody.ReminderOffsets)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Pending reminders for removed offsets are cancelled by the next reminder run
+	err = models.UpdateTaskReminderOffsets(ctx.Request.Context(), taskID, offsets)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	task.ReminderOffsets = offsets
+	ctx.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// ResetTaskReminders removes the task override so assignees' own reminder preferences apply again
+func ResetTaskReminders(ctx *gin.Context) {
+	taskID := ctx.Param("taskId")
+	groupID := ctx.Param("groupID")
+
+	task, ok := getTaskForUpdate(ctx, taskID, groupID)
+	if !ok {
+		return
+	}
+
+	err := models.UpdateTaskReminderOffsets(ctx.Request.Context(), taskID, nil)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	task.ReminderOffsets = nil
+	ctx.JSON(http.StatusOK, gin.H{"task": task})
+}
+
+// getTaskForUpdate loads the task and checks that the caller may change it, e.g. its reminders
+// or checklist (owners, admins and assignees). It writes the error response and returns false otherwise.
+func getTaskForUpdate(ctx *gin.Context, taskID string, groupID string) (*models.Task, bool) {
+	task, err := models.GetTaskByID(ctx.Request.Context(), taskID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		return nil, false
+	}
+
+	if task.GroupID != groupID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "task does not belong to this group"})
+		return nil, false
+	}
+
+	role := ctx.GetString("role")
+	if role != "owner" && role != "admin" {
+		taskAssignment := models.TaskAssignment{
+			TaskID: taskID,
+			UserID: ctx.GetString("userID"),
+		}
+		if err := taskAssignment.Get(ctx.Request.Context()); err != nil {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "you do not have permission to update this task"})
+			return nil, false
+		}
+	}
+
+	return task, true
+}
+
+// validRecurrenceScopes lists the edit/delete scopes accepted for recurring tasks
+var validRecurrenceScopes = map[string]bool{
+	"this":      true,
+	"following": true,
+	"all":       true,
+}
+
+// validateRecurrence checks a recurrence rule and timezone and returns them in stored form
+func validateRecurrence(rule string, timezone string, dtstart time.Time) (string, string, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	rule = utils.NormalizeRecurrenceRule(rule)
+
+	if _, err := utils.ParseRecurrence(rule, timezone, dtstart); err != nil {
+		return "", "", err
+	}
+
+	return rule, timezone, nil
+}
+
+// applyRecurrenceChange applies a new rule (empty to stop recurring) to a task and the occurrences in scope.
+// It returns the series the task belongs to afterwards.
+func applyRecurrenceChange(ctx context.Context, task *models.Task, scope string, rule string, timezone string, dueDate time.Time) (*string, error) {
+	// A one-off task becomes the first occurrence of a new series
+	if task.SeriesID == nil {
+		return startTaskSeries(ctx, task.ID, rule, timezone, dueDate)
+	}
+
+	series := &models.TaskSeries{ID: *task.SeriesID}
+
+	if scope == "all" {
+		if rule == "" {
+			// Keep existing occurrences but stop generating new ones
+			return task.SeriesID, series.End(ctx)
+		}
+		series.RecurrenceRule = rule
+		series.RecurrenceTimezone = timezone
+		return task.SeriesID, series.UpdateRule(ctx)
+	}
+
+	// "following": split the series at this occurrence. The old series ends here and this task
+	// either starts a new series with the new rule or becomes a one-off task.
+	if rule == "" {
+		return nil, models.SplitTaskSeries(ctx, task, nil)
+	}
+	next := newTaskSeries(rule, timezone, dueDate)
+	if err := models.SplitTaskSeries(ctx, task, next); err != nil {
+		return nil, err
+	}
+	return &next.ID, nil
+}
+
+// startTaskSeries creates a series anchored at dueDate and makes the task its first occurrence
+func startTaskSeries(ctx context.Context, taskID string, rule string, timezone string, dueDate time.Time) (*string, error) {
+	series := newTaskSeries(rule, timezone, dueDate)
+	if err := models.StartTaskSeries(ctx, taskID, series); err != nil {
+		return nil, err
+	}
+	return &series.ID, nil
+}
+
+// newTaskSeries is a series anchored at dueDate, whose first occurrence is due then
+func newTaskSeries(rule string, timezone string, dueDate time.Time) *models.TaskSeries {
+	return &models.TaskSeries{
+		RecurrenceRule:     rule,
+		RecurrenceTimezone: timezone,
+		DTStart:            dueDate,
+		LastOccurrenceAt:   dueDate,
+	}
+}
+
+// recordTaskChange adds a change made by the current user to the task's activity feed
+func recordTaskChange(ctx *gin.Context, taskID string, activityType string, oldValue string, newValue string) {
+	actorID := ctx.GetString("userID")
+	var oldPtr, newPtr *string
+	if oldValue != "" {
+		oldPtr = &oldValue
+	}
+	if newValue != "" {
+		newPtr = &newValue
+	}
+	services.RecordTaskActivity(ctx.Request.Context(), taskID, &actorID, activityType, oldPtr, newPtr)
+}
+
+// checkStatusPreconditions writes a 409 and returns false when the task cannot move to status yet:
+// open blockers keep it from becoming active or completed, and open subtasks keep it from being
+// completed when requireSubtasksComplete is set
+func checkStatusPreconditions(ctx *gin.Context, task *models.Task, status string, requireSubtasksComplete bool) bool {
+	if (status == "active" || status == "completed") && task.OpenBlockers > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":         "task is blocked by tasks that are not done yet",
+			"open_blockers": task.OpenBlockers,
+		})
+		return false
+	}
+
+	if status == "completed" && requireSubtasksComplete && task.Progress.HasOpenSubtasks() {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":    "task cannot be completed while it has open subtasks",
+			"progress": task.Progress,
+		})
+		return false
+	}
+
+	return true
+}
+
+// generateNextOccurrenceAsync materializes the next occurrence of a series without blocking the response
+func generateNextOccurrenceAsync(seriesID string) {
+	go func() {
+		generateCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if _, err := services.GenerateNextOccurrence(generateCtx, seriesID); err != nil {
+			log.Printf("Error generating next occurrence for series %s: %v", seriesID, err)
+		}
+	}()
+}
+
+// notifyAssigneesAsync creates and delivers a notification of the given type to every assignee of the task
+// (background, errors don't block the response)
+func notifyAssigneesAsync(taskID string, notificationType string) {
+	go func() {
+		notificationCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		// Get all assignees for the task
+		assignments, err := models.GetTaskAssignments(notificationCtx, taskID)
+		if err != nil {
+			// Log error but don't block response
+			return
+		}
+
+		// Create notification for each assignee (scheduled for immediate send)
+		for _, assignment := range assignments {
+			notification := models.TaskNotification{
+				TaskID:           taskID,
+				UserID:           assignment.UserID,
+				NotificationType: notificationType,
+				ScheduledAt:      time.Now(), // Send immediately
+				Status:           "pending",
+			}
+			if err := notification.Save(notificationCtx); err != nil {
+				continue // Log errors but don't block
+			}
+			// Deliver right away instead of waiting for the scheduler
+			services.DeliverNotification(notificationCtx, &notification)
+		}
+	}()
 }
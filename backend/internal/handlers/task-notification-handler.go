@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -163,7 +164,8 @@ func UpdateNotification(ctx *gin.Context) {
 		NotificationType string `json:"notification_type"`
 		ScheduledAt      string `json:"scheduled_at"`
 		Status           string `json:"status"`
-		RetryCount       int    `json:"retry_count"`
+		RetryCount       *int   `json:"retry_count"`
+		Read             *bool  `json:"read"` // Marks the notification read or unread
 	}
 
 	err = ctx.ShouldBindJSON(&requestBody)
@@ -172,6 +174,12 @@ func UpdateNotification(ctx *gin.Context) {
 		return
 	}
 
+	// Only the recipient can mark a notification read
+	if requestBody.Read != nil && notification.UserID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only mark your own notifications as read"})
+		return
+	}
+
 	// Update only provided fields
 	if requestBody.NotificationType != "" {
 		// Validate notification type
@@ -202,14 +210,28 @@ func UpdateNotification(ctx *gin.Context) {
 		notification.Status = requestBody.Status
 	}
 
-	if requestBody.RetryCount >= 0 {
-		notification.RetryCount = requestBody.RetryCount
+	if requestBody.RetryCount != nil && *requestBody.RetryCount >= 0 {
+		notification.RetryCount = *requestBody.RetryCount
 	}
 
-	err = notification.Update(ctx.Request.Context())
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// {"read": ...} on its own only touches read_at
+	if requestBody.NotificationType != "" || requestBody.ScheduledAt != "" || requestBody.Status != "" || requestBody.RetryCount != nil {
+		err = notification.Update(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if requestBody.Read != nil {
+		err = notification.MarkAsRead(ctx.Request.Context(), *requestBody.Read)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Keep badges in the user's other tabs and devices in sync
+		services.PushUnreadCounts(userID, ctx.GetString("email"))
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"notification": notification})
@@ -273,5 +295,27 @@ func GetUserNotifications(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"notifications": notifications})
+	unreadCount, err := models.CountUnreadNotifications(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"notifications": notifications, "unread_count": unreadCount})
+}
+
+// MarkAllNotificationsRead marks every delivered notification of the current user as read
+// POST /api/notifications/read
+func MarkAllNotificationsRead(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+
+	updated, err := models.MarkAllNotificationsRead(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	services.PushUnreadCounts(userID, ctx.GetString("email"))
+
+	ctx.JSON(http.StatusOK, gin.H{"updated": updated})
 }
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTrash lists what was deleted in the group and can still be restored, most recently deleted
+// first. It accepts type (task, file, folder or link), limit and offset.
+// GET /api/groups/:groupID/trash
+func GetTrash(ctx *gin.Context) {
+	itemType := ctx.Query("type")
+	if itemType != "" && !slices.Contains(models.TrashItemTypes, itemType) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid type. Must be one of: " + strings.Join(models.TrashItemTypes, ", ")})
+		return
+	}
+
+	limit, offset, ok := getPagination(ctx, 50)
+	if !ok {
+		return
+	}
+
+	items, total, err := models.GetGroupTrash(ctx.Request.Context(), ctx.Param("groupID"), itemType, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	retention := utils.TrashRetention()
+	trash := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		trash = append(trash, gin.H{
+			"item":     item,
+			"purge_at": item.DeletedAt.Add(retention), // Deleted for good at the first purge after this
+		})
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"trash":          trash,
+		"total":          total,
+		"retention_days": int(retention / (24 * time.Hour)),
+	})
+}
+
+// RestoreTrashItem brings an item back from the trash along with everything deleted with it:
+// subtasks of a task, subfolders and files of a folder. Owners, admins and whoever deleted the
+// item can restore it.
+// POST /api/groups/:groupID/trash/:type/:id/restore
+func RestoreTrashItem(ctx *gin.Context) {
+	itemType := ctx.Param("type")
+	if !slices.Contains(models.TrashItemTypes, itemType) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid type. Must be one of: " + strings.Join(models.TrashItemTypes, ", ")})
+		return
+	}
+
+	if _, err := uuid.Parse(ctx.Param("id")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid item ID"})
+		return
+	}
+
+	item, err := models.GetTrashItem(ctx.Request.Context(), ctx.Param("groupID"), itemType, ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if item == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "item not found in trash"})
+		return
+	}
+
+	role := ctx.GetString("role")
+	deletedByUser := item.DeletedBy != nil && *item.DeletedBy == ctx.GetString("userID")
+	if !deletedByUser && role != "owner" && role != "admin" {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only admins, owners and whoever deleted it can restore this item"})
+		return
+	}
+
+	var reason string
+	switch itemType {
+	case "task":
+		reason, err = models.RestoreTask(ctx.Request.Context(), item.ID)
+	case "file":
+		reason, err = models.RestoreFile(ctx.Request.Context(), item.ID)
+	case "folder":
+		reason, err = models.RestoreFolder(ctx.Request.Context(), item.ID)
+	case "link":
+		err = models.RestoreLink(ctx.Request.Context(), item.ID)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if reason != "" {
+		ctx.JSON(http.StatusConflict, gin.H{"error": reason})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Item restored successfully",
+		"item":    item,
+	})
+}
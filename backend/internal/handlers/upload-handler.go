@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Upload session limits. Content above the threshold is uploaded in parts of at least
+// minUploadPartSize, so that a file never needs more than maxUploadParts.
+const (
+	maxUploadSize            = 100 << 30
+	multipartUploadThreshold = 64 << 20
+	minUploadPartSize        = 16 << 20
+	maxUploadPartSize        = 5 << 30
+	maxUploadParts           = 1000
+	uploadURLExpiry          = 1 * time.Hour
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// uploadURL is where the client PUTs the content, or one part of it, with the headers to send
+type uploadURL struct {
+	PartNumber int32             `json:"part_number,omitempty"`
+	URL        string            `json:"url"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Size       int64             `json:"size"`
+}
+
+// uploadPartSize is the part size suggested for content of the given size: the smallest whole
+// number of MiB, at least minUploadPartSize, that needs no more than maxUploadParts
+func uploadPartSize(size int64) int64 {
+	partSize := max(int64(minUploadPartSize), (size+maxUploadParts-1)/maxUploadParts)
+	return (partSize + (1<<20 - 1)) &^ (1<<20 - 1)
+}
+
+// CreateUploadSession starts a direct upload: the response holds presigned URLs the client PUTs
+// the content to (one URL, or one per part for large files), then it calls the complete endpoint.
+// The URLs only accept content of the announced size and SHA-256, so large files are announced
+// with part_size and the SHA-256 of every part.
+// With file_id the upload becomes a new version of that file, which only its creator and the
+// group's owners and admins can add.
+// POST /api/groups/:groupID/files/uploads
+func CreateUploadSession(ctx *gin.Context) {
+	if blobStore == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "File storage not initialized",
+		})
+		return
+	}
+
+	var requestBody struct {
+		Name     string  `json:"name" binding:"required"`
+		Size     int64   `json:"size" binding:"required,min=1"`
+		MimeType string  `json:"mime_type"`
+		SHA256   string  `json:"sha256" binding:"required"` // Hex digest of the content
+		FolderID *string `json:"folder_id"`
+		FileID   *string `json:"file_id"`
+		// Multipart uploads: the size of every part but the last, and the hex digest of every part
+		PartSize    int64    `json:"part_size"`
+		PartSHA256s []string `json:"part_sha256s"`
+	}
+
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	groupID := ctx.Param("groupID")
+	session := &models.UploadSession{
+		ID:        uuid.New().String(),
+		GroupID:   groupID,
+		Name:      strings.TrimSpace(requestBody.Name),
+		Size:      requestBody.Size,
+		MimeType:  requestBody.MimeType,
+		SHA256:    strings.ToLower(requestBody.SHA256),
+		S3Bucket:  blobStore.Bucket(),
+		CreatedBy: ctx.GetString("userID"),
+	}
+	if session.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "File name cannot be empty",
+		})
+		return
+	}
+	if session.Size > maxUploadSize {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Files can be at most %d bytes", int64(maxUploadSize)),
+		})
+		return
+	}
+	if !sha256Pattern.MatchString(session.SHA256) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "sha256 must be the hex SHA-256 digest of the content",
+		})
+		return
+	}
+	if session.MimeType == "" {
+		session.MimeType = "application/octet-stream"
+	}
+
+	// New files take the session ID as their ID, so every version lives under the file's prefix
+	fileID := session.ID
+	if requestBody.FileID != nil {
+		file := &models.File{ID: *requestBody.FileID}
+		if err := file.GetByID(ctx); err != nil || file.GroupID == nil || *file.GroupID != groupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "File not found",
+			})
+			return
+		}
+		if !canChangeFile(ctx, file) {
+			return
+		}
+		fileID = file.ID
+		session.FileID = &file.ID
+	} else if requestBody.FolderID != nil {
+		folder := &models.Folder{ID: *requestBody.FolderID}
+		if err := folder.GetByID(ctx); err != nil || folder.GroupID == nil || *folder.GroupID != groupID {
+			ctx.JSON(http.StatusNotFound, gin.H{
+				"error": "Folder not found",
+			})
+			return
+		}
+		session.FolderID = &folder.ID
+	}
+
+	// The session itself holds the storage once it is saved
+	reservation, ok := reserveStorage(ctx, groupID, session.Size)
+	if !ok {
+		return
+	}
+	defer releaseStorage(reservation)
+
+	session.S3Key = utils.GenerateFileVersionKey(groupID, fileID, session.Name)
+
+	// Backends without multipart uploads (the local disk) take any size in a single PUT
+	if multipart, ok := blobStore.(utils.MultipartBlobStore); ok && session.Size > multipartUploadThreshold {
+		partSize := requestBody.PartSize
+		if partSize == 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":     fmt.Sprintf("Files over %d bytes are uploaded in parts: send part_size and the sha256 of every part in part_sha256s", int64(multipartUploadThreshold)),
+				"part_size": uploadPartSize(session.Size),
+			})
+			return
+		}
+		partCount := int((session.Size + partSize - 1) / partSize)
+		if partSize < minUploadPartSize || partSize > maxUploadPartSize || partCount > maxUploadParts {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":     fmt.Sprintf("part_size must be between %d and %d bytes and make at most %d parts", int64(minUploadPartSize), int64(maxUploadPartSize), maxUploadParts),
+				"part_size": uploadPartSize(session.Size),
+			})
+			return
+		}
+		partSHA256s := make([]string, len(requestBody.PartSHA256s))
+		for i, digest := range requestBody.PartSHA256s {
+			partSHA256s[i] = strings.ToLower(digest)
+			if !sha256Pattern.MatchString(partSHA256s[i]) {
+				partSHA256s = nil
+				break
+			}
+		}
+		if len(partSHA256s) != partCount {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("part_sha256s must hold the hex SHA-256 digests of the %d parts", partCount),
+			})
+			return
+		}
+
+		uploadID, err := multipart.CreateMultipartUpload(ctx, session.S3Key, session.MimeType)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("Failed to start multipart upload: %v", err),
+			})
+			return
+		}
+		session.MultipartUploadID = &uploadID
+		session.PartSize = &partSize
+		session.PartCount = &partCount
+		session.PartSHA256s = partSHA256s
+	}
+
+	if err := session.Save(ctx); err != nil {
+		if multipart, ok := blobStore.(utils.MultipartBlobStore); ok && session.MultipartUploadID != nil {
+			_ = multipart.AbortMultipartUpload(ctx, session.S3Key, *session.MultipartUploadID)
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to create upload session: %v", err),
+		})
+		return
+	}
+
+	respondWithUploadURLs(ctx, http.StatusCreated, session)
+}
+
+// GetUploadSession returns an upload session and, while it is pending, fresh upload URLs
+// GET /api/groups/:groupID/files/uploads/:uploadID
+func GetUploadSession(ctx *gin.Context) {
+	session, ok := getUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	if session.Status != "pending" || blobStore == nil {
+		ctx.JSON(http.StatusOK, gin.H{
+			"upload": session,
+		})
+		return
+	}
+
+	respondWithUploadURLs(ctx, http.StatusOK, session)
+}
+
+// CompleteUploadSession checks the uploaded content has the announced size and SHA-256 and creates
+// the file (or the new version). Multipart uploads send the ETag of every part.
+// POST /api/groups/:groupID/files/uploads/:uploadID/complete
+func CompleteUploadSession(ctx *gin.Context) {
+	if blobStore == nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "File storage not initialized",
+		})
+		return
+	}
+
+	session, ok := getUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Parts []utils.UploadedPart `json:"parts" binding:"dive"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	claimed, err := session.Claim(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !claimed {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":  "Upload session can no longer be completed",
+			"upload": session,
+		})
+		return
+	}
+
+	// A retried completion finds the parts already assembled
+	if info, err := blobStore.Head(ctx, session.S3Key); session.MultipartUploadID != nil && (err != nil || info.Size != session.Size) {
+		multipart, ok := blobStore.(utils.MultipartBlobStore)
+		if !ok {
+			_ = session.Release(ctx)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "File storage does not support multipart uploads",
+			})
+			return
+		}
+
+		parts := requestBody.Parts
+		slices.SortFunc(parts, func(a, b utils.UploadedPart) int { return int(a.PartNumber - b.PartNumber) })
+		for i, part := range parts {
+			if part.PartNumber != int32(i+1) {
+				parts = nil
+				break
+			}
+		}
+		if len(parts) != *session.PartCount {
+			_ = session.Release(ctx)
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("parts must list the ETags of parts 1 to %d", *session.PartCount),
+			})
+			return
+		}
+		for i := range parts {
+			parts[i].SHA256 = session.PartSHA256s[i]
+		}
+
+		if err := multipart.CompleteMultipartUpload(ctx, session.S3Key, *session.MultipartUploadID, parts); err != nil {
+			_ = session.Release(ctx)
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Failed to complete multipart upload: %v", err),
+			})
+			return
+		}
+	}
+
+	reason, err := verifyUploadedObject(ctx, blobStore, session)
+	if err != nil {
+		_ = session.Release(ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to verify upload: %v", err),
+		})
+		return
+	}
+	if reason != "" {
+		_ = services.DiscardUpload(ctx, session)
+		_ = session.Finish(ctx, "failed", &reason)
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  reason,
+			"upload": session,
+		})
+		return
+	}
+
+	if session.FileID != nil {
+		version := &models.FileVersion{
+			FileID:    *session.FileID,
+			Name:      session.Name,
+			Size:      session.Size,
+			MimeType:  session.MimeType,
+			S3Bucket:  session.S3Bucket,
+			S3Key:     session.S3Key,
+			CreatedBy: session.CreatedBy,
+		}
+		if err := version.Append(ctx); err != nil {
+			_ = session.Release(ctx)
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("Failed to save file version: %v", err),
+			})
+			return
+		}
+		pruneFileVersions(ctx, version.FileID)
+
+		_ = session.Finish(ctx, "completed", nil)
+		checkStorageThresholdAsync(session.GroupID)
+		ctx.JSON(http.StatusCreated, gin.H{
+			"message": "File version uploaded successfully",
+			"upload":  session,
+			"version": version,
+		})
+		return
+	}
+
+	file := &models.File{
+		ID:        session.ID,
+		Name:      session.Name,
+		Size:      session.Size,
+		MimeType:  session.MimeType,
+		S3Bucket:  session.S3Bucket,
+		S3Key:     session.S3Key,
+		FolderID:  session.FolderID,
+		GroupID:   &session.GroupID,
+		CreatedBy: session.CreatedBy,
+	}
+	if err := file.Save(ctx); err != nil {
+		_ = session.Release(ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to save file metadata: %v", err),
+		})
+		return
+	}
+
+	_ = session.Finish(ctx, "completed", nil)
+	checkStorageThresholdAsync(session.GroupID)
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "File uploaded successfully",
+		"upload":  session,
+		"file":    file,
+	})
+}
+
+// AbortUploadSession cancels a pending upload and discards what was uploaded
+// DELETE /api/groups/:groupID/files/uploads/:uploadID
+func AbortUploadSession(ctx *gin.Context) {
+	session, ok := getUploadSession(ctx)
+	if !ok {
+		return
+	}
+
+	if session.Status != "pending" {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error":  "Only pending uploads can be aborted",
+			"upload": session,
+		})
+		return
+	}
+
+	if err := services.DiscardUpload(ctx, session); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("Failed to discard upload: %v", err),
+		})
+		return
+	}
+
+	if err := session.Finish(ctx, "aborted", nil); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Upload aborted successfully",
+	})
+}
+
+// getUploadSession loads the session from the URL; only the user who started it can see it
+func getUploadSession(ctx *gin.Context) (*models.UploadSession, bool) {
+	session := &models.UploadSession{ID: ctx.Param("uploadID")}
+	err := session.Get(ctx)
+	if err != nil || session.GroupID != ctx.Param("groupID") || session.CreatedBy != ctx.GetString("userID") {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "Upload session not found",
+		})
+		return nil, false
+	}
+	return session, true
+}
+
+// respondWithUploadURLs presigns the URLs the content of a pending session goes to
+func respondWithUploadURLs(ctx *gin.Context, status int, session *models.UploadSession) {
+	urls := []uploadURL{}
+
+	if session.MultipartUploadID == nil {
+		content := utils.UploadContent{Size: session.Size, SHA256: session.SHA256, ContentType: session.MimeType}
+		upload, err := blobStore.PresignPut(ctx, session.S3Key, content, uploadURLExpiry)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("Failed to generate upload URL: %v", err),
+			})
+			return
+		}
+		urls = append(urls, uploadURL{URL: upload.URL, Headers: upload.Headers, Size: session.Size})
+	} else {
+		multipart, ok := blobStore.(utils.MultipartBlobStore)
+		if !ok {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": "File storage does not support multipart uploads",
+			})
+			return
+		}
+		for part := 1; part <= *session.PartCount; part++ {
+			size := min(*session.PartSize, session.Size-int64(part-1)*(*session.PartSize))
+			content := utils.UploadContent{Size: size, SHA256: session.PartSHA256s[part-1]}
+			upload, err := multipart.PresignPart(ctx, session.S3Key, *session.MultipartUploadID, int32(part), content, uploadURLExpiry)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"error": fmt.Sprintf("Failed to generate upload URL: %v", err),
+				})
+				return
+			}
+			urls = append(urls, uploadURL{PartNumber: int32(part), URL: upload.URL, Headers: upload.Headers, Size: size})
+		}
+	}
+
+	ctx.JSON(status, gin.H{
+		"upload":          session,
+		"method":          http.MethodPut,
+		"urls":            urls,
+		"urls_expire_in":  int(uploadURLExpiry.Seconds()),
+		"content_type":    session.MimeType,
+		"multipart":       session.MultipartUploadID != nil,
+		"complete_before": session.ExpiresAt,
+	})
+}
+
+// verifyUploadedObject compares the stored object with the size and SHA-256 the client announced,
+// using the checksum the store verified the content against instead of reading it back. Objects
+// assembled from parts carry the checksum of their parts' digests. It returns why the content does
+// not match, or an error when the announced digests are unusable.
+func verifyUploadedObject(ctx context.Context, store utils.BlobStore, session *models.UploadSession) (string, error) {
+	info, err := store.Head(ctx, session.S3Key)
+	if err != nil {
+		return "Uploaded content not found", nil
+	}
+	if info.Size != session.Size {
+		return fmt.Sprintf("Uploaded %d bytes but the upload announced %d", info.Size, session.Size), nil
+	}
+
+	var expected string
+	if session.MultipartUploadID != nil {
+		expected, err = utils.CompositeChecksumSHA256(session.PartSHA256s)
+	} else {
+		expected, err = utils.ChecksumSHA256(session.SHA256)
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.ChecksumSHA256 == "" {
+		return "Uploaded content has no SHA-256 checksum", nil
+	}
+	if info.ChecksumSHA256 != expected {
+		return "Uploaded content does not match the announced SHA-256", nil
+	}
+	return "", nil
+}
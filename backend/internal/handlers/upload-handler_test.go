@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// headOnlyStore reports a fixed object; verification must not need anything but Head
+type headOnlyStore struct {
+	utils.BlobStore
+	info *utils.BlobInfo
+}
+
+func (s *headOnlyStore) Head(ctx context.Context, key string) (*utils.BlobInfo, error) {
+	if s.info == nil {
+		return nil, os.ErrNotExist
+	}
+	return s.info, nil
+}
+
+func hexDigest(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyUploadedObject(t *testing.T) {
+	content := "uploaded content"
+	checksum, _ := utils.ChecksumSHA256(hexDigest(content))
+	otherChecksum, _ := utils.ChecksumSHA256(hexDigest("other content"))
+	session := &models.UploadSession{S3Key: "group/file", Size: int64(len(content)), SHA256: hexDigest(content)}
+
+	tests := []struct {
+		name    string
+		info    *utils.BlobInfo
+		matches bool
+	}{
+		{"matching checksum", &utils.BlobInfo{Size: session.Size, ChecksumSHA256: checksum}, true},
+		{"missing object", nil, false},
+		{"wrong size", &utils.BlobInfo{Size: session.Size + 1, ChecksumSHA256: checksum}, false},
+		{"wrong checksum", &utils.BlobInfo{Size: session.Size, ChecksumSHA256: otherChecksum}, false},
+		{"no checksum", &utils.BlobInfo{Size: session.Size}, false},
+	}
+	for _, test := range tests {
+		reason, err := verifyUploadedObject(context.Background(), &headOnlyStore{info: test.info}, session)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if matches := reason == ""; matches != test.matches {
+			t.Errorf("%s: reason %q, want match %v", test.name, reason, test.matches)
+		}
+	}
+}
+
+func TestVerifyMultipartUpload(t *testing.T) {
+	parts := []string{hexDigest("first"), hexDigest("second")}
+	uploadID := "upload-1"
+	session := &models.UploadSession{S3Key: "group/file", Size: 11, MultipartUploadID: &uploadID, PartSHA256s: parts}
+
+	composite, _ := utils.CompositeChecksumSHA256(parts)
+	reason, err := verifyUploadedObject(context.Background(), &headOnlyStore{info: &utils.BlobInfo{Size: 11, ChecksumSHA256: composite}}, session)
+	if err != nil || reason != "" {
+		t.Fatalf("matching parts: reason %q, err %v", reason, err)
+	}
+
+	swapped, _ := utils.CompositeChecksumSHA256([]string{parts[1], parts[0]})
+	reason, err = verifyUploadedObject(context.Background(), &headOnlyStore{info: &utils.BlobInfo{Size: 11, ChecksumSHA256: swapped}}, session)
+	if err != nil || reason == "" {
+		t.Fatalf("parts in another order: reason %q, err %v", reason, err)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 	"strings"
 
@@ -23,15 +24,34 @@ func getUserInitials(name string) string {
 }
 
 func RegisterUser(ctx *gin.Context) {
-	user := &models.User{}
+	var requestBody struct {
+		models.User
+		InvitationToken string `json:"invitation_token"` // Optional token from an emailed group invitation
+	}
 
-	err := ctx.ShouldBindJSON(&user)
+	err := ctx.ShouldBindJSON(&requestBody)
 
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	user := &requestBody.User
+
+	// Check the invitation before creating the account so a bad link doesn't leave a stray user behind
+	var invitation *models.GroupInvitation
+	if requestBody.InvitationToken != "" {
+		invitation, err = getInvitationForToken(ctx.Request.Context(), requestBody.InvitationToken)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !strings.EqualFold(invitation.InviteeEmail, user.Email) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "email must match the address the invitation was sent to"})
+			return
+		}
+	}
+
 	err = user.Save()
 
 	if err != nil {
@@ -67,30 +87,47 @@ func RegisterUser(ctx *gin.Context) {
 		_ = groupMember.Save() // Ignore error, continue with registration
 	}
 
-	auth := &models.Auth{
-		UserID: user.ID,
-	}
-
-	auth, err = auth.Create()
+	// Join the group from the invitation; the account stays usable even if this fails
+	var joinedGroupID *string
+	if invitation != nil {
+		if err := invitation.ConsumeToken(ctx.Request.Context(), user.ID); err != nil {
+			log.Printf("Error accepting invitation %s for new user %s: %v", invitation.ID, user.ID, err)
+		} else {
+			groupMember := &models.GroupMember{
+				GroupID: invitation.GroupID,
+				UserID:  user.ID,
+				Role:    invitation.Role,
+			}
+			if err := groupMember.Save(); err != nil {
+				log.Printf("Error adding new user %s to group %s: %v", user.ID, invitation.GroupID, err)
+			} else {
+				joinedGroupID = &invitation.GroupID
+			}
+		}
 
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		// The invitation link was delivered to this address, which proves the user owns it
+		if err := user.MarkEmailVerified(ctx.Request.Context(), user.Email); err != nil {
+			log.Printf("Error marking email of new user %s verified: %v", user.ID, err)
+		}
 	}
 
-	var authDetails utils.AuthDetails
-
-	authDetails.UserID = user.ID
-	authDetails.AuthUuid = auth.AuthUUID
+	if !user.IsEmailVerified() {
+		sendVerificationEmailAsync(*user)
+	}
 
-	token, err := utils.GenerateToken(authDetails)
+	response, err := createSession(ctx, user.ID)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, gin.H{"token": token, "id": user.ID, "name": user.Name, "email": user.Email})
+	response["id"] = user.ID
+	response["name"] = user.Name
+	response["email"] = user.Email
+	response["joined_group_id"] = joinedGroupID
+
+	ctx.JSON(http.StatusCreated, response)
 }
 
 func Login(ctx *gin.Context) {
@@ -109,30 +146,18 @@ func Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
 		return
 	}
-	auth := &models.Auth{
-		UserID: user.ID,
-	}
-
-	auth, err = auth.Create()
-
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	var authDetails utils.AuthDetails
-
-	authDetails.UserID = user.ID
-	authDetails.AuthUuid = auth.AuthUUID
-
-	token, err := utils.GenerateToken(authDetails)
+	response, err := createSession(ctx, user.ID)
 
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"token": token, "id": user.ID, "name": user.Name, "email": user.Email})
+	response["id"] = user.ID
+	response["name"] = user.Name
+	response["email"] = user.Email
+
+	ctx.JSON(http.StatusOK, response)
 
 }
 
@@ -171,6 +196,7 @@ func GetUser(ctx *gin.Context) {
 		"email":            user.Email,
 		"phone":            user.Phone,
 		"created_at":       user.CreatedAt,
+		"email_verified":   user.IsEmailVerified(),
 		"role_permissions": rolePermissions,
 	})
 }
@@ -210,6 +236,11 @@ func UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	// A new address has to be verified again
+	if updateData.Email != nil && !user.IsEmailVerified() {
+		sendVerificationEmailAsync(*user)
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"id": user.ID, "name": user.Name, "email": user.Email, "phone": user.Phone, "updated_at": user.UpdatedAt})
 }
 
@@ -224,3 +255,81 @@ func GetUsersFromMyGroups(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"users": users})
 }
+
+// GetUserPreferences returns the current user's preferences: reminder offsets in minutes before the
+// due date, notification channels per notification type and the webhook URL
+func GetUserPreferences(ctx *gin.Context) {
+	preferences := &models.UserPreferences{
+		UserID: ctx.GetString("userID"),
+	}
+
+	err := preferences.Get(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
+
+func UpdateUserPreferences(ctx *gin.Context) {
+	var updateData struct {
+		ReminderOffsets      *[]int               `json:"reminder_offsets"`
+		NotificationChannels *map[string][]string `json:"notification_channels"` // Replaces the whole mapping
+		WebhookURL           *string              `json:"webhook_url"`           // Empty string removes the webhook
+	}
+
+	err := ctx.ShouldBindJSON(&updateData)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preferences := &models.UserPreferences{
+		UserID: ctx.GetString("userID"),
+	}
+
+	err = preferences.Get(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if updateData.ReminderOffsets != nil {
+		offsets, err := models.NormalizeReminderOffsets(*updateData.ReminderOffsets)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		preferences.ReminderOffsets = offsets
+	}
+
+	if updateData.NotificationChannels != nil {
+		channels, err := models.NormalizeNotificationChannels(*updateData.NotificationChannels)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		preferences.NotificationChannels = channels
+	}
+
+	if updateData.WebhookURL != nil {
+		if *updateData.WebhookURL == "" {
+			preferences.WebhookURL = nil
+		} else {
+			if err := models.ValidateWebhookURL(*updateData.WebhookURL); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			preferences.WebhookURL = updateData.WebhookURL
+		}
+	}
+
+	err = preferences.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"preferences": preferences})
+}
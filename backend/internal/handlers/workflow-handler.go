@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWorkflow adds an automation to the group; owners and admins only. The definition has a
+// trigger, optional conditions and a list of actions (see models.WorkflowDefinition), e.g.
+//
+//	{"trigger": {"type": "task_status_changed", "to_status": "completed"},
+//	 "actions": [{"type": "create_task", "title": "Review {{task.title}}", "assign_to": "creator"},
+//	             {"type": "post_message", "content": "{{task.title}} is done"}]}
+//
+// POST /api/groups/:groupID/workflows
+func CreateWorkflow(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage workflows") {
+		return
+	}
+
+	var requestBody struct {
+		Name       string                    `json:"name" binding:"required"`
+		Definition models.WorkflowDefinition `json:"definition"`
+		Enabled    *bool                     `json:"enabled"` // Defaults to true
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workflow := models.Workflow{
+		GroupID:    ctx.Param("groupID"),
+		Name:       strings.TrimSpace(requestBody.Name),
+		Definition: requestBody.Definition,
+		Enabled:    requestBody.Enabled == nil || *requestBody.Enabled,
+		CreatedBy:  ctx.GetString("userID"),
+	}
+	if workflow.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "workflow name cannot be empty"})
+		return
+	}
+	if !validateWorkflowDefinition(ctx, workflow.GroupID, &workflow.Definition) {
+		return
+	}
+
+	err = workflow.Save(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"workflow": workflow})
+}
+
+// GetWorkflows lists the group's workflows
+// GET /api/groups/:groupID/workflows
+func GetWorkflows(ctx *gin.Context) {
+	workflows, err := models.GetGroupWorkflows(ctx.Request.Context(), ctx.Param("groupID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workflows": workflows})
+}
+
+// GetWorkflow returns one workflow of the group
+// GET /api/groups/:groupID/workflows/:workflowID
+func GetWorkflow(ctx *gin.Context) {
+	workflow, ok := getGroupWorkflow(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workflow": workflow})
+}
+
+// UpdateWorkflow renames, redefines, enables or disables a workflow; owners and admins only
+// PATCH /api/groups/:groupID/workflows/:workflowID
+func UpdateWorkflow(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage workflows") {
+		return
+	}
+
+	workflow, ok := getGroupWorkflow(ctx)
+	if !ok {
+		return
+	}
+
+	var requestBody struct {
+		Name       *string                    `json:"name"`
+		Definition *models.WorkflowDefinition `json:"definition"`
+		Enabled    *bool                      `json:"enabled"`
+	}
+
+	err := ctx.ShouldBindJSON(&requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if requestBody.Name != nil {
+		workflow.Name = strings.TrimSpace(*requestBody.Name)
+		if workflow.Name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "workflow name cannot be empty"})
+			return
+		}
+	}
+	if requestBody.Definition != nil {
+		if !validateWorkflowDefinition(ctx, workflow.GroupID, requestBody.Definition) {
+			return
+		}
+		workflow.Definition = *requestBody.Definition
+	}
+	if requestBody.Enabled != nil {
+		workflow.Enabled = *requestBody.Enabled
+	}
+
+	err = workflow.Update(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"workflow": workflow})
+}
+
+// DeleteWorkflow removes a workflow and its execution log; owners and admins only
+// DELETE /api/groups/:groupID/workflows/:workflowID
+func DeleteWorkflow(ctx *gin.Context) {
+	if !requireGroupAdmin(ctx, "only admins and owners can manage workflows") {
+		return
+	}
+
+	workflow, ok := getGroupWorkflow(ctx)
+	if !ok {
+		return
+	}
+
+	err := workflow.Delete(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Workflow deleted successfully"})
+}
+
+// GetWorkflowRuns returns the workflow's execution log, newest first, with limit and offset
+// GET /api/groups/:groupID/workflows/:workflowID/runs
+func GetWorkflowRuns(ctx *gin.Context) {
+	workflow, ok := getGroupWorkflow(ctx)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := getPagination(ctx, 20)
+	if !ok {
+		return
+	}
+
+	runs, total, err := models.GetWorkflowRuns(ctx.Request.Context(), workflow.ID, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"runs": runs, "total": total})
+}
+
+// getGroupWorkflow loads the workflow from the URL and checks it belongs to the group, writing the error response otherwise
+func getGroupWorkflow(ctx *gin.Context) (*models.Workflow, bool) {
+	workflow := &models.Workflow{ID: ctx.Param("workflowID")}
+	err := workflow.Get(ctx.Request.Context())
+	if err != nil || workflow.GroupID != ctx.Param("groupID") {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "workflow not found"})
+		return nil, false
+	}
+	return workflow, true
+}
+
+// validateWorkflowDefinition checks the definition's shape and that the labels and users it
+// references belong to the group, writing a 400 otherwise
+func validateWorkflowDefinition(ctx *gin.Context, groupID string, definition *models.WorkflowDefinition) bool {
+	if err := definition.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	labelIDs := []string{}
+	for _, condition := range definition.Conditions {
+		if condition.Field == "label" {
+			labelIDs = append(labelIDs, condition.Value)
+		}
+	}
+	userIDs := []string{}
+	for _, action := range definition.Actions {
+		if action.Type == "add_label" {
+			labelIDs = append(labelIDs, action.LabelID)
+		}
+		if action.AssignTo != "" && !slices.Contains(models.WorkflowAssigneeTargets, action.AssignTo) {
+			userIDs = append(userIDs, action.AssignTo)
+		}
+	}
+
+	for _, labelID := range labelIDs {
+		label := &models.GroupLabel{ID: labelID}
+		if err := label.Get(ctx.Request.Context()); err != nil || label.GroupID != groupID {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "label " + labelID + " not found in this group"})
+			return false
+		}
+	}
+
+	for _, userID := range userIDs {
+		groupMember := &models.GroupMember{GroupID: groupID, UserID: userID}
+		isMember, err := groupMember.IsMember(ctx.Request.Context())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		if !isMember {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "assign_to must be assignees, creator, owner or the ID of a group member"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// runTaskWorkflowsAsync runs the workflows triggered by a task event without blocking the response
+func runTaskWorkflowsAsync(event services.TaskEvent) {
+	go func() {
+		workflowCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		services.RunTaskWorkflows(workflowCtx, event)
+	}()
+}
+
+// runStatusWorkflowsAsync runs the task_status_changed workflows for a status change
+func runStatusWorkflowsAsync(taskID string, fromStatus string, toStatus string) {
+	runTaskWorkflowsAsync(services.TaskEvent{
+		Type:       "task_status_changed",
+		TaskID:     taskID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/KoiralaSam/Remindly/backend/internal/WS"
 	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/services"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 
@@ -44,7 +45,7 @@ func (h *WShandler) CreateRoom(ctx *gin.Context) {
 	h.hub.Rooms[req.ID] = &WS.Room{
 		ID:      req.ID,
 		Name:    req.Name,
-		Clients: make(map[string]*WS.Client),
+		Clients: make(map[*WS.Client]bool),
 	}
 
 	ctx.JSON(http.StatusOK, req)
@@ -88,6 +89,11 @@ func (h *WShandler) JoinRoom(ctx *gin.Context) {
 		return
 	}
 
+	// Edits, reactions and read updates on the connection are checked against the room
+	if !isGroupRoom(ctx) {
+		return
+	}
+
 	// Get group name using group ID (before upgrade so we can return proper errors)
 	group, err := models.GetGroupByID(groupID)
 	if err != nil {
@@ -100,7 +106,7 @@ func (h *WShandler) JoinRoom(ctx *gin.Context) {
 		h.hub.Rooms[roomID] = &WS.Room{
 			ID:      roomID,
 			Name:    group.Name,
-			Clients: make(map[string]*WS.Client),
+			Clients: make(map[*WS.Client]bool),
 		}
 	}
 
@@ -142,6 +148,52 @@ func (h *WShandler) JoinRoom(ctx *gin.Context) {
 	cl.ReadMessage(h.hub)
 }
 
+// JoinNotifications opens the current user's notification stream. It pushes task notifications,
+// invitations and unread count changes from every group the user belongs to.
+// GET /api/ws/notifications
+func (h *WShandler) JoinNotifications(ctx *gin.Context) {
+	userID := ctx.GetString("userID")
+	if userID == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user ID not found - authentication required"})
+		return
+	}
+
+	// Initial counts so the client can render badges without a separate request
+	counts, err := services.UnreadCounts(ctx.Request.Context(), userID, ctx.GetString("email"))
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+
+	cl := &WS.Client{
+		Conn:     conn,
+		ID:       userID,
+		Username: ctx.GetString("username"),
+		Message:  make(chan *WS.Message, 10),
+	}
+
+	cl.Message <- &WS.Message{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Type:      "unread_count",
+		Data:      counts,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+
+	h.hub.RegisterUser <- cl
+
+	// Reuse the chat write pump (JSON frames and keep-alive pings)
+	go cl.WriteMessage()
+
+	// Blocks until the connection closes
+	cl.ReadNotifications(h.hub)
+}
+
 type RoomRes struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -175,7 +227,13 @@ func (h *WShandler) GetClients(ctx *gin.Context) {
 		return
 	}
 
-	for _, c := range h.hub.Rooms[roomID].Clients {
+	// Users with several tabs open are listed once
+	listed := make(map[string]bool)
+	for c := range h.hub.Rooms[roomID].Clients {
+		if listed[c.ID] {
+			continue
+		}
+		listed[c.ID] = true
 		clients = append(clients, ClientRes{
 			ID:       c.ID,
 			Username: c.Username,
@@ -52,6 +52,20 @@ func AuthMiddleware() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			return
 		}
+
+		// Logged-out and revoked sessions no longer exist, even though their tokens are still signed
+		auth, err := models.FetchAuth(claims)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session has been revoked"})
+			return
+		}
+
+		// Tokens without an expiry only belong to sessions from before refresh tokens
+		if claims.Legacy && auth.RefreshTokenHash != "" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
 		user := &models.User{ID: claims.UserID}
 
 		err = user.Get()
@@ -63,6 +77,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Set user info in context
 		ctx.Set("userID", claims.UserID)
+		ctx.Set("authUUID", claims.AuthUuid)
 		ctx.Set("username", user.Name)
 		ctx.Set("email", user.Email) // if you have it
 
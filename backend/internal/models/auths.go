@@ -3,21 +3,57 @@ package models
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
 	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/jackc/pgx/v5"
 )
 
+// Auth is one login session. Access tokens carry its AuthUUID; the refresh token is stored hashed.
 type Auth struct {
-	ID       uint64
-	UserID   string
-	AuthUUID string
+	ID                       uint64    `json:"id"`
+	UserID                   string    `json:"user_id"`
+	AuthUUID                 string    `json:"-"`
+	RefreshTokenHash         string    `json:"-"` // Empty for sessions created before refresh tokens
+	PreviousRefreshTokenHash *string   `json:"-"`
+	UserAgent                string    `json:"user_agent"`
+	IPAddress                string    `json:"ip_address"`
+	CreatedAt                time.Time `json:"created_at"`
+	LastUsedAt               time.Time `json:"last_used_at"`
+	ExpiresAt                time.Time `json:"expires_at"`
 }
 
-func (a *Auth) Create() (*Auth, error) {
-	query := `INSERT INTO auths (user_id) VALUES ($1) RETURNING id, user_id, auth_uuid`
+// authColumns is the select list shared by session queries
+const authColumns = `id, user_id, auth_uuid, COALESCE(refresh_token_hash, ''), previous_refresh_token_hash, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expires_at`
+
+func scanAuth(row pgx.Row, a *Auth) error {
+	return row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.AuthUUID,
+		&a.RefreshTokenHash,
+		&a.PreviousRefreshTokenHash,
+		&a.UserAgent,
+		&a.IPAddress,
+		&a.CreatedAt,
+		&a.LastUsedAt,
+		&a.ExpiresAt,
+	)
+}
 
-	err := db.GetDB().QueryRow(context.Background(), query, a.UserID).Scan(&a.ID, &a.UserID, &a.AuthUUID)
+func (a *Auth) Create() (*Auth, error) {
+	query := `INSERT INTO auths (user_id, refresh_token_hash, user_agent, ip_address, expires_at) 
+	          VALUES ($1, $2, $3, $4, $5) 
+	          RETURNING id, user_id, auth_uuid, created_at, last_used_at`
+
+	err := db.GetDB().QueryRow(context.Background(), query,
+		a.UserID,
+		a.RefreshTokenHash,
+		a.UserAgent,
+		a.IPAddress,
+		a.ExpiresAt,
+	).Scan(&a.ID, &a.UserID, &a.AuthUUID, &a.CreatedAt, &a.LastUsedAt)
 
 	if err != nil {
 		return nil, errors.New("failed to create auth: " + err.Error())
@@ -26,12 +62,13 @@ func (a *Auth) Create() (*Auth, error) {
 	return a, nil
 }
 
+// FetchAuth returns the session an access token belongs to, if it still exists and hasn't expired
 func FetchAuth(authD *utils.AuthDetails) (*Auth, error) {
-	query := `SELECT id, user_id, auth_uuid FROM auths WHERE user_id = $1 AND auth_uuid = $2`
+	query := `SELECT ` + authColumns + ` FROM auths WHERE user_id = $1 AND auth_uuid = $2 AND expires_at > NOW()`
 
 	var auth Auth
 
-	err := db.GetDB().QueryRow(context.Background(), query, authD.UserID, authD.AuthUuid).Scan(&auth.ID, &auth.UserID, &auth.AuthUUID)
+	err := scanAuth(db.GetDB().QueryRow(context.Background(), query, authD.UserID, authD.AuthUuid), &auth)
 
 	if err != nil {
 		return nil, errors.New("failed to fetch auth: " + err.Error())
@@ -40,6 +77,77 @@ func FetchAuth(authD *utils.AuthDetails) (*Auth, error) {
 	return &auth, nil
 }
 
+// FetchAuthByRefreshToken finds the session for a refresh token hash. reused is true when the hash
+// belongs to a token that was already rotated out, which means the token was copied.
+func FetchAuthByRefreshToken(ctx context.Context, tokenHash string) (auth *Auth, reused bool, err error) {
+	query := `SELECT ` + authColumns + ` 
+	          FROM auths 
+	          WHERE refresh_token_hash = $1 OR previous_refresh_token_hash = $1`
+
+	auth = &Auth{}
+	err = scanAuth(db.GetDB().QueryRow(ctx, query, tokenHash), auth)
+	if err != nil {
+		return nil, false, errors.New("failed to fetch auth: " + err.Error())
+	}
+
+	return auth, auth.RefreshTokenHash != tokenHash, nil
+}
+
+// Rotate replaces the refresh token and extends the session. It fails if another request rotated the
+// same token first.
+func (a *Auth) Rotate(ctx context.Context, newTokenHash string, expiresAt time.Time, userAgent string, ipAddress string) error {
+	query := `UPDATE auths 
+	          SET previous_refresh_token_hash = refresh_token_hash, 
+	              refresh_token_hash = $1, 
+	              expires_at = $2, 
+	              user_agent = $3, 
+	              ip_address = $4, 
+	              last_used_at = NOW() 
+	          WHERE id = $5 AND refresh_token_hash = $6 
+	          RETURNING last_used_at`
+
+	err := db.GetDB().QueryRow(ctx, query, newTokenHash, expiresAt, userAgent, ipAddress, a.ID, a.RefreshTokenHash).Scan(&a.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("refresh token has already been used")
+	}
+	if err != nil {
+		return errors.New("failed to rotate refresh token: " + err.Error())
+	}
+
+	previous := a.RefreshTokenHash
+	a.PreviousRefreshTokenHash = &previous
+	a.RefreshTokenHash = newTokenHash
+	a.ExpiresAt = expiresAt
+	a.UserAgent = userAgent
+	a.IPAddress = ipAddress
+	return nil
+}
+
+// GetUserAuths lists the user's active sessions, most recently used first
+func GetUserAuths(ctx context.Context, userID string) ([]Auth, error) {
+	query := `SELECT ` + authColumns + ` 
+	          FROM auths 
+	          WHERE user_id = $1 AND expires_at > NOW() 
+	          ORDER BY last_used_at DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, userID)
+	if err != nil {
+		return nil, errors.New("failed to get sessions: " + err.Error())
+	}
+	defer rows.Close()
+
+	auths := []Auth{}
+	for rows.Next() {
+		var auth Auth
+		if err := scanAuth(rows, &auth); err != nil {
+			return nil, errors.New("failed to scan session: " + err.Error())
+		}
+		auths = append(auths, auth)
+	}
+
+	return auths, nil
+}
+
 func DeleteAuth(authD *utils.AuthDetails) error {
 	query := `DELETE FROM auths WHERE user_id = $1 AND auth_uuid = $2`
 
@@ -51,3 +159,39 @@ func DeleteAuth(authD *utils.AuthDetails) error {
 
 	return nil
 }
+
+// DeleteAuthByID revokes one of the user's sessions. It reports whether a session was deleted.
+func DeleteAuthByID(ctx context.Context, userID string, id uint64) (bool, error) {
+	query := `DELETE FROM auths WHERE user_id = $1 AND id = $2`
+
+	result, err := db.GetDB().Exec(ctx, query, userID, id)
+	if err != nil {
+		return false, errors.New("failed to delete auth: " + err.Error())
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// DeleteUserAuths revokes every session of the user
+func DeleteUserAuths(ctx context.Context, userID string) (int64, error) {
+	query := `DELETE FROM auths WHERE user_id = $1`
+
+	result, err := db.GetDB().Exec(ctx, query, userID)
+	if err != nil {
+		return 0, errors.New("failed to delete auths: " + err.Error())
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteExpiredAuths removes sessions whose refresh token expired
+func DeleteExpiredAuths(ctx context.Context) (int64, error) {
+	query := `DELETE FROM auths WHERE expires_at <= NOW()`
+
+	result, err := db.GetDB().Exec(ctx, query)
+	if err != nil {
+		return 0, errors.New("failed to delete expired auths: " + err.Error())
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,422 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// TaskStatuses lists the task statuses board columns can map onto
+var TaskStatuses = []string{"pending", "rejected", "active", "completed", "cancelled"}
+
+// maxRankLength is the rank length past which a column is rebalanced on the next move
+const maxRankLength = 32
+
+// Board is a group's kanban view of its tasks
+type Board struct {
+	ID        string        `json:"id"`
+	GroupID   string        `json:"group_id"`
+	Name      string        `json:"name"`
+	CreatedBy string        `json:"created_by"`
+	Columns   []BoardColumn `json:"columns"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// BoardColumn is a user-defined board column mapped onto a task status
+type BoardColumn struct {
+	ID        string      `json:"id"`
+	BoardID   string      `json:"board_id"`
+	Name      string      `json:"name"`
+	Status    string      `json:"status"`
+	Position  int         `json:"position"`
+	Tasks     []BoardTask `json:"tasks,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// BoardTask is a task placed in a board column; Rank is nil until the task is first moved
+type BoardTask struct {
+	Task
+	Rank *string `json:"rank"`
+}
+
+// DefaultBoardColumns are used when a board is created without columns
+func DefaultBoardColumns() []BoardColumn {
+	return []BoardColumn{
+		{Name: "To Do", Status: "pending"},
+		{Name: "In Progress", Status: "active"},
+		{Name: "Done", Status: "completed"},
+	}
+}
+
+const boardColumns = `id, group_id, name, created_by, created_at, updated_at`
+
+const boardColumnColumns = `id, board_id, name, status, position, created_at, updated_at`
+
+// boardPlacementColumn is the column a task shows in on board $1: the column it was moved to while its status
+// still matches that column, otherwise the first column mapped to its status (NULL when there is none)
+const boardPlacementColumn = `COALESCE(
+	(SELECT p.column_id FROM task_board_positions p JOIN board_columns c ON c.id = p.column_id
+		WHERE p.board_id = $1 AND p.task_id = tasks.id AND c.status = tasks.status),
+	(SELECT c.id FROM board_columns c WHERE c.board_id = $1 AND c.status = tasks.status ORDER BY c.position, c.created_at LIMIT 1))`
+
+// boardPlacementRank is the task's rank on board $1, NULL when it is not ranked in its current column
+const boardPlacementRank = `(SELECT p.rank FROM task_board_positions p JOIN board_columns c ON c.id = p.column_id
+	WHERE p.board_id = $1 AND p.task_id = tasks.id AND c.status = tasks.status)`
+
+func scanBoardColumn(row pgx.Row, c *BoardColumn) error {
+	return row.Scan(&c.ID, &c.BoardID, &c.Name, &c.Status, &c.Position, &c.CreatedAt, &c.UpdatedAt)
+}
+
+// Save creates the board together with its columns
+func (b *Board) Save(ctx context.Context) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO boards (group_id, name, created_by) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at`
+	err = tx.QueryRow(ctx, query, b.GroupID, b.Name, b.CreatedBy).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to create board: " + err.Error())
+	}
+
+	for i := range b.Columns {
+		column := &b.Columns[i]
+		column.BoardID = b.ID
+		column.Position = i
+
+		query := `INSERT INTO board_columns (board_id, name, status, position) VALUES ($1, $2, $3, $4) RETURNING ` + boardColumnColumns
+		err := scanBoardColumn(tx.QueryRow(ctx, query, column.BoardID, column.Name, column.Status, column.Position), column)
+		if err != nil {
+			return errors.New("failed to create board column: " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// Get loads the board and its columns
+func (b *Board) Get(ctx context.Context) error {
+	query := `SELECT ` + boardColumns + ` FROM boards WHERE id = $1`
+	err := db.GetDB().QueryRow(ctx, query, b.ID).Scan(&b.ID, &b.GroupID, &b.Name, &b.CreatedBy, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to get board: " + err.Error())
+	}
+
+	b.Columns, err = GetBoardColumns(ctx, b.ID)
+	return err
+}
+
+func (b *Board) Update(ctx context.Context) error {
+	query := `UPDATE boards SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING updated_at`
+	err := db.GetDB().QueryRow(ctx, query, b.Name, b.ID).Scan(&b.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to update board: " + err.Error())
+	}
+	return nil
+}
+
+// Delete removes the board; its tasks are not affected
+func (b *Board) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM boards WHERE id = $1`, b.ID)
+	if err != nil {
+		return errors.New("failed to delete board: " + err.Error())
+	}
+	return nil
+}
+
+// GetGroupBoards retrieves the group's boards with their columns (without tasks)
+func GetGroupBoards(ctx context.Context, groupID string) ([]Board, error) {
+	query := `SELECT ` + boardColumns + ` FROM boards WHERE group_id = $1 ORDER BY created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get boards: " + err.Error())
+	}
+
+	boards := []Board{}
+	for rows.Next() {
+		var board Board
+		if err := rows.Scan(&board.ID, &board.GroupID, &board.Name, &board.CreatedBy, &board.CreatedAt, &board.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, errors.New("failed to scan board: " + err.Error())
+		}
+		boards = append(boards, board)
+	}
+	rows.Close()
+
+	for i := range boards {
+		boards[i].Columns, err = GetBoardColumns(ctx, boards[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return boards, nil
+}
+
+// GetBoardColumns retrieves the columns of a board in display order
+func GetBoardColumns(ctx context.Context, boardID string) ([]BoardColumn, error) {
+	query := `SELECT ` + boardColumnColumns + ` FROM board_columns WHERE board_id = $1 ORDER BY position ASC, created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, boardID)
+	if err != nil {
+		return nil, errors.New("failed to get board columns: " + err.Error())
+	}
+	defer rows.Close()
+
+	columns := []BoardColumn{}
+	for rows.Next() {
+		var column BoardColumn
+		if err := scanBoardColumn(rows, &column); err != nil {
+			return nil, errors.New("failed to scan board column: " + err.Error())
+		}
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// Save appends the column to its board
+func (c *BoardColumn) Save(ctx context.Context) error {
+	query := `INSERT INTO board_columns (board_id, name, status, position) 
+	          VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position) + 1, 0) FROM board_columns WHERE board_id = $1)) 
+	          RETURNING ` + boardColumnColumns
+
+	err := scanBoardColumn(db.GetDB().QueryRow(ctx, query, c.BoardID, c.Name, c.Status), c)
+	if err != nil {
+		return errors.New("failed to create board column: " + err.Error())
+	}
+	return nil
+}
+
+func (c *BoardColumn) Get(ctx context.Context) error {
+	query := `SELECT ` + boardColumnColumns + ` FROM board_columns WHERE id = $1`
+	err := scanBoardColumn(db.GetDB().QueryRow(ctx, query, c.ID), c)
+	if err != nil {
+		return errors.New("failed to get board column: " + err.Error())
+	}
+	return nil
+}
+
+// Update saves the name, status and position. Tasks ranked in the column whose status no longer
+// matches fall back to the first column of their status.
+func (c *BoardColumn) Update(ctx context.Context) error {
+	query := `UPDATE board_columns SET name = $1, status = $2, position = $3, updated_at = NOW() 
+	          WHERE id = $4 RETURNING ` + boardColumnColumns
+
+	err := scanBoardColumn(db.GetDB().QueryRow(ctx, query, c.Name, c.Status, c.Position, c.ID), c)
+	if err != nil {
+		return errors.New("failed to update board column: " + err.Error())
+	}
+	return nil
+}
+
+// Delete removes the column; its tasks fall back to the first remaining column of their status
+func (c *BoardColumn) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM board_columns WHERE id = $1`, c.ID)
+	if err != nil {
+		return errors.New("failed to delete board column: " + err.Error())
+	}
+	return nil
+}
+
+// LoadTasks fills each column of the board with its tasks: ranked tasks in rank order, then
+// unranked ones oldest first. Tasks whose status has no column are left out.
+func (b *Board) LoadTasks(ctx context.Context) error {
+	query := `SELECT ` + taskColumns + `, ` + boardPlacementColumn + `, ` + boardPlacementRank + ` 
+	          FROM tasks LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE tasks.group_id = $2 AND tasks.deleted_at IS NULL`
+
+	rows, err := db.GetDB().Query(ctx, query, b.ID, b.GroupID)
+	if err != nil {
+		return errors.New("failed to get board tasks: " + err.Error())
+	}
+	defer rows.Close()
+
+	tasksByColumn := map[string][]BoardTask{}
+	for rows.Next() {
+		var task BoardTask
+		var columnID *string
+		if err := scanTask(rows, &task.Task, &columnID, &task.Rank); err != nil {
+			return errors.New("failed to scan task: " + err.Error())
+		}
+		if columnID != nil {
+			tasksByColumn[*columnID] = append(tasksByColumn[*columnID], task)
+		}
+	}
+
+	for i := range b.Columns {
+		tasks := tasksByColumn[b.Columns[i].ID]
+		slices.SortStableFunc(tasks, func(x, y BoardTask) int {
+			return compareBoardPlacement(x.Rank, x.CreatedAt, y.Rank, y.CreatedAt)
+		})
+		if tasks == nil {
+			tasks = []BoardTask{}
+		}
+		b.Columns[i].Tasks = tasks
+	}
+
+	return nil
+}
+
+// MoveTaskOnBoard places the task in the column right after afterTaskID (at the top when empty)
+// and sets the task's status to the column's, atomically. It returns false without moving when
+// afterTaskID is not in the column.
+func MoveTaskOnBoard(ctx context.Context, board *Board, taskID string, column *BoardColumn, afterTaskID string) (string, bool, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return "", false, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Moves on the same board are serialized so neighbours' ranks cannot change underneath us
+	if _, err := tx.Exec(ctx, `SELECT id FROM boards WHERE id = $1 FOR UPDATE`, board.ID); err != nil {
+		return "", false, errors.New("failed to lock board: " + err.Error())
+	}
+
+	// Current content of the target column, without the task being moved
+	query := `SELECT tasks.id, ` + boardPlacementRank + `, tasks.created_at FROM tasks 
+	          WHERE tasks.group_id = $2 AND tasks.id <> $3 AND tasks.deleted_at IS NULL AND ` + boardPlacementColumn + ` = $4`
+
+	rows, err := tx.Query(ctx, query, board.ID, board.GroupID, taskID, column.ID)
+	if err != nil {
+		return "", false, errors.New("failed to get column tasks: " + err.Error())
+	}
+
+	type placement struct {
+		taskID    string
+		rank      *string
+		createdAt time.Time
+	}
+	var placements []placement
+	for rows.Next() {
+		var p placement
+		if err := rows.Scan(&p.taskID, &p.rank, &p.createdAt); err != nil {
+			rows.Close()
+			return "", false, errors.New("failed to scan column task: " + err.Error())
+		}
+		placements = append(placements, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", false, errors.New("failed to get column tasks: " + err.Error())
+	}
+
+	slices.SortStableFunc(placements, func(x, y placement) int {
+		return compareBoardPlacement(x.rank, x.createdAt, y.rank, y.createdAt)
+	})
+
+	insertAt := 0
+	if afterTaskID != "" {
+		index := slices.IndexFunc(placements, func(p placement) bool { return p.taskID == afterTaskID })
+		if index < 0 {
+			return "", false, nil
+		}
+		insertAt = index + 1
+	}
+
+	// Unranked tasks sit at the end of the column; give them ranks so the new position is stable
+	previous := ""
+	for i := range placements {
+		if placements[i].rank == nil {
+			rank, err := utils.RankBetween(previous, "")
+			if err != nil {
+				return "", false, err
+			}
+			placements[i].rank = &rank
+			if err := upsertBoardPosition(ctx, tx, board.ID, placements[i].taskID, column.ID, rank); err != nil {
+				return "", false, err
+			}
+		}
+		previous = *placements[i].rank
+	}
+
+	before, after := "", ""
+	if insertAt > 0 {
+		before = *placements[insertAt-1].rank
+	}
+	if insertAt < len(placements) {
+		after = *placements[insertAt].rank
+	}
+
+	rank, err := utils.RankBetween(before, after)
+	if err != nil {
+		return "", false, err
+	}
+
+	// Many inserts at the same spot make ranks grow; spread the whole column out again
+	if len(rank) > maxRankLength {
+		ranks := utils.EvenRanks(len(placements) + 1)
+		rank = ranks[insertAt]
+		for i, p := range placements {
+			newRank := ranks[i]
+			if i >= insertAt {
+				newRank = ranks[i+1]
+			}
+			if err := upsertBoardPosition(ctx, tx, board.ID, p.taskID, column.ID, newRank); err != nil {
+				return "", false, err
+			}
+		}
+	}
+
+	if err := upsertBoardPosition(ctx, tx, board.ID, taskID, column.ID, rank); err != nil {
+		return "", false, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE tasks SET status = $1, updated_at = NOW() WHERE id = $2 AND status <> $1`, column.Status, taskID)
+	if err != nil {
+		return "", false, errors.New("failed to update task status: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", false, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return rank, true, nil
+}
+
+func upsertBoardPosition(ctx context.Context, tx pgx.Tx, boardID string, taskID string, columnID string, rank string) error {
+	query := `INSERT INTO task_board_positions (board_id, task_id, column_id, rank) 
+	          VALUES ($1, $2, $3, $4) 
+	          ON CONFLICT (board_id, task_id) DO UPDATE 
+	          SET column_id = EXCLUDED.column_id, rank = EXCLUDED.rank, updated_at = NOW()`
+
+	_, err := tx.Exec(ctx, query, boardID, taskID, columnID, rank)
+	if err != nil {
+		return errors.New("failed to save board position: " + err.Error())
+	}
+	return nil
+}
+
+// compareBoardPlacement orders ranked tasks by rank before unranked tasks, which are ordered oldest first
+func compareBoardPlacement(rankX *string, createdX time.Time, rankY *string, createdY time.Time) int {
+	switch {
+	case rankX != nil && rankY != nil:
+		if *rankX < *rankY {
+			return -1
+		}
+		if *rankX > *rankY {
+			return 1
+		}
+		return 0
+	case rankX != nil:
+		return -1
+	case rankY != nil:
+		return 1
+	default:
+		return createdX.Compare(createdY)
+	}
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// MaxCanvasElements caps the number of elements on one canvas
+const MaxCanvasElements = 5000
+
+// CanvasOpTypes lists the operations collaborative edits are made of
+var CanvasOpTypes = []string{"add", "update", "delete", "reorder"}
+
+type Canvas struct {
+	ID        string          `json:"id"`
+	GroupID   string          `json:"group_id"`
+	Name      string          `json:"name"`
+	Data      *CanvasDocument `json:"canvas_data,omitempty"` // Not loaded in listings
+	Version   int64           `json:"version"`               // Operations applied so far; the live version can be ahead of the stored one
+	CreatedBy string          `json:"created_by"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// CanvasDocument is the content of a canvas: elements by ID (shapes, text, connectors... the
+// properties are up to the client) and their stacking order from back to front
+type CanvasDocument struct {
+	Elements map[string]map[string]any `json:"elements"`
+	Order    []string                  `json:"order"`
+}
+
+// CanvasOp is one change to a canvas document:
+//   - add: Element with ID, on top or at Index
+//   - update: Props merged into the element; a null value removes the property
+//   - delete: removes the element
+//   - reorder: moves the element to Index in the stacking order
+//
+// Updates merge property by property, so concurrent edits to different properties of the same
+// element both survive; for the same property the edit applied last wins.
+type CanvasOp struct {
+	Op      string         `json:"op"`
+	ID      string         `json:"id"`
+	Element map[string]any `json:"element,omitempty"`
+	Props   map[string]any `json:"props,omitempty"`
+	Index   *int           `json:"index,omitempty"`
+}
+
+// Validate checks the operation is well formed
+func (op *CanvasOp) Validate() error {
+	if !slices.Contains(CanvasOpTypes, op.Op) {
+		return fmt.Errorf("invalid op %q", op.Op)
+	}
+	if op.ID == "" {
+		return errors.New(op.Op + " needs an element id")
+	}
+	if op.Op == "update" && len(op.Props) == 0 {
+		return errors.New("update needs props")
+	}
+	if op.Op == "reorder" && op.Index == nil {
+		return errors.New("reorder needs an index")
+	}
+	return nil
+}
+
+// Normalize makes sure the document's maps and slices are usable, dropping order entries
+// without an element and appending elements missing from the order
+func (d *CanvasDocument) Normalize() {
+	if d.Elements == nil {
+		d.Elements = map[string]map[string]any{}
+	}
+
+	order := make([]string, 0, len(d.Elements))
+	seen := map[string]bool{}
+	for _, id := range d.Order {
+		if _, ok := d.Elements[id]; ok && !seen[id] {
+			order = append(order, id)
+			seen[id] = true
+		}
+	}
+	missing := []string{}
+	for id := range d.Elements {
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	slices.Sort(missing)
+	d.Order = append(order, missing...)
+}
+
+// Apply performs the operation on the document. It returns false without an error when the
+// operation no longer applies, e.g. an update to an element a concurrent edit deleted, or an add
+// that was already applied.
+func (d *CanvasDocument) Apply(op CanvasOp) (bool, error) {
+	element, exists := d.Elements[op.ID]
+
+	switch op.Op {
+	case "add":
+		if exists {
+			return false, nil
+		}
+		if len(d.Elements) >= MaxCanvasElements {
+			return false, fmt.Errorf("a canvas can have at most %d elements", MaxCanvasElements)
+		}
+		element = map[string]any{}
+		for key, value := range op.Element {
+			if value != nil {
+				element[key] = value
+			}
+		}
+		d.Elements[op.ID] = element
+		d.Order = slices.Insert(d.Order, d.clampIndex(op.Index, len(d.Order)), op.ID)
+
+	case "update":
+		if !exists {
+			return false, nil
+		}
+		for key, value := range op.Props {
+			if value == nil {
+				delete(element, key)
+			} else {
+				element[key] = value
+			}
+		}
+
+	case "delete":
+		if !exists {
+			return false, nil
+		}
+		delete(d.Elements, op.ID)
+		d.Order = slices.DeleteFunc(d.Order, func(id string) bool { return id == op.ID })
+
+	case "reorder":
+		if !exists {
+			return false, nil
+		}
+		d.Order = slices.DeleteFunc(d.Order, func(id string) bool { return id == op.ID })
+		d.Order = slices.Insert(d.Order, d.clampIndex(op.Index, len(d.Order)), op.ID)
+	}
+
+	return true, nil
+}
+
+// clampIndex turns an optional index into a valid insert position; nil means on top
+func (d *CanvasDocument) clampIndex(index *int, length int) int {
+	if index == nil || *index > length {
+		return length
+	}
+	if *index < 0 {
+		return 0
+	}
+	return *index
+}
+
+const canvasColumns = `id, group_id, name, canvas_data, version, created_by, created_at, updated_at`
+
+func scanCanvas(row pgx.Row, c *Canvas) error {
+	if err := row.Scan(&c.ID, &c.GroupID, &c.Name, &c.Data, &c.Version, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return err
+	}
+	if c.Data == nil {
+		c.Data = &CanvasDocument{}
+	}
+	c.Data.Normalize()
+	return nil
+}
+
+func (c *Canvas) Save(ctx context.Context) error {
+	if c.Data == nil {
+		c.Data = &CanvasDocument{}
+	}
+	c.Data.Normalize()
+
+	query := `INSERT INTO canvases (group_id, name, canvas_data, created_by)
+	          VALUES ($1, $2, $3, $4)
+	          RETURNING ` + canvasColumns
+
+	err := scanCanvas(db.GetDB().QueryRow(ctx, query, c.GroupID, c.Name, c.Data, c.CreatedBy), c)
+	if err != nil {
+		return errors.New("failed to create canvas: " + err.Error())
+	}
+	return nil
+}
+
+func (c *Canvas) Get(ctx context.Context) error {
+	query := `SELECT ` + canvasColumns + ` FROM canvases WHERE id = $1`
+
+	err := scanCanvas(db.GetDB().QueryRow(ctx, query, c.ID), c)
+	if err != nil {
+		return errors.New("failed to get canvas: " + err.Error())
+	}
+	return nil
+}
+
+// Rename changes the canvas name; the content is only changed through operations
+func (c *Canvas) Rename(ctx context.Context, name string) error {
+	query := `UPDATE canvases SET name = $1, updated_at = NOW() WHERE id = $2 RETURNING ` + canvasColumns
+
+	err := scanCanvas(db.GetDB().QueryRow(ctx, query, name, c.ID), c)
+	if err != nil {
+		return errors.New("failed to update canvas: " + err.Error())
+	}
+	return nil
+}
+
+func (c *Canvas) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM canvases WHERE id = $1`, c.ID)
+	if err != nil {
+		return errors.New("failed to delete canvas: " + err.Error())
+	}
+	return nil
+}
+
+// GetGroupCanvases lists the group's canvases without their content, most recently updated first
+func GetGroupCanvases(ctx context.Context, groupID string) ([]Canvas, error) {
+	query := `SELECT id, group_id, name, version, created_by, created_at, updated_at
+	          FROM canvases
+	          WHERE group_id = $1
+	          ORDER BY updated_at DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get canvases: " + err.Error())
+	}
+	defer rows.Close()
+
+	canvases := []Canvas{}
+	for rows.Next() {
+		var c Canvas
+		if err := rows.Scan(&c.ID, &c.GroupID, &c.Name, &c.Version, &c.CreatedBy, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, errors.New("failed to scan canvas: " + err.Error())
+		}
+		canvases = append(canvases, c)
+	}
+	return canvases, nil
+}
+
+// SaveCanvasSnapshot persists the document as of version. Snapshots older than the stored one
+// are ignored, so out-of-order saves cannot roll the canvas back.
+func SaveCanvasSnapshot(ctx context.Context, canvasID string, data json.RawMessage, version int64) error {
+	query := `UPDATE canvases SET canvas_data = $1, version = $2, updated_at = NOW()
+	          WHERE id = $3 AND version < $2`
+
+	_, err := db.GetDB().Exec(ctx, query, data, version, canvasID)
+	if err != nil {
+		return errors.New("failed to save canvas snapshot: " + err.Error())
+	}
+	return nil
+}
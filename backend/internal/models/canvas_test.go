@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// testCanvas returns a document with elements a, b and c stacked in that order
+func testCanvas() *CanvasDocument {
+	return &CanvasDocument{
+		Elements: map[string]map[string]any{
+			"a": {"type": "rect", "x": 1.0},
+			"b": {"type": "text", "text": "hello"},
+			"c": {"type": "arrow"},
+		},
+		Order: []string{"a", "b", "c"},
+	}
+}
+
+func canvasIndex(i int) *int {
+	return &i
+}
+
+func TestCanvasDocumentApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      CanvasOp
+		applied bool
+		order   []string
+		element map[string]any // Expected element op.ID after the op, nil when it must be gone
+	}{
+		{"add on top", CanvasOp{Op: "add", ID: "d", Element: map[string]any{"type": "rect", "fill": nil}}, true, []string{"a", "b", "c", "d"}, map[string]any{"type": "rect"}},
+		{"add at index", CanvasOp{Op: "add", ID: "d", Element: map[string]any{"type": "rect"}, Index: canvasIndex(1)}, true, []string{"a", "d", "b", "c"}, map[string]any{"type": "rect"}},
+		{"add below everything", CanvasOp{Op: "add", ID: "d", Index: canvasIndex(-3)}, true, []string{"d", "a", "b", "c"}, map[string]any{}},
+		{"add past the top", CanvasOp{Op: "add", ID: "d", Index: canvasIndex(99)}, true, []string{"a", "b", "c", "d"}, map[string]any{}},
+		{"add twice", CanvasOp{Op: "add", ID: "a", Element: map[string]any{"type": "circle"}}, false, []string{"a", "b", "c"}, map[string]any{"type": "rect", "x": 1.0}},
+		{"update merges", CanvasOp{Op: "update", ID: "a", Props: map[string]any{"y": 2.0}}, true, []string{"a", "b", "c"}, map[string]any{"type": "rect", "x": 1.0, "y": 2.0}},
+		{"update overwrites and removes", CanvasOp{Op: "update", ID: "a", Props: map[string]any{"x": 5.0, "type": nil}}, true, []string{"a", "b", "c"}, map[string]any{"x": 5.0}},
+		{"update deleted element", CanvasOp{Op: "update", ID: "z", Props: map[string]any{"x": 5.0}}, false, []string{"a", "b", "c"}, nil},
+		{"delete", CanvasOp{Op: "delete", ID: "b"}, true, []string{"a", "c"}, nil},
+		{"delete twice", CanvasOp{Op: "delete", ID: "z"}, false, []string{"a", "b", "c"}, nil},
+		{"reorder to the back", CanvasOp{Op: "reorder", ID: "c", Index: canvasIndex(0)}, true, []string{"c", "a", "b"}, map[string]any{"type": "arrow"}},
+		{"reorder to the top", CanvasOp{Op: "reorder", ID: "a", Index: canvasIndex(2)}, true, []string{"b", "c", "a"}, map[string]any{"type": "rect", "x": 1.0}},
+		{"reorder past the top", CanvasOp{Op: "reorder", ID: "a", Index: canvasIndex(10)}, true, []string{"b", "c", "a"}, map[string]any{"type": "rect", "x": 1.0}},
+		{"reorder deleted element", CanvasOp{Op: "reorder", ID: "z", Index: canvasIndex(0)}, false, []string{"a", "b", "c"}, nil},
+	}
+	for _, test := range tests {
+		document := testCanvas()
+		applied, err := document.Apply(test.op)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if applied != test.applied {
+			t.Errorf("%s: applied %v, want %v", test.name, applied, test.applied)
+		}
+		if !slices.Equal(document.Order, test.order) {
+			t.Errorf("%s: order %v, want %v", test.name, document.Order, test.order)
+		}
+		element, exists := document.Elements[test.op.ID]
+		if exists != (test.element != nil) || !maps.Equal(element, test.element) {
+			t.Errorf("%s: element %v, want %v", test.name, element, test.element)
+		}
+	}
+}
+
+func TestCanvasDocumentApplyConcurrentUpdates(t *testing.T) {
+	document := testCanvas()
+	// Two editors change different properties of the same element, then one deletes it
+	ops := []CanvasOp{
+		{Op: "update", ID: "a", Props: map[string]any{"x": 3.0}},
+		{Op: "update", ID: "a", Props: map[string]any{"fill": "red"}},
+		{Op: "delete", ID: "a"},
+		{Op: "update", ID: "a", Props: map[string]any{"x": 4.0}},
+	}
+	var applied []bool
+	for _, op := range ops {
+		ok, err := document.Apply(op)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied = append(applied, ok)
+	}
+	if !slices.Equal(applied, []bool{true, true, true, false}) {
+		t.Errorf("applied %v, want the update after the delete dropped", applied)
+	}
+	if _, exists := document.Elements["a"]; exists || slices.Contains(document.Order, "a") {
+		t.Error("deleted element came back")
+	}
+}
+
+func TestCanvasDocumentApplyElementLimit(t *testing.T) {
+	document := &CanvasDocument{Elements: map[string]map[string]any{}}
+	for i := range MaxCanvasElements {
+		if _, err := document.Apply(CanvasOp{Op: "add", ID: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("add %d: %v", i, err)
+		}
+	}
+	if _, err := document.Apply(CanvasOp{Op: "add", ID: "one too many"}); err == nil {
+		t.Errorf("added element %d", MaxCanvasElements+1)
+	}
+	if len(document.Elements) != MaxCanvasElements || len(document.Order) != MaxCanvasElements {
+		t.Errorf("%d elements, %d in order, want %d", len(document.Elements), len(document.Order), MaxCanvasElements)
+	}
+}
@@ -0,0 +1,236 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// CustomFieldTypes lists the supported custom field types
+var CustomFieldTypes = []string{"text", "number", "date", "select"}
+
+// CustomField is a typed field defined by a group that its tasks can fill in
+type CustomField struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Name      string    `json:"name"`
+	FieldType string    `json:"field_type"`
+	Options   []string  `json:"options"` // Allowed values of select fields
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskFieldValue is a custom field value embedded in tasks. Value is a string for text, select
+// and date (RFC3339) fields and a number for number fields.
+type TaskFieldValue struct {
+	FieldID   string `json:"field_id"`
+	Name      string `json:"name"`
+	FieldType string `json:"field_type"`
+	Value     any    `json:"value"`
+}
+
+// CustomFieldValue is a value parsed for a field; only the member matching the field type is set
+type CustomFieldValue struct {
+	Text   *string
+	Number *float64
+	Date   *time.Time
+}
+
+const customFieldColumns = `id, group_id, name, field_type, options, position, created_at, updated_at`
+
+func scanCustomField(row pgx.Row, f *CustomField) error {
+	return row.Scan(&f.ID, &f.GroupID, &f.Name, &f.FieldType, &f.Options, &f.Position, &f.CreatedAt, &f.UpdatedAt)
+}
+
+// Validate checks the field type and the options of select fields
+func (f *CustomField) Validate() error {
+	if !slices.Contains(CustomFieldTypes, f.FieldType) {
+		return errors.New("invalid field_type. Must be one of: text, number, date, select")
+	}
+	if f.FieldType == "select" {
+		if len(f.Options) == 0 {
+			return errors.New("select fields need at least one option")
+		}
+		for i, option := range f.Options {
+			if option == "" || slices.Contains(f.Options[:i], option) {
+				return errors.New("select options must be non-empty and unique")
+			}
+		}
+	} else if len(f.Options) > 0 {
+		return errors.New("options only apply to select fields")
+	}
+	return nil
+}
+
+// ParseValue converts a JSON value (or a query string value) to the field's type
+func (f *CustomField) ParseValue(raw any) (CustomFieldValue, error) {
+	var value CustomFieldValue
+
+	switch f.FieldType {
+	case "number":
+		switch v := raw.(type) {
+		case float64:
+			value.Number = &v
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return value, fmt.Errorf("%s must be a number", f.Name)
+			}
+			value.Number = &n
+		default:
+			return value, fmt.Errorf("%s must be a number", f.Name)
+		}
+	case "date":
+		s, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("%s must be an RFC3339 date", f.Name)
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return value, fmt.Errorf("%s must be an RFC3339 date", f.Name)
+		}
+		value.Date = &t
+	default:
+		s, ok := raw.(string)
+		if !ok {
+			return value, fmt.Errorf("%s must be a string", f.Name)
+		}
+		if f.FieldType == "select" && !slices.Contains(f.Options, s) {
+			return value, fmt.Errorf("%s must be one of the field's options", f.Name)
+		}
+		value.Text = &s
+	}
+
+	return value, nil
+}
+
+// valueColumn is the task_custom_field_values column holding values of this field
+func (f *CustomField) valueColumn() string {
+	switch f.FieldType {
+	case "number":
+		return "value_number"
+	case "date":
+		return "value_date"
+	default:
+		return "value_text"
+	}
+}
+
+func (f *CustomField) Save(ctx context.Context) error {
+	query := `INSERT INTO group_custom_fields (group_id, name, field_type, options, position) 
+	          VALUES ($1, $2, $3, $4, (SELECT COALESCE(MAX(position) + 1, 0) FROM group_custom_fields WHERE group_id = $1)) 
+	          RETURNING ` + customFieldColumns
+
+	if f.Options == nil {
+		f.Options = []string{}
+	}
+
+	err := scanCustomField(db.GetDB().QueryRow(ctx, query, f.GroupID, f.Name, f.FieldType, f.Options), f)
+	if err != nil {
+		return errors.New("failed to create custom field: " + err.Error())
+	}
+
+	return nil
+}
+
+func (f *CustomField) Get(ctx context.Context) error {
+	query := `SELECT ` + customFieldColumns + ` FROM group_custom_fields WHERE id = $1`
+
+	err := scanCustomField(db.GetDB().QueryRow(ctx, query, f.ID), f)
+	if err != nil {
+		return errors.New("failed to get custom field: " + err.Error())
+	}
+
+	return nil
+}
+
+// Update saves the name, options and position. The type cannot change since stored values depend on it;
+// select values that are no longer an option are removed.
+func (f *CustomField) Update(ctx context.Context) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE group_custom_fields SET name = $1, options = $2, position = $3, updated_at = NOW() 
+	          WHERE id = $4 RETURNING ` + customFieldColumns
+
+	err = scanCustomField(tx.QueryRow(ctx, query, f.Name, f.Options, f.Position, f.ID), f)
+	if err != nil {
+		return errors.New("failed to update custom field: " + err.Error())
+	}
+
+	if f.FieldType == "select" {
+		query = `DELETE FROM task_custom_field_values WHERE field_id = $1 AND NOT (value_text = ANY($2))`
+		if _, err := tx.Exec(ctx, query, f.ID, f.Options); err != nil {
+			return errors.New("failed to remove stale custom field values: " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// Delete removes the field and every task's value for it
+func (f *CustomField) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM group_custom_fields WHERE id = $1`, f.ID)
+	if err != nil {
+		return errors.New("failed to delete custom field: " + err.Error())
+	}
+	return nil
+}
+
+// GetGroupCustomFields retrieves the custom fields of a group in display order
+func GetGroupCustomFields(ctx context.Context, groupID string) ([]CustomField, error) {
+	query := `SELECT ` + customFieldColumns + ` FROM group_custom_fields WHERE group_id = $1 ORDER BY position ASC, name ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get custom fields: " + err.Error())
+	}
+	defer rows.Close()
+
+	fields := []CustomField{}
+	for rows.Next() {
+		var field CustomField
+		if err := scanCustomField(rows, &field); err != nil {
+			return nil, errors.New("failed to scan custom field: " + err.Error())
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// SetTaskCustomFieldValue stores the task's value for the field; a nil value clears it
+func SetTaskCustomFieldValue(ctx context.Context, taskID string, field *CustomField, value *CustomFieldValue) error {
+	if value == nil {
+		_, err := db.GetDB().Exec(ctx, `DELETE FROM task_custom_field_values WHERE task_id = $1 AND field_id = $2`, taskID, field.ID)
+		if err != nil {
+			return errors.New("failed to clear custom field value: " + err.Error())
+		}
+		return nil
+	}
+
+	query := `INSERT INTO task_custom_field_values (task_id, field_id, value_text, value_number, value_date) 
+	          VALUES ($1, $2, $3, $4, $5) 
+	          ON CONFLICT (task_id, field_id) DO UPDATE 
+	          SET value_text = EXCLUDED.value_text, value_number = EXCLUDED.value_number, value_date = EXCLUDED.value_date, updated_at = NOW()`
+
+	_, err := db.GetDB().Exec(ctx, query, taskID, field.ID, value.Text, value.Number, value.Date)
+	if err != nil {
+		return errors.New("failed to set custom field value: " + err.Error())
+	}
+	return nil
+}
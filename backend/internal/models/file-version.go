@@ -0,0 +1,203 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// FileVersion is one upload of a file. Restoring a version appends a new one that points at the
+// same S3 object, so several versions can share an object.
+type FileVersion struct {
+	ID            string    `json:"id"`
+	FileID        string    `json:"file_id"`
+	VersionNumber int       `json:"version_number"`
+	Name          string    `json:"name"` // Name the version was uploaded under
+	Size          int64     `json:"size"`
+	MimeType      string    `json:"mime_type"`
+	S3Bucket      string    `json:"s3_bucket"`
+	S3Key         string    `json:"s3_key"`
+	RestoredFrom  *int      `json:"restored_from,omitempty"`
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+const fileVersionColumns = `id, file_id, version_number, name, size, mime_type, s3_bucket, s3_key, restored_from, created_by, created_at`
+
+func scanFileVersion(row pgx.Row, v *FileVersion) error {
+	return row.Scan(&v.ID, &v.FileID, &v.VersionNumber, &v.Name, &v.Size, &v.MimeType, &v.S3Bucket, &v.S3Key, &v.RestoredFrom, &v.CreatedBy, &v.CreatedAt)
+}
+
+// Append adds the version on top of the file's history and makes it the file's current content
+func (v *FileVersion) Append(ctx context.Context) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the file serializes concurrent uploads of new versions
+	lockQuery := `SELECT (SELECT COALESCE(MAX(version_number), 0) FROM file_versions WHERE file_id = files.id)
+	              FROM files WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	var latest int
+	if err := tx.QueryRow(ctx, lockQuery, v.FileID).Scan(&latest); err != nil {
+		return errors.New("failed to get file: " + err.Error())
+	}
+	v.VersionNumber = latest + 1
+
+	insertQuery := `INSERT INTO file_versions (file_id, version_number, name, size, mime_type, s3_bucket, s3_key, restored_from, created_by)
+	                VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	                RETURNING ` + fileVersionColumns
+
+	err = scanFileVersion(tx.QueryRow(ctx, insertQuery,
+		v.FileID, v.VersionNumber, v.Name, v.Size, v.MimeType, v.S3Bucket, v.S3Key, v.RestoredFrom, v.CreatedBy,
+	), v)
+	if err != nil {
+		return errors.New("failed to save file version: " + err.Error())
+	}
+
+	updateQuery := `UPDATE files
+	                SET size = $1, mime_type = $2, s3_bucket = $3, s3_key = $4, current_version = $5, updated_at = NOW()
+	                WHERE id = $6`
+
+	_, err = tx.Exec(ctx, updateQuery, v.Size, v.MimeType, v.S3Bucket, v.S3Key, v.VersionNumber, v.FileID)
+	if err != nil {
+		return errors.New("failed to update file: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// GetFileVersion returns one version of a file
+func GetFileVersion(ctx context.Context, fileID string, versionNumber int) (*FileVersion, error) {
+	query := `SELECT ` + fileVersionColumns + ` FROM file_versions WHERE file_id = $1 AND version_number = $2`
+
+	var version FileVersion
+	if err := scanFileVersion(db.GetDB().QueryRow(ctx, query, fileID, versionNumber), &version); err != nil {
+		return nil, errors.New("file version not found: " + err.Error())
+	}
+	return &version, nil
+}
+
+// GetFileVersions returns the file's versions, newest first
+func GetFileVersions(ctx context.Context, fileID string) ([]FileVersion, error) {
+	query := `SELECT ` + fileVersionColumns + ` FROM file_versions WHERE file_id = $1 ORDER BY version_number DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, fileID)
+	if err != nil {
+		return nil, errors.New("failed to fetch file versions: " + err.Error())
+	}
+	defer rows.Close()
+
+	versions := []FileVersion{}
+	for rows.Next() {
+		var version FileVersion
+		if err := scanFileVersion(rows, &version); err != nil {
+			return nil, errors.New("failed to scan file version: " + err.Error())
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// PruneFileVersions deletes the versions beyond the keep most recent ones, never the current one,
+// and returns the S3 keys no version references anymore so their objects can be deleted
+func PruneFileVersions(ctx context.Context, fileID string, keep int) ([]string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return nil, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the file like Append does keeps a version being added or restored from being pruned
+	// on the basis of a stale current_version
+	var lockedID string
+	err = tx.QueryRow(ctx, `SELECT id FROM files WHERE id = $1 FOR UPDATE`, fileID).Scan(&lockedID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return []string{}, nil
+		}
+		return nil, errors.New("failed to get file: " + err.Error())
+	}
+
+	deleteQuery := `DELETE FROM file_versions
+	                WHERE file_id = $1
+	                  AND version_number <> (SELECT current_version FROM files WHERE id = $1)
+	                  AND version_number NOT IN (
+	                      SELECT version_number FROM file_versions WHERE file_id = $1
+	                      ORDER BY version_number DESC LIMIT $2
+	                  )
+	                RETURNING s3_key`
+
+	pruned, err := collectStrings(tx.Query(ctx, deleteQuery, fileID, keep))
+	if err != nil {
+		return nil, errors.New("failed to prune file versions: " + err.Error())
+	}
+	if len(pruned) == 0 {
+		return pruned, nil
+	}
+
+	referencedQuery := `SELECT s3_key FROM file_versions WHERE s3_key = ANY($1)
+	                    UNION
+	                    SELECT s3_key FROM files WHERE s3_key = ANY($1)`
+
+	referenced, err := collectStrings(tx.Query(ctx, referencedQuery, pruned))
+	if err != nil {
+		return nil, errors.New("failed to check pruned file versions: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	inUse := map[string]bool{}
+	for _, key := range referenced {
+		inUse[key] = true
+	}
+	orphaned := []string{}
+	for _, key := range pruned {
+		if !inUse[key] {
+			orphaned = append(orphaned, key)
+			inUse[key] = true
+		}
+	}
+	return orphaned, nil
+}
+
+// GetFileObjectKeys returns the S3 keys of every version of the file
+func GetFileObjectKeys(ctx context.Context, fileID string) ([]string, error) {
+	query := `SELECT s3_key FROM file_versions WHERE file_id = $1
+	          UNION
+	          SELECT s3_key FROM files WHERE id = $1`
+
+	keys, err := collectStrings(db.GetDB().Query(ctx, query, fileID))
+	if err != nil {
+		return nil, errors.New("failed to fetch file objects: " + err.Error())
+	}
+	return keys, nil
+}
+
+// collectStrings reads a single text column
+func collectStrings(rows pgx.Rows, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
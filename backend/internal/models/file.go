@@ -11,21 +11,22 @@ import (
 )
 
 type File struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Size      int64     `json:"size"`
-	MimeType  string    `json:"mime_type"`
-	S3Bucket  string    `json:"s3_bucket"`
-	S3Key     string    `json:"s3_key"`
-	S3URL     *string   `json:"s3_url,omitempty"`
-	FolderID  *string   `json:"folder_id,omitempty"`
-	GroupID   *string   `json:"group_id,omitempty"`
-	CreatedBy string    `json:"created_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	MimeType       string    `json:"mime_type"`
+	S3Bucket       string    `json:"s3_bucket"`
+	S3Key          string    `json:"s3_key"`
+	S3URL          *string   `json:"s3_url,omitempty"`
+	FolderID       *string   `json:"folder_id,omitempty"`
+	GroupID        *string   `json:"group_id,omitempty"`
+	CreatedBy      string    `json:"created_by"`
+	CurrentVersion int       `json:"current_version"` // The version the size, type and S3 object come from
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
-// Save saves a file record to the database
+// Save saves a file record to the database along with its first version
 func (f *File) Save(ctx context.Context) error {
 	if f.ID == "" {
 		f.ID = uuid.New().String()
@@ -47,32 +48,53 @@ func (f *File) Save(ctx context.Context) error {
 			s3_url = EXCLUDED.s3_url,
 			folder_id = EXCLUDED.folder_id,
 			updated_at = EXCLUDED.updated_at
-		RETURNING id, created_at, updated_at
+		RETURNING id, created_at, updated_at, current_version
 	`
 
-	err := db.GetDB().QueryRow(ctx, query,
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, query,
 		f.ID, f.Name, f.Size, f.MimeType, f.S3Bucket, f.S3Key, f.S3URL,
 		f.FolderID, f.GroupID, f.CreatedBy, f.CreatedAt, f.UpdatedAt,
-	).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
+	).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt, &f.CurrentVersion)
 
 	if err != nil {
 		return errors.New("failed to save file: " + err.Error())
 	}
 
+	versionQuery := `
+		INSERT INTO file_versions (file_id, version_number, name, size, mime_type, s3_bucket, s3_key, created_by, created_at)
+		VALUES ($1, 1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (file_id, version_number) DO NOTHING
+	`
+
+	_, err = tx.Exec(ctx, versionQuery, f.ID, f.Name, f.Size, f.MimeType, f.S3Bucket, f.S3Key, f.CreatedBy, f.CreatedAt)
+	if err != nil {
+		return errors.New("failed to save file version: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+
 	return nil
 }
 
 // GetByID retrieves a file by ID
 func (f *File) GetByID(ctx context.Context) error {
 	query := `
-		SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at
+		SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at, current_version
 		FROM files
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	err := db.GetDB().QueryRow(ctx, query, f.ID).Scan(
 		&f.ID, &f.Name, &f.Size, &f.MimeType, &f.S3Bucket, &f.S3Key,
-		&f.S3URL, &f.FolderID, &f.GroupID, &f.CreatedBy, &f.CreatedAt, &f.UpdatedAt,
+		&f.S3URL, &f.FolderID, &f.GroupID, &f.CreatedBy, &f.CreatedAt, &f.UpdatedAt, &f.CurrentVersion,
 	)
 
 	if err != nil {
@@ -90,17 +112,17 @@ func GetFilesByGroupID(ctx context.Context, groupID string, folderID *string) ([
 
 	if folderID != nil {
 		query = `
-			SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at
+			SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at, current_version
 			FROM files
-			WHERE group_id = $1 AND folder_id = $2
+			WHERE group_id = $1 AND folder_id = $2 AND deleted_at IS NULL
 			ORDER BY created_at DESC
 		`
 		rows, err = db.GetDB().Query(ctx, query, groupID, folderID)
 	} else {
 		query = `
-			SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at
+			SELECT id, name, size, mime_type, s3_bucket, s3_key, s3_url, folder_id, group_id, created_by, created_at, updated_at, current_version
 			FROM files
-			WHERE group_id = $1 AND folder_id IS NULL
+			WHERE group_id = $1 AND folder_id IS NULL AND deleted_at IS NULL
 			ORDER BY created_at DESC
 		`
 		rows, err = db.GetDB().Query(ctx, query, groupID)
@@ -116,7 +138,7 @@ func GetFilesByGroupID(ctx context.Context, groupID string, folderID *string) ([
 		var file File
 		err := rows.Scan(
 			&file.ID, &file.Name, &file.Size, &file.MimeType, &file.S3Bucket, &file.S3Key,
-			&file.S3URL, &file.FolderID, &file.GroupID, &file.CreatedBy, &file.CreatedAt, &file.UpdatedAt,
+			&file.S3URL, &file.FolderID, &file.GroupID, &file.CreatedBy, &file.CreatedAt, &file.UpdatedAt, &file.CurrentVersion,
 		)
 		if err != nil {
 			return nil, errors.New("failed to scan file: " + err.Error())
@@ -127,6 +149,34 @@ func GetFilesByGroupID(ctx context.Context, groupID string, folderID *string) ([
 	return files, nil
 }
 
+// Move renames the file and moves it to folderID (nil for the group's root)
+func (f *File) Move(ctx context.Context, name string, folderID *string) error {
+	query := `UPDATE files SET name = $1, folder_id = $2, updated_at = NOW() WHERE id = $3 RETURNING updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, name, folderID, f.ID).Scan(&f.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to move file: " + err.Error())
+	}
+	f.Name = name
+	f.FolderID = folderID
+	return nil
+}
+
+// Trash moves the file to its group's trash
+func (f *File) Trash(ctx context.Context, userID string) error {
+	query := `UPDATE files SET deleted_at = NOW(), deleted_by = $1 WHERE id = $2 AND deleted_at IS NULL`
+	result, err := db.GetDB().Exec(ctx, query, userID, f.ID)
+	if err != nil {
+		return errors.New("failed to trash file: " + err.Error())
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.New("file not found")
+	}
+
+	return nil
+}
+
 // Delete deletes a file record from the database
 func (f *File) Delete(ctx context.Context) error {
 	query := `DELETE FROM files WHERE id = $1`
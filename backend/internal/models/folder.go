@@ -6,10 +6,12 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type Folder struct {
@@ -43,7 +45,7 @@ func (f *Folder) Save(ctx context.Context) error {
 	query := `
 		INSERT INTO folders (id, name, parent_id, path, group_id, created_by, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		ON CONFLICT (path, group_id) DO UPDATE SET
+		ON CONFLICT (path, group_id) WHERE deleted_at IS NULL DO UPDATE SET
 			name = EXCLUDED.name,
 			parent_id = EXCLUDED.parent_id,
 			updated_at = EXCLUDED.updated_at
@@ -82,7 +84,7 @@ func (f *Folder) GetByID(ctx context.Context) error {
 	query := `
 		SELECT id, name, parent_id, path, group_id, created_by, created_at, updated_at
 		FROM folders
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	err := db.GetDB().QueryRow(ctx, query, f.ID).Scan(
@@ -106,7 +108,7 @@ func GetFoldersByGroupID(ctx context.Context, groupID string, parentID *string)
 		query = `
 			SELECT id, name, parent_id, path, group_id, created_by, created_at, updated_at
 			FROM folders
-			WHERE group_id = $1 AND parent_id = $2
+			WHERE group_id = $1 AND parent_id = $2 AND deleted_at IS NULL
 			ORDER BY name ASC
 		`
 		rows, err = db.GetDB().Query(ctx, query, groupID, parentID)
@@ -114,7 +116,7 @@ func GetFoldersByGroupID(ctx context.Context, groupID string, parentID *string)
 		query = `
 			SELECT id, name, parent_id, path, group_id, created_by, created_at, updated_at
 			FROM folders
-			WHERE group_id = $1 AND parent_id IS NULL
+			WHERE group_id = $1 AND parent_id IS NULL AND deleted_at IS NULL
 			ORDER BY name ASC
 		`
 		rows, err = db.GetDB().Query(ctx, query, groupID)
@@ -156,6 +158,151 @@ func (f *Folder) Delete(ctx context.Context) error {
 	return nil
 }
 
+// Contains tells whether other is this folder or one of its descendants
+func (f *Folder) Contains(other *Folder) bool {
+	return other.ID == f.ID || strings.HasPrefix(other.Path, f.Path+"/")
+}
+
+// Move renames the folder and moves it under parentID (nil for the group's root), rewriting the
+// paths of all its descendants in the same transaction. It returns false when the group already
+// has a folder at the new path.
+func (f *Folder) Move(ctx context.Context, name string, parentID *string) (bool, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return false, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the folder so concurrent moves of it apply one after the other
+	var oldPath string
+	err = tx.QueryRow(ctx, `SELECT path FROM folders WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, f.ID).Scan(&oldPath)
+	if err != nil {
+		return false, errors.New("folder not found: " + err.Error())
+	}
+
+	newPath := "/" + name
+	if parentID != nil {
+		var parentPath string
+		err = tx.QueryRow(ctx, `SELECT path FROM folders WHERE id = $1 AND group_id IS NOT DISTINCT FROM $2 AND deleted_at IS NULL FOR SHARE`,
+			*parentID, f.GroupID).Scan(&parentPath)
+		if err != nil {
+			return false, errors.New("parent folder not found: " + err.Error())
+		}
+		if parentPath == oldPath || strings.HasPrefix(parentPath, oldPath+"/") {
+			return false, errors.New("a folder cannot be moved into itself")
+		}
+		newPath = parentPath + "/" + name
+	}
+
+	query := `
+		UPDATE folders SET name = $1, parent_id = $2, path = $3, updated_at = NOW()
+		WHERE id = $4
+		RETURNING name, parent_id, path, updated_at
+	`
+	err = tx.QueryRow(ctx, query, name, parentID, newPath, f.ID).Scan(&f.Name, &f.ParentID, &f.Path, &f.UpdatedAt)
+	if isUniqueViolation(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to move folder: " + err.Error())
+	}
+
+	if newPath != oldPath {
+		// Descendants are found through parent_id rather than by path prefix: trashed folders keep
+		// the path they were deleted at, which a live folder may have taken since. Trashed
+		// descendants move too so they are restored where their parent is.
+		// Paths are cut in characters, as Postgres counts them.
+		prefixLength := utf8.RuneCountInString(oldPath) + 1
+		descendantsQuery := `
+			WITH RECURSIVE descendants AS (
+				SELECT id FROM folders WHERE parent_id = $3
+				UNION ALL
+				SELECT folders.id FROM folders JOIN descendants ON folders.parent_id = descendants.id
+			)
+			UPDATE folders SET path = $1 || substr(path, $2), updated_at = NOW()
+			WHERE id IN (SELECT id FROM descendants)
+		`
+		_, err = tx.Exec(ctx, descendantsQuery, newPath, prefixLength, f.ID)
+		if isUniqueViolation(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.New("failed to move subfolders: " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, errors.New("failed to commit transaction: " + err.Error())
+	}
+	return true, nil
+}
+
+// liveSubtree lists the folder $1 and the subfolders under it that are not in the trash
+const liveSubtree = `WITH RECURSIVE subtree AS (
+		SELECT id FROM folders WHERE id = $1
+		UNION ALL
+		SELECT folders.id FROM folders JOIN subtree ON folders.parent_id = subtree.id
+		WHERE folders.deleted_at IS NULL
+	)`
+
+// CountContents returns how many subfolders and files the folder contains at any depth, leaving
+// out those in the trash
+func (f *Folder) CountContents(ctx context.Context) (int, int, error) {
+	query := liveSubtree + `
+		SELECT (SELECT COUNT(*) FROM subtree) - 1,
+		       (SELECT COUNT(*) FROM files WHERE folder_id IN (SELECT id FROM subtree) AND deleted_at IS NULL)
+	`
+
+	var folders, files int
+	err := db.GetDB().QueryRow(ctx, query, f.ID).Scan(&folders, &files)
+	if err != nil {
+		return 0, 0, errors.New("failed to count folder contents: " + err.Error())
+	}
+	return folders, files, nil
+}
+
+// Trash moves the folder to its group's trash together with its subfolders and the files in them.
+// They all get the same deleted_at, which is how restoring the folder finds them again.
+func (f *Folder) Trash(ctx context.Context, userID string) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the folder so it cannot be moved while its subtree is trashed
+	err = tx.QueryRow(ctx, `SELECT path FROM folders WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, f.ID).Scan(&f.Path)
+	if err != nil {
+		return errors.New("folder not found: " + err.Error())
+	}
+
+	// NOW() is the start of the transaction, the same for both statements
+	filesQuery := liveSubtree + `
+		UPDATE files SET deleted_at = NOW(), deleted_by = $2
+		WHERE folder_id IN (SELECT id FROM subtree) AND deleted_at IS NULL`
+	if _, err := tx.Exec(ctx, filesQuery, f.ID, userID); err != nil {
+		return errors.New("failed to trash files: " + err.Error())
+	}
+
+	foldersQuery := liveSubtree + `
+		UPDATE folders SET deleted_at = NOW(), deleted_by = $2
+		WHERE id IN (SELECT id FROM subtree)`
+	if _, err := tx.Exec(ctx, foldersQuery, f.ID, userID); err != nil {
+		return errors.New("failed to trash folders: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// isUniqueViolation tells whether the statement failed on a unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
 // SanitizeName sanitizes folder name to prevent path traversal
 func SanitizeFolderName(name string) string {
 	// Remove path separators and other dangerous characters
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestFolderContains(t *testing.T) {
+	docs := &Folder{ID: "docs", Path: "/docs"}
+	tests := []struct {
+		other    *Folder
+		contains bool
+	}{
+		{docs, true},
+		{&Folder{ID: "specs", Path: "/docs/specs"}, true},
+		{&Folder{ID: "v1", Path: "/docs/specs/v1"}, true},
+		{&Folder{ID: "docs2", Path: "/docs2"}, false},
+		{&Folder{ID: "archive", Path: "/archive/docs"}, false},
+		{&Folder{ID: "root", Path: "/"}, false},
+	}
+	for _, test := range tests {
+		if contains := docs.Contains(test.other); contains != test.contains {
+			t.Errorf("Contains(%s): %v, want %v", test.other.Path, contains, test.contains)
+		}
+	}
+}
+
+// createTestFolder creates a folder of the group under parent, at the root when it is nil
+func createTestFolder(t *testing.T, user *User, group *Group, name string, parent *Folder) *Folder {
+	t.Helper()
+	folder := &Folder{Name: name, GroupID: &group.ID, CreatedBy: user.ID}
+	if parent != nil {
+		folder.ParentID = &parent.ID
+	}
+	if err := folder.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return folder
+}
+
+// folderPath reads the stored path of a folder, trashed or not
+func folderPath(t *testing.T, folder *Folder) string {
+	t.Helper()
+	var path string
+	if err := db.GetDB().QueryRow(context.Background(), `SELECT path FROM folders WHERE id = $1`, folder.ID).Scan(&path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFolderMoveRewritesPaths(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	// Multi-byte names check paths are cut in characters
+	docs := createTestFolder(t, user, group, "résumés", nil)
+	specs := createTestFolder(t, user, group, "specs", docs)
+	v1 := createTestFolder(t, user, group, "v1", specs)
+	drafts := createTestFolder(t, user, group, "drafts", specs)
+	archive := createTestFolder(t, user, group, "archive", nil)
+	// Shares the prefix of the moved folder's path without being inside it
+	sibling := createTestFolder(t, user, group, "résumés-old", nil)
+	if err := drafts.Trash(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := docs.Move(ctx, "cv", &archive.ID)
+	if err != nil || !moved {
+		t.Fatalf("Move: moved %v, err %v", moved, err)
+	}
+	want := map[*Folder]string{
+		docs:    "/archive/cv",
+		specs:   "/archive/cv/specs",
+		v1:      "/archive/cv/specs/v1",
+		drafts:  "/archive/cv/specs/drafts",
+		archive: "/archive",
+		sibling: "/résumés-old",
+	}
+	for folder, path := range want {
+		if got := folderPath(t, folder); got != path {
+			t.Errorf("%s: path %q, want %q", folder.Name, got, path)
+		}
+	}
+
+	// Back to the root under another name
+	if moved, err := specs.Move(ctx, "plans", nil); err != nil || !moved {
+		t.Fatalf("Move to root: moved %v, err %v", moved, err)
+	}
+	if got := folderPath(t, v1); got != "/plans/v1" {
+		t.Errorf("v1: path %q, want /plans/v1", got)
+	}
+}
+
+func TestFolderMoveRejectsCyclesAndConflicts(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	docs := createTestFolder(t, user, group, "docs", nil)
+	specs := createTestFolder(t, user, group, "specs", docs)
+	createTestFolder(t, user, group, "specs", nil)
+
+	if _, err := docs.Move(ctx, "docs", &specs.ID); err == nil {
+		t.Error("folder moved into its own subfolder")
+	}
+	if _, err := docs.Move(ctx, "docs", &docs.ID); err == nil {
+		t.Error("folder moved into itself")
+	}
+
+	// The root already has a specs folder
+	moved, err := specs.Move(ctx, "specs", nil)
+	if err != nil || moved {
+		t.Errorf("Move onto a taken path: moved %v, err %v", moved, err)
+	}
+	if got := folderPath(t, specs); got != "/docs/specs" {
+		t.Errorf("path %q after a refused move, want /docs/specs", got)
+	}
+}
@@ -6,8 +6,12 @@ import (
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
+// InvitationTTL is how long an invitation (and its emailed accept link) stays valid
+const InvitationTTL = 7 * 24 * time.Hour
+
 type GroupInvitation struct {
 	ID           string     `json:"id"`
 	GroupID      string     `json:"group_id"`
@@ -20,12 +24,13 @@ type GroupInvitation struct {
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
 	GroupName    *string    `json:"group_name,omitempty"` // Optional field populated when joining with groups table
+	TokenHash    *string    `json:"-"`                    // Hash of the emailed accept token, never exposed
 }
 
 // Save creates a new group invitation
 func (gi *GroupInvitation) Save(ctx context.Context) error {
-	query := `INSERT INTO group_invitations (group_id, inviter_id, invitee_email, invitee_id, role, status, expires_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7) 
+	query := `INSERT INTO group_invitations (group_id, inviter_id, invitee_email, invitee_id, role, status, expires_at, token_hash) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
 	          RETURNING id, created_at, updated_at`
 
 	var inviteeID interface{}
@@ -46,6 +51,7 @@ func (gi *GroupInvitation) Save(ctx context.Context) error {
 		gi.Role,
 		gi.Status,
 		expiresAt,
+		gi.TokenHash,
 	).Scan(&gi.ID, &gi.CreatedAt, &gi.UpdatedAt)
 
 	if err != nil {
@@ -281,10 +287,26 @@ func CheckPendingInvitationExists(ctx context.Context, groupID, email string) (b
 	return exists, nil
 }
 
-// UpdateStatus updates the invitation status
+// CountPendingInvitations counts the pending invitations addressed to a user by ID or email
+func CountPendingInvitations(ctx context.Context, userID string, email string) (int, error) {
+	query := `SELECT COUNT(*) 
+	          FROM group_invitations 
+	          WHERE status = 'pending' AND (invitee_id = $1 OR invitee_email = $2)`
+
+	var count int
+	err := db.GetDB().QueryRow(ctx, query, userID, email).Scan(&count)
+	if err != nil {
+		return 0, errors.New("failed to count pending invitations: " + err.Error())
+	}
+	return count, nil
+}
+
+// UpdateStatus updates the invitation status. Leaving the pending state invalidates the accept token.
 func (gi *GroupInvitation) UpdateStatus(ctx context.Context, status string) error {
 	query := `UPDATE group_invitations 
-	          SET status = $1, updated_at = NOW() 
+	          SET status = $1, 
+	              token_hash = CASE WHEN $1 = 'pending' THEN token_hash END, 
+	              updated_at = NOW() 
 	          WHERE id = $2 
 	          RETURNING updated_at`
 
@@ -313,3 +335,75 @@ func (gi *GroupInvitation) SetInviteeID(ctx context.Context, userID string) erro
 	gi.InviteeID = userIDPtr
 	return nil
 }
+
+// IsExpired reports whether the invitation is past its expiry time
+func (gi *GroupInvitation) IsExpired() bool {
+	return gi.ExpiresAt != nil && !gi.ExpiresAt.After(time.Now())
+}
+
+// GetInvitationByTokenHash finds the invitation an emailed accept token belongs to
+func GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*GroupInvitation, error) {
+	query := `SELECT gi.id, gi.group_id, gi.inviter_id, gi.invitee_email, gi.invitee_id, gi.role, gi.status, gi.expires_at, gi.created_at, gi.updated_at, g.name 
+	          FROM group_invitations gi 
+	          JOIN groups g ON g.id = gi.group_id 
+	          WHERE gi.token_hash = $1`
+
+	var inv GroupInvitation
+	err := db.GetDB().QueryRow(ctx, query, tokenHash).Scan(
+		&inv.ID,
+		&inv.GroupID,
+		&inv.InviterID,
+		&inv.InviteeEmail,
+		&inv.InviteeID,
+		&inv.Role,
+		&inv.Status,
+		&inv.ExpiresAt,
+		&inv.CreatedAt,
+		&inv.UpdatedAt,
+		&inv.GroupName,
+	)
+	if err != nil {
+		return nil, errors.New("invitation not found: " + err.Error())
+	}
+
+	return &inv, nil
+}
+
+// ConsumeToken accepts the invitation on behalf of userID and invalidates its token. It fails if the
+// token was already used, the invitation is no longer pending, or it expired in the meantime.
+func (gi *GroupInvitation) ConsumeToken(ctx context.Context, userID string) error {
+	query := `UPDATE group_invitations 
+	          SET status = 'accepted', invitee_id = $1, token_hash = NULL, updated_at = NOW() 
+	          WHERE id = $2 
+	            AND status = 'pending' 
+	            AND token_hash IS NOT NULL 
+	            AND (expires_at IS NULL OR expires_at > NOW()) 
+	          RETURNING updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, userID, gi.ID).Scan(&gi.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("invitation link has already been used or has expired")
+	}
+	if err != nil {
+		return errors.New("failed to accept invitation: " + err.Error())
+	}
+
+	gi.Status = "accepted"
+	gi.InviteeID = &userID
+	gi.TokenHash = nil
+	return nil
+}
+
+// ExpireInvitations moves pending invitations past their expiry time to the expired status
+func ExpireInvitations(ctx context.Context) (int64, error) {
+	query := `UPDATE group_invitations 
+	          SET status = 'expired', token_hash = NULL, updated_at = NOW() 
+	          WHERE status = 'pending' AND expires_at IS NOT NULL AND expires_at <= NOW()`
+
+	result, err := db.GetDB().Exec(ctx, query)
+	if err != nil {
+		return 0, errors.New("failed to expire invitations: " + err.Error())
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// GroupLabel is a colored tag that tasks of a group can carry
+type GroupLabel struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"` // #RRGGBB
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskLabel is the label summary embedded in tasks
+type TaskLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ValidateLabelColor checks the color is a #RRGGBB hex value
+func ValidateLabelColor(color string) error {
+	if !labelColorPattern.MatchString(color) {
+		return errors.New("invalid color. Use a hex value like #1f883d")
+	}
+	return nil
+}
+
+const labelColumns = `id, group_id, name, color, created_at, updated_at`
+
+func scanLabel(row pgx.Row, label *GroupLabel) error {
+	return row.Scan(&label.ID, &label.GroupID, &label.Name, &label.Color, &label.CreatedAt, &label.UpdatedAt)
+}
+
+func (l *GroupLabel) Save(ctx context.Context) error {
+	query := `INSERT INTO group_labels (group_id, name, color) VALUES ($1, $2, $3) RETURNING ` + labelColumns
+
+	err := scanLabel(db.GetDB().QueryRow(ctx, query, l.GroupID, l.Name, l.Color), l)
+	if err != nil {
+		return errors.New("failed to create label: " + err.Error())
+	}
+
+	return nil
+}
+
+func (l *GroupLabel) Get(ctx context.Context) error {
+	query := `SELECT ` + labelColumns + ` FROM group_labels WHERE id = $1`
+
+	err := scanLabel(db.GetDB().QueryRow(ctx, query, l.ID), l)
+	if err != nil {
+		return errors.New("failed to get label: " + err.Error())
+	}
+
+	return nil
+}
+
+func (l *GroupLabel) Update(ctx context.Context) error {
+	query := `UPDATE group_labels SET name = $1, color = $2, updated_at = NOW() WHERE id = $3 RETURNING ` + labelColumns
+
+	err := scanLabel(db.GetDB().QueryRow(ctx, query, l.Name, l.Color, l.ID), l)
+	if err != nil {
+		return errors.New("failed to update label: " + err.Error())
+	}
+
+	return nil
+}
+
+// Delete removes the label from the group and from every task carrying it
+func (l *GroupLabel) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM group_labels WHERE id = $1`, l.ID)
+	if err != nil {
+		return errors.New("failed to delete label: " + err.Error())
+	}
+	return nil
+}
+
+// GetGroupLabels retrieves the labels of a group ordered by name
+func GetGroupLabels(ctx context.Context, groupID string) ([]GroupLabel, error) {
+	query := `SELECT ` + labelColumns + ` FROM group_labels WHERE group_id = $1 ORDER BY LOWER(name) ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get labels: " + err.Error())
+	}
+	defer rows.Close()
+
+	labels := []GroupLabel{}
+	for rows.Next() {
+		var label GroupLabel
+		if err := scanLabel(rows, &label); err != nil {
+			return nil, errors.New("failed to scan label: " + err.Error())
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// SetTaskLabels replaces the task's labels. Nothing changes when a label does not belong to the group.
+func SetTaskLabels(ctx context.Context, taskID string, groupID string, labelIDs []string) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM task_labels WHERE task_id = $1`, taskID)
+	if err != nil {
+		return errors.New("failed to clear task labels: " + err.Error())
+	}
+
+	query := `INSERT INTO task_labels (task_id, label_id) 
+	          SELECT $1, id FROM group_labels WHERE id = ANY($2::uuid[]) AND group_id = $3`
+	tag, err := tx.Exec(ctx, query, taskID, labelIDs, groupID)
+	if err != nil {
+		return errors.New("failed to set task labels: " + err.Error())
+	}
+
+	distinct := slices.Clone(labelIDs)
+	slices.Sort(distinct)
+	if tag.RowsAffected() != int64(len(slices.Compact(distinct))) {
+		return errors.New("some labels do not belong to this group")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return nil
+}
+
+// AddTaskLabel puts a label of the group on the task; labels the task already has are left alone
+func AddTaskLabel(ctx context.Context, taskID string, groupID string, labelID string) error {
+	query := `INSERT INTO task_labels (task_id, label_id) 
+	          SELECT $1, id FROM group_labels WHERE id = $2 AND group_id = $3 
+	          ON CONFLICT DO NOTHING`
+	_, err := db.GetDB().Exec(ctx, query, taskID, labelID, groupID)
+	if err != nil {
+		return errors.New("failed to add task label: " + err.Error())
+	}
+	return nil
+}
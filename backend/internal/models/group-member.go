@@ -167,3 +167,32 @@ func (gm *GroupMember) IsMember(ctx context.Context) (bool, error) {
 	}
 	return true, nil
 }
+
+// GetGroupMemberIDs returns the IDs of the group's members with one of the given roles
+func GetGroupMemberIDs(ctx context.Context, groupID string, roles ...string) ([]string, error) {
+	query := `SELECT user_id FROM group_members WHERE group_id = $1 AND role = ANY($2) ORDER BY created_at`
+	rows, err := db.GetDB().Query(ctx, query, groupID, roles)
+	if err != nil {
+		return nil, errors.New("failed to get group members: " + err.Error())
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, errors.New("failed to scan group member: " + err.Error())
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+// GetUserGroupIDs returns the IDs of the groups the user belongs to
+func GetUserGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	groupIDs, err := collectStrings(db.GetDB().Query(ctx, `SELECT group_id FROM group_members WHERE user_id = $1`, userID))
+	if err != nil {
+		return nil, errors.New("failed to get user groups: " + err.Error())
+	}
+	return groupIDs, nil
+}
@@ -17,6 +17,9 @@ type Link struct {
 	PreviewImageURL *string   `json:"preview_image_url,omitempty"`
 	PreviewImageS3Key *string `json:"preview_image_s3_key,omitempty"`
 	GroupID         *string   `json:"group_id,omitempty"`
+	PreviewStatus    string     `json:"preview_status"` // pending, ready or failed
+	PreviewError     *string    `json:"preview_error,omitempty"`
+	PreviewFetchedAt *time.Time `json:"preview_fetched_at,omitempty"`
 	CreatedBy       string    `json:"created_by"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
@@ -33,15 +36,18 @@ func (l *Link) Save(ctx context.Context) error {
 		l.CreatedAt = now
 	}
 	l.UpdatedAt = now
+	if l.PreviewStatus == "" {
+		l.PreviewStatus = "pending"
+	}
 
 	query := `
-		INSERT INTO links (id, url, title, description, preview_image_url, preview_image_s3_key, group_id, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO links (id, url, title, description, preview_image_url, preview_image_s3_key, group_id, preview_status, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING created_at, updated_at
 	`
 
 	err := db.GetDB().QueryRow(ctx, query,
-		l.ID, l.URL, l.Title, l.Description, l.PreviewImageURL, l.PreviewImageS3Key, l.GroupID, l.CreatedBy, l.CreatedAt, l.UpdatedAt,
+		l.ID, l.URL, l.Title, l.Description, l.PreviewImageURL, l.PreviewImageS3Key, l.GroupID, l.PreviewStatus, l.CreatedBy, l.CreatedAt, l.UpdatedAt,
 	).Scan(&l.CreatedAt, &l.UpdatedAt)
 
 	if err != nil {
@@ -54,13 +60,13 @@ func (l *Link) Save(ctx context.Context) error {
 // GetByID retrieves a link by ID
 func (l *Link) GetByID(ctx context.Context) error {
 	query := `
-		SELECT id, url, title, description, preview_image_url, preview_image_s3_key, group_id, created_by, created_at, updated_at
+		SELECT id, url, title, description, preview_image_url, preview_image_s3_key, group_id, preview_status, preview_error, preview_fetched_at, created_by, created_at, updated_at
 		FROM links
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	err := db.GetDB().QueryRow(ctx, query, l.ID).Scan(
-		&l.ID, &l.URL, &l.Title, &l.Description, &l.PreviewImageURL, &l.PreviewImageS3Key, &l.GroupID, &l.CreatedBy, &l.CreatedAt, &l.UpdatedAt,
+		&l.ID, &l.URL, &l.Title, &l.Description, &l.PreviewImageURL, &l.PreviewImageS3Key, &l.GroupID, &l.PreviewStatus, &l.PreviewError, &l.PreviewFetchedAt, &l.CreatedBy, &l.CreatedAt, &l.UpdatedAt,
 	)
 
 	if err != nil {
@@ -73,9 +79,9 @@ func (l *Link) GetByID(ctx context.Context) error {
 // GetLinksByGroupID retrieves all links for a group
 func GetLinksByGroupID(ctx context.Context, groupID string) ([]Link, error) {
 	query := `
-		SELECT id, url, title, description, preview_image_url, preview_image_s3_key, group_id, created_by, created_at, updated_at
+		SELECT id, url, title, description, preview_image_url, preview_image_s3_key, group_id, preview_status, preview_error, preview_fetched_at, created_by, created_at, updated_at
 		FROM links
-		WHERE group_id = $1
+		WHERE group_id = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -89,7 +95,7 @@ func GetLinksByGroupID(ctx context.Context, groupID string) ([]Link, error) {
 	for rows.Next() {
 		var link Link
 		err := rows.Scan(
-			&link.ID, &link.URL, &link.Title, &link.Description, &link.PreviewImageURL, &link.PreviewImageS3Key, &link.GroupID, &link.CreatedBy, &link.CreatedAt, &link.UpdatedAt,
+			&link.ID, &link.URL, &link.Title, &link.Description, &link.PreviewImageURL, &link.PreviewImageS3Key, &link.GroupID, &link.PreviewStatus, &link.PreviewError, &link.PreviewFetchedAt, &link.CreatedBy, &link.CreatedAt, &link.UpdatedAt,
 		)
 		if err != nil {
 			return nil, errors.New("failed to scan link: " + err.Error())
@@ -100,6 +106,49 @@ func GetLinksByGroupID(ctx context.Context, groupID string) ([]Link, error) {
 	return links, nil
 }
 
+// SavePreview stores the result of fetching the link's preview: the preview image, the status and
+// the error of a failed fetch. Title and description are only written where they still hold
+// loadedTitle and loadedDescription, the values the fetch started from, so an edit made meanwhile
+// is kept; the link is updated with what was stored.
+func (l *Link) SavePreview(ctx context.Context, loadedTitle *string, loadedDescription *string) error {
+	query := `
+		UPDATE links
+		SET title = CASE WHEN title IS NOT DISTINCT FROM $1 THEN $2 ELSE title END,
+		    description = CASE WHEN description IS NOT DISTINCT FROM $3 THEN $4 ELSE description END,
+		    preview_image_url = $5, preview_image_s3_key = $6,
+		    preview_status = $7, preview_error = $8, preview_fetched_at = NOW(), updated_at = NOW()
+		WHERE id = $9
+		RETURNING title, description, preview_fetched_at, updated_at
+	`
+
+	err := db.GetDB().QueryRow(ctx, query,
+		loadedTitle, l.Title, loadedDescription, l.Description,
+		l.PreviewImageURL, l.PreviewImageS3Key, l.PreviewStatus, l.PreviewError, l.ID,
+	).Scan(&l.Title, &l.Description, &l.PreviewFetchedAt, &l.UpdatedAt)
+
+	if err != nil {
+		return errors.New("failed to save link preview: " + err.Error())
+	}
+
+	return nil
+}
+
+// Trash moves the link to its group's trash
+func (l *Link) Trash(ctx context.Context, userID string) error {
+	query := `UPDATE links SET deleted_at = NOW(), deleted_by = $1 WHERE id = $2 AND deleted_at IS NULL`
+
+	result, err := db.GetDB().Exec(ctx, query, userID, l.ID)
+	if err != nil {
+		return errors.New("failed to trash link: " + err.Error())
+	}
+
+	if result.RowsAffected() == 0 {
+		return errors.New("link not found")
+	}
+
+	return nil
+}
+
 // Delete deletes a link record from the database
 func (l *Link) Delete(ctx context.Context) error {
 	query := `DELETE FROM links WHERE id = $1`
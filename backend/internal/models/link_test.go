@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestSavePreviewKeepsConcurrentEdits(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	link := &Link{URL: "https://example.com", GroupID: &group.ID, CreatedBy: user.ID}
+	if err := link.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two fetches start from the empty link; the first one to finish fills the title
+	first, second := "First title", "Second title"
+	description := "Fetched description"
+	link.Title, link.Description, link.PreviewStatus = &first, &description, "ready"
+	if err := link.SavePreview(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stale := &Link{ID: link.ID, Title: &second, PreviewStatus: "ready"}
+	if err := stale.SavePreview(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if stale.Title == nil || *stale.Title != first || stale.Description == nil || *stale.Description != description {
+		t.Errorf("stale preview overwrote the link: title %v, description %v", stale.Title, stale.Description)
+	}
+
+	// Starting from what is stored, the title can be replaced
+	fresh := &Link{ID: link.ID, Title: &second, Description: &description, PreviewStatus: "ready"}
+	if err := fresh.SavePreview(ctx, &first, &description); err != nil {
+		t.Fatal(err)
+	}
+	stored := &Link{ID: link.ID}
+	if err := stored.GetByID(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title == nil || *stored.Title != second {
+		t.Errorf("title %v, want %q", stored.Title, second)
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+)
+
+// maxReactionLength bounds an emoji in bytes; sequences with skin tones and joiners take up to ~30
+const maxReactionLength = 64
+
+// ReactionSummary is how many members reacted to a message with one emoji, and who
+type ReactionSummary struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// ValidReactionEmoji tells whether emoji can be used as a reaction: a short text without spaces
+func ValidReactionEmoji(emoji string) bool {
+	if emoji == "" || len(emoji) > maxReactionLength {
+		return false
+	}
+	return !strings.ContainsFunc(emoji, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	})
+}
+
+// AddMessageReaction records a member's reaction to a message. It returns false when the member had
+// already reacted with that emoji.
+func AddMessageReaction(ctx context.Context, messageID string, userID string, emoji string) (bool, error) {
+	query := `INSERT INTO message_reactions (message_id, user_id, emoji) 
+	          VALUES ($1, $2, $3) 
+	          ON CONFLICT (message_id, user_id, emoji) DO NOTHING`
+
+	result, err := db.GetDB().Exec(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return false, errors.New("failed to add reaction: " + err.Error())
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// RemoveMessageReaction removes a member's reaction. It returns false when there was none.
+func RemoveMessageReaction(ctx context.Context, messageID string, userID string, emoji string) (bool, error) {
+	query := `DELETE FROM message_reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+
+	result, err := db.GetDB().Exec(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		return false, errors.New("failed to remove reaction: " + err.Error())
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// GetMessageReactions returns the reactions of each message, the emoji used first coming first
+func GetMessageReactions(ctx context.Context, messageIDs []string) (map[string][]ReactionSummary, error) {
+	reactions := map[string][]ReactionSummary{}
+	if len(messageIDs) == 0 {
+		return reactions, nil
+	}
+
+	query := `SELECT message_id, emoji, COUNT(*), ARRAY_AGG(user_id ORDER BY created_at) 
+	          FROM message_reactions 
+	          WHERE message_id = ANY($1) 
+	          GROUP BY message_id, emoji 
+	          ORDER BY message_id, MIN(created_at)`
+
+	rows, err := db.GetDB().Query(ctx, query, messageIDs)
+	if err != nil {
+		return nil, errors.New("failed to get reactions: " + err.Error())
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID string
+		var reaction ReactionSummary
+		if err := rows.Scan(&messageID, &reaction.Emoji, &reaction.Count, &reaction.UserIDs); err != nil {
+			return nil, errors.New("failed to scan reaction: " + err.Error())
+		}
+		reactions[messageID] = append(reactions[messageID], reaction)
+	}
+
+	return reactions, nil
+}
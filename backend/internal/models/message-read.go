@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// MessageRead is how far a member has read a room: up to and including LastReadMessageID
+type MessageRead struct {
+	UserID            string    `json:"user_id"`
+	RoomID            string    `json:"room_id"`
+	LastReadMessageID *string   `json:"last_read_message_id"` // Nil once that message is deleted
+	LastReadAt        time.Time `json:"last_read_at"`         // When the last read message was sent
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// MarkMessageRead moves the member's read pointer in the room up to the message. The pointer never
+// moves back: it returns false, leaving read as it was, when a later message was read already.
+func MarkMessageRead(ctx context.Context, userID string, roomID string, messageID string) (*MessageRead, bool, error) {
+	query := `INSERT INTO message_reads (user_id, room_id, last_read_message_id, last_read_at, updated_at) 
+	          SELECT $1, room_id, id, created_at, NOW() FROM messages WHERE id = $3 AND room_id = $2 
+	          ON CONFLICT (user_id, room_id) DO UPDATE SET 
+	              last_read_message_id = EXCLUDED.last_read_message_id, 
+	              last_read_at = EXCLUDED.last_read_at, 
+	              updated_at = NOW() 
+	          WHERE message_reads.last_read_at < EXCLUDED.last_read_at 
+	          RETURNING user_id, room_id, last_read_message_id, last_read_at, updated_at`
+
+	var read MessageRead
+	err := db.GetDB().QueryRow(ctx, query, userID, roomID, messageID).Scan(
+		&read.UserID, &read.RoomID, &read.LastReadMessageID, &read.LastReadAt, &read.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.New("failed to mark message read: " + err.Error())
+	}
+	return &read, true, nil
+}
+
+// GetMessageRead returns the member's read pointer in the room, nil when nothing was read there yet
+func GetMessageRead(ctx context.Context, userID string, roomID string) (*MessageRead, error) {
+	query := `SELECT user_id, room_id, last_read_message_id, last_read_at, updated_at 
+	          FROM message_reads WHERE user_id = $1 AND room_id = $2`
+
+	var read MessageRead
+	err := db.GetDB().QueryRow(ctx, query, userID, roomID).Scan(
+		&read.UserID, &read.RoomID, &read.LastReadMessageID, &read.LastReadAt, &read.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to get read receipt: " + err.Error())
+	}
+	return &read, nil
+}
+
+// GetRoomReads returns the read pointers of the room's current members
+func GetRoomReads(ctx context.Context, roomID string) ([]MessageRead, error) {
+	query := `SELECT r.user_id, r.room_id, r.last_read_message_id, r.last_read_at, r.updated_at 
+	          FROM message_reads r 
+	          JOIN group_members gm ON gm.group_id = r.room_id AND gm.user_id = r.user_id 
+	          WHERE r.room_id = $1 
+	          ORDER BY r.last_read_at DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, roomID)
+	if err != nil {
+		return nil, errors.New("failed to get read receipts: " + err.Error())
+	}
+	defer rows.Close()
+
+	reads := []MessageRead{}
+	for rows.Next() {
+		var read MessageRead
+		if err := rows.Scan(&read.UserID, &read.RoomID, &read.LastReadMessageID, &read.LastReadAt, &read.UpdatedAt); err != nil {
+			return nil, errors.New("failed to scan read receipt: " + err.Error())
+		}
+		reads = append(reads, read)
+	}
+	return reads, nil
+}
+
+// GetUnreadMessageCounts returns, for every group of the user, how many messages other members
+// sent after the user's read pointer, or since the user joined when nothing was read yet
+func GetUnreadMessageCounts(ctx context.Context, userID string) (map[string]int, error) {
+	query := `SELECT gm.group_id, 
+	                 (SELECT COUNT(*) FROM messages m 
+	                  WHERE m.room_id = gm.group_id AND m.user_id <> gm.user_id 
+	                    AND m.created_at > COALESCE(r.last_read_at, gm.created_at)) 
+	          FROM group_members gm 
+	          LEFT JOIN message_reads r ON r.user_id = gm.user_id AND r.room_id = gm.group_id 
+	          WHERE gm.user_id = $1`
+
+	rows, err := db.GetDB().Query(ctx, query, userID)
+	if err != nil {
+		return nil, errors.New("failed to count unread messages: " + err.Error())
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var groupID string
+		var count int
+		if err := rows.Scan(&groupID, &count); err != nil {
+			return nil, errors.New("failed to scan unread messages: " + err.Error())
+		}
+		counts[groupID] = count
+	}
+	return counts, nil
+}
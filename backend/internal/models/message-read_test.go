@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestMarkMessageReadOnlyMovesForward(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+	_, otherGroup := createTestGroup(t)
+
+	first := createTestMessage(t, user, group, "first", nil)
+	second := createTestMessage(t, user, group, "second", nil)
+	third := createTestMessage(t, user, group, "third", nil)
+	elsewhere := createTestMessage(t, user, otherGroup, "elsewhere", nil)
+
+	steps := []struct {
+		name     string
+		message  *Message
+		advanced bool
+		readID   string
+	}{
+		{"first read", second, true, second.ID},
+		{"older message", first, false, second.ID},
+		{"same message again", second, false, second.ID},
+		{"message of another room", elsewhere, false, second.ID},
+		{"newer message", third, true, third.ID},
+	}
+	for _, step := range steps {
+		_, advanced, err := MarkMessageRead(ctx, user.ID, group.ID, step.message.ID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if advanced != step.advanced {
+			t.Errorf("%s: advanced %v, want %v", step.name, advanced, step.advanced)
+		}
+		read, err := GetMessageRead(ctx, user.ID, group.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read == nil || read.LastReadMessageID == nil || *read.LastReadMessageID != step.readID {
+			t.Errorf("%s: read pointer %+v, want message %s", step.name, read, step.readID)
+		}
+	}
+
+	// Deleting the last read message keeps the pointer where it was
+	if _, err := DeleteMessage(ctx, third.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, advanced, err := MarkMessageRead(ctx, user.ID, group.ID, second.ID); err != nil || advanced {
+		t.Errorf("older message after the last read one was deleted: advanced %v, err %v", advanced, err)
+	}
+}
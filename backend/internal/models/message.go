@@ -6,23 +6,39 @@ import (
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
 type Message struct {
-	ID        string    `json:"id"`
-	RoomID    string    `json:"room_id"`
-	UserID    string    `json:"user_id"`
-	Username  string    `json:"username"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string            `json:"id"`
+	RoomID     string            `json:"room_id"`
+	UserID     string            `json:"user_id"`
+	Username   string            `json:"username"`
+	Content    string            `json:"content"`
+	ParentID   *string           `json:"parent_id,omitempty"` // The first message of the thread this is a reply in
+	ReplyCount int               `json:"reply_count"`
+	EditedAt   *time.Time        `json:"edited_at,omitempty"`
+	DeletedAt  *time.Time        `json:"deleted_at,omitempty"` // Set when the message was deleted but stays for its replies
+	Reactions  []ReactionSummary `json:"reactions"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+const messageColumns = `messages.id, messages.room_id, messages.user_id, messages.username, messages.content,
+	messages.parent_id, (SELECT COUNT(*) FROM messages replies WHERE replies.parent_id = messages.id),
+	messages.edited_at, messages.deleted_at, messages.created_at, messages.updated_at`
+
+// scanMessage reads a row selected with messageColumns
+func scanMessage(row pgx.Row, m *Message) error {
+	return row.Scan(&m.ID, &m.RoomID, &m.UserID, &m.Username, &m.Content,
+		&m.ParentID, &m.ReplyCount, &m.EditedAt, &m.DeletedAt, &m.CreatedAt, &m.UpdatedAt)
 }
 
 // Save persists a message to the database (ID is auto-generated)
 func (m *Message) Save(ctx context.Context) error {
 
-	query := `INSERT INTO messages (room_id, user_id, username, content) 
-	          VALUES ($1, $2, $3, $4) 
+	query := `INSERT INTO messages (room_id, user_id, username, content, parent_id) 
+	          VALUES ($1, $2, $3, $4, $5) 
 	          RETURNING id, created_at, updated_at`
 
 	err := db.GetDB().QueryRow(ctx, query,
@@ -30,19 +46,21 @@ func (m *Message) Save(ctx context.Context) error {
 		m.UserID,
 		m.Username,
 		m.Content,
+		m.ParentID,
 	).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
 
 	if err != nil {
 		return errors.New("failed to save message: " + err.Error())
 	}
 
+	m.Reactions = []ReactionSummary{}
 	return nil
 }
 
 // SaveWithID persists a message to the database with a pre-generated ID
 func (m *Message) SaveWithID(ctx context.Context) error {
-	query := `INSERT INTO messages (id, room_id, user_id, username, content, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	query := `INSERT INTO messages (id, room_id, user_id, username, content, parent_id, created_at, updated_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
 
 	_, err := db.GetDB().Exec(ctx, query,
 		m.ID,
@@ -50,6 +68,7 @@ func (m *Message) SaveWithID(ctx context.Context) error {
 		m.UserID,
 		m.Username,
 		m.Content,
+		m.ParentID,
 		m.CreatedAt,
 		m.UpdatedAt,
 	)
@@ -63,53 +82,127 @@ func (m *Message) SaveWithID(ctx context.Context) error {
 
 // GetByID retrieves a message by its ID
 func (m *Message) GetByID(ctx context.Context) error {
-	query := `SELECT id, room_id, user_id, username, content, created_at, updated_at 
+	query := `SELECT ` + messageColumns + ` 
 	          FROM messages 
-	          WHERE id = $1`
-
-	err := db.GetDB().QueryRow(ctx, query, m.ID).Scan(
-		&m.ID,
-		&m.RoomID,
-		&m.UserID,
-		&m.Username,
-		&m.Content,
-		&m.CreatedAt,
-		&m.UpdatedAt,
-	)
+	          WHERE messages.id = $1`
 
+	err := scanMessage(db.GetDB().QueryRow(ctx, query, m.ID), m)
 	if err != nil {
 		return errors.New("message not found: " + err.Error())
 	}
 
+	reactions, err := GetMessageReactions(ctx, []string{m.ID})
+	if err != nil {
+		return err
+	}
+	m.Reactions = reactions[m.ID]
+	if m.Reactions == nil {
+		m.Reactions = []ReactionSummary{}
+	}
+
 	return nil
 }
 
-// GetRoomMessages retrieves all messages for a room with pagination
+// Edit replaces the content of the message and marks it edited; deleted messages cannot be edited
+func (m *Message) Edit(ctx context.Context, content string) error {
+	query := `UPDATE messages SET content = $1, edited_at = NOW(), updated_at = NOW() 
+	          WHERE id = $2 AND deleted_at IS NULL 
+	          RETURNING edited_at, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, content, m.ID).Scan(&m.EditedAt, &m.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("message not found")
+	}
+	if err != nil {
+		return errors.New("failed to edit message: " + err.Error())
+	}
+	m.Content = content
+	return nil
+}
+
+// GetThreadRoot returns the ID of the thread a reply to parentID belongs in: parentID itself, or the
+// first message of its thread when parentID is a reply. It returns false when the room has no such message.
+func GetThreadRoot(ctx context.Context, roomID string, parentID string) (string, bool, error) {
+	query := `SELECT COALESCE(parent_id, id) FROM messages WHERE id = $1 AND room_id = $2`
+
+	var rootID string
+	err := db.GetDB().QueryRow(ctx, query, parentID, roomID).Scan(&rootID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.New("failed to get thread: " + err.Error())
+	}
+	return rootID, true, nil
+}
+
+// GetRoomMessages retrieves the messages of a room with pagination, leaving out thread replies
 func GetRoomMessages(ctx context.Context, roomID string, limit int, offset int) ([]Message, error) {
-	query := `SELECT id, room_id, user_id, username, content, created_at, updated_at 
+	query := `SELECT ` + messageColumns + ` 
 	          FROM messages 
-	          WHERE room_id = $1 
+	          WHERE room_id = $1 AND parent_id IS NULL 
 	          ORDER BY created_at DESC 
 	          LIMIT $2 OFFSET $3`
 
-	rows, err := db.GetDB().Query(ctx, query, roomID, limit, offset)
+	messages, err := queryMessages(ctx, query, roomID, limit, offset)
 	if err != nil {
 		return nil, errors.New("failed to fetch room messages: " + err.Error())
 	}
+
+	// Reverse to get chronological order (oldest first)
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// GetThreadMessages retrieves the replies in a thread, oldest first
+func GetThreadMessages(ctx context.Context, parentID string, limit int, offset int) ([]Message, error) {
+	query := `SELECT ` + messageColumns + ` 
+	          FROM messages 
+	          WHERE parent_id = $1 
+	          ORDER BY created_at ASC 
+	          LIMIT $2 OFFSET $3`
+
+	messages, err := queryMessages(ctx, query, parentID, limit, offset)
+	if err != nil {
+		return nil, errors.New("failed to fetch thread messages: " + err.Error())
+	}
+	return messages, nil
+}
+
+// queryMessages runs a query selecting messageColumns and loads the reactions of the messages
+func queryMessages(ctx context.Context, query string, args ...any) ([]Message, error) {
+	rows, err := db.GetDB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var messages []Message
+	var ids []string
 	for rows.Next() {
 		var msg Message
-		if err := rows.Scan(&msg.ID, &msg.RoomID, &msg.UserID, &msg.Username, &msg.Content, &msg.CreatedAt, &msg.UpdatedAt); err != nil {
-			return nil, errors.New("failed to scan message: " + err.Error())
+		if err := scanMessage(rows, &msg); err != nil {
+			return nil, err
 		}
 		messages = append(messages, msg)
+		ids = append(ids, msg.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	// Reverse to get chronological order (oldest first)
-	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
-		messages[i], messages[j] = messages[j], messages[i]
+	reactions, err := GetMessageReactions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range messages {
+		messages[i].Reactions = reactions[messages[i].ID]
+		if messages[i].Reactions == nil {
+			messages[i].Reactions = []ReactionSummary{}
+		}
 	}
 
 	return messages, nil
@@ -117,26 +210,16 @@ func GetRoomMessages(ctx context.Context, roomID string, limit int, offset int)
 
 // GetUserRoomMessages retrieves messages for a specific user in a room
 func GetUserRoomMessages(ctx context.Context, roomID string, userID string, limit int, offset int) ([]Message, error) {
-	query := `SELECT id, room_id, user_id, username, content, created_at, updated_at 
+	query := `SELECT ` + messageColumns + ` 
 	          FROM messages 
 	          WHERE room_id = $1 AND user_id = $2 
 	          ORDER BY created_at DESC 
 	          LIMIT $3 OFFSET $4`
 
-	rows, err := db.GetDB().Query(ctx, query, roomID, userID, limit, offset)
+	messages, err := queryMessages(ctx, query, roomID, userID, limit, offset)
 	if err != nil {
 		return nil, errors.New("failed to fetch user room messages: " + err.Error())
 	}
-	defer rows.Close()
-
-	var messages []Message
-	for rows.Next() {
-		var msg Message
-		if err := rows.Scan(&msg.ID, &msg.RoomID, &msg.UserID, &msg.Username, &msg.Content, &msg.CreatedAt, &msg.UpdatedAt); err != nil {
-			return nil, errors.New("failed to scan message: " + err.Error())
-		}
-		messages = append(messages, msg)
-	}
 
 	// Reverse to get chronological order (oldest first)
 	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
@@ -146,18 +229,44 @@ func GetUserRoomMessages(ctx context.Context, roomID string, userID string, limi
 	return messages, nil
 }
 
-// DeleteMessage deletes a message by its ID
-func DeleteMessage(ctx context.Context, messageID string) error {
-	query := `DELETE FROM messages WHERE id = $1`
+// DeleteMessage deletes a message by its ID. A message with replies is emptied and kept as a
+// placeholder instead, so the replies keep their thread; it returns true when that is the case.
+func DeleteMessage(ctx context.Context, messageID string) (bool, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return false, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
 
-	result, err := db.GetDB().Exec(ctx, query, messageID)
+	// Locking the message holds back replies to it until the delete is done
+	var hasReplies bool
+	query := `SELECT EXISTS (SELECT 1 FROM messages replies WHERE replies.parent_id = messages.id) 
+	          FROM messages 
+	          WHERE id = $1 
+	          FOR UPDATE`
+	err = tx.QueryRow(ctx, query, messageID).Scan(&hasReplies)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, errors.New("message not found")
+	}
 	if err != nil {
-		return errors.New("failed to delete message: " + err.Error())
+		return false, errors.New("failed to delete message: " + err.Error())
 	}
 
-	if result.RowsAffected() == 0 {
-		return errors.New("message not found")
+	if hasReplies {
+		query = `UPDATE messages SET content = '', deleted_at = COALESCE(deleted_at, NOW()), updated_at = NOW() WHERE id = $1`
+		if _, err := tx.Exec(ctx, query, messageID); err != nil {
+			return false, errors.New("failed to delete message: " + err.Error())
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM message_reactions WHERE message_id = $1`, messageID); err != nil {
+			return false, errors.New("failed to delete message reactions: " + err.Error())
+		}
+	} else if _, err := tx.Exec(ctx, `DELETE FROM messages WHERE id = $1`, messageID); err != nil {
+		return false, errors.New("failed to delete message: " + err.Error())
 	}
 
-	return nil
+	if err := tx.Commit(ctx); err != nil {
+		return false, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return hasReplies, nil
 }
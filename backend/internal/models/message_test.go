@@ -0,0 +1,119 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+	"github.com/google/uuid"
+)
+
+func TestValidReactionEmoji(t *testing.T) {
+	tests := []struct {
+		emoji string
+		valid bool
+	}{
+		{"👍", true},
+		{"👍🏽", true},
+		{"👨‍👩‍👧‍👦", true},
+		{"🏳️‍🌈", true},
+		{":party:", true},
+		{"", false},
+		{"👍 👍", false},
+		{"👍\n", false},
+		{"\t", false},
+		{"\x00", false},
+		{strings.Repeat("👍", maxReactionLength/4), true},
+		{strings.Repeat("👍", maxReactionLength/4+1), false},
+	}
+	for _, test := range tests {
+		if valid := ValidReactionEmoji(test.emoji); valid != test.valid {
+			t.Errorf("ValidReactionEmoji(%q) = %v, want %v", test.emoji, valid, test.valid)
+		}
+	}
+}
+
+// createTestMessage saves a message of the user in the group's room, as a reply when parentID is set
+func createTestMessage(t *testing.T, user *User, group *Group, content string, parentID *string) *Message {
+	t.Helper()
+	message := &Message{RoomID: group.ID, UserID: user.ID, Username: user.Name, Content: content, ParentID: parentID}
+	if err := message.Save(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestGetThreadRoot(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+	_, otherGroup := createTestGroup(t)
+
+	root := createTestMessage(t, user, group, "Release on Friday?", nil)
+	reply := createTestMessage(t, user, group, "Yes", &root.ID)
+	elsewhere := createTestMessage(t, user, otherGroup, "Not this room", nil)
+
+	tests := []struct {
+		name     string
+		parentID string
+		rootID   string
+		found    bool
+	}{
+		{"first message of a thread", root.ID, root.ID, true},
+		{"reply goes in its parent's thread", reply.ID, root.ID, true},
+		{"message of another room", elsewhere.ID, "", false},
+		{"unknown message", uuid.New().String(), "", false},
+	}
+	for _, test := range tests {
+		rootID, found, err := GetThreadRoot(ctx, group.ID, test.parentID)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if rootID != test.rootID || found != test.found {
+			t.Errorf("%s: got %q, %v, want %q, %v", test.name, rootID, found, test.rootID, test.found)
+		}
+	}
+}
+
+func TestDeleteMessageKeepsReplies(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	root := createTestMessage(t, user, group, "Release on Friday?", nil)
+	reply := createTestMessage(t, user, group, "Yes", &root.ID)
+	if _, err := AddMessageReaction(ctx, root.ID, user.ID, "👍"); err != nil {
+		t.Fatal(err)
+	}
+
+	placeholder, err := DeleteMessage(ctx, root.ID)
+	if err != nil || !placeholder {
+		t.Fatalf("DeleteMessage of a thread's first message: placeholder %v, err %v", placeholder, err)
+	}
+	if err := root.GetByID(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if root.DeletedAt == nil || root.Content != "" || len(root.Reactions) != 0 || root.ReplyCount != 1 {
+		t.Errorf("placeholder: deleted at %v, content %q, %d reactions, %d replies", root.DeletedAt, root.Content, len(root.Reactions), root.ReplyCount)
+	}
+	if err := root.Edit(ctx, "Back again"); err == nil {
+		t.Error("a deleted message was edited")
+	}
+	replies, err := GetThreadMessages(ctx, root.ID, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 1 || replies[0].ID != reply.ID {
+		t.Errorf("thread after deleting its first message: %+v", replies)
+	}
+
+	// A reply has no replies of its own, so it goes for good
+	placeholder, err = DeleteMessage(ctx, reply.ID)
+	if err != nil || placeholder {
+		t.Fatalf("DeleteMessage of a reply: placeholder %v, err %v", placeholder, err)
+	}
+	if err := reply.GetByID(ctx); err == nil {
+		t.Error("deleted reply is still stored")
+	}
+}
@@ -0,0 +1,198 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+)
+
+var SearchResultTypes = []string{"task", "message", "file", "folder", "link"}
+
+// SearchResult is a task, message, file, folder or link matching a search. Highlight is an excerpt
+// of the matched text, HTML-escaped, with the matched words wrapped in <mark> tags.
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	GroupName string    `json:"group_name"`
+	Title     string    `json:"title"` // Task title, message author, file name, folder path or link title
+	Highlight string    `json:"highlight"`
+	Rank      float64   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SearchQuery is a search in the groups a user belongs to
+type SearchQuery struct {
+	UserID  string
+	Text    string   // Web search syntax: quoted phrases, OR, -word
+	Types   []string // Empty for all types
+	GroupID *string  // Only search this group
+	After   *SearchCursor
+	Limit   int
+}
+
+// SearchCursor is where a page of search results ended; results are ordered by rank, then type and ID
+type SearchCursor struct {
+	Rank float64 `json:"r"`
+	Type string  `json:"t"`
+	ID   string  `json:"i"`
+}
+
+// Encode turns the cursor into the opaque string handed to clients
+func (c *SearchCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeSearchCursor reads a cursor returned by Encode
+func DecodeSearchCursor(value string) (*SearchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor SearchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Type == "" || cursor.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// searchMatches lists what matches query $2 in the groups user $1 belongs to (only group $3 when
+// set), leaving out the trash. body is the text highlights are cut from.
+const searchMatches = `WITH search_query AS (SELECT websearch_to_tsquery('english', $2) AS query),
+	member_groups AS (
+		SELECT group_id FROM group_members WHERE user_id = $1 AND ($3::uuid IS NULL OR group_id = $3)
+	),
+	matches AS (
+		SELECT 'task' AS type, t.id::text AS id, t.group_id, t.title AS title, t.title || ' ' || t.description AS body,
+		       ts_rank(t.search_vector, search_query.query)::float8 AS rank, t.created_at
+		FROM tasks t, search_query
+		WHERE t.group_id IN (SELECT group_id FROM member_groups) AND t.deleted_at IS NULL AND t.search_vector @@ search_query.query
+		UNION ALL
+		SELECT 'message', m.id::text, m.room_id, m.username, m.content,
+		       ts_rank(m.search_vector, search_query.query)::float8, m.created_at
+		FROM messages m, search_query
+		WHERE m.room_id IN (SELECT group_id FROM member_groups) AND m.search_vector @@ search_query.query
+		UNION ALL
+		SELECT 'file', f.id::text, f.group_id, f.name, f.name,
+		       ts_rank(f.search_vector, search_query.query)::float8, f.created_at
+		FROM files f, search_query
+		WHERE f.group_id IN (SELECT group_id FROM member_groups) AND f.deleted_at IS NULL AND f.search_vector @@ search_query.query
+		UNION ALL
+		SELECT 'folder', fo.id::text, fo.group_id, fo.path, fo.path,
+		       ts_rank(fo.search_vector, search_query.query)::float8, fo.created_at
+		FROM folders fo, search_query
+		WHERE fo.group_id IN (SELECT group_id FROM member_groups) AND fo.deleted_at IS NULL AND fo.search_vector @@ search_query.query
+		UNION ALL
+		SELECT 'link', l.id::text, l.group_id, COALESCE(l.title, l.url), COALESCE(l.title, '') || ' ' || COALESCE(l.description, ''),
+		       ts_rank(l.search_vector, search_query.query)::float8, l.created_at
+		FROM links l, search_query
+		WHERE l.group_id IN (SELECT group_id FROM member_groups) AND l.deleted_at IS NULL AND l.search_vector @@ search_query.query
+	)`
+
+// ts_headline marks matches with these control characters, which are first removed from the text,
+// so they cannot be confused with anything the text contains; escapeHighlight turns them into tags
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// Search returns a page of results, best match first, and how many results there are of each type
+// regardless of q.Types and q.After. The cursor is nil on the last page.
+func Search(ctx context.Context, q SearchQuery) ([]SearchResult, map[string]int64, *SearchCursor, error) {
+	facets := map[string]int64{}
+	for _, resultType := range SearchResultTypes {
+		facets[resultType] = 0
+	}
+
+	facetRows, err := db.GetDB().Query(ctx, searchMatches+` SELECT type, COUNT(*) FROM matches GROUP BY type`,
+		q.UserID, q.Text, q.GroupID)
+	if err != nil {
+		return nil, nil, nil, errors.New("failed to count search results: " + err.Error())
+	}
+	for facetRows.Next() {
+		var resultType string
+		var count int64
+		if err := facetRows.Scan(&resultType, &count); err != nil {
+			facetRows.Close()
+			return nil, nil, nil, errors.New("failed to scan search facet: " + err.Error())
+		}
+		facets[resultType] = count
+	}
+	facetRows.Close()
+	if err := facetRows.Err(); err != nil {
+		return nil, nil, nil, errors.New("failed to count search results: " + err.Error())
+	}
+
+	var afterRank *float64
+	var afterType, afterID *string
+	if q.After != nil {
+		afterRank, afterType, afterID = &q.After.Rank, &q.After.Type, &q.After.ID
+	}
+
+	// Highlights are only worked out for the page, they are the expensive part
+	query := searchMatches + `,
+	page AS (
+		SELECT * FROM matches
+		WHERE (cardinality($4::text[]) = 0 OR type = ANY($4))
+		  AND ($5::float8 IS NULL OR rank < $5 OR (rank = $5 AND (type, id) > ($6, $7)))
+		ORDER BY rank DESC, type, id
+		LIMIT $8
+	)
+	SELECT page.type, page.id, page.group_id, groups.name, page.title,
+	       ts_headline('english', translate(page.body, '` + highlightStart + highlightStop + `', ''), search_query.query,
+	                   'StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=" … "'),
+	       page.rank, page.created_at
+	FROM page
+	JOIN groups ON groups.id = page.group_id
+	CROSS JOIN search_query
+	ORDER BY page.rank DESC, page.type, page.id`
+
+	// One more than asked for tells whether there is a next page
+	types := q.Types
+	if types == nil {
+		types = []string{}
+	}
+	rows, err := db.GetDB().Query(ctx, query, q.UserID, q.Text, q.GroupID, types, afterRank, afterType, afterID, q.Limit+1)
+	if err != nil {
+		return nil, nil, nil, errors.New("failed to search: " + err.Error())
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var result SearchResult
+		err := rows.Scan(&result.Type, &result.ID, &result.GroupID, &result.GroupName, &result.Title,
+			&result.Highlight, &result.Rank, &result.CreatedAt)
+		if err != nil {
+			return nil, nil, nil, errors.New("failed to scan search result: " + err.Error())
+		}
+		result.Highlight = escapeHighlight(result.Highlight)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, nil, errors.New("failed to search: " + err.Error())
+	}
+
+	var next *SearchCursor
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+		last := results[len(results)-1]
+		next = &SearchCursor{Rank: last.Rank, Type: last.Type, ID: last.ID}
+	}
+	return results, facets, next, nil
+}
+
+// highlightTags swaps the highlight markers for the tags clients render
+var highlightTags = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// escapeHighlight escapes a ts_headline excerpt for HTML, then wraps the highlighted words in <mark> tags
+func escapeHighlight(headline string) string {
+	return highlightTags.Replace(html.EscapeString(headline))
+}
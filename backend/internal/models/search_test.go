@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestEscapeHighlight(t *testing.T) {
+	mark := func(word string) string { return highlightStart + word + highlightStop }
+
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{"plain text", "nothing matched", "nothing matched"},
+		{"one match", "fix the " + mark("login") + " page", "fix the <mark>login</mark> page"},
+		{"several matches", mark("login") + " and " + mark("logout"), "<mark>login</mark> and <mark>logout</mark>"},
+		{"markup around a match", "<b>" + mark("login") + "</b>", "&lt;b&gt;<mark>login</mark>&lt;/b&gt;"},
+		{"markup in a match", mark("<script>"), "<mark>&lt;script&gt;</mark>"},
+		{"mark tags in the text", "<mark>not a match</mark> " + mark("login"), "&lt;mark&gt;not a match&lt;/mark&gt; <mark>login</mark>"},
+		{"unbalanced mark tag in the text", "</mark><img src=x onerror=alert(1)>", "&lt;/mark&gt;&lt;img src=x onerror=alert(1)&gt;"},
+		{"quotes and ampersands", `"Q&A" ` + mark("notes"), "&#34;Q&amp;A&#34; <mark>notes</mark>"},
+	}
+	for _, test := range tests {
+		if got := escapeHighlight(test.headline); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	cursors := []SearchCursor{
+		{Rank: 0.0759909, Type: "task", ID: "6f1c2b0e-8a8e-4a53-9b1e-2f4a3c5d6e7f"},
+		{Rank: 0, Type: "link", ID: "a"},
+		{Rank: 1e-20, Type: "message", ID: "id with spaces/and+symbols"},
+	}
+	for _, cursor := range cursors {
+		decoded, err := DecodeSearchCursor(cursor.Encode())
+		if err != nil {
+			t.Errorf("%+v: %v", cursor, err)
+			continue
+		}
+		if *decoded != cursor {
+			t.Errorf("decoded %+v, want %+v", *decoded, cursor)
+		}
+	}
+}
+
+func TestDecodeSearchCursorRejectsInvalid(t *testing.T) {
+	encode := func(data string) string { return base64.RawURLEncoding.EncodeToString([]byte(data)) }
+
+	for _, value := range []string{
+		"",
+		"not base64!",
+		encode("not json"),
+		encode(`{"r":1,"t":"task"}`),
+		encode(`{"r":1,"i":"id"}`),
+		encode(`{"r":"high","t":"task","i":"id"}`),
+	} {
+		if cursor, err := DecodeSearchCursor(value); err == nil {
+			t.Errorf("DecodeSearchCursor(%q) = %+v, want an error", value, cursor)
+		}
+	}
+}
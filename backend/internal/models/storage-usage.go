@@ -0,0 +1,273 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// StorageReservationTTL is how long reserved storage counts against a quota when the upload never
+// releases it (e.g. the server stopped mid-way)
+const StorageReservationTTL = 1 * time.Hour
+
+// StorageUsage is how much a group, or one member of it, stores. Every retained version counts,
+// but versions sharing an object (restores) count once.
+type StorageUsage struct {
+	UsedBytes     int64 `json:"used_bytes"`
+	ReservedBytes int64 `json:"reserved_bytes"` // Held by uploads that are not completed yet
+	FileCount     int   `json:"file_count"`
+}
+
+// FolderStorageUsage is the storage used by the files directly in a folder; FolderID is nil for
+// files outside folders
+type FolderStorageUsage struct {
+	FolderID   *string `json:"folder_id"`
+	FolderPath *string `json:"folder_path"`
+	UsedBytes  int64   `json:"used_bytes"`
+	FileCount  int     `json:"file_count"`
+}
+
+type MimeTypeStorageUsage struct {
+	MimeType  string `json:"mime_type"`
+	UsedBytes int64  `json:"used_bytes"`
+	FileCount int    `json:"file_count"`
+}
+
+// groupStoredObjects lists the distinct objects of group $1 with the file they belong to, the
+// mime type and uploader of their first version
+const groupStoredObjects = `WITH objects AS (
+		SELECT DISTINCT ON (v.s3_bucket, v.s3_key) v.file_id, f.folder_id, v.mime_type, v.size, v.created_by
+		FROM file_versions v
+		JOIN files f ON f.id = v.file_id
+		WHERE f.group_id = $1
+		ORDER BY v.s3_bucket, v.s3_key, v.version_number
+	)`
+
+// Storage is reserved by upload sessions that are not completed yet and by uploads sent through
+// the API that are not saved yet
+const (
+	groupStorageUsage = groupStoredObjects + `
+		SELECT COALESCE(SUM(size), 0)::BIGINT, COUNT(DISTINCT file_id),
+		       (SELECT COALESCE(SUM(size), 0)::BIGINT FROM upload_sessions
+		        WHERE group_id = $1 AND (status = 'completing' OR (status = 'pending' AND expires_at > NOW())))
+		       + (SELECT COALESCE(SUM(size), 0)::BIGINT FROM storage_reservations
+		          WHERE group_id = $1 AND expires_at > NOW())
+		FROM objects`
+
+	memberStorageUsage = groupStoredObjects + `
+		SELECT COALESCE(SUM(size), 0)::BIGINT, COUNT(DISTINCT file_id),
+		       (SELECT COALESCE(SUM(size), 0)::BIGINT FROM upload_sessions
+		        WHERE group_id = $1 AND created_by = $2
+		          AND (status = 'completing' OR (status = 'pending' AND expires_at > NOW())))
+		       + (SELECT COALESCE(SUM(size), 0)::BIGINT FROM storage_reservations
+		          WHERE group_id = $1 AND user_id = $2 AND expires_at > NOW())
+		FROM objects
+		WHERE created_by = $2`
+)
+
+// GetGroupStorageUsage returns the storage used by a group
+func GetGroupStorageUsage(ctx context.Context, groupID string) (*StorageUsage, error) {
+	var usage StorageUsage
+	err := db.GetDB().QueryRow(ctx, groupStorageUsage, groupID).Scan(&usage.UsedBytes, &usage.FileCount, &usage.ReservedBytes)
+	if err != nil {
+		return nil, errors.New("failed to get group storage usage: " + err.Error())
+	}
+	return &usage, nil
+}
+
+// GetMemberStorageUsage returns the storage used by the objects a member uploaded to a group
+func GetMemberStorageUsage(ctx context.Context, groupID string, userID string) (*StorageUsage, error) {
+	var usage StorageUsage
+	err := db.GetDB().QueryRow(ctx, memberStorageUsage, groupID, userID).Scan(&usage.UsedBytes, &usage.FileCount, &usage.ReservedBytes)
+	if err != nil {
+		return nil, errors.New("failed to get member storage usage: " + err.Error())
+	}
+	return &usage, nil
+}
+
+// StorageReservation holds storage of a group for one upload until it is released
+type StorageReservation struct {
+	ID      string
+	GroupID string
+	UserID  string
+	Size    int64
+}
+
+// Reserve holds the storage when check, given the usage of the group and of the member, returns
+// no reason to refuse it. Reservations of a group are serialized, so concurrent uploads cannot
+// both take the last free bytes. It returns check's reason when the storage is not reserved.
+func (r *StorageReservation) Reserve(ctx context.Context, check func(group *StorageUsage, member *StorageUsage) string) (string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return "", errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('storage:' || $1::text))`, r.GroupID)
+	if err != nil {
+		return "", errors.New("failed to lock group storage: " + err.Error())
+	}
+
+	var group, member StorageUsage
+	err = tx.QueryRow(ctx, groupStorageUsage, r.GroupID).Scan(&group.UsedBytes, &group.FileCount, &group.ReservedBytes)
+	if err != nil {
+		return "", errors.New("failed to get group storage usage: " + err.Error())
+	}
+	err = tx.QueryRow(ctx, memberStorageUsage, r.GroupID, r.UserID).Scan(&member.UsedBytes, &member.FileCount, &member.ReservedBytes)
+	if err != nil {
+		return "", errors.New("failed to get member storage usage: " + err.Error())
+	}
+	if reason := check(&group, &member); reason != "" {
+		return reason, nil
+	}
+
+	err = tx.QueryRow(ctx, `INSERT INTO storage_reservations (group_id, user_id, size, expires_at)
+	                        VALUES ($1, $2, $3, $4)
+	                        RETURNING id`,
+		r.GroupID, r.UserID, r.Size, time.Now().Add(StorageReservationTTL)).Scan(&r.ID)
+	if err != nil {
+		return "", errors.New("failed to reserve storage: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", errors.New("failed to commit transaction: " + err.Error())
+	}
+	return "", nil
+}
+
+// Release gives the reserved storage back, once the upload is saved (and counted as used) or failed
+func (r *StorageReservation) Release(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM storage_reservations WHERE id = $1`, r.ID)
+	if err != nil {
+		return errors.New("failed to release storage reservation: " + err.Error())
+	}
+	return nil
+}
+
+// DeleteExpiredStorageReservations removes reservations their upload never released
+func DeleteExpiredStorageReservations(ctx context.Context) (int64, error) {
+	result, err := db.GetDB().Exec(ctx, `DELETE FROM storage_reservations WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, errors.New("failed to delete expired storage reservations: " + err.Error())
+	}
+	return result.RowsAffected(), nil
+}
+
+// GetStorageUsageByFolder breaks a group's storage down by folder, largest first
+func GetStorageUsageByFolder(ctx context.Context, groupID string) ([]FolderStorageUsage, error) {
+	query := groupStoredObjects + `
+		SELECT o.folder_id, fo.path, SUM(o.size)::BIGINT, COUNT(DISTINCT o.file_id)
+		FROM objects o
+		LEFT JOIN folders fo ON fo.id = o.folder_id
+		GROUP BY o.folder_id, fo.path
+		ORDER BY 3 DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get storage usage by folder: " + err.Error())
+	}
+	defer rows.Close()
+
+	usage := []FolderStorageUsage{}
+	for rows.Next() {
+		var folder FolderStorageUsage
+		if err := rows.Scan(&folder.FolderID, &folder.FolderPath, &folder.UsedBytes, &folder.FileCount); err != nil {
+			return nil, errors.New("failed to scan folder storage usage: " + err.Error())
+		}
+		usage = append(usage, folder)
+	}
+	return usage, nil
+}
+
+// GetStorageUsageByMimeType breaks a group's storage down by mime type, largest first
+func GetStorageUsageByMimeType(ctx context.Context, groupID string) ([]MimeTypeStorageUsage, error) {
+	query := groupStoredObjects + `
+		SELECT mime_type, SUM(size)::BIGINT, COUNT(DISTINCT file_id)
+		FROM objects
+		GROUP BY mime_type
+		ORDER BY 2 DESC`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID)
+	if err != nil {
+		return nil, errors.New("failed to get storage usage by mime type: " + err.Error())
+	}
+	defer rows.Close()
+
+	usage := []MimeTypeStorageUsage{}
+	for rows.Next() {
+		var mimeType MimeTypeStorageUsage
+		if err := rows.Scan(&mimeType.MimeType, &mimeType.UsedBytes, &mimeType.FileCount); err != nil {
+			return nil, errors.New("failed to scan mime type storage usage: " + err.Error())
+		}
+		usage = append(usage, mimeType)
+	}
+	return usage, nil
+}
+
+// GetGroupStorageQuota returns the quota set on the group, nil when it uses the default
+func GetGroupStorageQuota(ctx context.Context, groupID string) (*int64, error) {
+	var quota *int64
+	err := db.GetDB().QueryRow(ctx, `SELECT storage_quota FROM groups WHERE id = $1`, groupID).Scan(&quota)
+	if err != nil {
+		return nil, errors.New("failed to get group storage quota: " + err.Error())
+	}
+	return quota, nil
+}
+
+// SetGroupStorageQuota sets the group's quota; nil goes back to the default
+func SetGroupStorageQuota(ctx context.Context, groupID string, quota *int64) error {
+	_, err := db.GetDB().Exec(ctx, `UPDATE groups SET storage_quota = $1, updated_at = NOW() WHERE id = $2`, quota, groupID)
+	if err != nil {
+		return errors.New("failed to update group storage quota: " + err.Error())
+	}
+	return nil
+}
+
+// GetMemberStorageQuota returns the quota set on a member of the group, nil when it uses the default
+func GetMemberStorageQuota(ctx context.Context, groupID string, userID string) (*int64, error) {
+	var quota *int64
+	err := db.GetDB().QueryRow(ctx, `SELECT storage_quota FROM group_members WHERE group_id = $1 AND user_id = $2`, groupID, userID).Scan(&quota)
+	if err != nil {
+		return nil, errors.New("failed to get member storage quota: " + err.Error())
+	}
+	return quota, nil
+}
+
+// SetMemberStorageQuota sets a member's quota in the group; nil goes back to the default.
+// It returns false when the user is not a member.
+func SetMemberStorageQuota(ctx context.Context, groupID string, userID string, quota *int64) (bool, error) {
+	result, err := db.GetDB().Exec(ctx, `UPDATE group_members SET storage_quota = $1 WHERE group_id = $2 AND user_id = $3`, quota, groupID, userID)
+	if err != nil {
+		return false, errors.New("failed to update member storage quota: " + err.Error())
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// MarkStorageWarningSent records that the owners were warned about the group's storage. It
+// returns false when they already were, so each crossing of the threshold warns once.
+func MarkStorageWarningSent(ctx context.Context, groupID string) (bool, error) {
+	var id string
+	err := db.GetDB().QueryRow(ctx, `UPDATE groups SET storage_warning_sent_at = NOW()
+	                                WHERE id = $1 AND storage_warning_sent_at IS NULL
+	                                RETURNING id`, groupID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to mark storage warning sent: " + err.Error())
+	}
+	return true, nil
+}
+
+// ClearStorageWarning allows warning the owners again once the group is back under the threshold
+func ClearStorageWarning(ctx context.Context, groupID string) error {
+	_, err := db.GetDB().Exec(ctx, `UPDATE groups SET storage_warning_sent_at = NULL
+	                                WHERE id = $1 AND storage_warning_sent_at IS NOT NULL`, groupID)
+	if err != nil {
+		return errors.New("failed to clear storage warning: " + err.Error())
+	}
+	return nil
+}
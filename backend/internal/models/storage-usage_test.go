@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestConcurrentReservationsRespectQuota(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+
+	user := &User{Name: "Ada", Email: fmt.Sprintf("ada-%d@example.com", time.Now().UnixNano()), Password: "password"}
+	if err := user.Save(); err != nil {
+		t.Fatal(err)
+	}
+	group := &Group{Name: "Storage", CreatedBy: user.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Room for three uploads of 100 bytes; ten try at once
+	const quota, size = 300, 100
+	fits := func(group *StorageUsage, member *StorageUsage) string {
+		if group.UsedBytes+group.ReservedBytes+size > quota {
+			return "over quota"
+		}
+		return ""
+	}
+
+	var reserved atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reservation := &StorageReservation{GroupID: group.ID, UserID: user.ID, Size: size}
+			reason, err := reservation.Reserve(ctx, fits)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if reason == "" {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := reserved.Load(); got != quota/size {
+		t.Fatalf("%d uploads reserved storage, want %d", got, quota/size)
+	}
+	usage, err := GetGroupStorageUsage(ctx, group.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.ReservedBytes != quota {
+		t.Errorf("reserved %d bytes, want %d", usage.ReservedBytes, quota)
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+)
+
+// TaskActivity is a change to a task shown in its activity feed (status, assignees, due date)
+type TaskActivity struct {
+	ID           string    `json:"id"`
+	TaskID       string    `json:"task_id"`
+	ActorID      *string   `json:"actor_id"` // nil when the system made the change
+	ActivityType string    `json:"activity_type"`
+	OldValue     *string   `json:"old_value,omitempty"`
+	NewValue     *string   `json:"new_value,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TaskFeedItem is an entry of a task's activity feed: either a comment or an activity
+type TaskFeedItem struct {
+	Type      string        `json:"type"` // "comment" or "activity"
+	CreatedAt time.Time     `json:"created_at"`
+	Comment   *TaskComment  `json:"comment,omitempty"`
+	Activity  *TaskActivity `json:"activity,omitempty"`
+}
+
+func (ta *TaskActivity) Save(ctx context.Context) error {
+	query := `INSERT INTO task_activities (task_id, actor_id, activity_type, old_value, new_value) 
+	          VALUES ($1, $2, $3, $4, $5) 
+	          RETURNING id, created_at`
+
+	err := db.GetDB().QueryRow(ctx, query,
+		ta.TaskID,
+		ta.ActorID,
+		ta.ActivityType,
+		ta.OldValue,
+		ta.NewValue,
+	).Scan(&ta.ID, &ta.CreatedAt)
+
+	if err != nil {
+		return errors.New("failed to create task activity: " + err.Error())
+	}
+
+	return nil
+}
+
+// GetTaskFeed retrieves a page of a task's comments and activities interleaved, newest first
+func GetTaskFeed(ctx context.Context, taskID string, limit int64, offset int64) ([]TaskFeedItem, int64, error) {
+	var total int64
+	countQuery := `SELECT (SELECT COUNT(*) FROM task_comments WHERE task_id = $1) + 
+	                      (SELECT COUNT(*) FROM task_activities WHERE task_id = $1)`
+	err := db.GetDB().QueryRow(ctx, countQuery, taskID).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("failed to get task feed count: " + err.Error())
+	}
+
+	// Page over the ids of both tables first, then load each entry in full
+	query := `SELECT kind, id, created_at FROM (
+	              SELECT 'comment' AS kind, id, created_at FROM task_comments WHERE task_id = $1
+	              UNION ALL
+	              SELECT 'activity' AS kind, id, created_at FROM task_activities WHERE task_id = $1
+	          ) feed 
+	          ORDER BY created_at DESC, id DESC 
+	          LIMIT $2 OFFSET $3`
+
+	rows, err := db.GetDB().Query(ctx, query, taskID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.New("failed to get task feed: " + err.Error())
+	}
+
+	items := []TaskFeedItem{}
+	var commentIDs, activityIDs []string
+	for rows.Next() {
+		var item TaskFeedItem
+		var id string
+		if err := rows.Scan(&item.Type, &id, &item.CreatedAt); err != nil {
+			rows.Close()
+			return nil, 0, errors.New("failed to scan task feed item: " + err.Error())
+		}
+		if item.Type == "comment" {
+			item.Comment = &TaskComment{ID: id}
+			commentIDs = append(commentIDs, id)
+		} else {
+			item.Activity = &TaskActivity{ID: id}
+			activityIDs = append(activityIDs, id)
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.New("failed to get task feed: " + err.Error())
+	}
+
+	comments := map[string]TaskComment{}
+	if len(commentIDs) > 0 {
+		rows, err := db.GetDB().Query(ctx, `SELECT `+commentColumns+` FROM task_comments WHERE task_comments.id = ANY($1)`, commentIDs)
+		if err != nil {
+			return nil, 0, errors.New("failed to get task comments: " + err.Error())
+		}
+		for rows.Next() {
+			var comment TaskComment
+			if err := scanComment(rows, &comment); err != nil {
+				rows.Close()
+				return nil, 0, errors.New("failed to scan task comment: " + err.Error())
+			}
+			comments[comment.ID] = comment
+		}
+		rows.Close()
+	}
+
+	activities := map[string]TaskActivity{}
+	if len(activityIDs) > 0 {
+		query := `SELECT id, task_id, actor_id, activity_type, old_value, new_value, created_at 
+		          FROM task_activities WHERE id = ANY($1)`
+		rows, err := db.GetDB().Query(ctx, query, activityIDs)
+		if err != nil {
+			return nil, 0, errors.New("failed to get task activities: " + err.Error())
+		}
+		for rows.Next() {
+			var activity TaskActivity
+			err := rows.Scan(&activity.ID, &activity.TaskID, &activity.ActorID, &activity.ActivityType, &activity.OldValue, &activity.NewValue, &activity.CreatedAt)
+			if err != nil {
+				rows.Close()
+				return nil, 0, errors.New("failed to scan task activity: " + err.Error())
+			}
+			activities[activity.ID] = activity
+		}
+		rows.Close()
+	}
+
+	for i := range items {
+		if items[i].Comment != nil {
+			comment := comments[items[i].Comment.ID]
+			items[i].Comment = &comment
+		} else {
+			activity := activities[items[i].Activity.ID]
+			items[i].Activity = &activity
+		}
+	}
+
+	return items, total, nil
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// TaskApprovalStatuses are the states of an approval request; only pending requests can be decided
+var TaskApprovalStatuses = []string{"pending", "approved", "rejected", "cancelled"}
+
+// TaskApproval is a request to have a pending task approved by a group owner or admin
+type TaskApproval struct {
+	ID              string     `json:"id"`
+	TaskID          string     `json:"task_id"`
+	RequestedBy     string     `json:"requested_by"`
+	Reason          *string    `json:"reason,omitempty"`
+	Status          string     `json:"status"`
+	DecidedBy       *string    `json:"decided_by,omitempty"`
+	DecisionComment *string    `json:"decision_comment,omitempty"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TaskApprovalWithTask is an entry of a group's approval queue
+type TaskApprovalWithTask struct {
+	TaskApproval
+	Task Task `json:"task"`
+}
+
+// approvalColumns is the select list shared by task approval queries
+const approvalColumns = `task_approvals.id, task_approvals.task_id, task_approvals.requested_by, task_approvals.reason,
+	task_approvals.status, task_approvals.decided_by, task_approvals.decision_comment, task_approvals.decided_at,
+	task_approvals.created_at, task_approvals.updated_at`
+
+// approvalDest returns the scan destinations matching approvalColumns
+func approvalDest(a *TaskApproval) []any {
+	return []any{
+		&a.ID,
+		&a.TaskID,
+		&a.RequestedBy,
+		&a.Reason,
+		&a.Status,
+		&a.DecidedBy,
+		&a.DecisionComment,
+		&a.DecidedAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	}
+}
+
+// Save opens the request. It returns false without an error when the task already has an open request.
+func (a *TaskApproval) Save(ctx context.Context) (bool, error) {
+	query := `INSERT INTO task_approvals (task_id, requested_by, reason) 
+	          VALUES ($1, $2, $3) 
+	          ON CONFLICT (task_id) WHERE status = 'pending' DO NOTHING 
+	          RETURNING ` + approvalColumns
+
+	err := db.GetDB().QueryRow(ctx, query, a.TaskID, a.RequestedBy, a.Reason).Scan(approvalDest(a)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, errors.New("failed to create task approval: " + err.Error())
+	}
+
+	return true, nil
+}
+
+func (a *TaskApproval) Get(ctx context.Context) error {
+	query := `SELECT ` + approvalColumns + ` FROM task_approvals WHERE task_approvals.id = $1`
+
+	err := db.GetDB().QueryRow(ctx, query, a.ID).Scan(approvalDest(a)...)
+	if err != nil {
+		return errors.New("failed to get task approval: " + err.Error())
+	}
+
+	return nil
+}
+
+// Decide approves or rejects the request and moves the still pending task to taskStatus in one transaction.
+// It returns false without an error when the request was already decided.
+func (a *TaskApproval) Decide(ctx context.Context, decidedBy string, status string, comment *string, taskStatus string) (bool, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return false, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	decided, err := closeApproval(ctx, tx, `task_approvals.id = $1`, a.ID, decidedBy, status, comment)
+	if err != nil || decided == nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE tasks SET status = $1 WHERE id = $2 AND status = 'pending'`, taskStatus, decided.TaskID)
+	if err != nil {
+		return false, errors.New("failed to update task status: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	*a = *decided
+	return true, nil
+}
+
+// CloseTaskApproval settles the task's open request, if any, after its status was changed directly.
+// It returns nil without an error when there was no open request.
+func CloseTaskApproval(ctx context.Context, taskID string, decidedBy string, status string) (*TaskApproval, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return nil, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	closed, err := closeApproval(ctx, tx, `task_approvals.task_id = $1`, taskID, decidedBy, status, nil)
+	if err != nil || closed == nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return closed, nil
+}
+
+// closeApproval decides the pending request matching condition ($1 = key) and returns it,
+// or nil when no pending request matched
+func closeApproval(ctx context.Context, tx pgx.Tx, condition string, key string, decidedBy string, status string, comment *string) (*TaskApproval, error) {
+	query := `UPDATE task_approvals 
+	          SET status = $2, decided_by = $3, decision_comment = $4, decided_at = NOW(), updated_at = NOW() 
+	          WHERE ` + condition + ` AND task_approvals.status = 'pending' 
+	          RETURNING ` + approvalColumns
+
+	var approval TaskApproval
+	err := tx.QueryRow(ctx, query, key, status, decidedBy, comment).Scan(approvalDest(&approval)...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.New("failed to decide task approval: " + err.Error())
+	}
+
+	return &approval, nil
+}
+
+// GetTaskApprovals returns the full approval history of a task, oldest first
+func GetTaskApprovals(ctx context.Context, taskID string) ([]TaskApproval, error) {
+	query := `SELECT ` + approvalColumns + ` FROM task_approvals 
+	          WHERE task_approvals.task_id = $1 
+	          ORDER BY task_approvals.created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, taskID)
+	if err != nil {
+		return nil, errors.New("failed to get task approvals: " + err.Error())
+	}
+	defer rows.Close()
+
+	approvals := []TaskApproval{}
+	for rows.Next() {
+		var approval TaskApproval
+		if err := rows.Scan(approvalDest(&approval)...); err != nil {
+			return nil, errors.New("failed to scan task approval: " + err.Error())
+		}
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, nil
+}
+
+// GetGroupApprovals returns the group's approval requests in the given status together with their
+// tasks; pending requests come oldest first so the queue is worked in order, decided ones newest first
+func GetGroupApprovals(ctx context.Context, groupID string, status string, limit int64, offset int64) ([]TaskApprovalWithTask, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM task_approvals 
+	               JOIN tasks ON tasks.id = task_approvals.task_id 
+	               WHERE tasks.group_id = $1 AND tasks.deleted_at IS NULL AND task_approvals.status = $2`
+	err := db.GetDB().QueryRow(ctx, countQuery, groupID, status).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("failed to count task approvals: " + err.Error())
+	}
+
+	order := "task_approvals.created_at ASC"
+	if status != "pending" {
+		order = "task_approvals.decided_at DESC"
+	}
+
+	query := `SELECT ` + taskColumns + `, ` + approvalColumns + ` 
+	          FROM task_approvals 
+	          JOIN tasks ON tasks.id = task_approvals.task_id 
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE tasks.group_id = $1 AND tasks.deleted_at IS NULL AND task_approvals.status = $2 
+	          ORDER BY ` + order + ` 
+	          LIMIT $3 OFFSET $4`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID, status, limit, offset)
+	if err != nil {
+		return nil, 0, errors.New("failed to get task approvals: " + err.Error())
+	}
+	defer rows.Close()
+
+	approvals := []TaskApprovalWithTask{}
+	for rows.Next() {
+		var approval TaskApprovalWithTask
+		if err := scanTask(rows, &approval.Task, approvalDest(&approval.TaskApproval)...); err != nil {
+			return nil, 0, errors.New("failed to scan task approval: " + err.Error())
+		}
+		approvals = append(approvals, approval)
+	}
+
+	return approvals, total, nil
+}
@@ -124,3 +124,47 @@ func GetTaskAssignments(ctx context.Context, taskID string) ([]TaskAssignment, e
 
 	return assignments, nil
 }
+
+// ReplaceTaskAssignments makes userIDs the only assignees of the task and returns the users that
+// were not assigned before. Nothing changes when one of them cannot be assigned.
+func ReplaceTaskAssignments(ctx context.Context, taskID string, userIDs []string, assignedBy string) ([]string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return nil, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM task_assignments WHERE task_id = $1 AND NOT (user_id = ANY($2::uuid[]))`, taskID, userIDs)
+	if err != nil {
+		return nil, errors.New("failed to clear task assignments: " + err.Error())
+	}
+
+	query := `INSERT INTO task_assignments (task_id, user_id, assigned_by) 
+	          SELECT $1, user_id, $3 FROM UNNEST($2::uuid[]) AS user_id 
+	          ON CONFLICT DO NOTHING 
+	          RETURNING user_id`
+	rows, err := tx.Query(ctx, query, taskID, userIDs, assignedBy)
+	if err != nil {
+		return nil, errors.New("failed to assign task: " + err.Error())
+	}
+
+	added := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, errors.New("failed to scan task assignment: " + err.Error())
+		}
+		added = append(added, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("failed to assign task: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return added, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// TaskChecklistItem is a lightweight to-do inside a task; unlike subtasks it has no assignees or due date
+type TaskChecklistItem struct {
+	ID          string     `json:"id"`
+	TaskID      string     `json:"task_id"`
+	Title       string     `json:"title"`
+	Position    int        `json:"position"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CompletedBy *string    `json:"completed_by,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// checklistColumns is the select list shared by checklist item queries
+const checklistColumns = `id, task_id, title, position, completed_at, completed_by, created_by, created_at, updated_at`
+
+// scanChecklistItem scans a row selected with checklistColumns into item
+func scanChecklistItem(row pgx.Row, item *TaskChecklistItem) error {
+	return row.Scan(
+		&item.ID,
+		&item.TaskID,
+		&item.Title,
+		&item.Position,
+		&item.CompletedAt,
+		&item.CompletedBy,
+		&item.CreatedBy,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+}
+
+// Save appends the item at the end of the task's checklist
+func (item *TaskChecklistItem) Save(ctx context.Context) error {
+	query := `INSERT INTO task_checklist_items (task_id, title, created_by, position) 
+	          VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position) + 1, 0) FROM task_checklist_items WHERE task_id = $1)) 
+	          RETURNING ` + checklistColumns
+
+	err := scanChecklistItem(db.GetDB().QueryRow(ctx, query, item.TaskID, item.Title, item.CreatedBy), item)
+	if err != nil {
+		return errors.New("failed to create checklist item: " + err.Error())
+	}
+
+	return nil
+}
+
+func (item *TaskChecklistItem) Get(ctx context.Context) error {
+	query := `SELECT ` + checklistColumns + ` FROM task_checklist_items WHERE id = $1`
+
+	err := scanChecklistItem(db.GetDB().QueryRow(ctx, query, item.ID), item)
+	if err != nil {
+		return errors.New("failed to get checklist item: " + err.Error())
+	}
+
+	return nil
+}
+
+// Update saves the title and position and checks or unchecks the item; completedBy is
+// recorded when the item becomes checked
+func (item *TaskChecklistItem) Update(ctx context.Context, completed bool, completedBy string) error {
+	query := `UPDATE task_checklist_items 
+	          SET title = $1, position = $2, 
+	              completed_at = CASE WHEN NOT $3 THEN NULL ELSE COALESCE(completed_at, NOW()) END, 
+	              completed_by = CASE WHEN NOT $3 THEN NULL WHEN completed_at IS NULL THEN $4::uuid ELSE completed_by END, 
+	              updated_at = NOW() 
+	          WHERE id = $5 
+	          RETURNING ` + checklistColumns
+
+	err := scanChecklistItem(db.GetDB().QueryRow(ctx, query, item.Title, item.Position, completed, completedBy, item.ID), item)
+	if err != nil {
+		return errors.New("failed to update checklist item: " + err.Error())
+	}
+
+	return nil
+}
+
+func (item *TaskChecklistItem) Delete(ctx context.Context) error {
+	query := `DELETE FROM task_checklist_items WHERE id = $1`
+	_, err := db.GetDB().Exec(ctx, query, item.ID)
+	if err != nil {
+		return errors.New("failed to delete checklist item: " + err.Error())
+	}
+	return nil
+}
+
+// GetTaskChecklist retrieves a task's checklist in display order
+func GetTaskChecklist(ctx context.Context, taskID string) ([]TaskChecklistItem, error) {
+	query := `SELECT ` + checklistColumns + ` FROM task_checklist_items 
+	          WHERE task_id = $1 
+	          ORDER BY position ASC, created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, taskID)
+	if err != nil {
+		return nil, errors.New("failed to get checklist: " + err.Error())
+	}
+	defer rows.Close()
+
+	items := []TaskChecklistItem{}
+	for rows.Next() {
+		var item TaskChecklistItem
+		if err := scanChecklistItem(rows, &item); err != nil {
+			return nil, errors.New("failed to scan checklist item: " + err.Error())
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type TaskComment struct {
+	ID        string     `json:"id"`
+	TaskID    string     `json:"task_id"`
+	UserID    string     `json:"user_id"`
+	Body      string     `json:"body"`
+	Mentions  []string   `json:"mentions"` // IDs of the mentioned group members
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TaskCommentEdit holds a comment body as it was before one of its edits
+type TaskCommentEdit struct {
+	ID           string    `json:"id"`
+	CommentID    string    `json:"comment_id"`
+	PreviousBody string    `json:"previous_body"`
+	EditedBy     string    `json:"edited_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// commentColumns is the select list shared by task comment queries
+const commentColumns = `task_comments.id, task_comments.task_id, task_comments.user_id, task_comments.body,
+	COALESCE((SELECT ARRAY_AGG(user_id ORDER BY created_at) FROM task_comment_mentions WHERE comment_id = task_comments.id), ARRAY[]::uuid[]),
+	task_comments.edited_at, task_comments.created_at, task_comments.updated_at`
+
+// scanComment scans a row selected with commentColumns into c
+func scanComment(row pgx.Row, c *TaskComment) error {
+	return row.Scan(
+		&c.ID,
+		&c.TaskID,
+		&c.UserID,
+		&c.Body,
+		&c.Mentions,
+		&c.EditedAt,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+}
+
+// Save inserts the comment together with its mentions
+func (c *TaskComment) Save(ctx context.Context) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO task_comments (task_id, user_id, body) 
+	          VALUES ($1, $2, $3) 
+	          RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(ctx, query, c.TaskID, c.UserID, c.Body).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to create task comment: " + err.Error())
+	}
+
+	if _, err := addCommentMentions(ctx, tx, c.ID, c.Mentions); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	if c.Mentions == nil {
+		c.Mentions = []string{}
+	}
+
+	return nil
+}
+
+func (c *TaskComment) Get(ctx context.Context) error {
+	query := `SELECT ` + commentColumns + ` FROM task_comments WHERE task_comments.id = $1`
+
+	err := scanComment(db.GetDB().QueryRow(ctx, query, c.ID), c)
+	if err != nil {
+		return errors.New("failed to get task comment: " + err.Error())
+	}
+
+	return nil
+}
+
+// Edit replaces the comment body and mentions, recording the previous body in the edit history.
+// It returns the users that were not mentioned before this edit.
+func (c *TaskComment) Edit(ctx context.Context, editedBy string, body string, mentions []string) ([]string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return nil, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the row so concurrent edits record each other's bodies in the history
+	var previousBody string
+	err = tx.QueryRow(ctx, `SELECT body FROM task_comments WHERE id = $1 FOR UPDATE`, c.ID).Scan(&previousBody)
+	if err != nil {
+		return nil, errors.New("failed to get task comment: " + err.Error())
+	}
+
+	if previousBody != body {
+		query := `INSERT INTO task_comment_edits (comment_id, previous_body, edited_by) VALUES ($1, $2, $3)`
+		if _, err := tx.Exec(ctx, query, c.ID, previousBody, editedBy); err != nil {
+			return nil, errors.New("failed to record task comment edit: " + err.Error())
+		}
+
+		query = `UPDATE task_comments SET body = $1, edited_at = NOW(), updated_at = NOW() WHERE id = $2`
+		if _, err := tx.Exec(ctx, query, body, c.ID); err != nil {
+			return nil, errors.New("failed to update task comment: " + err.Error())
+		}
+	}
+
+	// Mentions removed from the body are dropped, new ones are added
+	_, err = tx.Exec(ctx, `DELETE FROM task_comment_mentions WHERE comment_id = $1 AND NOT (user_id = ANY($2::uuid[]))`, c.ID, mentions)
+	if err != nil {
+		return nil, errors.New("failed to update task comment mentions: " + err.Error())
+	}
+
+	added, err := addCommentMentions(ctx, tx, c.ID, mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	if err := c.Get(ctx); err != nil {
+		return nil, err
+	}
+
+	return added, nil
+}
+
+func (c *TaskComment) Delete(ctx context.Context) error {
+	query := `DELETE FROM task_comments WHERE id = $1`
+	_, err := db.GetDB().Exec(ctx, query, c.ID)
+	if err != nil {
+		return errors.New("failed to delete task comment: " + err.Error())
+	}
+	return nil
+}
+
+// GetCommentEdits retrieves a comment's edit history, oldest first
+func (c *TaskComment) GetCommentEdits(ctx context.Context) ([]TaskCommentEdit, error) {
+	query := `SELECT id, comment_id, previous_body, edited_by, created_at 
+	          FROM task_comment_edits 
+	          WHERE comment_id = $1 
+	          ORDER BY created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, c.ID)
+	if err != nil {
+		return nil, errors.New("failed to get task comment edits: " + err.Error())
+	}
+	defer rows.Close()
+
+	edits := []TaskCommentEdit{}
+	for rows.Next() {
+		var edit TaskCommentEdit
+		if err := rows.Scan(&edit.ID, &edit.CommentID, &edit.PreviousBody, &edit.EditedBy, &edit.CreatedAt); err != nil {
+			return nil, errors.New("failed to scan task comment edit: " + err.Error())
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits, nil
+}
+
+// GetTaskComments retrieves a page of a task's comments, oldest first
+func GetTaskComments(ctx context.Context, taskID string, limit int64, offset int64) ([]TaskComment, int64, error) {
+	var total int64
+	err := db.GetDB().QueryRow(ctx, `SELECT COUNT(*) FROM task_comments WHERE task_id = $1`, taskID).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("failed to get task comment count: " + err.Error())
+	}
+
+	query := `SELECT ` + commentColumns + ` FROM task_comments 
+	          WHERE task_comments.task_id = $1 
+	          ORDER BY task_comments.created_at ASC 
+	          LIMIT $2 OFFSET $3`
+
+	rows, err := db.GetDB().Query(ctx, query, taskID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.New("failed to get task comments: " + err.Error())
+	}
+	defer rows.Close()
+
+	comments := []TaskComment{}
+	for rows.Next() {
+		var comment TaskComment
+		if err := scanComment(rows, &comment); err != nil {
+			return nil, 0, errors.New("failed to scan task comment: " + err.Error())
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, total, nil
+}
+
+// addCommentMentions inserts the mentions that don't exist yet and returns the newly mentioned users
+func addCommentMentions(ctx context.Context, tx pgx.Tx, commentID string, mentions []string) ([]string, error) {
+	added := []string{}
+	for _, userID := range mentions {
+		tag, err := tx.Exec(ctx, `INSERT INTO task_comment_mentions (comment_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, commentID, userID)
+		if err != nil {
+			return nil, errors.New("failed to save task comment mention: " + err.Error())
+		}
+		if tag.RowsAffected() > 0 {
+			added = append(added, userID)
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+)
+
+// TaskDependency means TaskID cannot start until DependsOnID is done
+type TaskDependency struct {
+	TaskID      string    `json:"task_id"`
+	DependsOnID string    `json:"depends_on_id"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IsOpenTaskStatus reports whether a task in this status still blocks its dependents.
+// Completed, cancelled and rejected tasks no longer do.
+func IsOpenTaskStatus(status string) bool {
+	return status == "pending" || status == "active"
+}
+
+// Save inserts the dependency. It returns false without saving when the edge would create a cycle,
+// i.e. DependsOnID already (transitively) depends on TaskID.
+func (td *TaskDependency) Save(ctx context.Context) (bool, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return false, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize edge inserts per group so two concurrent inserts cannot close a cycle together
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('task_dependencies:' || group_id::text)) FROM tasks WHERE id = $1`, td.TaskID)
+	if err != nil {
+		return false, errors.New("failed to lock task dependencies: " + err.Error())
+	}
+
+	query := `WITH RECURSIVE blockers AS (
+	              SELECT depends_on_id FROM task_dependencies WHERE task_id = $1
+	              UNION
+	              SELECT d.depends_on_id FROM task_dependencies d JOIN blockers b ON d.task_id = b.depends_on_id
+	          )
+	          SELECT EXISTS (SELECT 1 FROM blockers WHERE depends_on_id = $2)`
+
+	var cycle bool
+	err = tx.QueryRow(ctx, query, td.DependsOnID, td.TaskID).Scan(&cycle)
+	if err != nil {
+		return false, errors.New("failed to check task dependencies: " + err.Error())
+	}
+	if cycle {
+		return false, nil
+	}
+
+	query = `INSERT INTO task_dependencies (task_id, depends_on_id, created_by) 
+	         VALUES ($1, $2, $3) 
+	         ON CONFLICT (task_id, depends_on_id) DO UPDATE SET task_id = EXCLUDED.task_id 
+	         RETURNING created_by, created_at`
+
+	err = tx.QueryRow(ctx, query, td.TaskID, td.DependsOnID, td.CreatedBy).Scan(&td.CreatedBy, &td.CreatedAt)
+	if err != nil {
+		return false, errors.New("failed to create task dependency: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, errors.New("failed to commit transaction: " + err.Error())
+	}
+
+	return true, nil
+}
+
+// Delete removes the dependency; it returns false when it did not exist
+func (td *TaskDependency) Delete(ctx context.Context) (bool, error) {
+	query := `DELETE FROM task_dependencies WHERE task_id = $1 AND depends_on_id = $2`
+	tag, err := db.GetDB().Exec(ctx, query, td.TaskID, td.DependsOnID)
+	if err != nil {
+		return false, errors.New("failed to delete task dependency: " + err.Error())
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// GetTaskBlockers retrieves the tasks the given task depends on
+func GetTaskBlockers(ctx context.Context, taskID string) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM task_dependencies 
+	          JOIN tasks ON tasks.id = task_dependencies.depends_on_id 
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE task_dependencies.task_id = $1 AND tasks.deleted_at IS NULL 
+	          ORDER BY tasks.due_date ASC`
+	return queryTasks(ctx, query, taskID)
+}
+
+// GetTaskDependents retrieves the tasks that depend on the given task
+func GetTaskDependents(ctx context.Context, taskID string) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM task_dependencies 
+	          JOIN tasks ON tasks.id = task_dependencies.task_id 
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE task_dependencies.depends_on_id = $1 AND tasks.deleted_at IS NULL 
+	          ORDER BY tasks.due_date ASC`
+	return queryTasks(ctx, query, taskID)
+}
+
+// GetUnblockedDependents retrieves the open dependents of a task that have no open blocker left
+func GetUnblockedDependents(ctx context.Context, taskID string) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM task_dependencies 
+	          JOIN tasks ON tasks.id = task_dependencies.task_id 
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE task_dependencies.depends_on_id = $1 
+	            AND tasks.status IN ('pending', 'active') AND tasks.deleted_at IS NULL 
+	            AND NOT EXISTS (
+	                SELECT 1 FROM task_dependencies other 
+	                JOIN tasks blocker ON blocker.id = other.depends_on_id 
+	                WHERE other.task_id = tasks.id AND blocker.deleted_at IS NULL AND blocker.status IN ('pending', 'active')
+	            )`
+	return queryTasks(ctx, query, taskID)
+}
+
+// queryTasks runs a query selecting taskColumns and scans every row
+func queryTasks(ctx context.Context, query string, args ...any) ([]Task, error) {
+	rows, err := db.GetDB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.New("failed to get tasks: " + err.Error())
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		var task Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, errors.New("failed to scan task: " + err.Error())
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
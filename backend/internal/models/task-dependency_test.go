@@ -0,0 +1,83 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestTaskDependencyRejectsCycles(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+	a := createTestTask(t, user, group, "active", nil)
+	b := createTestTask(t, user, group, "active", nil)
+	c := createTestTask(t, user, group, "active", nil)
+
+	depend := func(task *Task, on *Task) bool {
+		t.Helper()
+		saved, err := (&TaskDependency{TaskID: task.ID, DependsOnID: on.ID, CreatedBy: user.ID}).Save(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return saved
+	}
+
+	// c waits for b, which waits for a
+	if !depend(b, a) || !depend(c, b) {
+		t.Fatal("a chain of dependencies was rejected")
+	}
+	if !depend(c, b) {
+		t.Error("saving an existing dependency again was rejected")
+	}
+	if depend(a, b) {
+		t.Error("direct cycle a -> b -> a was saved")
+	}
+	if depend(a, c) {
+		t.Error("transitive cycle a -> c -> b -> a was saved")
+	}
+	if !depend(c, a) {
+		t.Error("shortcut c -> a, which is no cycle, was rejected")
+	}
+
+	if _, err := (&TaskDependency{TaskID: a.ID, DependsOnID: a.ID, CreatedBy: user.ID}).Save(ctx); err == nil {
+		t.Error("a task was saved as depending on itself")
+	}
+}
+
+func TestGetUnblockedDependents(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+	first := createTestTask(t, user, group, "active", nil)
+	second := createTestTask(t, user, group, "active", nil)
+	dependent := createTestTask(t, user, group, "pending", nil)
+
+	for _, blocker := range []*Task{first, second} {
+		if _, err := (&TaskDependency{TaskID: dependent.ID, DependsOnID: blocker.ID, CreatedBy: user.ID}).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	unblocked := func(blocker *Task) int {
+		t.Helper()
+		dueDate := blocker.DueDate.Format(time.RFC3339)
+		if err := UpdateTask(ctx, blocker.ID, blocker.Title, blocker.Description, dueDate, "completed"); err != nil {
+			t.Fatal(err)
+		}
+		tasks, err := GetUnblockedDependents(ctx, blocker.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(tasks)
+	}
+
+	if n := unblocked(first); n != 0 {
+		t.Errorf("%d dependents unblocked while another blocker is open", n)
+	}
+	if n := unblocked(second); n != 1 {
+		t.Errorf("%d dependents unblocked after the last blocker completed, want 1", n)
+	}
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// TaskPriorities lists the task priorities from least to most urgent
+var TaskPriorities = []string{"none", "low", "medium", "high", "urgent"}
+
+// taskSortColumns maps the sort keys accepted by TaskFilter to ORDER BY expressions
+var taskSortColumns = map[string]string{
+	"created_at": "tasks.created_at",
+	"due_date":   "tasks.due_date",
+	"title":      "LOWER(tasks.title)",
+	"priority":   "(SELECT rank FROM task_priorities WHERE task_priorities.priority = tasks.priority)",
+}
+
+// TaskFilter narrows and orders task listings. Zero values do not filter.
+type TaskFilter struct {
+	Status     string
+	CreatedBy  string
+	Priorities []string            // Any of these priorities
+	LabelIDs   []string            // All of these labels
+	Fields     []CustomFieldFilter // All of these conditions
+	SortBy     string              // created_at (default), due_date, title or priority
+	SortAsc    bool                // Sort ascending instead of descending
+}
+
+// CustomFieldFilter compares a task's value for Field with Value
+type CustomFieldFilter struct {
+	Field CustomField
+	Op    string // eq, gte, lte, or contains (text fields)
+	Value CustomFieldValue
+}
+
+// CustomFieldFilterOps lists the supported custom field comparisons
+var CustomFieldFilterOps = []string{"eq", "gte", "lte", "contains"}
+
+// IsValidTaskSort reports whether tasks can be sorted by the given key
+func IsValidTaskSort(sortBy string) bool {
+	_, ok := taskSortColumns[sortBy]
+	return ok
+}
+
+// queryBuilder collects WHERE conditions together with their positional arguments
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// Arg adds a positional argument and returns its placeholder
+func (b *queryBuilder) Arg(value any) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where adds a condition; each "?" in it is bound to the next of args
+func (b *queryBuilder) Where(condition string, args ...any) {
+	for _, arg := range args {
+		condition = strings.Replace(condition, "?", b.Arg(arg), 1)
+	}
+	b.conditions = append(b.conditions, condition)
+}
+
+// WhereClause joins the conditions into a WHERE clause
+func (b *queryBuilder) WhereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// apply adds the filter's conditions to the builder
+func (f TaskFilter) apply(b *queryBuilder) {
+	if f.Status != "" {
+		b.Where("tasks.status = ?", f.Status)
+	}
+	if f.CreatedBy != "" {
+		b.Where("tasks.created_by = ?", f.CreatedBy)
+	}
+	if len(f.Priorities) > 0 {
+		b.Where("tasks.priority = ANY(?)", f.Priorities)
+	}
+	if len(f.LabelIDs) > 0 {
+		// Every label must be on the task; a repeated ID must not raise the count to match
+		labelIDs := slices.Compact(slices.Sorted(slices.Values(f.LabelIDs)))
+		b.Where(`(SELECT COUNT(*) FROM task_labels WHERE task_labels.task_id = tasks.id AND task_labels.label_id = ANY(?::uuid[])) = ?`,
+			labelIDs, len(labelIDs))
+	}
+	for _, field := range f.Fields {
+		column := "task_custom_field_values." + field.Field.valueColumn()
+
+		var comparison string
+		var value any
+		switch field.Op {
+		case "gte":
+			comparison = column + " >= ?"
+		case "lte":
+			comparison = column + " <= ?"
+		case "contains":
+			comparison = column + ` ILIKE '%' || ? || '%' ESCAPE '\'`
+		default:
+			comparison = column + " = ?"
+		}
+		switch {
+		case field.Value.Number != nil:
+			value = *field.Value.Number
+		case field.Value.Date != nil:
+			value = *field.Value.Date
+		case field.Value.Text != nil && field.Op == "contains":
+			value = escapeLike(*field.Value.Text)
+		case field.Value.Text != nil:
+			value = *field.Value.Text
+		}
+
+		b.Where(`EXISTS (SELECT 1 FROM task_custom_field_values 
+			WHERE task_custom_field_values.task_id = tasks.id AND task_custom_field_values.field_id = ? AND `+comparison+`)`,
+			field.Field.ID, value)
+	}
+}
+
+// likeEscaper makes LIKE wildcards in user input match literally (with ESCAPE '\')
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// orderBy returns the ORDER BY clause for the filter's sort
+func (f TaskFilter) orderBy() string {
+	column, ok := taskSortColumns[f.SortBy]
+	if !ok {
+		column = taskSortColumns["created_at"]
+	}
+	direction := "DESC"
+	if f.SortAsc {
+		direction = "ASC"
+	}
+	// Ties fall back to the newest task first so pages stay stable
+	return "ORDER BY " + column + " " + direction + " NULLS LAST, tasks.created_at DESC, tasks.id"
+}
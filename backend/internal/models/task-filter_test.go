@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"100%":       `100\%`,
+		"snake_case": `snake\_case`,
+		`C:\temp`:    `C:\\temp`,
+		`%_\`:        `\%\_\\`,
+	}
+	for input, want := range tests {
+		if got := escapeLike(input); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestTaskFilterContainsEscapesWildcards(t *testing.T) {
+	text := "50%_off"
+	filter := TaskFilter{Fields: []CustomFieldFilter{{
+		Field: CustomField{ID: "field-1", FieldType: "text"},
+		Op:    "contains",
+		Value: CustomFieldValue{Text: &text},
+	}}}
+
+	var b queryBuilder
+	filter.apply(&b)
+
+	if !strings.Contains(b.WhereClause(), `ILIKE '%' || $2 || '%' ESCAPE '\'`) {
+		t.Errorf("contains condition does not escape: %s", b.WhereClause())
+	}
+	if len(b.args) != 2 || b.args[1] != `50\%\_off` {
+		t.Errorf("contains argument = %v, want escaped text", b.args)
+	}
+}
+
+func TestTaskFilterLabelsIgnoreRepeats(t *testing.T) {
+	filter := TaskFilter{LabelIDs: []string{"label-b", "label-a", "label-b"}}
+
+	var b queryBuilder
+	filter.apply(&b)
+
+	if len(b.args) != 2 {
+		t.Fatalf("args = %v, want the label IDs and their count", b.args)
+	}
+	if labels, _ := b.args[0].([]string); len(labels) != 2 {
+		t.Errorf("label IDs = %v, want each once", b.args[0])
+	}
+	if b.args[1] != 2 {
+		t.Errorf("required label count = %v, want 2", b.args[1])
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
 type TaskNotification struct {
@@ -17,13 +18,38 @@ type TaskNotification struct {
 	SentAt           *time.Time `json:"sent_at,omitempty"`
 	Status           string     `json:"status"`
 	RetryCount       int        `json:"retry_count"`
+	ReminderOffset   *int       `json:"reminder_offset,omitempty"` // Minutes before due date, set for rule-based reminders
+	ReadAt           *time.Time `json:"read_at,omitempty"`
+	CommentID        *string    `json:"comment_id,omitempty"` // Set for mention notifications
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
+// notificationColumns is the select list shared by task notification queries
+const notificationColumns = `id, task_id, user_id, notification_type, scheduled_at, sent_at, status, retry_count, reminder_offset, read_at, comment_id, created_at, updated_at`
+
+// scanNotification scans a row selected with notificationColumns into tn
+func scanNotification(row pgx.Row, tn *TaskNotification) error {
+	return row.Scan(
+		&tn.ID,
+		&tn.TaskID,
+		&tn.UserID,
+		&tn.NotificationType,
+		&tn.ScheduledAt,
+		&tn.SentAt,
+		&tn.Status,
+		&tn.RetryCount,
+		&tn.ReminderOffset,
+		&tn.ReadAt,
+		&tn.CommentID,
+		&tn.CreatedAt,
+		&tn.UpdatedAt,
+	)
+}
+
 func (tn *TaskNotification) Save(ctx context.Context) error {
-	query := `INSERT INTO task_notifications (task_id, user_id, notification_type, scheduled_at, status) 
-	          VALUES ($1, $2, $3, $4, $5) 
+	query := `INSERT INTO task_notifications (task_id, user_id, notification_type, scheduled_at, status, reminder_offset, comment_id) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7) 
 	          RETURNING id, sent_at, retry_count, created_at, updated_at`
 
 	err := db.GetDB().QueryRow(ctx, query,
@@ -32,6 +58,8 @@ func (tn *TaskNotification) Save(ctx context.Context) error {
 		tn.NotificationType,
 		tn.ScheduledAt,
 		tn.Status,
+		tn.ReminderOffset,
+		tn.CommentID,
 	).Scan(&tn.ID, &tn.SentAt, &tn.RetryCount, &tn.CreatedAt, &tn.UpdatedAt)
 
 	if err != nil {
@@ -42,22 +70,11 @@ func (tn *TaskNotification) Save(ctx context.Context) error {
 }
 
 func (tn *TaskNotification) Get(ctx context.Context) error {
-	query := `SELECT id, task_id, user_id, notification_type, scheduled_at, sent_at, status, retry_count, created_at, updated_at 
+	query := `SELECT ` + notificationColumns + ` 
 	          FROM task_notifications 
 	          WHERE id = $1`
 
-	err := db.GetDB().QueryRow(ctx, query, tn.ID).Scan(
-		&tn.ID,
-		&tn.TaskID,
-		&tn.UserID,
-		&tn.NotificationType,
-		&tn.ScheduledAt,
-		&tn.SentAt,
-		&tn.Status,
-		&tn.RetryCount,
-		&tn.CreatedAt,
-		&tn.UpdatedAt,
-	)
+	err := scanNotification(db.GetDB().QueryRow(ctx, query, tn.ID), tn)
 
 	if err != nil {
 		return errors.New("failed to get task notification: " + err.Error())
@@ -67,7 +84,7 @@ func (tn *TaskNotification) Get(ctx context.Context) error {
 }
 
 func GetNotificationsByTaskID(ctx context.Context, taskID string) ([]TaskNotification, error) {
-	query := `SELECT id, task_id, user_id, notification_type, scheduled_at, sent_at, status, retry_count, created_at, updated_at 
+	query := `SELECT ` + notificationColumns + ` 
 	          FROM task_notifications 
 	          WHERE task_id = $1 
 	          ORDER BY scheduled_at ASC`
@@ -81,18 +98,7 @@ func GetNotificationsByTaskID(ctx context.Context, taskID string) ([]TaskNotific
 	var notifications []TaskNotification
 	for rows.Next() {
 		var notification TaskNotification
-		err := rows.Scan(
-			&notification.ID,
-			&notification.TaskID,
-			&notification.UserID,
-			&notification.NotificationType,
-			&notification.ScheduledAt,
-			&notification.SentAt,
-			&notification.Status,
-			&notification.RetryCount,
-			&notification.CreatedAt,
-			&notification.UpdatedAt,
-		)
+		err := scanNotification(rows, &notification)
 		if err != nil {
 			return nil, errors.New("failed to scan task notification: " + err.Error())
 		}
@@ -104,7 +110,7 @@ func GetNotificationsByTaskID(ctx context.Context, taskID string) ([]TaskNotific
 
 // GetNotificationsByUserID retrieves all notifications for a user
 func GetNotificationsByUserID(ctx context.Context, userID string) ([]TaskNotification, error) {
-	query := `SELECT id, task_id, user_id, notification_type, scheduled_at, sent_at, status, retry_count, created_at, updated_at 
+	query := `SELECT ` + notificationColumns + ` 
 	          FROM task_notifications 
 	          WHERE user_id = $1 
 	          ORDER BY created_at DESC
@@ -119,18 +125,7 @@ func GetNotificationsByUserID(ctx context.Context, userID string) ([]TaskNotific
 	var notifications []TaskNotification
 	for rows.Next() {
 		var notification TaskNotification
-		err := rows.Scan(
-			&notification.ID,
-			&notification.TaskID,
-			&notification.UserID,
-			&notification.NotificationType,
-			&notification.ScheduledAt,
-			&notification.SentAt,
-			&notification.Status,
-			&notification.RetryCount,
-			&notification.CreatedAt,
-			&notification.UpdatedAt,
-		)
+		err := scanNotification(rows, &notification)
 		if err != nil {
 			return nil, errors.New("failed to scan notification: " + err.Error())
 		}
@@ -174,14 +169,16 @@ func (tn *TaskNotification) Delete(ctx context.Context) error {
 
 func GetPendingNotifications(ctx context.Context, beforeTime time.Time) ([]TaskNotification, error) {
 	query := `
-        SELECT id, task_id, user_id, notification_type, scheduled_at, sent_at, status, retry_count, created_at, updated_at
+        SELECT ` + notificationColumns + `
         FROM task_notifications
-        WHERE status = 'pending'
+        WHERE (status = 'pending' OR (status = 'sending' AND updated_at < $2))
           AND scheduled_at <= $1
+          -- Notifications of trashed tasks wait until the task is purged or restored, which cancels the overdue ones
+          AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.id = task_notifications.task_id AND tasks.deleted_at IS NOT NULL)
         ORDER BY scheduled_at ASC
         LIMIT 100`
 
-	rows, err := db.GetDB().Query(ctx, query, beforeTime)
+	rows, err := db.GetDB().Query(ctx, query, beforeTime, time.Now().Add(-staleClaimAfter))
 	if err != nil {
 		return nil, errors.New("failed to get pending notifications: " + err.Error())
 	}
@@ -190,18 +187,7 @@ func GetPendingNotifications(ctx context.Context, beforeTime time.Time) ([]TaskN
 	var notifications []TaskNotification
 	for rows.Next() {
 		var notification TaskNotification
-		err := rows.Scan(
-			&notification.ID,
-			&notification.TaskID,
-			&notification.UserID,
-			&notification.NotificationType,
-			&notification.ScheduledAt,
-			&notification.SentAt,
-			&notification.Status,
-			&notification.RetryCount,
-			&notification.CreatedAt,
-			&notification.UpdatedAt,
-		)
+		err := scanNotification(rows, &notification)
 		if err != nil {
 			return nil, errors.New("failed to scan notification: " + err.Error())
 		}
@@ -228,6 +214,91 @@ func (tn *TaskNotification) MarkAsSent(ctx context.Context) error {
 	return nil
 }
 
+// staleClaimAfter is how long a notification may stay in 'sending' before it is assumed that its
+// worker died and another one may claim it
+const staleClaimAfter = 10 * time.Minute
+
+// Claim moves a pending notification to 'sending' before it is delivered, so the scheduler and
+// immediate delivery from handlers never send the same notification twice. It reports whether
+// this caller won the claim; the outcome is recorded afterwards with MarkAsSent, ScheduleRetry or
+// MarkAsFailed. Claims left behind by a crashed worker are taken over once they are stale.
+func (tn *TaskNotification) Claim(ctx context.Context) (bool, error) {
+	query := `
+        UPDATE task_notifications
+        SET status = 'sending', updated_at = NOW()
+        WHERE id = $1 AND (status = 'pending' OR (status = 'sending' AND updated_at < $2))
+        RETURNING updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, tn.ID, time.Now().Add(-staleClaimAfter)).Scan(&tn.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to claim notification: " + err.Error())
+	}
+	tn.Status = "sending"
+	return true, nil
+}
+
+// ScheduleRetry puts a notification whose delivery failed back in the queue, to be tried again at retryAt
+func (tn *TaskNotification) ScheduleRetry(ctx context.Context, retryAt time.Time) error {
+	query := `
+        UPDATE task_notifications
+        SET status = 'pending', sent_at = NULL, scheduled_at = $1, retry_count = retry_count + 1, updated_at = NOW()
+        WHERE id = $2
+        RETURNING scheduled_at, retry_count, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, retryAt, tn.ID).Scan(&tn.ScheduledAt, &tn.RetryCount, &tn.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to schedule notification retry: " + err.Error())
+	}
+	tn.Status = "pending"
+	tn.SentAt = nil
+	return nil
+}
+
+// MarkAsRead sets or clears the read timestamp of the notification
+func (tn *TaskNotification) MarkAsRead(ctx context.Context, read bool) error {
+	query := `
+        UPDATE task_notifications
+        SET read_at = CASE WHEN $1 THEN COALESCE(read_at, NOW()) END, updated_at = NOW()
+        WHERE id = $2
+        RETURNING read_at, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, read, tn.ID).Scan(&tn.ReadAt, &tn.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to mark notification as read: " + err.Error())
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks every delivered notification of the user as read
+func MarkAllNotificationsRead(ctx context.Context, userID string) (int64, error) {
+	query := `UPDATE task_notifications 
+	          SET read_at = NOW(), updated_at = NOW() 
+	          WHERE user_id = $1 AND status = 'sent' AND read_at IS NULL`
+
+	result, err := db.GetDB().Exec(ctx, query, userID)
+	if err != nil {
+		return 0, errors.New("failed to mark notifications as read: " + err.Error())
+	}
+	return result.RowsAffected(), nil
+}
+
+// CountUnreadNotifications counts the delivered notifications the user has not read yet
+func CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COUNT(*) 
+	          FROM task_notifications 
+	          WHERE user_id = $1 AND status = 'sent' AND read_at IS NULL`
+
+	var count int
+	err := db.GetDB().QueryRow(ctx, query, userID).Scan(&count)
+	if err != nil {
+		return 0, errors.New("failed to count unread notifications: " + err.Error())
+	}
+	return count, nil
+}
+
 // NotificationExists checks if a notification of a specific type already exists for a task/user combination
 func NotificationExists(ctx context.Context, taskID, userID, notificationType string) (bool, error) {
 	query := `SELECT COUNT(*) 
@@ -248,7 +319,7 @@ func NotificationExists(ctx context.Context, taskID, userID, notificationType st
 func (tn *TaskNotification) MarkAsFailed(ctx context.Context) error {
 	query := `
         UPDATE task_notifications
-        SET status = 'failed', retry_count = retry_count + 1, updated_at = NOW()
+        SET status = 'failed', sent_at = NULL, retry_count = retry_count + 1, updated_at = NOW()
         WHERE id = $1
         RETURNING retry_count, updated_at`
 
@@ -257,5 +328,104 @@ func (tn *TaskNotification) MarkAsFailed(ctx context.Context) error {
 		return errors.New("failed to mark notification as failed: " + err.Error())
 	}
 	tn.Status = "failed"
+	tn.SentAt = nil
 	return nil
 }
+
+// SaveReminder creates a rule-based reminder unless one already exists for the same task, user,
+// rule and schedule. It reports whether a new notification was created.
+func (tn *TaskNotification) SaveReminder(ctx context.Context) (bool, error) {
+	query := `INSERT INTO task_notifications (task_id, user_id, notification_type, scheduled_at, status, reminder_offset) 
+	          VALUES ($1, $2, $3, $4, $5, $6) 
+	          ON CONFLICT (task_id, user_id, reminder_offset, scheduled_at) WHERE reminder_offset IS NOT NULL DO NOTHING 
+	          RETURNING id, sent_at, retry_count, created_at, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query,
+		tn.TaskID,
+		tn.UserID,
+		tn.NotificationType,
+		tn.ScheduledAt,
+		tn.Status,
+		tn.ReminderOffset,
+	).Scan(&tn.ID, &tn.SentAt, &tn.RetryCount, &tn.CreatedAt, &tn.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to create reminder notification: " + err.Error())
+	}
+
+	return true, nil
+}
+
+// ReminderCandidate is a reminder that a task's rules call for: one per assignee and offset
+type ReminderCandidate struct {
+	TaskID      string
+	UserID      string
+	DueDate     time.Time
+	Offset      int // Minutes before the due date
+	ScheduledAt time.Time
+}
+
+// GetReminderCandidates expands the reminder offsets of open tasks (the task override, else each
+// assignee's preferences, else the 24 hour default) and returns those scheduled between from and to
+func GetReminderCandidates(ctx context.Context, from time.Time, to time.Time) ([]ReminderCandidate, error) {
+	query := `
+		SELECT t.id, ta.user_id, t.due_date, o.offset_minutes, t.due_date - make_interval(mins => o.offset_minutes) AS scheduled_at
+		FROM tasks t
+		JOIN task_assignments ta ON ta.task_id = t.id
+		LEFT JOIN user_preferences up ON up.user_id = ta.user_id
+		CROSS JOIN LATERAL unnest(COALESCE(t.reminder_offsets, up.reminder_offsets, ARRAY[1440])) AS o(offset_minutes)
+		WHERE t.status NOT IN ('completed', 'cancelled')
+		  AND t.deleted_at IS NULL
+		  AND t.due_date - make_interval(mins => o.offset_minutes) >= $1
+		  AND t.due_date - make_interval(mins => o.offset_minutes) <= $2
+		ORDER BY scheduled_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, from, to)
+	if err != nil {
+		return nil, errors.New("failed to get reminder candidates: " + err.Error())
+	}
+	defer rows.Close()
+
+	var candidates []ReminderCandidate
+	for rows.Next() {
+		var c ReminderCandidate
+		if err := rows.Scan(&c.TaskID, &c.UserID, &c.DueDate, &c.Offset, &c.ScheduledAt); err != nil {
+			return nil, errors.New("failed to scan reminder candidate: " + err.Error())
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, nil
+}
+
+// CancelStaleReminders cancels pending rule-based reminders that no longer match their task: the due
+// date moved, the task was closed, the assignee was removed, or the rule was dropped from the offsets
+func CancelStaleReminders(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE task_notifications tn
+		SET status = 'cancelled', updated_at = NOW()
+		FROM tasks t
+		WHERE tn.task_id = t.id
+		  AND tn.status = 'pending'
+		  AND tn.reminder_offset IS NOT NULL
+		  AND (
+		      t.status IN ('completed', 'cancelled')
+		      OR tn.scheduled_at <> t.due_date - make_interval(mins => tn.reminder_offset)
+		      OR NOT EXISTS (SELECT 1 FROM task_assignments ta WHERE ta.task_id = t.id AND ta.user_id = tn.user_id)
+		      OR NOT (tn.reminder_offset = ANY(COALESCE(
+		          t.reminder_offsets,
+		          (SELECT up.reminder_offsets FROM user_preferences up WHERE up.user_id = tn.user_id),
+		          ARRAY[1440]
+		      )))
+		  )`
+
+	result, err := db.GetDB().Exec(ctx, query)
+	if err != nil {
+		return 0, errors.New("failed to cancel stale reminders: " + err.Error())
+	}
+
+	return result.RowsAffected(), nil
+}
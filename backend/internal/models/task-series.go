@@ -0,0 +1,337 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbtx is what the pool and a transaction have in common, so a statement can run on either
+type dbtx interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// TaskSeries holds the recurrence rule shared by all occurrences of a recurring task.
+// Each occurrence is a regular row in tasks pointing back at its series.
+type TaskSeries struct {
+	ID                 string     `json:"id"`
+	RecurrenceRule     string     `json:"recurrence_rule"`
+	RecurrenceTimezone string     `json:"recurrence_timezone"`
+	DTStart            time.Time  `json:"dtstart"`
+	LastOccurrenceAt   time.Time  `json:"last_occurrence_at"`
+	EndedAt            *time.Time `json:"ended_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+func (s *TaskSeries) Save(ctx context.Context) error {
+	return s.insert(ctx, db.GetDB())
+}
+
+func (s *TaskSeries) insert(ctx context.Context, q dbtx) error {
+	query := `INSERT INTO task_series (recurrence_rule, recurrence_timezone, dtstart, last_occurrence_at) 
+	          VALUES ($1, $2, $3, $4) 
+	          RETURNING id, created_at, updated_at`
+
+	err := q.QueryRow(ctx, query,
+		s.RecurrenceRule,
+		s.RecurrenceTimezone,
+		s.DTStart,
+		s.LastOccurrenceAt,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+
+	if err != nil {
+		return errors.New("failed to create task series: " + err.Error())
+	}
+
+	return nil
+}
+
+func (s *TaskSeries) Get(ctx context.Context) error {
+	query := `SELECT id, recurrence_rule, recurrence_timezone, dtstart, last_occurrence_at, ended_at, created_at, updated_at 
+	          FROM task_series 
+	          WHERE id = $1`
+
+	err := db.GetDB().QueryRow(ctx, query, s.ID).Scan(
+		&s.ID,
+		&s.RecurrenceRule,
+		&s.RecurrenceTimezone,
+		&s.DTStart,
+		&s.LastOccurrenceAt,
+		&s.EndedAt,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+
+	if err != nil {
+		return errors.New("failed to get task series: " + err.Error())
+	}
+
+	return nil
+}
+
+// UpdateRule replaces the recurrence rule and timezone of the series
+func (s *TaskSeries) UpdateRule(ctx context.Context) error {
+	query := `UPDATE task_series 
+	          SET recurrence_rule = $1, recurrence_timezone = $2, updated_at = NOW() 
+	          WHERE id = $3 
+	          RETURNING updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, s.RecurrenceRule, s.RecurrenceTimezone, s.ID).Scan(&s.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to update task series: " + err.Error())
+	}
+
+	return nil
+}
+
+// AdvanceCursor records that the occurrence at occurrenceAt has been materialized
+func (s *TaskSeries) AdvanceCursor(ctx context.Context, occurrenceAt time.Time) error {
+	query := `UPDATE task_series 
+	          SET last_occurrence_at = GREATEST(last_occurrence_at, $1), updated_at = NOW() 
+	          WHERE id = $2 
+	          RETURNING last_occurrence_at`
+
+	err := db.GetDB().QueryRow(ctx, query, occurrenceAt, s.ID).Scan(&s.LastOccurrenceAt)
+	if err != nil {
+		return errors.New("failed to advance task series: " + err.Error())
+	}
+
+	return nil
+}
+
+// End stops the series from generating further occurrences
+func (s *TaskSeries) End(ctx context.Context) error {
+	return s.end(ctx, db.GetDB())
+}
+
+func (s *TaskSeries) end(ctx context.Context, q dbtx) error {
+	query := `UPDATE task_series 
+	          SET ended_at = COALESCE(ended_at, NOW()), updated_at = NOW() 
+	          WHERE id = $1 
+	          RETURNING ended_at`
+
+	err := q.QueryRow(ctx, query, s.ID).Scan(&s.EndedAt)
+	if err != nil {
+		return errors.New("failed to end task series: " + err.Error())
+	}
+
+	return nil
+}
+
+func (s *TaskSeries) Delete(ctx context.Context) error {
+	query := `DELETE FROM task_series WHERE id = $1`
+
+	_, err := db.GetDB().Exec(ctx, query, s.ID)
+	if err != nil {
+		return errors.New("failed to delete task series: " + err.Error())
+	}
+
+	return nil
+}
+
+// CreateWithSeries saves the series and creates the task as its first occurrence, at the series'
+// start. Either both are written or neither.
+func (t *Task) CreateWithSeries(ctx context.Context, s *TaskSeries) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.insert(ctx, tx); err != nil {
+		return err
+	}
+	t.SeriesID = &s.ID
+	t.OccurrenceAt = &s.DTStart
+	t.RecurrenceRule = &s.RecurrenceRule
+	t.RecurrenceTimezone = &s.RecurrenceTimezone
+	if err := t.insert(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// StartTaskSeries saves the series and makes an existing task its first occurrence
+func StartTaskSeries(ctx context.Context, taskID string, s *TaskSeries) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.insert(ctx, tx); err != nil {
+		return err
+	}
+	if err := setTaskSeries(ctx, tx, taskID, &s.ID, &s.DTStart); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// SplitTaskSeries ends the task's series at the task: the task starts the next series, or becomes
+// a one-off task when next is nil, and the open occurrences after it that the old rule generated
+// are deleted
+func SplitTaskSeries(ctx context.Context, task *Task, next *TaskSeries) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	previous := &TaskSeries{ID: *task.SeriesID}
+	if err := previous.end(ctx, tx); err != nil {
+		return err
+	}
+
+	if next != nil {
+		if err := next.insert(ctx, tx); err != nil {
+			return err
+		}
+		err = setTaskSeries(ctx, tx, task.ID, &next.ID, &next.DTStart)
+	} else {
+		err = setTaskSeries(ctx, tx, task.ID, nil, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := deleteSeriesTasks(ctx, tx, previous.ID, task.OccurrenceAt, true); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// TrashSeries moves the occurrences of a series to the trash, optionally only those at or after
+// fromOccurrence, and stops the series from generating more. The series is only ended so restored
+// occurrences keep it; the purge deletes it once empty.
+func TrashSeries(ctx context.Context, seriesID string, fromOccurrence *time.Time, userID string) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	if err := trashSeriesTasks(ctx, tx, seriesID, fromOccurrence, userID); err != nil {
+		return err
+	}
+	series := &TaskSeries{ID: seriesID}
+	if err := series.end(ctx, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to commit transaction: " + err.Error())
+	}
+	return nil
+}
+
+// GetLatestSeriesTask returns the most recent occurrence of a series
+func GetLatestSeriesTask(ctx context.Context, seriesID string) (*Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE tasks.series_id = $1 
+	          ORDER BY tasks.occurrence_at DESC 
+	          LIMIT 1`
+
+	var task Task
+	err := scanTask(db.GetDB().QueryRow(ctx, query, seriesID), &task)
+	if err != nil {
+		return nil, errors.New("failed to get latest series task: " + err.Error())
+	}
+
+	return &task, nil
+}
+
+// GetSeriesDueForGeneration returns active series whose latest occurrence has been completed or
+// cancelled, or is still open but past its due date, so the next occurrence should be materialized
+func GetSeriesDueForGeneration(ctx context.Context, now time.Time) ([]TaskSeries, error) {
+	query := `
+		SELECT s.id, s.recurrence_rule, s.recurrence_timezone, s.dtstart, s.last_occurrence_at, s.ended_at, s.created_at, s.updated_at
+		FROM task_series s
+		JOIN LATERAL (
+			SELECT t.status, t.due_date
+			FROM tasks t
+			WHERE t.series_id = s.id
+			ORDER BY t.occurrence_at DESC
+			LIMIT 1
+		) latest ON true
+		WHERE s.ended_at IS NULL
+		  AND (latest.status IN ('completed', 'cancelled')
+		       OR (latest.status = 'active' AND latest.due_date <= $1))
+		ORDER BY s.last_occurrence_at ASC
+		LIMIT 100`
+
+	rows, err := db.GetDB().Query(ctx, query, now)
+	if err != nil {
+		return nil, errors.New("failed to get task series due for generation: " + err.Error())
+	}
+	defer rows.Close()
+
+	var series []TaskSeries
+	for rows.Next() {
+		var s TaskSeries
+		err := rows.Scan(
+			&s.ID,
+			&s.RecurrenceRule,
+			&s.RecurrenceTimezone,
+			&s.DTStart,
+			&s.LastOccurrenceAt,
+			&s.EndedAt,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, errors.New("failed to scan task series: " + err.Error())
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+// CreateOccurrence inserts the task as an occurrence of its series. It reports false without an
+// error when that occurrence has already been materialized by a concurrent generator.
+func (t *Task) CreateOccurrence(ctx context.Context) (bool, error) {
+	query := `INSERT INTO tasks (group_id, title, description, due_date, created_by, status, series_id, occurrence_at, reminder_offsets) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+	          ON CONFLICT (series_id, occurrence_at) WHERE series_id IS NOT NULL DO NOTHING 
+	          RETURNING id, created_at, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query,
+		t.GroupID,
+		t.Title,
+		t.Description,
+		t.DueDate,
+		t.CreatedBy,
+		t.Status,
+		t.SeriesID,
+		t.OccurrenceAt,
+		t.ReminderOffsets,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to create task occurrence: " + err.Error())
+	}
+
+	return true, nil
+}
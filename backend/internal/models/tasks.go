@@ -4,22 +4,113 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
 )
 
 type Task struct {
-	ID          string    `json:"id"`
-	GroupID     string    `json:"group_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	DueDate     time.Time `json:"due_date"`
-	Status      string    `json:"status"`
-	CreatedBy   string    `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                 string     `json:"id"`
+	GroupID            string     `json:"group_id"`
+	Title              string     `json:"title"`
+	Description        string     `json:"description"`
+	DueDate            time.Time  `json:"due_date"`
+	Status             string     `json:"status"`
+	CreatedBy          string     `json:"created_by"`
+	SeriesID           *string    `json:"series_id,omitempty"`
+	OccurrenceAt       *time.Time `json:"occurrence_at,omitempty"`
+	RecurrenceRule     *string    `json:"recurrence_rule,omitempty"`     // RFC 5545 RRULE, populated from the task's series
+	RecurrenceTimezone *string    `json:"recurrence_timezone,omitempty"` // IANA timezone the rule is evaluated in
+	ReminderOffsets    []int      `json:"reminder_offsets"`              // Minutes before due date; null uses each assignee's preferences
+	ParentID           *string    `json:"parent_id,omitempty"`
+	// When set, the task cannot be completed while any of its subtasks is open
+	RequireSubtasksComplete bool             `json:"require_subtasks_complete"`
+	Progress                TaskProgress     `json:"progress"`
+	OpenBlockers            int              `json:"open_blockers"` // Tasks this one depends on that are not done yet
+	Priority                string           `json:"priority"`
+	Labels                  []TaskLabel      `json:"labels"`
+	CustomFields            []TaskFieldValue `json:"custom_fields"`
+	CreatedAt               time.Time        `json:"created_at"`
+	UpdatedAt               time.Time        `json:"updated_at"`
+}
+
+// TaskProgress rolls up a task's subtasks and checklist items. Cancelled and rejected
+// subtasks are not counted.
+type TaskProgress struct {
+	SubtasksTotal      int `json:"subtasks_total"`
+	SubtasksCompleted  int `json:"subtasks_completed"`
+	ChecklistTotal     int `json:"checklist_total"`
+	ChecklistCompleted int `json:"checklist_completed"`
+	Percent            int `json:"percent"` // Share of subtasks and checklist items done; 0 when there are none
+}
+
+// HasOpenSubtasks reports whether any counted subtask is not completed yet
+func (p TaskProgress) HasOpenSubtasks() bool {
+	return p.SubtasksCompleted < p.SubtasksTotal
+}
+
+func (p *TaskProgress) computePercent() {
+	total := p.SubtasksTotal + p.ChecklistTotal
+	if total == 0 {
+		p.Percent = 0
+		return
+	}
+	p.Percent = (p.SubtasksCompleted + p.ChecklistCompleted) * 100 / total
+}
+
+// taskColumns is the select list shared by task queries; it expects task_series to be LEFT JOINed
+const taskColumns = `tasks.id, tasks.group_id, tasks.title, tasks.description, tasks.due_date, tasks.status, tasks.created_by,
+	tasks.series_id, tasks.occurrence_at, task_series.recurrence_rule, task_series.recurrence_timezone, tasks.reminder_offsets,
+	tasks.parent_id, tasks.require_subtasks_complete,
+	(SELECT COUNT(*) FROM tasks sub WHERE sub.parent_id = tasks.id AND sub.deleted_at IS NULL AND sub.status NOT IN ('cancelled', 'rejected')),
+	(SELECT COUNT(*) FROM tasks sub WHERE sub.parent_id = tasks.id AND sub.deleted_at IS NULL AND sub.status = 'completed'),
+	(SELECT COUNT(*) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id),
+	(SELECT COUNT(*) FROM task_checklist_items WHERE task_checklist_items.task_id = tasks.id AND task_checklist_items.completed_at IS NOT NULL),
+	(SELECT COUNT(*) FROM task_dependencies JOIN tasks blocker ON blocker.id = task_dependencies.depends_on_id
+		WHERE task_dependencies.task_id = tasks.id AND blocker.deleted_at IS NULL AND blocker.status IN ('pending', 'active')),
+	tasks.priority,
+	COALESCE((SELECT JSON_AGG(JSON_BUILD_OBJECT('id', group_labels.id, 'name', group_labels.name, 'color', group_labels.color) ORDER BY LOWER(group_labels.name))
+		FROM task_labels JOIN group_labels ON group_labels.id = task_labels.label_id WHERE task_labels.task_id = tasks.id), '[]'),
+	COALESCE((SELECT JSON_AGG(JSON_BUILD_OBJECT('field_id', f.id, 'name', f.name, 'field_type', f.field_type,
+			'value', CASE f.field_type WHEN 'number' THEN TO_JSONB(v.value_number) WHEN 'date' THEN TO_JSONB(v.value_date) ELSE TO_JSONB(v.value_text) END)
+			ORDER BY f.position, f.name)
+		FROM task_custom_field_values v JOIN group_custom_fields f ON f.id = v.field_id WHERE v.task_id = tasks.id), '[]'),
+	tasks.created_at, tasks.updated_at`
+
+// scanTask scans a row selected with taskColumns into task, followed by any extra destinations
+func scanTask(row pgx.Row, task *Task, extra ...any) error {
+	dest := []any{
+		&task.ID,
+		&task.GroupID,
+		&task.Title,
+		&task.Description,
+		&task.DueDate,
+		&task.Status,
+		&task.CreatedBy,
+		&task.SeriesID,
+		&task.OccurrenceAt,
+		&task.RecurrenceRule,
+		&task.RecurrenceTimezone,
+		&task.ReminderOffsets,
+		&task.ParentID,
+		&task.RequireSubtasksComplete,
+		&task.Progress.SubtasksTotal,
+		&task.Progress.SubtasksCompleted,
+		&task.Progress.ChecklistTotal,
+		&task.Progress.ChecklistCompleted,
+		&task.OpenBlockers,
+		&task.Priority,
+		&task.Labels,
+		&task.CustomFields,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	task.Progress.computePercent()
+	return nil
 }
 
 type TaskWithAssignees struct {
@@ -28,17 +119,31 @@ type TaskWithAssignees struct {
 }
 
 func (t *Task) CreateTask(ctx context.Context) error {
-	query := `INSERT INTO tasks (group_id, title, description, due_date, created_by, status) 
-	          VALUES ($1, $2, $3, $4, $5, $6) 
+	return t.insert(ctx, db.GetDB())
+}
+
+func (t *Task) insert(ctx context.Context, q dbtx) error {
+	if t.Priority == "" {
+		t.Priority = "none"
+	}
+
+	query := `INSERT INTO tasks (group_id, title, description, due_date, created_by, status, series_id, occurrence_at, reminder_offsets, parent_id, require_subtasks_complete, priority) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
 	          RETURNING id, created_at, updated_at`
 
-	err := db.GetDB().QueryRow(ctx, query,
+	err := q.QueryRow(ctx, query,
 		t.GroupID,
 		t.Title,
 		t.Description,
 		t.DueDate,
 		t.CreatedBy,
 		t.Status,
+		t.SeriesID,
+		t.OccurrenceAt,
+		t.ReminderOffsets,
+		t.ParentID,
+		t.RequireSubtasksComplete,
+		t.Priority,
 	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
 
 	if err != nil {
@@ -48,80 +153,27 @@ func (t *Task) CreateTask(ctx context.Context) error {
 	return nil
 }
 
-func GetTasksByGroupID(ctx context.Context, groupID string, status string, assignee string, limit int64, offset int64) ([]Task, int64, error) {
-	// Build WHERE clause and arguments for both queries
-	whereClause := `WHERE group_id = $1`
-	args := []any{groupID}
-	paramNum := 2
-
-	if status != "" {
-		whereClause += ` AND status = $` + strconv.Itoa(paramNum)
-		args = append(args, status)
-		paramNum++
-	}
-
-	if assignee != "" {
-		whereClause += ` AND created_by = $` + strconv.Itoa(paramNum)
-		args = append(args, assignee)
-		paramNum++
-	}
-
-	// Acquire a connection from the pool to avoid prepared statement conflicts with dynamic queries
-	conn, err := db.GetDB().Acquire(ctx)
-	if err != nil {
-		return nil, 0, errors.New("failed to acquire database connection: " + err.Error())
-	}
-	defer conn.Release()
-
-	// Get total count
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM tasks %s`, whereClause)
-	var total int64
-	err = conn.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, errors.New("failed to get task count: " + err.Error())
-	}
-
-	// Get tasks with pagination
-	query := fmt.Sprintf(`SELECT id, group_id, title, description, due_date, status, created_by, created_at, updated_at FROM tasks %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, whereClause, paramNum, paramNum+1)
-	queryArgs := append(args, limit, offset)
-
-	rows, err := conn.Query(ctx, query, queryArgs...)
-	if err != nil {
-		return nil, 0, errors.New("failed to get tasks: " + err.Error())
-	}
-	defer rows.Close()
-
-	tasks := []Task{}
-
-	for rows.Next() {
-		var task Task
-		err := rows.Scan(&task.ID, &task.GroupID, &task.Title, &task.Description, &task.DueDate, &task.Status, &task.CreatedBy, &task.CreatedAt, &task.UpdatedAt)
-		if err != nil {
-			return nil, 0, errors.New("failed to scan task: " + err.Error())
-		}
-		tasks = append(tasks, task)
-	}
+func GetTasksByGroupID(ctx context.Context, groupID string, filter TaskFilter, limit int64, offset int64) ([]Task, int64, error) {
+	builder := &queryBuilder{}
+	builder.Where("tasks.group_id = ?", groupID)
+	builder.Where("tasks.deleted_at IS NULL")
+	filter.apply(builder)
 
-	return tasks, total, nil
+	return listTasks(ctx, `FROM tasks`, builder, filter, limit, offset)
 }
 
-func GetTasksByUserID(ctx context.Context, userID string, status string, assignee string, limit int64, offset int64) ([]Task, int64, error) {
-	// Build WHERE clause and arguments for both queries
-	whereClause := `WHERE task_assignments.user_id = $1`
-	args := []any{userID}
-	paramNum := 2
+func GetTasksByUserID(ctx context.Context, userID string, filter TaskFilter, limit int64, offset int64) ([]Task, int64, error) {
+	builder := &queryBuilder{}
+	builder.Where("task_assignments.user_id = ?", userID)
+	builder.Where("tasks.deleted_at IS NULL")
+	filter.apply(builder)
 
-	if status != "" {
-		whereClause += ` AND tasks.status = $` + strconv.Itoa(paramNum)
-		args = append(args, status)
-		paramNum++
-	}
+	return listTasks(ctx, `FROM tasks JOIN task_assignments ON tasks.id = task_assignments.task_id`, builder, filter, limit, offset)
+}
 
-	if assignee != "" {
-		whereClause += ` AND tasks.created_by = $` + strconv.Itoa(paramNum)
-		args = append(args, assignee)
-		paramNum++
-	}
+// listTasks counts and pages the tasks matched by the builder's conditions
+func listTasks(ctx context.Context, from string, builder *queryBuilder, filter TaskFilter, limit int64, offset int64) ([]Task, int64, error) {
+	whereClause := builder.WhereClause()
 
 	// Acquire a connection from the pool to avoid prepared statement conflicts with dynamic queries
 	conn, err := db.GetDB().Acquire(ctx)
@@ -130,19 +182,20 @@ func GetTasksByUserID(ctx context.Context, userID string, status string, assigne
 	}
 	defer conn.Release()
 
-	// Get total count - need JOIN for task_assignments
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM tasks JOIN task_assignments ON tasks.id = task_assignments.task_id %s`, whereClause)
+	// Get total count
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) %s %s`, from, whereClause)
 	var total int64
-	err = conn.QueryRow(ctx, countQuery, args...).Scan(&total)
+	err = conn.QueryRow(ctx, countQuery, builder.args...).Scan(&total)
 	if err != nil {
 		return nil, 0, errors.New("failed to get task count: " + err.Error())
 	}
 
 	// Get tasks with pagination
-	query := fmt.Sprintf(`SELECT tasks.id, tasks.group_id, tasks.title, tasks.description, tasks.due_date, tasks.status, tasks.created_by, tasks.created_at, tasks.updated_at FROM tasks JOIN task_assignments ON tasks.id = task_assignments.task_id %s ORDER BY tasks.created_at DESC LIMIT $%d OFFSET $%d`, whereClause, paramNum, paramNum+1)
-	queryArgs := append(args, limit, offset)
+	limitParam, offsetParam := builder.Arg(limit), builder.Arg(offset)
+	query := fmt.Sprintf(`SELECT %s %s LEFT JOIN task_series ON tasks.series_id = task_series.id %s %s LIMIT %s OFFSET %s`,
+		taskColumns, from, whereClause, filter.orderBy(), limitParam, offsetParam)
 
-	rows, err := conn.Query(ctx, query, queryArgs...)
+	rows, err := conn.Query(ctx, query, builder.args...)
 	if err != nil {
 		return nil, 0, errors.New("failed to get tasks: " + err.Error())
 	}
@@ -152,7 +205,7 @@ func GetTasksByUserID(ctx context.Context, userID string, status string, assigne
 
 	for rows.Next() {
 		var task Task
-		err := rows.Scan(&task.ID, &task.GroupID, &task.Title, &task.Description, &task.DueDate, &task.Status, &task.CreatedBy, &task.CreatedAt, &task.UpdatedAt)
+		err := scanTask(rows, &task)
 		if err != nil {
 			return nil, 0, errors.New("failed to scan task: " + err.Error())
 		}
@@ -163,9 +216,9 @@ func GetTasksByUserID(ctx context.Context, userID string, status string, assigne
 }
 
 func GetTaskByID(ctx context.Context, taskID string) (*Task, error) {
-	query := `SELECT id, group_id, title, description, due_date, status, created_by, created_at, updated_at FROM tasks WHERE id = $1`
+	query := `SELECT ` + taskColumns + ` FROM tasks LEFT JOIN task_series ON tasks.series_id = task_series.id WHERE tasks.id = $1 AND tasks.deleted_at IS NULL`
 	var task Task
-	err := db.GetDB().QueryRow(ctx, query, taskID).Scan(&task.ID, &task.GroupID, &task.Title, &task.Description, &task.DueDate, &task.Status, &task.CreatedBy, &task.CreatedAt, &task.UpdatedAt)
+	err := scanTask(db.GetDB().QueryRow(ctx, query, taskID), &task)
 	if err != nil {
 		return nil, errors.New("failed to get task: " + err.Error())
 	}
@@ -173,27 +226,17 @@ func GetTaskByID(ctx context.Context, taskID string) (*Task, error) {
 }
 func GetTaskByIDWithAssignees(ctx context.Context, taskID string) (*TaskWithAssignees, error) {
 	// Get the task with all assignees using ARRAY_AGG
-	query := `SELECT tasks.id, tasks.group_id, tasks.title, tasks.description, tasks.due_date, tasks.status, tasks.created_by, tasks.created_at, tasks.updated_at, 
+	query := `SELECT ` + taskColumns + `, 
 	          COALESCE(ARRAY_AGG(task_assignments.user_id) FILTER (WHERE task_assignments.user_id IS NOT NULL), ARRAY[]::uuid[]) AS assignees 
 	          FROM tasks 
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id 
 	          LEFT JOIN task_assignments ON tasks.id = task_assignments.task_id 
-	          WHERE tasks.id = $1 
-	          GROUP BY tasks.id, tasks.group_id, tasks.title, tasks.description, tasks.due_date, tasks.status, tasks.created_by, tasks.created_at, tasks.updated_at`
+	          WHERE tasks.id = $1 AND tasks.deleted_at IS NULL 
+	          GROUP BY tasks.id, task_series.id`
 
 	var task Task
 	var assignees []string
-	err := db.GetDB().QueryRow(ctx, query, taskID).Scan(
-		&task.ID,
-		&task.GroupID,
-		&task.Title,
-		&task.Description,
-		&task.DueDate,
-		&task.Status,
-		&task.CreatedBy,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-		&assignees,
-	)
+	err := scanTask(db.GetDB().QueryRow(ctx, query, taskID), &task, &assignees)
 
 	if err != nil {
 		return nil, errors.New("failed to get task: " + err.Error())
@@ -224,3 +267,164 @@ func DeleteTask(ctx context.Context, taskID string) error {
 	}
 	return nil
 }
+
+// TrashTask moves a task to its group's trash together with its subtasks at any depth. They all
+// get the same deleted_at, which is how restoring the task finds them again.
+func TrashTask(ctx context.Context, taskID string, userID string) error {
+	query := `WITH RECURSIVE subtree AS (
+	              SELECT id FROM tasks WHERE id = $1 AND deleted_at IS NULL
+	              UNION ALL
+	              SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+	              WHERE tasks.deleted_at IS NULL
+	          )
+	          UPDATE tasks SET deleted_at = NOW(), deleted_by = $2 
+	          WHERE id IN (SELECT id FROM subtree)`
+
+	result, err := db.GetDB().Exec(ctx, query, taskID, userID)
+	if err != nil {
+		return errors.New("failed to trash task: " + err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("task not found")
+	}
+	return nil
+}
+
+// trashSeriesTasks moves the occurrences of a series to the trash with their subtasks, optionally only
+// those at or after fromOccurrence
+func trashSeriesTasks(ctx context.Context, q dbtx, seriesID string, fromOccurrence *time.Time, userID string) error {
+	query := `WITH RECURSIVE subtree AS (
+	              SELECT id FROM tasks 
+	              WHERE series_id = $1 AND ($2::timestamptz IS NULL OR occurrence_at >= $2) AND deleted_at IS NULL
+	              UNION ALL
+	              SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+	              WHERE tasks.deleted_at IS NULL
+	          )
+	          UPDATE tasks SET deleted_at = NOW(), deleted_by = $3 
+	          WHERE id IN (SELECT id FROM subtree)`
+
+	_, err := q.Exec(ctx, query, seriesID, fromOccurrence, userID)
+	if err != nil {
+		return errors.New("failed to trash series tasks: " + err.Error())
+	}
+	return nil
+}
+
+// GetSeriesTasks retrieves the occurrences of a series, optionally only those at or after fromOccurrence
+func GetSeriesTasks(ctx context.Context, seriesID string, fromOccurrence *time.Time) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE tasks.series_id = $1 AND ($2::timestamptz IS NULL OR tasks.occurrence_at >= $2) AND tasks.deleted_at IS NULL 
+	          ORDER BY tasks.occurrence_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, seriesID, fromOccurrence)
+	if err != nil {
+		return nil, errors.New("failed to get series tasks: " + err.Error())
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		var task Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, errors.New("failed to scan task: " + err.Error())
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// UpdateSeriesTasks applies a title/description edit to the other occurrences of a series and shifts the
+// due date of those still open by dueShift. When fromOccurrence is set only that occurrence and later ones
+// are touched ("this and following"), otherwise the whole series is ("all").
+func UpdateSeriesTasks(ctx context.Context, seriesID string, excludeTaskID string, fromOccurrence *time.Time, title string, description string, dueShift time.Duration) error {
+	query := `UPDATE tasks 
+	          SET title = $1, description = $2, 
+	              due_date = CASE WHEN status IN ('completed', 'cancelled') THEN due_date ELSE due_date + ($3::bigint * INTERVAL '1 second') END, 
+	              updated_at = NOW() 
+	          WHERE series_id = $4 AND id <> $5 AND ($6::timestamptz IS NULL OR occurrence_at >= $6) AND deleted_at IS NULL`
+
+	_, err := db.GetDB().Exec(ctx, query, title, description, int64(dueShift.Seconds()), seriesID, excludeTaskID, fromOccurrence)
+	if err != nil {
+		return errors.New("failed to update series tasks: " + err.Error())
+	}
+	return nil
+}
+
+// deleteSeriesTasks deletes the occurrences of a series, optionally only those at or after fromOccurrence.
+// With onlyOpen set, completed and cancelled occurrences are kept as history.
+func deleteSeriesTasks(ctx context.Context, q dbtx, seriesID string, fromOccurrence *time.Time, onlyOpen bool) error {
+	query := `DELETE FROM tasks 
+	          WHERE series_id = $1 AND ($2::timestamptz IS NULL OR occurrence_at >= $2) 
+	            AND (NOT $3 OR status NOT IN ('completed', 'cancelled'))`
+
+	_, err := q.Exec(ctx, query, seriesID, fromOccurrence, onlyOpen)
+	if err != nil {
+		return errors.New("failed to delete series tasks: " + err.Error())
+	}
+	return nil
+}
+
+// setTaskSeries moves a task into a series as the given occurrence (nil seriesID detaches it)
+func setTaskSeries(ctx context.Context, q dbtx, taskID string, seriesID *string, occurrenceAt *time.Time) error {
+	query := `UPDATE tasks SET series_id = $1, occurrence_at = $2, updated_at = NOW() WHERE id = $3`
+	_, err := q.Exec(ctx, query, seriesID, occurrenceAt, taskID)
+	if err != nil {
+		return errors.New("failed to set task series: " + err.Error())
+	}
+	return nil
+}
+
+// UpdateTaskReminderOffsets sets the task's reminder override; nil reverts to the assignees' preferences
+func UpdateTaskReminderOffsets(ctx context.Context, taskID string, offsets []int) error {
+	query := `UPDATE tasks SET reminder_offsets = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.GetDB().Exec(ctx, query, offsets, taskID)
+	if err != nil {
+		return errors.New("failed to update task reminders: " + err.Error())
+	}
+	return nil
+}
+
+// GetSubtasks retrieves the direct subtasks of a task, oldest first
+func GetSubtasks(ctx context.Context, parentID string) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks LEFT JOIN task_series ON tasks.series_id = task_series.id 
+	          WHERE tasks.parent_id = $1 AND tasks.deleted_at IS NULL 
+	          ORDER BY tasks.created_at ASC`
+
+	rows, err := db.GetDB().Query(ctx, query, parentID)
+	if err != nil {
+		return nil, errors.New("failed to get subtasks: " + err.Error())
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		var task Task
+		if err := scanTask(rows, &task); err != nil {
+			return nil, errors.New("failed to scan task: " + err.Error())
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// UpdateTaskRequireSubtasksComplete sets whether the task can only be completed once its subtasks are
+func UpdateTaskRequireSubtasksComplete(ctx context.Context, taskID string, require bool) error {
+	query := `UPDATE tasks SET require_subtasks_complete = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.GetDB().Exec(ctx, query, require, taskID)
+	if err != nil {
+		return errors.New("failed to update task: " + err.Error())
+	}
+	return nil
+}
+
+// UpdateTaskPriority sets the task's priority
+func UpdateTaskPriority(ctx context.Context, taskID string, priority string) error {
+	query := `UPDATE tasks SET priority = $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.GetDB().Exec(ctx, query, priority, taskID)
+	if err != nil {
+		return errors.New("failed to update task priority: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+// createTestGroup creates a user and a group they created
+func createTestGroup(t *testing.T) (*User, *Group) {
+	t.Helper()
+	user := &User{Name: "Ada", Email: fmt.Sprintf("ada-%d@example.com", time.Now().UnixNano()), Password: "password"}
+	if err := user.Save(); err != nil {
+		t.Fatal(err)
+	}
+	group := &Group{Name: "Tasks", CreatedBy: user.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+	return user, group
+}
+
+// createTestTask creates a task in the group, as a subtask of parentID when it is set
+func createTestTask(t *testing.T, user *User, group *Group, status string, parentID *string) *Task {
+	t.Helper()
+	task := &Task{
+		GroupID:     group.ID,
+		Title:       "Task " + status,
+		Description: "Test task",
+		DueDate:     time.Now().Add(24 * time.Hour),
+		CreatedBy:   user.ID,
+		Status:      status,
+		ParentID:    parentID,
+	}
+	if err := task.CreateTask(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+func TestTaskProgressPercent(t *testing.T) {
+	tests := []struct {
+		progress TaskProgress
+		percent  int
+		open     bool
+	}{
+		{TaskProgress{}, 0, false},
+		{TaskProgress{SubtasksTotal: 2, SubtasksCompleted: 1}, 50, true},
+		{TaskProgress{ChecklistTotal: 3, ChecklistCompleted: 1}, 33, false},
+		{TaskProgress{SubtasksTotal: 1, SubtasksCompleted: 1, ChecklistTotal: 3, ChecklistCompleted: 2}, 75, false},
+		{TaskProgress{SubtasksTotal: 2, SubtasksCompleted: 2, ChecklistTotal: 2, ChecklistCompleted: 2}, 100, false},
+	}
+	for _, test := range tests {
+		progress := test.progress
+		progress.computePercent()
+		if progress.Percent != test.percent {
+			t.Errorf("%+v: percent %d, want %d", test.progress, progress.Percent, test.percent)
+		}
+		if progress.HasOpenSubtasks() != test.open {
+			t.Errorf("%+v: HasOpenSubtasks %v, want %v", test.progress, progress.HasOpenSubtasks(), test.open)
+		}
+	}
+}
+
+func TestTaskProgressRollup(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	parent := createTestTask(t, user, group, "active", nil)
+	createTestTask(t, user, group, "completed", &parent.ID)
+	createTestTask(t, user, group, "active", &parent.ID)
+	// Cancelled subtasks are not counted, nor are the subtasks of subtasks
+	child := createTestTask(t, user, group, "cancelled", &parent.ID)
+	createTestTask(t, user, group, "completed", &child.ID)
+
+	for i, title := range []string{"Draft", "Review"} {
+		item := &TaskChecklistItem{TaskID: parent.ID, Title: title, CreatedBy: user.ID}
+		if err := item.Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err := item.Update(ctx, true, user.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	task, err := GetTaskByID(ctx, parent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TaskProgress{SubtasksTotal: 2, SubtasksCompleted: 1, ChecklistTotal: 2, ChecklistCompleted: 1, Percent: 50}
+	if task.Progress != want {
+		t.Errorf("progress %+v, want %+v", task.Progress, want)
+	}
+	if !task.Progress.HasOpenSubtasks() {
+		t.Error("an active subtask does not count as open")
+	}
+}
@@ -0,0 +1,355 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+var TrashItemTypes = []string{"task", "file", "folder", "link"}
+
+// TrashItem is something deleted in a group that can still be restored. Subtasks and the contents
+// of folders are not listed on their own; they come back with the task or folder they were
+// deleted with.
+type TrashItem struct {
+	Type      string    `json:"type"` // task, file, folder or link
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy *string   `json:"deleted_by,omitempty"` // Nil when the user no longer exists
+}
+
+// trashItems lists the trashed items of group $1; an item is left out when it was deleted along with
+// its parent task or folder, which is when they share deleted_at
+const trashItems = `
+	SELECT 'task' AS type, t.id, t.title AS name, t.deleted_at, t.deleted_by FROM tasks t
+	WHERE t.group_id = $1 AND t.deleted_at IS NOT NULL
+	  AND NOT EXISTS (SELECT 1 FROM tasks p WHERE p.id = t.parent_id AND p.deleted_at = t.deleted_at)
+	UNION ALL
+	SELECT 'folder', fo.id, fo.path, fo.deleted_at, fo.deleted_by FROM folders fo
+	WHERE fo.group_id = $1 AND fo.deleted_at IS NOT NULL
+	  AND NOT EXISTS (SELECT 1 FROM folders p WHERE p.id = fo.parent_id AND p.deleted_at = fo.deleted_at)
+	UNION ALL
+	SELECT 'file', f.id, f.name, f.deleted_at, f.deleted_by FROM files f
+	WHERE f.group_id = $1 AND f.deleted_at IS NOT NULL
+	  AND NOT EXISTS (SELECT 1 FROM folders p WHERE p.id = f.folder_id AND p.deleted_at = f.deleted_at)
+	UNION ALL
+	SELECT 'link', l.id, COALESCE(l.title, l.url), l.deleted_at, l.deleted_by FROM links l
+	WHERE l.group_id = $1 AND l.deleted_at IS NOT NULL`
+
+// GetGroupTrash returns the group's trash, most recently deleted first, optionally only one type of item
+func GetGroupTrash(ctx context.Context, groupID string, itemType string, limit int64, offset int64) ([]TrashItem, int64, error) {
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM (` + trashItems + `) trash WHERE $2 = '' OR type = $2`
+	err := db.GetDB().QueryRow(ctx, countQuery, groupID, itemType).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("failed to count trash: " + err.Error())
+	}
+
+	query := `SELECT type, id, name, deleted_at, deleted_by FROM (` + trashItems + `) trash
+	          WHERE $2 = '' OR type = $2
+	          ORDER BY deleted_at DESC, id
+	          LIMIT $3 OFFSET $4`
+
+	rows, err := db.GetDB().Query(ctx, query, groupID, itemType, limit, offset)
+	if err != nil {
+		return nil, 0, errors.New("failed to get trash: " + err.Error())
+	}
+	defer rows.Close()
+
+	items := []TrashItem{}
+	for rows.Next() {
+		var item TrashItem
+		if err := rows.Scan(&item.Type, &item.ID, &item.Name, &item.DeletedAt, &item.DeletedBy); err != nil {
+			return nil, 0, errors.New("failed to scan trash item: " + err.Error())
+		}
+		items = append(items, item)
+	}
+
+	return items, total, nil
+}
+
+// GetTrashItem returns a trashed item of the group, including one deleted along with its parent.
+// It returns nil when the group has no such item in its trash.
+func GetTrashItem(ctx context.Context, groupID string, itemType string, id string) (*TrashItem, error) {
+	var query string
+	switch itemType {
+	case "task":
+		query = `SELECT title, deleted_at, deleted_by FROM tasks WHERE id = $1 AND group_id = $2 AND deleted_at IS NOT NULL`
+	case "file":
+		query = `SELECT name, deleted_at, deleted_by FROM files WHERE id = $1 AND group_id = $2 AND deleted_at IS NOT NULL`
+	case "folder":
+		query = `SELECT path, deleted_at, deleted_by FROM folders WHERE id = $1 AND group_id = $2 AND deleted_at IS NOT NULL`
+	case "link":
+		query = `SELECT COALESCE(title, url), deleted_at, deleted_by FROM links WHERE id = $1 AND group_id = $2 AND deleted_at IS NOT NULL`
+	default:
+		return nil, errors.New("invalid trash item type: " + itemType)
+	}
+
+	item := TrashItem{Type: itemType, ID: id}
+	err := db.GetDB().QueryRow(ctx, query, id, groupID).Scan(&item.Name, &item.DeletedAt, &item.DeletedBy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.New("failed to get trash item: " + err.Error())
+	}
+	return &item, nil
+}
+
+// RestoreTask brings a task back from the trash with the subtasks deleted along with it, cancelling
+// their notifications that came due while they were trashed. It returns why the task cannot be
+// restored, or "" once it is.
+func RestoreTask(ctx context.Context, taskID string) (string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return "", errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt time.Time
+	var parentTrashed bool
+	query := `SELECT tasks.deleted_at, parent.deleted_at IS NOT NULL
+	          FROM tasks LEFT JOIN tasks parent ON parent.id = tasks.parent_id
+	          WHERE tasks.id = $1 AND tasks.deleted_at IS NOT NULL
+	          FOR UPDATE OF tasks`
+	err = tx.QueryRow(ctx, query, taskID).Scan(&deletedAt, &parentTrashed)
+	if err != nil {
+		return "", errors.New("task not found in trash: " + err.Error())
+	}
+	if parentTrashed {
+		return "The parent task is in the trash; restore it first", nil
+	}
+
+	restoreQuery := `WITH RECURSIVE subtree AS (
+	                     SELECT id FROM tasks WHERE id = $1
+	                     UNION ALL
+	                     SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id
+	                     WHERE tasks.deleted_at = $2
+	                 )
+	                 UPDATE tasks SET deleted_at = NULL, deleted_by = NULL
+	                 WHERE id IN (SELECT id FROM subtree)
+	                 RETURNING id`
+	restored, err := collectStrings(tx.Query(ctx, restoreQuery, taskID, deletedAt))
+	if err != nil {
+		return "", errors.New("failed to restore task: " + err.Error())
+	}
+
+	// Notifications are held back while a task is in the trash; the ones that came due meanwhile are stale
+	cancelQuery := `UPDATE task_notifications SET status = 'cancelled', updated_at = NOW()
+	                WHERE task_id = ANY($1) AND status = 'pending' AND scheduled_at <= NOW()`
+	if _, err := tx.Exec(ctx, cancelQuery, restored); err != nil {
+		return "", errors.New("failed to cancel task notifications: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", errors.New("failed to commit transaction: " + err.Error())
+	}
+	return "", nil
+}
+
+// RestoreFolder brings a folder back from the trash with the subfolders and files deleted along
+// with it. It returns why the folder cannot be restored, or "" once it is.
+func RestoreFolder(ctx context.Context, folderID string) (string, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return "", errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	var deletedAt time.Time
+	var parentTrashed bool
+	query := `SELECT folders.deleted_at, parent.deleted_at IS NOT NULL
+	          FROM folders LEFT JOIN folders parent ON parent.id = folders.parent_id
+	          WHERE folders.id = $1 AND folders.deleted_at IS NOT NULL
+	          FOR UPDATE OF folders`
+	err = tx.QueryRow(ctx, query, folderID).Scan(&deletedAt, &parentTrashed)
+	if err != nil {
+		return "", errors.New("folder not found in trash: " + err.Error())
+	}
+	if parentTrashed {
+		return "The parent folder is in the trash; restore it first", nil
+	}
+
+	subtree := `WITH RECURSIVE subtree AS (
+		SELECT id FROM folders WHERE id = $1
+		UNION ALL
+		SELECT folders.id FROM folders JOIN subtree ON folders.parent_id = subtree.id
+		WHERE folders.deleted_at = $2
+	)`
+
+	_, err = tx.Exec(ctx, subtree+`
+		UPDATE folders SET deleted_at = NULL, deleted_by = NULL
+		WHERE id IN (SELECT id FROM subtree)`, folderID, deletedAt)
+	if isUniqueViolation(err) {
+		return "A folder with the same path exists; rename or move it first", nil
+	}
+	if err != nil {
+		return "", errors.New("failed to restore folder: " + err.Error())
+	}
+
+	_, err = tx.Exec(ctx, subtree+`
+		UPDATE files SET deleted_at = NULL, deleted_by = NULL
+		WHERE folder_id IN (SELECT id FROM subtree) AND deleted_at = $2`, folderID, deletedAt)
+	if err != nil {
+		return "", errors.New("failed to restore files: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", errors.New("failed to commit transaction: " + err.Error())
+	}
+	return "", nil
+}
+
+// RestoreFile brings a file back from the trash. It returns why the file cannot be restored, or ""
+// once it is.
+func RestoreFile(ctx context.Context, fileID string) (string, error) {
+	query := `UPDATE files SET deleted_at = NULL, deleted_by = NULL
+	          WHERE id = $1 AND deleted_at IS NOT NULL
+	            AND NOT EXISTS (SELECT 1 FROM folders WHERE folders.id = files.folder_id AND folders.deleted_at IS NOT NULL)`
+
+	result, err := db.GetDB().Exec(ctx, query, fileID)
+	if err != nil {
+		return "", errors.New("failed to restore file: " + err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return "The file's folder is in the trash; restore it first", nil
+	}
+	return "", nil
+}
+
+// RestoreLink brings a link back from the trash
+func RestoreLink(ctx context.Context, linkID string) error {
+	query := `UPDATE links SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	result, err := db.GetDB().Exec(ctx, query, linkID)
+	if err != nil {
+		return errors.New("failed to restore link: " + err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return errors.New("link not found in trash")
+	}
+	return nil
+}
+
+// TrashPurge is what PurgeTrash deleted for good
+type TrashPurge struct {
+	Tasks      int64
+	Files      int64
+	Folders    int64
+	Links      int64
+	ObjectKeys []string // Stored objects no remaining row references, to be deleted
+	GroupIDs   []string // Groups whose storage usage went down
+}
+
+// PurgeTrash deletes everything trashed before the given time, along with the rows that cascade from
+// it (assignments, notifications, file versions...), and the series that were ended and have no
+// occurrences left. Files and links are only purged withObjects, when the caller can delete the
+// stored objects they leave behind.
+func PurgeTrash(ctx context.Context, before time.Time, withObjects bool) (*TrashPurge, error) {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return nil, errors.New("failed to begin transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	purge := &TrashPurge{}
+
+	result, err := tx.Exec(ctx, `DELETE FROM tasks WHERE deleted_at < $1`, before)
+	if err != nil {
+		return nil, errors.New("failed to purge tasks: " + err.Error())
+	}
+	purge.Tasks = result.RowsAffected()
+
+	_, err = tx.Exec(ctx, `DELETE FROM task_series
+	                       WHERE ended_at IS NOT NULL
+	                         AND NOT EXISTS (SELECT 1 FROM tasks WHERE tasks.series_id = task_series.id)`)
+	if err != nil {
+		return nil, errors.New("failed to purge task series: " + err.Error())
+	}
+
+	if withObjects {
+		if err := purgeTrashedObjects(ctx, tx, before, purge); err != nil {
+			return nil, err
+		}
+	}
+
+	// Subfolders trashed with them go too (ON DELETE CASCADE); they were trashed no later
+	result, err = tx.Exec(ctx, `DELETE FROM folders WHERE deleted_at < $1`, before)
+	if err != nil {
+		return nil, errors.New("failed to purge folders: " + err.Error())
+	}
+	purge.Folders = result.RowsAffected()
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.New("failed to commit transaction: " + err.Error())
+	}
+	return purge, nil
+}
+
+// purgeTrashedObjects deletes the links and files trashed before the given time and collects the
+// stored objects no remaining row references
+func purgeTrashedObjects(ctx context.Context, tx pgx.Tx, before time.Time, purge *TrashPurge) error {
+	previewKeys, err := collectStrings(tx.Query(ctx, `DELETE FROM links WHERE deleted_at < $1
+	                                                  RETURNING COALESCE(preview_image_s3_key, '')`, before))
+	if err != nil {
+		return errors.New("failed to purge links: " + err.Error())
+	}
+	purge.Links = int64(len(previewKeys))
+
+	versionKeys, err := collectStrings(tx.Query(ctx, `DELETE FROM file_versions
+	                                                  WHERE file_id IN (SELECT id FROM files WHERE deleted_at < $1)
+	                                                  RETURNING s3_key`, before))
+	if err != nil {
+		return errors.New("failed to purge file versions: " + err.Error())
+	}
+
+	rows, err := tx.Query(ctx, `DELETE FROM files WHERE deleted_at < $1 RETURNING s3_key, group_id`, before)
+	if err != nil {
+		return errors.New("failed to purge files: " + err.Error())
+	}
+	fileKeys := []string{}
+	groups := map[string]bool{}
+	for rows.Next() {
+		var key string
+		var groupID *string
+		if err := rows.Scan(&key, &groupID); err != nil {
+			rows.Close()
+			return errors.New("failed to scan purged file: " + err.Error())
+		}
+		fileKeys = append(fileKeys, key)
+		if groupID != nil && !groups[*groupID] {
+			groups[*groupID] = true
+			purge.GroupIDs = append(purge.GroupIDs, *groupID)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return errors.New("failed to purge files: " + err.Error())
+	}
+	purge.Files = int64(len(fileKeys))
+
+	// Objects of the purged files' versions may still be shared with files that remain
+	candidates := append(versionKeys, fileKeys...)
+	referenced, err := collectStrings(tx.Query(ctx, `SELECT s3_key FROM file_versions WHERE s3_key = ANY($1)
+	                                                 UNION
+	                                                 SELECT s3_key FROM files WHERE s3_key = ANY($1)`, candidates))
+	if err != nil {
+		return errors.New("failed to check purged files: " + err.Error())
+	}
+
+	skip := map[string]bool{"": true}
+	for _, key := range referenced {
+		skip[key] = true
+	}
+	for _, key := range append(candidates, previewKeys...) {
+		if !skip[key] {
+			skip[key] = true
+			purge.ObjectKeys = append(purge.ObjectKeys, key)
+		}
+	}
+	return nil
+}
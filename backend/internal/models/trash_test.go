@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+)
+
+func TestRestoreTaskCancelsNotificationsThatCameDue(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+	user, group := createTestGroup(t)
+
+	task := createTestTask(t, user, group, "active", nil)
+	subtask := createTestTask(t, user, group, "active", &task.ID)
+	notify := func(taskID string, scheduledAt time.Time) *TaskNotification {
+		t.Helper()
+		notification := &TaskNotification{TaskID: taskID, UserID: user.ID, NotificationType: "reminder", ScheduledAt: scheduledAt, Status: "pending"}
+		if err := notification.Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return notification
+	}
+	cameDue := notify(task.ID, time.Now().Add(time.Minute))
+	subtaskCameDue := notify(subtask.ID, time.Now().Add(time.Minute))
+	upcoming := notify(task.ID, time.Now().Add(time.Hour))
+
+	if err := TrashTask(ctx, task.ID, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Held back while the task is in the trash
+	pending, err := GetPendingNotifications(ctx, time.Now().Add(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, notification := range pending {
+		if notification.TaskID == task.ID || notification.TaskID == subtask.ID {
+			t.Fatalf("notification %s of a trashed task is pending delivery", notification.ID)
+		}
+	}
+
+	// Restored after the first two came due
+	_, err = db.GetDB().Exec(ctx, `UPDATE task_notifications SET scheduled_at = NOW() - INTERVAL '1 minute' WHERE id = ANY($1)`,
+		[]string{cameDue.ID, subtaskCameDue.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, err := RestoreTask(ctx, task.ID); err != nil || reason != "" {
+		t.Fatalf("RestoreTask: %q, %v", reason, err)
+	}
+
+	want := map[*TaskNotification]string{cameDue: "cancelled", subtaskCameDue: "cancelled", upcoming: "pending"}
+	for notification, status := range want {
+		if err := notification.Get(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if notification.Status != status {
+			t.Errorf("notification scheduled at %s is %s, want %s", notification.ScheduledAt, notification.Status, status)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// UploadSessionTTL is how long the client has to upload the content and complete a session
+const UploadSessionTTL = 24 * time.Hour
+
+// UploadSession is a direct-to-S3 upload in progress. Completing a session without FileID creates
+// a file whose ID is the session's ID; with FileID it adds a version to that file.
+type UploadSession struct {
+	ID                string     `json:"id"`
+	GroupID           string     `json:"group_id"`
+	FolderID          *string    `json:"folder_id,omitempty"`
+	FileID            *string    `json:"file_id,omitempty"`
+	Name              string     `json:"name"`
+	Size              int64      `json:"size"`
+	MimeType          string     `json:"mime_type"`
+	SHA256            string     `json:"sha256"`
+	S3Bucket          string     `json:"-"`
+	S3Key             string     `json:"-"`
+	MultipartUploadID *string    `json:"-"`
+	PartSize          *int64     `json:"part_size,omitempty"`
+	PartCount         *int       `json:"part_count,omitempty"`
+	PartSHA256s       []string   `json:"part_sha256s,omitempty"`
+	Status            string     `json:"status"` // pending, completing, completed, failed, aborted or expired
+	Error             *string    `json:"error,omitempty"`
+	CreatedBy         string     `json:"created_by"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+}
+
+const uploadSessionColumns = `id, group_id, folder_id, file_id, name, size, mime_type, sha256, s3_bucket, s3_key,
+	multipart_upload_id, part_size, part_count, part_sha256s, status, error, created_by, expires_at, created_at, completed_at`
+
+func scanUploadSession(row pgx.Row, u *UploadSession) error {
+	return row.Scan(&u.ID, &u.GroupID, &u.FolderID, &u.FileID, &u.Name, &u.Size, &u.MimeType, &u.SHA256, &u.S3Bucket, &u.S3Key,
+		&u.MultipartUploadID, &u.PartSize, &u.PartCount, &u.PartSHA256s, &u.Status, &u.Error, &u.CreatedBy, &u.ExpiresAt, &u.CreatedAt, &u.CompletedAt)
+}
+
+// Save creates the session; ID must be set since the S3 key is derived from it
+func (u *UploadSession) Save(ctx context.Context) error {
+	query := `INSERT INTO upload_sessions (id, group_id, folder_id, file_id, name, size, mime_type, sha256, s3_bucket, s3_key,
+	                                       multipart_upload_id, part_size, part_count, part_sha256s, created_by, expires_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	          RETURNING ` + uploadSessionColumns
+
+	err := scanUploadSession(db.GetDB().QueryRow(ctx, query,
+		u.ID, u.GroupID, u.FolderID, u.FileID, u.Name, u.Size, u.MimeType, u.SHA256, u.S3Bucket, u.S3Key,
+		u.MultipartUploadID, u.PartSize, u.PartCount, u.PartSHA256s, u.CreatedBy, time.Now().Add(UploadSessionTTL),
+	), u)
+	if err != nil {
+		return errors.New("failed to create upload session: " + err.Error())
+	}
+	return nil
+}
+
+func (u *UploadSession) Get(ctx context.Context) error {
+	query := `SELECT ` + uploadSessionColumns + ` FROM upload_sessions WHERE id = $1`
+
+	err := scanUploadSession(db.GetDB().QueryRow(ctx, query, u.ID), u)
+	if err != nil {
+		return errors.New("failed to get upload session: " + err.Error())
+	}
+	return nil
+}
+
+// IsUploadPending reports whether the key belongs to an upload session that still accepts content
+func IsUploadPending(ctx context.Context, s3Key string) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM upload_sessions WHERE s3_key = $1 AND status = 'pending' AND expires_at > NOW())`
+
+	var pending bool
+	if err := db.GetDB().QueryRow(ctx, query, s3Key).Scan(&pending); err != nil {
+		return false, errors.New("failed to check upload session: " + err.Error())
+	}
+	return pending, nil
+}
+
+// Claim moves a pending, unexpired session to completing so only one completion runs at a time.
+// It returns false when the session cannot be completed.
+func (u *UploadSession) Claim(ctx context.Context) (bool, error) {
+	query := `UPDATE upload_sessions SET status = 'completing'
+	          WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+	          RETURNING ` + uploadSessionColumns
+
+	err := scanUploadSession(db.GetDB().QueryRow(ctx, query, u.ID), u)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.New("failed to claim upload session: " + err.Error())
+	}
+	return true, nil
+}
+
+// Release puts a claimed session back to pending, e.g. when the client reported wrong parts and can retry
+func (u *UploadSession) Release(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `UPDATE upload_sessions SET status = 'pending' WHERE id = $1 AND status = 'completing'`, u.ID)
+	if err != nil {
+		return errors.New("failed to release upload session: " + err.Error())
+	}
+	u.Status = "pending"
+	return nil
+}
+
+// Finish ends the session with a final status and, for failures, the reason
+func (u *UploadSession) Finish(ctx context.Context, status string, reason *string) error {
+	query := `UPDATE upload_sessions SET status = $1, error = $2, completed_at = NOW()
+	          WHERE id = $3
+	          RETURNING ` + uploadSessionColumns
+
+	err := scanUploadSession(db.GetDB().QueryRow(ctx, query, status, reason, u.ID), u)
+	if err != nil {
+		return errors.New("failed to update upload session: " + err.Error())
+	}
+	return nil
+}
+
+// GetExpiredUploadSessions returns the sessions that were never completed in time. Sessions stuck
+// completing (e.g. the server stopped mid-way) are included an hour after they expired.
+func GetExpiredUploadSessions(ctx context.Context, limit int) ([]UploadSession, error) {
+	query := `SELECT ` + uploadSessionColumns + `
+	          FROM upload_sessions
+	          WHERE (status = 'pending' AND expires_at < NOW())
+	             OR (status = 'completing' AND expires_at < NOW() - INTERVAL '1 hour')
+	          ORDER BY expires_at
+	          LIMIT $1`
+
+	rows, err := db.GetDB().Query(ctx, query, limit)
+	if err != nil {
+		return nil, errors.New("failed to get expired upload sessions: " + err.Error())
+	}
+	defer rows.Close()
+
+	sessions := []UploadSession{}
+	for rows.Next() {
+		var session UploadSession
+		if err := scanUploadSession(rows, &session); err != nil {
+			return nil, errors.New("failed to scan upload session: " + err.Error())
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultReminderOffsets is used for users who never set preferences: one reminder 24 hours before
+var DefaultReminderOffsets = []int{1440}
+
+// MaxReminderOffset caps how far ahead of the due date a reminder can be (30 days, in minutes)
+const MaxReminderOffset = 30 * 24 * 60
+
+// NotificationChannelNames lists the channels a user can receive notifications on
+var NotificationChannelNames = []string{"email", "in_app", "webhook"}
+
+// DefaultNotificationChannels is used for notification types the user has not configured
+var DefaultNotificationChannels = []string{"email", "in_app"}
+
+// NotificationTypes lists the task notification types users can configure channels for
+var NotificationTypes = []string{"assignment", "reminder", "due_date", "status_change", "update", "mention", "unblocked", "approval_request", "approval_decision"}
+
+type UserPreferences struct {
+	UserID               string              `json:"user_id"`
+	ReminderOffsets      []int               `json:"reminder_offsets"`      // Minutes before the due date, e.g. [10080, 1440, 15]
+	NotificationChannels map[string][]string `json:"notification_channels"` // Channels per notification type; missing types use the defaults
+	WebhookURL           *string             `json:"webhook_url"`
+	CreatedAt            time.Time           `json:"created_at"`
+	UpdatedAt            time.Time           `json:"updated_at"`
+}
+
+// Get loads the user's preferences, falling back to the defaults when none are stored
+func (p *UserPreferences) Get(ctx context.Context) error {
+	query := `SELECT user_id, reminder_offsets, notification_channels, webhook_url, created_at, updated_at 
+	          FROM user_preferences 
+	          WHERE user_id = $1`
+
+	err := db.GetDB().QueryRow(ctx, query, p.UserID).Scan(
+		&p.UserID,
+		&p.ReminderOffsets,
+		&p.NotificationChannels,
+		&p.WebhookURL,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		p.ReminderOffsets = slices.Clone(DefaultReminderOffsets)
+		p.NotificationChannels = map[string][]string{}
+		return nil
+	}
+	if err != nil {
+		return errors.New("failed to get user preferences: " + err.Error())
+	}
+
+	return nil
+}
+
+// Save creates or replaces the user's preferences
+func (p *UserPreferences) Save(ctx context.Context) error {
+	if p.NotificationChannels == nil {
+		p.NotificationChannels = map[string][]string{}
+	}
+
+	query := `INSERT INTO user_preferences (user_id, reminder_offsets, notification_channels, webhook_url) 
+	          VALUES ($1, $2, $3, $4) 
+	          ON CONFLICT (user_id) DO UPDATE SET 
+	              reminder_offsets = EXCLUDED.reminder_offsets, 
+	              notification_channels = EXCLUDED.notification_channels, 
+	              webhook_url = EXCLUDED.webhook_url, 
+	              updated_at = NOW() 
+	          RETURNING created_at, updated_at`
+
+	err := db.GetDB().QueryRow(ctx, query, p.UserID, p.ReminderOffsets, p.NotificationChannels, p.WebhookURL).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return errors.New("failed to save user preferences: " + err.Error())
+	}
+
+	return nil
+}
+
+// ChannelsFor returns the channels the user receives the given notification type on
+func (p *UserPreferences) ChannelsFor(notificationType string) []string {
+	if channels, ok := p.NotificationChannels[notificationType]; ok {
+		return channels
+	}
+	return DefaultNotificationChannels
+}
+
+// NormalizeReminderOffsets validates reminder offsets (minutes before due date) and returns them
+// de-duplicated and sorted from furthest to closest
+func NormalizeReminderOffsets(offsets []int) ([]int, error) {
+	if len(offsets) > 10 {
+		return nil, errors.New("at most 10 reminder offsets are allowed")
+	}
+
+	normalized := []int{}
+	for _, offset := range offsets {
+		if offset < 0 || offset > MaxReminderOffset {
+			return nil, fmt.Errorf("reminder offset %d is out of range (0 to %d minutes)", offset, MaxReminderOffset)
+		}
+		if !slices.Contains(normalized, offset) {
+			normalized = append(normalized, offset)
+		}
+	}
+
+	slices.Sort(normalized)
+	slices.Reverse(normalized)
+
+	return normalized, nil
+}
+
+// NormalizeNotificationChannels validates channel preferences per notification type and de-duplicates
+// the channels. An empty list mutes that notification type.
+func NormalizeNotificationChannels(channels map[string][]string) (map[string][]string, error) {
+	normalized := map[string][]string{}
+	for notificationType, list := range channels {
+		if !slices.Contains(NotificationTypes, notificationType) {
+			return nil, fmt.Errorf("invalid notification type %q", notificationType)
+		}
+
+		normalized[notificationType] = []string{}
+		for _, channel := range list {
+			if !slices.Contains(NotificationChannelNames, channel) {
+				return nil, fmt.Errorf("invalid notification channel %q. Must be one of: email, in_app, webhook", channel)
+			}
+			if !slices.Contains(normalized[notificationType], channel) {
+				normalized[notificationType] = append(normalized[notificationType], channel)
+			}
+		}
+	}
+
+	return normalized, nil
+}
+
+// ValidateWebhookURL checks that a webhook target is an absolute http(s) URL that is not an
+// internal address. Hostnames are checked again when the webhook is called.
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := utils.ValidateOutboundURL(rawURL)
+	if err != nil {
+		return errors.New("webhook_url must be an absolute http or https URL without credentials")
+	}
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil && !utils.IsPublicIP(addr) {
+		return errors.New("webhook_url must not point to a private or loopback address")
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// Lifetimes of emailed account tokens
+const (
+	PasswordResetTokenTTL     = time.Hour
+	EmailVerificationTokenTTL = 48 * time.Hour
+)
+
+// UserToken is a single-use token emailed to a user, stored by hash
+type UserToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Purpose   string     `json:"purpose"` // "password_reset" or "email_verification"
+	TokenHash string     `json:"-"`
+	Email     string     `json:"email"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Save stores the token and invalidates older unused tokens of the same purpose, so only the most
+// recently emailed link works
+func (t *UserToken) Save(ctx context.Context) error {
+	tx, err := db.GetDB().Begin(ctx)
+	if err != nil {
+		return errors.New("failed to start transaction: " + err.Error())
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, t.UserID, t.Purpose)
+	if err != nil {
+		return errors.New("failed to invalidate previous tokens: " + err.Error())
+	}
+
+	query := `INSERT INTO user_tokens (user_id, purpose, token_hash, email, expires_at) 
+	          VALUES ($1, $2, $3, $4, $5) 
+	          RETURNING id, created_at`
+
+	err = tx.QueryRow(ctx, query, t.UserID, t.Purpose, t.TokenHash, t.Email, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return errors.New("failed to save token: " + err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.New("failed to save token: " + err.Error())
+	}
+
+	return nil
+}
+
+// ConsumeUserToken marks an unused, unexpired token as used and returns it. Each token works once.
+func ConsumeUserToken(ctx context.Context, purpose string, tokenHash string) (*UserToken, error) {
+	query := `UPDATE user_tokens 
+	          SET used_at = NOW() 
+	          WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW() 
+	          RETURNING id, user_id, purpose, token_hash, email, expires_at, used_at, created_at`
+
+	var t UserToken
+	err := db.GetDB().QueryRow(ctx, query, tokenHash, purpose).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.Purpose,
+		&t.TokenHash,
+		&t.Email,
+		&t.ExpiresAt,
+		&t.UsedAt,
+		&t.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errors.New("link is invalid or has expired")
+	}
+	if err != nil {
+		return nil, errors.New("failed to use token: " + err.Error())
+	}
+
+	return &t, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/KoiralaSam/Remindly/backend/internal/db"
 	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/jackc/pgx/v5"
 )
 
 type User struct {
@@ -17,6 +18,8 @@ type User struct {
 	Password  string    `json:"password"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Set once the user proves they own Email; cleared when the address changes
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
 func (u *User) Save() error {
@@ -59,9 +62,9 @@ func (u *User) ValidateCredentials() error {
 }
 
 func (u *User) Get() error {
-	query := `SELECT id, name, email, phone, created_at FROM users WHERE id = $1`
+	query := `SELECT id, name, email, phone, created_at, email_verified_at FROM users WHERE id = $1`
 
-	err := db.GetDB().QueryRow(context.Background(), query, u.ID).Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.CreatedAt)
+	err := db.GetDB().QueryRow(context.Background(), query, u.ID).Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.CreatedAt, &u.EmailVerifiedAt)
 
 	if err != nil {
 		return errors.New("failed to get user: " + err.Error())
@@ -85,9 +88,12 @@ func (u *User) Update() error {
 		phoneValue = u.Phone
 	}
 
-	query := `UPDATE users SET name = COALESCE($1, name), email = COALESCE($2, email), phone = COALESCE($3, phone), updated_at = NOW() WHERE id = $4 RETURNING id, name, email, phone, updated_at`
+	// Changing the email address drops its verification
+	query := `UPDATE users SET name = COALESCE($1, name), email = COALESCE($2, email), phone = COALESCE($3, phone), 
+	          email_verified_at = CASE WHEN $2::text IS NOT NULL AND $2::text <> email THEN NULL ELSE email_verified_at END, 
+	          updated_at = NOW() WHERE id = $4 RETURNING id, name, email, phone, updated_at, email_verified_at`
 
-	err := db.GetDB().QueryRow(context.Background(), query, nameValue, emailValue, phoneValue, u.ID).Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.UpdatedAt)
+	err := db.GetDB().QueryRow(context.Background(), query, nameValue, emailValue, phoneValue, u.ID).Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.UpdatedAt, &u.EmailVerifiedAt)
 
 	if err != nil {
 		return errors.New("failed to update user: " + err.Error())
@@ -97,9 +103,9 @@ func (u *User) Update() error {
 }
 
 func (u *User) GetByEmail() error {
-	query := `SELECT id, name, email, phone FROM users WHERE email = $1`
+	query := `SELECT id, name, email, phone, email_verified_at FROM users WHERE email = $1`
 
-	err := db.GetDB().QueryRow(context.Background(), query, u.Email).Scan(&u.ID, &u.Name, &u.Email, &u.Phone)
+	err := db.GetDB().QueryRow(context.Background(), query, u.Email).Scan(&u.ID, &u.Name, &u.Email, &u.Phone, &u.EmailVerifiedAt)
 
 	if err != nil {
 		return errors.New("user not found")
@@ -107,3 +113,43 @@ func (u *User) GetByEmail() error {
 
 	return nil
 }
+
+// IsEmailVerified reports whether the user proved ownership of their current email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// MarkEmailVerified records that the user owns the given address. It does nothing if the user
+// changed their email since the verification link was sent.
+func (u *User) MarkEmailVerified(ctx context.Context, email string) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() 
+	          WHERE id = $1 AND email = $2 
+	          RETURNING email_verified_at`
+
+	err := db.GetDB().QueryRow(ctx, query, u.ID, email).Scan(&u.EmailVerifiedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("email address has changed since the verification link was sent")
+	}
+	if err != nil {
+		return errors.New("failed to verify email: " + err.Error())
+	}
+
+	return nil
+}
+
+// UpdatePassword replaces the user's password
+func (u *User) UpdatePassword(ctx context.Context, password string) error {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("failed to hash password: " + err.Error())
+	}
+
+	query := `UPDATE users SET password = $1, updated_at = NOW() WHERE id = $2`
+
+	_, err = db.GetDB().Exec(ctx, query, hashedPassword, u.ID)
+	if err != nil {
+		return errors.New("failed to update password: " + err.Error())
+	}
+
+	return nil
+}
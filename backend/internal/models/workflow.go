@@ -0,0 +1,416 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// WorkflowTriggerTypes lists the task events a workflow can react to
+var WorkflowTriggerTypes = []string{"task_created", "task_status_changed", "task_overdue"}
+
+// WorkflowConditionFields lists the task fields a workflow condition can test
+var WorkflowConditionFields = []string{"status", "priority", "label", "title"}
+
+// WorkflowConditionOps lists the comparison operators of workflow conditions
+var WorkflowConditionOps = []string{"eq", "neq", "in", "contains"}
+
+// WorkflowActionTypes lists what a workflow can do once triggered
+var WorkflowActionTypes = []string{"create_task", "post_message", "reassign", "set_priority", "add_label"}
+
+// WorkflowAssigneeTargets are the symbolic users a create_task or reassign action can target
+// besides a user ID: the triggering task's assignees, its creator, or the group's owners
+var WorkflowAssigneeTargets = []string{"assignees", "creator", "owner"}
+
+// maxWorkflowActions caps how much a single run can do
+const maxWorkflowActions = 10
+
+// Workflow is a group automation stored in the workflows table; Definition is the workflow_data column
+type Workflow struct {
+	ID         string             `json:"id"`
+	GroupID    string             `json:"group_id"`
+	Name       string             `json:"name"`
+	Definition WorkflowDefinition `json:"definition"`
+	Enabled    bool               `json:"enabled"`
+	CreatedBy  string             `json:"created_by"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// WorkflowDefinition reads as "when <trigger>, if every condition holds, run the actions in order"
+type WorkflowDefinition struct {
+	Trigger    WorkflowTrigger     `json:"trigger"`
+	Conditions []WorkflowCondition `json:"conditions"`
+	Actions    []WorkflowAction    `json:"actions"`
+}
+
+// WorkflowTrigger selects the task event. FromStatus and ToStatus narrow task_status_changed;
+// OverdueMinutes is how long past its due date an open task must be for task_overdue.
+type WorkflowTrigger struct {
+	Type           string `json:"type"`
+	FromStatus     string `json:"from_status,omitempty"`
+	ToStatus       string `json:"to_status,omitempty"`
+	OverdueMinutes int    `json:"overdue_minutes,omitempty"`
+}
+
+// WorkflowCondition tests a field of the triggering task. "label" compares label IDs: eq means the task
+// has the label, neq that it does not. "in" takes Values, the other operators take Value.
+type WorkflowCondition struct {
+	Field  string   `json:"field"`
+	Op     string   `json:"op"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+// WorkflowAction is one step of a workflow; only the fields of its type are used:
+//   - create_task: Title, Description, DueInMinutes, AssignTo, Priority, AsSubtask
+//   - post_message: Content, posted to the group chat
+//   - reassign: AssignTo replaces the task's assignees
+//   - set_priority: Priority
+//   - add_label: LabelID
+//
+// Title, Description and Content can reference the triggering task with {{task.title}},
+// {{task.status}}, {{task.priority}} and {{task.due_date}}.
+type WorkflowAction struct {
+	Type         string `json:"type"`
+	Title        string `json:"title,omitempty"`
+	Description  string `json:"description,omitempty"`
+	DueInMinutes int    `json:"due_in_minutes,omitempty"`
+	AssignTo     string `json:"assign_to,omitempty"` // assignees, creator, owner or a user ID
+	Priority     string `json:"priority,omitempty"`
+	AsSubtask    bool   `json:"as_subtask,omitempty"`
+	Content      string `json:"content,omitempty"`
+	LabelID      string `json:"label_id,omitempty"`
+}
+
+// Validate checks the definition's shape. References to group data (labels, users) are checked by the caller.
+func (d *WorkflowDefinition) Validate() error {
+	t := d.Trigger
+	if !slices.Contains(WorkflowTriggerTypes, t.Type) {
+		return errors.New("invalid trigger type. Must be one of: " + strings.Join(WorkflowTriggerTypes, ", "))
+	}
+	for _, status := range []string{t.FromStatus, t.ToStatus} {
+		if status != "" && !slices.Contains(TaskStatuses, status) {
+			return errors.New("invalid trigger status: " + status)
+		}
+	}
+	if (t.FromStatus != "" || t.ToStatus != "") && t.Type != "task_status_changed" {
+		return errors.New("from_status and to_status only apply to task_status_changed triggers")
+	}
+	if t.Type == "task_overdue" {
+		if t.OverdueMinutes < 0 || t.OverdueMinutes > MaxReminderOffset {
+			return fmt.Errorf("overdue_minutes must be between 0 and %d", MaxReminderOffset)
+		}
+	} else if t.OverdueMinutes != 0 {
+		return errors.New("overdue_minutes only applies to task_overdue triggers")
+	}
+
+	for i, c := range d.Conditions {
+		if !slices.Contains(WorkflowConditionFields, c.Field) {
+			return fmt.Errorf("condition %d: invalid field. Must be one of: %s", i+1, strings.Join(WorkflowConditionFields, ", "))
+		}
+		if !slices.Contains(WorkflowConditionOps, c.Op) {
+			return fmt.Errorf("condition %d: invalid op. Must be one of: %s", i+1, strings.Join(WorkflowConditionOps, ", "))
+		}
+		if (c.Op == "contains") != (c.Field == "title") {
+			return fmt.Errorf("condition %d: contains is the only op for title and only applies to title", i+1)
+		}
+		if c.Op == "in" && len(c.Values) == 0 {
+			return fmt.Errorf("condition %d: in needs values", i+1)
+		}
+		if c.Op == "in" && c.Field == "label" {
+			return fmt.Errorf("condition %d: label conditions take eq or neq", i+1)
+		}
+		if c.Op != "in" && c.Value == "" {
+			return fmt.Errorf("condition %d: value is required", i+1)
+		}
+	}
+
+	if len(d.Actions) == 0 {
+		return errors.New("a workflow needs at least one action")
+	}
+	if len(d.Actions) > maxWorkflowActions {
+		return fmt.Errorf("a workflow can have at most %d actions", maxWorkflowActions)
+	}
+	for i, a := range d.Actions {
+		if err := a.validate(); err != nil {
+			return fmt.Errorf("action %d: %s", i+1, err.Error())
+		}
+	}
+
+	if d.Conditions == nil {
+		d.Conditions = []WorkflowCondition{}
+	}
+	return nil
+}
+
+func (a *WorkflowAction) validate() error {
+	switch a.Type {
+	case "create_task":
+		if strings.TrimSpace(a.Title) == "" {
+			return errors.New("create_task needs a title")
+		}
+		if a.DueInMinutes < 0 {
+			return errors.New("due_in_minutes cannot be negative")
+		}
+		if a.Priority != "" && !slices.Contains(TaskPriorities, a.Priority) {
+			return errors.New("invalid priority. Must be one of: " + strings.Join(TaskPriorities, ", "))
+		}
+	case "post_message":
+		if strings.TrimSpace(a.Content) == "" {
+			return errors.New("post_message needs content")
+		}
+	case "reassign":
+		if a.AssignTo == "" {
+			return errors.New("reassign needs assign_to")
+		}
+	case "set_priority":
+		if !slices.Contains(TaskPriorities, a.Priority) {
+			return errors.New("invalid priority. Must be one of: " + strings.Join(TaskPriorities, ", "))
+		}
+	case "add_label":
+		if a.LabelID == "" {
+			return errors.New("add_label needs label_id")
+		}
+	default:
+		return errors.New("invalid type. Must be one of: " + strings.Join(WorkflowActionTypes, ", "))
+	}
+	return nil
+}
+
+// Matches reports whether every condition holds for the task
+func (d *WorkflowDefinition) Matches(task *Task) bool {
+	for _, c := range d.Conditions {
+		var ok bool
+		switch c.Field {
+		case "status":
+			ok = compareWorkflowValue(task.Status, c)
+		case "priority":
+			ok = compareWorkflowValue(task.Priority, c)
+		case "title":
+			ok = strings.Contains(strings.ToLower(task.Title), strings.ToLower(c.Value))
+		case "label":
+			hasLabel := slices.ContainsFunc(task.Labels, func(l TaskLabel) bool { return l.ID == c.Value })
+			ok = hasLabel == (c.Op == "eq")
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func compareWorkflowValue(value string, c WorkflowCondition) bool {
+	switch c.Op {
+	case "eq":
+		return value == c.Value
+	case "neq":
+		return value != c.Value
+	case "in":
+		return slices.Contains(c.Values, value)
+	}
+	return false
+}
+
+// WorkflowRun is an entry of a workflow's execution log
+type WorkflowRun struct {
+	ID          string              `json:"id"`
+	WorkflowID  string              `json:"workflow_id"`
+	TaskID      *string             `json:"task_id,omitempty"`
+	TriggerType string              `json:"trigger_type"`
+	Status      string              `json:"status"` // running, succeeded or failed
+	Actions     []WorkflowRunAction `json:"actions"`
+	Error       *string             `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+}
+
+// WorkflowRunAction records the outcome of one action of a run
+type WorkflowRunAction struct {
+	Type   string `json:"type"`
+	Status string `json:"status"` // succeeded or failed
+	Error  string `json:"error,omitempty"`
+	TaskID string `json:"task_id,omitempty"` // Task created by a create_task action
+}
+
+const workflowColumns = `id, group_id, name, workflow_data, enabled, created_by, created_at, updated_at`
+
+const workflowRunColumns = `id, workflow_id, task_id, trigger_type, status, actions, error, created_at, finished_at`
+
+func scanWorkflow(row pgx.Row, w *Workflow) error {
+	return row.Scan(&w.ID, &w.GroupID, &w.Name, &w.Definition, &w.Enabled, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+}
+
+func scanWorkflowRun(row pgx.Row, r *WorkflowRun) error {
+	return row.Scan(&r.ID, &r.WorkflowID, &r.TaskID, &r.TriggerType, &r.Status, &r.Actions, &r.Error, &r.CreatedAt, &r.FinishedAt)
+}
+
+func (w *Workflow) Save(ctx context.Context) error {
+	query := `INSERT INTO workflows (group_id, name, workflow_data, enabled, created_by)
+	          VALUES ($1, $2, $3, $4, $5)
+	          RETURNING ` + workflowColumns
+
+	err := scanWorkflow(db.GetDB().QueryRow(ctx, query, w.GroupID, w.Name, w.Definition, w.Enabled, w.CreatedBy), w)
+	if err != nil {
+		return errors.New("failed to create workflow: " + err.Error())
+	}
+	return nil
+}
+
+func (w *Workflow) Get(ctx context.Context) error {
+	query := `SELECT ` + workflowColumns + ` FROM workflows WHERE id = $1`
+
+	err := scanWorkflow(db.GetDB().QueryRow(ctx, query, w.ID), w)
+	if err != nil {
+		return errors.New("failed to get workflow: " + err.Error())
+	}
+	return nil
+}
+
+// Update saves the name, definition and enabled flag
+func (w *Workflow) Update(ctx context.Context) error {
+	query := `UPDATE workflows SET name = $1, workflow_data = $2, enabled = $3, updated_at = NOW()
+	          WHERE id = $4
+	          RETURNING ` + workflowColumns
+
+	err := scanWorkflow(db.GetDB().QueryRow(ctx, query, w.Name, w.Definition, w.Enabled, w.ID), w)
+	if err != nil {
+		return errors.New("failed to update workflow: " + err.Error())
+	}
+	return nil
+}
+
+func (w *Workflow) Delete(ctx context.Context) error {
+	_, err := db.GetDB().Exec(ctx, `DELETE FROM workflows WHERE id = $1`, w.ID)
+	if err != nil {
+		return errors.New("failed to delete workflow: " + err.Error())
+	}
+	return nil
+}
+
+// GetGroupWorkflows returns the group's workflows, oldest first
+func GetGroupWorkflows(ctx context.Context, groupID string) ([]Workflow, error) {
+	query := `SELECT ` + workflowColumns + ` FROM workflows WHERE group_id = $1 ORDER BY created_at ASC`
+	return queryWorkflows(ctx, query, groupID)
+}
+
+// GetTriggeredWorkflows returns the enabled workflows with the given trigger type, in every group
+// when groupID is empty
+func GetTriggeredWorkflows(ctx context.Context, groupID string, triggerType string) ([]Workflow, error) {
+	// Containment keeps the lookup on the GIN index of workflow_data
+	trigger := map[string]any{"trigger": map[string]string{"type": triggerType}}
+	query := `SELECT ` + workflowColumns + ` FROM workflows
+	          WHERE enabled AND group_id IS NOT NULL AND workflow_data @> $1 AND ($2 = '' OR group_id::text = $2)
+	          ORDER BY created_at ASC`
+	return queryWorkflows(ctx, query, trigger, groupID)
+}
+
+func queryWorkflows(ctx context.Context, query string, args ...any) ([]Workflow, error) {
+	rows, err := db.GetDB().Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.New("failed to get workflows: " + err.Error())
+	}
+	defer rows.Close()
+
+	workflows := []Workflow{}
+	for rows.Next() {
+		var workflow Workflow
+		if err := scanWorkflow(rows, &workflow); err != nil {
+			return nil, errors.New("failed to scan workflow: " + err.Error())
+		}
+		workflows = append(workflows, workflow)
+	}
+	return workflows, nil
+}
+
+// GetOverdueTasksForWorkflow returns the open tasks of the workflow's group that are more than
+// overdueFor past their due date and that the workflow has not run for yet
+func GetOverdueTasksForWorkflow(ctx context.Context, workflow *Workflow, overdueFor time.Duration) ([]Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks
+	          LEFT JOIN task_series ON tasks.series_id = task_series.id
+	          WHERE tasks.group_id = $1
+	            AND tasks.deleted_at IS NULL
+	            AND tasks.status IN ('pending', 'active')
+	            AND tasks.due_date <= $2
+	            AND NOT EXISTS (
+	                SELECT 1 FROM workflow_runs
+	                WHERE workflow_runs.workflow_id = $3 AND workflow_runs.task_id = tasks.id AND workflow_runs.trigger_type = 'task_overdue'
+	            )
+	          ORDER BY tasks.due_date ASC`
+	return queryTasks(ctx, query, workflow.GroupID, time.Now().Add(-overdueFor), workflow.ID)
+}
+
+// Start records a new run. Overdue runs are recorded once per task: it returns false without an
+// error when the workflow already ran for the task.
+func (r *WorkflowRun) Start(ctx context.Context) (bool, error) {
+	query := `INSERT INTO workflow_runs (workflow_id, task_id, trigger_type)
+	          VALUES ($1, $2, $3)
+	          ON CONFLICT (workflow_id, task_id) WHERE trigger_type = 'task_overdue' DO NOTHING
+	          RETURNING ` + workflowRunColumns
+
+	err := scanWorkflowRun(db.GetDB().QueryRow(ctx, query, r.WorkflowID, r.TaskID, r.TriggerType), r)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, errors.New("failed to start workflow run: " + err.Error())
+	}
+	return true, nil
+}
+
+// Finish stores the outcome of the run's actions; the run failed when runErr is set
+func (r *WorkflowRun) Finish(ctx context.Context, runErr error) error {
+	r.Status = "succeeded"
+	r.Error = nil
+	if runErr != nil {
+		message := runErr.Error()
+		r.Status = "failed"
+		r.Error = &message
+	}
+
+	query := `UPDATE workflow_runs SET status = $1, actions = $2, error = $3, finished_at = NOW()
+	          WHERE id = $4
+	          RETURNING finished_at`
+
+	err := db.GetDB().QueryRow(ctx, query, r.Status, r.Actions, r.Error, r.ID).Scan(&r.FinishedAt)
+	if err != nil {
+		return errors.New("failed to finish workflow run: " + err.Error())
+	}
+	return nil
+}
+
+// GetWorkflowRuns returns a page of the workflow's execution log, newest first
+func GetWorkflowRuns(ctx context.Context, workflowID string, limit int64, offset int64) ([]WorkflowRun, int64, error) {
+	var total int64
+	err := db.GetDB().QueryRow(ctx, `SELECT COUNT(*) FROM workflow_runs WHERE workflow_id = $1`, workflowID).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.New("failed to count workflow runs: " + err.Error())
+	}
+
+	query := `SELECT ` + workflowRunColumns + ` FROM workflow_runs
+	          WHERE workflow_id = $1
+	          ORDER BY created_at DESC
+	          LIMIT $2 OFFSET $3`
+
+	rows, err := db.GetDB().Query(ctx, query, workflowID, limit, offset)
+	if err != nil {
+		return nil, 0, errors.New("failed to get workflow runs: " + err.Error())
+	}
+	defer rows.Close()
+
+	runs := []WorkflowRun{}
+	for rows.Next() {
+		var run WorkflowRun
+		if err := scanWorkflowRun(rows, &run); err != nil {
+			return nil, 0, errors.New("failed to scan workflow run: " + err.Error())
+		}
+		runs = append(runs, run)
+	}
+	return runs, total, nil
+}
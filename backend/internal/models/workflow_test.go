@@ -0,0 +1,125 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWorkflowDefinitionValidate(t *testing.T) {
+	action := WorkflowAction{Type: "post_message", Content: "{{task.title}} changed"}
+	tooMany := make([]WorkflowAction, maxWorkflowActions+1)
+	for i := range tooMany {
+		tooMany[i] = action
+	}
+
+	tests := []struct {
+		name       string
+		definition WorkflowDefinition
+		err        string // Part of the expected error, empty when the definition is valid
+	}{
+		{"minimal", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{action}}, ""},
+		{"status change", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_status_changed", FromStatus: "active", ToStatus: "completed"}, Actions: []WorkflowAction{action}}, ""},
+		{"overdue", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_overdue", OverdueMinutes: 60}, Actions: []WorkflowAction{action}}, ""},
+		{"all conditions", WorkflowDefinition{
+			Trigger: WorkflowTrigger{Type: "task_created"},
+			Conditions: []WorkflowCondition{
+				{Field: "status", Op: "eq", Value: "active"},
+				{Field: "priority", Op: "in", Values: []string{"high", "urgent"}},
+				{Field: "label", Op: "neq", Value: "label-1"},
+				{Field: "title", Op: "contains", Value: "bug"},
+			},
+			Actions: []WorkflowAction{action},
+		}, ""},
+		{"unknown trigger", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_deleted"}, Actions: []WorkflowAction{action}}, "invalid trigger type"},
+		{"unknown trigger status", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_status_changed", ToStatus: "done"}, Actions: []WorkflowAction{action}}, "invalid trigger status"},
+		{"status on another trigger", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created", ToStatus: "active"}, Actions: []WorkflowAction{action}}, "only apply to task_status_changed"},
+		{"negative overdue", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_overdue", OverdueMinutes: -1}, Actions: []WorkflowAction{action}}, "overdue_minutes must be between"},
+		{"overdue too long", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_overdue", OverdueMinutes: MaxReminderOffset + 1}, Actions: []WorkflowAction{action}}, "overdue_minutes must be between"},
+		{"overdue on another trigger", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created", OverdueMinutes: 5}, Actions: []WorkflowAction{action}}, "only applies to task_overdue"},
+		{"unknown field", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "assignee", Op: "eq", Value: "x"}}, Actions: []WorkflowAction{action}}, "condition 1: invalid field"},
+		{"unknown op", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "status", Op: "lt", Value: "x"}}, Actions: []WorkflowAction{action}}, "condition 1: invalid op"},
+		{"contains on status", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "status", Op: "contains", Value: "act"}}, Actions: []WorkflowAction{action}}, "contains is the only op for title"},
+		{"eq on title", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "title", Op: "eq", Value: "bug"}}, Actions: []WorkflowAction{action}}, "contains is the only op for title"},
+		{"in without values", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "status", Op: "in"}}, Actions: []WorkflowAction{action}}, "in needs values"},
+		{"in on labels", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "label", Op: "in", Values: []string{"label-1"}}}, Actions: []WorkflowAction{action}}, "label conditions take eq or neq"},
+		{"missing value", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Conditions: []WorkflowCondition{{Field: "status", Op: "eq"}}, Actions: []WorkflowAction{action}}, "value is required"},
+		{"no actions", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}}, "at least one action"},
+		{"too many actions", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: tooMany}, "at most"},
+		{"unknown action", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "delete_task"}}}, "action 1: invalid type"},
+		{"task without title", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{action, {Type: "create_task", Title: " "}}}, "action 2: create_task needs a title"},
+		{"task due in the past", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "create_task", Title: "Follow up", DueInMinutes: -5}}}, "due_in_minutes cannot be negative"},
+		{"task with unknown priority", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "create_task", Title: "Follow up", Priority: "critical"}}}, "invalid priority"},
+		{"empty message", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "post_message", Content: " "}}}, "post_message needs content"},
+		{"reassign to nobody", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "reassign"}}}, "reassign needs assign_to"},
+		{"priority left out", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "set_priority"}}}, "invalid priority"},
+		{"label left out", WorkflowDefinition{Trigger: WorkflowTrigger{Type: "task_created"}, Actions: []WorkflowAction{{Type: "add_label"}}}, "add_label needs label_id"},
+	}
+	for _, test := range tests {
+		err := test.definition.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %v", test.name, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%s: valid, want error %q", test.name, test.err)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%s: error %q, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWorkflowDefinitionValidateDefaultsConditions(t *testing.T) {
+	definition := WorkflowDefinition{
+		Trigger: WorkflowTrigger{Type: "task_created"},
+		Actions: []WorkflowAction{{Type: "set_priority", Priority: "high"}},
+	}
+	if err := definition.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if definition.Conditions == nil {
+		t.Error("conditions left nil; they are stored as null")
+	}
+}
+
+func TestWorkflowDefinitionMatches(t *testing.T) {
+	task := &Task{
+		Title:    "Fix Login Bug",
+		Status:   "active",
+		Priority: "high",
+		Labels:   []TaskLabel{{ID: "label-1"}, {ID: "label-2"}},
+	}
+
+	tests := []struct {
+		name       string
+		conditions []WorkflowCondition
+		matches    bool
+	}{
+		{"no conditions", nil, true},
+		{"status eq", []WorkflowCondition{{Field: "status", Op: "eq", Value: "active"}}, true},
+		{"status eq other", []WorkflowCondition{{Field: "status", Op: "eq", Value: "pending"}}, false},
+		{"status neq", []WorkflowCondition{{Field: "status", Op: "neq", Value: "pending"}}, true},
+		{"status neq same", []WorkflowCondition{{Field: "status", Op: "neq", Value: "active"}}, false},
+		{"priority in", []WorkflowCondition{{Field: "priority", Op: "in", Values: []string{"high", "urgent"}}}, true},
+		{"priority not in", []WorkflowCondition{{Field: "priority", Op: "in", Values: []string{"low"}}}, false},
+		{"title contains ignoring case", []WorkflowCondition{{Field: "title", Op: "contains", Value: "login bug"}}, true},
+		{"title does not contain", []WorkflowCondition{{Field: "title", Op: "contains", Value: "signup"}}, false},
+		{"has label", []WorkflowCondition{{Field: "label", Op: "eq", Value: "label-2"}}, true},
+		{"lacks label", []WorkflowCondition{{Field: "label", Op: "eq", Value: "label-3"}}, false},
+		{"neq label it has", []WorkflowCondition{{Field: "label", Op: "neq", Value: "label-1"}}, false},
+		{"neq label it lacks", []WorkflowCondition{{Field: "label", Op: "neq", Value: "label-3"}}, true},
+		{"every condition holds", []WorkflowCondition{
+			{Field: "status", Op: "eq", Value: "active"},
+			{Field: "priority", Op: "eq", Value: "high"},
+			{Field: "label", Op: "eq", Value: "label-1"},
+		}, true},
+		{"one condition fails", []WorkflowCondition{
+			{Field: "status", Op: "eq", Value: "active"},
+			{Field: "priority", Op: "eq", Value: "low"},
+		}, false},
+	}
+	for _, test := range tests {
+		definition := WorkflowDefinition{Conditions: test.conditions}
+		if matches := definition.Matches(task); matches != test.matches {
+			t.Errorf("%s: Matches = %v, want %v", test.name, matches, test.matches)
+		}
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHandler *handlers.SignalingHandler) {
+func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHandler *handlers.SignalingHandler, canvasHandler *handlers.CanvasHandler) {
 	// Health check endpoint (no auth required) - for App Platform health checks
 	server.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -20,19 +20,48 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	// App Platform strips /api prefix, so we need routes without /api for production
 	server.POST("/api/auth/register", handlers.RegisterUser)
 	server.POST("/api/auth/login", handlers.Login)
+	server.POST("/api/auth/refresh", handlers.RefreshToken)
+	server.POST("/api/auth/forgot-password", handlers.ForgotPassword)
+	server.POST("/api/auth/reset-password", handlers.ResetPassword)
+	server.POST("/api/auth/verify-email", handlers.VerifyEmail)
+	server.GET("/api/auth/invitations/:token", handlers.GetInvitationByToken)
 	// Also register without /api prefix for App Platform (where prefix is stripped)
 	server.POST("/auth/register", handlers.RegisterUser)
 	server.POST("/auth/login", handlers.Login)
+	server.POST("/auth/refresh", handlers.RefreshToken)
+	server.POST("/auth/forgot-password", handlers.ForgotPassword)
+	server.POST("/auth/reset-password", handlers.ResetPassword)
+	server.POST("/auth/verify-email", handlers.VerifyEmail)
+	server.GET("/auth/invitations/:token", handlers.GetInvitationByToken)
+
+	// Local file storage (presigned URLs carry their own signature, no auth required)
+	server.GET("/api/storage/*key", handlers.GetStoredObject)
+	server.PUT("/api/storage/*key", handlers.PutStoredObject)
 
 	authenticated := server.Group("/api")
 	authenticated.Use(middleware.AuthMiddleware())
 
 	authenticated.GET("/logout", handlers.Logout)
+	authenticated.POST("/logout", handlers.Logout)
+	authenticated.POST("/logout/all", handlers.LogoutAllDevices)
+
+	// Session Routes
+	authenticated.GET("/sessions", handlers.GetSessions)
+	authenticated.DELETE("/sessions/:sessionID", handlers.DeleteSession)
 
 	// User Routes
 	authenticated.GET("/users/me", handlers.GetUser)
 	authenticated.PATCH("/users/me", handlers.UpdateUser)
+	authenticated.POST("/users/me/verify-email", handlers.ResendVerificationEmail)
 	authenticated.GET("/users/from-my-groups", handlers.GetUsersFromMyGroups)
+	authenticated.GET("/users/me/preferences", handlers.GetUserPreferences)
+	authenticated.PATCH("/users/me/preferences", handlers.UpdateUserPreferences)
+
+	// Search across the groups the user belongs to
+	authenticated.GET("/search", handlers.Search)
+
+	// Per-user notification stream (task notifications, invitations, unread counts across all groups)
+	authenticated.GET("/ws/notifications", wsHandler.JoinNotifications)
 
 	// Group Routes
 	authenticated.POST("/groups", handlers.CreateGroup(wsHandler))
@@ -52,7 +81,15 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	authenticatedGroupMember.POST("/ws/rooms/:roomId/messages", handlers.CreateMessage(wsHandler.GetHub()))
 	authenticatedGroupMember.GET("/ws/rooms/:roomId/messages", handlers.GetRoomMessages)
 	authenticatedGroupMember.GET("/ws/rooms/:roomId/messages/:userId", handlers.GetUserRoomMessages)
-	authenticatedGroupMember.DELETE("/ws/messages/:messageId", handlers.DeleteMessage)
+	authenticatedGroupMember.PATCH("/ws/messages/:messageId", handlers.EditMessage(wsHandler.GetHub()))
+	authenticatedGroupMember.DELETE("/ws/messages/:messageId", handlers.DeleteMessage(wsHandler.GetHub()))
+	authenticatedGroupMember.GET("/ws/messages/:messageId/thread", handlers.GetMessageThread)
+	authenticatedGroupMember.POST("/ws/messages/:messageId/reactions", handlers.AddMessageReaction(wsHandler.GetHub()))
+	authenticatedGroupMember.DELETE("/ws/messages/:messageId/reactions/:emoji", handlers.RemoveMessageReaction(wsHandler.GetHub()))
+	authenticatedGroupMember.GET("/ws/reads", handlers.GetRoomReads)
+	authenticatedGroupMember.PUT("/ws/reads", handlers.MarkRoomRead(wsHandler.GetHub()))
+	authenticatedGroupMember.POST("/ws/typing", handlers.SendTyping(wsHandler.GetHub()))
+	authenticatedGroupMember.GET("/presence", handlers.GetGroupPresence(wsHandler.GetHub()))
 
 	authenticatedGroupMember.GET("", handlers.GetGroupByID)
 	authenticatedGroupMember.PATCH("", handlers.UpdateGroup)
@@ -62,6 +99,7 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	authenticatedGroupMember.POST("/members", handlers.AddGroupMember)
 	authenticatedGroupMember.GET("/members", handlers.GetGroupMembers)
 	authenticatedGroupMember.PATCH("members/:userId", handlers.UpdateGroupMemberRole)
+	authenticatedGroupMember.PUT("members/:userId/storage-quota", handlers.UpdateMemberStorageQuota)
 	authenticatedGroupMember.DELETE("members/:userId", handlers.DeleteGroupMember)
 
 	// Group Invitation Routes
@@ -76,18 +114,89 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	authenticatedGroupMember.GET("/tasks/:taskId", handlers.GetTaskByIDWithAssignees)
 	authenticatedGroupMember.PATCH("/tasks/:taskId", handlers.UpdateTask)
 	authenticatedGroupMember.DELETE("/tasks/:taskId", handlers.DeleteTask)
+	authenticatedGroupMember.PUT("/tasks/:taskId/reminders", handlers.UpdateTaskReminders)
+	authenticatedGroupMember.DELETE("/tasks/:taskId/reminders", handlers.ResetTaskReminders)
+	authenticatedGroupMember.GET("/tasks/:taskId/subtasks", handlers.GetSubtasks)
+
+	authenticatedGroupMember.PUT("/tasks/:taskId/labels", handlers.SetTaskLabels)
+	authenticatedGroupMember.PATCH("/tasks/:taskId/custom-fields", handlers.SetTaskCustomFields)
+
+	// Approval Routes
+	authenticatedGroupMember.GET("/approvals", handlers.GetApprovalQueue)
+	authenticatedGroupMember.POST("/approvals/:approvalID/approve", handlers.ApproveTask)
+	authenticatedGroupMember.POST("/approvals/:approvalID/reject", handlers.RejectTask)
+	authenticatedGroupMember.GET("/tasks/:taskId/approvals", handlers.GetTaskApprovals)
+
+	// Workflow Routes
+	authenticatedGroupMember.POST("/workflows", handlers.CreateWorkflow)
+	authenticatedGroupMember.GET("/workflows", handlers.GetWorkflows)
+	authenticatedGroupMember.GET("/workflows/:workflowID", handlers.GetWorkflow)
+	authenticatedGroupMember.PATCH("/workflows/:workflowID", handlers.UpdateWorkflow)
+	authenticatedGroupMember.DELETE("/workflows/:workflowID", handlers.DeleteWorkflow)
+	authenticatedGroupMember.GET("/workflows/:workflowID/runs", handlers.GetWorkflowRuns)
+
+	// Board Routes
+	authenticatedGroupMember.POST("/boards", handlers.CreateBoard)
+	authenticatedGroupMember.GET("/boards", handlers.GetBoards)
+	authenticatedGroupMember.GET("/boards/:boardID", handlers.GetBoard)
+	authenticatedGroupMember.PATCH("/boards/:boardID", handlers.UpdateBoard)
+	authenticatedGroupMember.DELETE("/boards/:boardID", handlers.DeleteBoard)
+	authenticatedGroupMember.POST("/boards/:boardID/columns", handlers.CreateBoardColumn)
+	authenticatedGroupMember.PATCH("/boards/:boardID/columns/:columnID", handlers.UpdateBoardColumn)
+	authenticatedGroupMember.DELETE("/boards/:boardID/columns/:columnID", handlers.DeleteBoardColumn)
+	authenticatedGroupMember.POST("/boards/:boardID/tasks/:taskId/move", handlers.MoveBoardTask(wsHandler.GetHub()))
+
+	// Canvas Routes
+	authenticatedGroupMember.POST("/canvases", canvasHandler.CreateCanvas)
+	authenticatedGroupMember.GET("/canvases", canvasHandler.GetCanvases)
+	authenticatedGroupMember.GET("/canvases/:canvasID", canvasHandler.GetCanvas)
+	authenticatedGroupMember.PATCH("/canvases/:canvasID", canvasHandler.UpdateCanvas)
+	authenticatedGroupMember.DELETE("/canvases/:canvasID", canvasHandler.DeleteCanvas)
+	authenticatedGroupMember.GET("/canvases/:canvasID/ws", canvasHandler.JoinCanvas)
+
+	// Label Routes
+	authenticatedGroupMember.POST("/labels", handlers.CreateLabel)
+	authenticatedGroupMember.GET("/labels", handlers.GetLabels)
+	authenticatedGroupMember.PATCH("/labels/:labelID", handlers.UpdateLabel)
+	authenticatedGroupMember.DELETE("/labels/:labelID", handlers.DeleteLabel)
+
+	// Custom Field Routes
+	authenticatedGroupMember.POST("/custom-fields", handlers.CreateCustomField)
+	authenticatedGroupMember.GET("/custom-fields", handlers.GetCustomFields)
+	authenticatedGroupMember.PATCH("/custom-fields/:fieldID", handlers.UpdateCustomField)
+	authenticatedGroupMember.DELETE("/custom-fields/:fieldID", handlers.DeleteCustomField)
+
+	// Task Dependency Routes
+	authenticatedGroupMember.POST("/tasks/:taskId/dependencies", handlers.AddTaskDependency)
+	authenticatedGroupMember.GET("/tasks/:taskId/dependencies", handlers.GetTaskDependencies)
+	authenticatedGroupMember.DELETE("/tasks/:taskId/dependencies/:dependsOnId", handlers.RemoveTaskDependency)
+
+	// Task Checklist Routes
+	authenticatedGroupMember.POST("/tasks/:taskId/checklist", handlers.CreateChecklistItem)
+	authenticatedGroupMember.GET("/tasks/:taskId/checklist", handlers.GetChecklist)
+	authenticatedGroupMember.PATCH("/tasks/:taskId/checklist/:itemId", handlers.UpdateChecklistItem)
+	authenticatedGroupMember.DELETE("/tasks/:taskId/checklist/:itemId", handlers.DeleteChecklistItem)
 
 	// Task Assignment Routes
 	authenticatedGroupMember.POST("/tasks/:taskId/assign", handlers.AssignTask)
 	authenticatedGroupMember.GET("/tasks/:taskId/assignments", handlers.GetTaskAssignments)
 	authenticatedGroupMember.DELETE("/tasks/:taskId/assignments", handlers.UnassignTask)
 
+	// Task Comment Routes
+	authenticatedGroupMember.POST("/tasks/:taskId/comments", handlers.CreateTaskComment)
+	authenticatedGroupMember.GET("/tasks/:taskId/comments", handlers.GetTaskComments)
+	authenticatedGroupMember.PATCH("/tasks/:taskId/comments/:commentId", handlers.UpdateTaskComment)
+	authenticatedGroupMember.DELETE("/tasks/:taskId/comments/:commentId", handlers.DeleteTaskComment)
+	authenticatedGroupMember.GET("/tasks/:taskId/comments/:commentId/history", handlers.GetTaskCommentHistory)
+	authenticatedGroupMember.GET("/tasks/:taskId/activity", handlers.GetTaskActivity)
+
 	//Task Notification Routes
 	authenticatedGroupMember.POST("/tasks/:taskId/notifications", handlers.CreateTaskNotification)
 	authenticatedGroupMember.GET("/tasks/:taskId/notifications", handlers.GetTaskNotifications)
 
 	// Notification Routes (direct access by ID)
 	authenticated.GET("/notifications", handlers.GetUserNotifications)
+	authenticated.POST("/notifications/read", handlers.MarkAllNotificationsRead)
 	authenticated.PATCH("/notifications/:id", handlers.UpdateNotification)
 	authenticated.DELETE("/notifications/:id", handlers.DeleteNotification)
 
@@ -96,12 +205,26 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	authenticatedGroupMember.GET("/files", handlers.GetFiles)
 	authenticatedGroupMember.GET("/files/:fileID", handlers.GetFileInfo)
 	authenticatedGroupMember.GET("/files/:fileID/download", handlers.GetFileDownloadURL)
+	authenticatedGroupMember.PATCH("/files/:fileID", handlers.UpdateFile)
+	authenticatedGroupMember.POST("/files/:fileID/move", handlers.MoveFile)
 	authenticatedGroupMember.DELETE("/files/:fileID", handlers.DeleteFile)
+	authenticatedGroupMember.POST("/files/uploads", handlers.CreateUploadSession)
+	authenticatedGroupMember.GET("/files/uploads/:uploadID", handlers.GetUploadSession)
+	authenticatedGroupMember.POST("/files/uploads/:uploadID/complete", handlers.CompleteUploadSession)
+	authenticatedGroupMember.DELETE("/files/uploads/:uploadID", handlers.AbortUploadSession)
+	authenticatedGroupMember.POST("/files/:fileID/versions", handlers.UploadFileVersion)
+	authenticatedGroupMember.GET("/files/:fileID/versions", handlers.GetFileVersions)
+	authenticatedGroupMember.GET("/files/:fileID/versions/:version/download", handlers.GetFileVersionDownloadURL)
+	authenticatedGroupMember.POST("/files/:fileID/versions/:version/restore", handlers.RestoreFileVersion)
+	authenticatedGroupMember.GET("/storage", handlers.GetStorageUsage)
+	authenticatedGroupMember.PUT("/storage/quota", handlers.UpdateGroupStorageQuota)
 
 	// Folder Routes
 	authenticatedGroupMember.POST("/folders", handlers.CreateFolder)
 	authenticatedGroupMember.GET("/folders", handlers.GetFolders)
 	authenticatedGroupMember.GET("/folders/:folderID", handlers.GetFolder)
+	authenticatedGroupMember.PATCH("/folders/:folderID", handlers.UpdateFolder)
+	authenticatedGroupMember.POST("/folders/:folderID/move", handlers.MoveFolder)
 	authenticatedGroupMember.DELETE("/folders/:folderID", handlers.DeleteFolder)
 
 	// Link Routes
@@ -109,4 +232,9 @@ func SetupRoutes(server *gin.Engine, wsHandler *handlers.WShandler, signalingHan
 	authenticatedGroupMember.GET("/links", handlers.GetLinks)
 	authenticatedGroupMember.GET("/links/:linkID", handlers.GetLink)
 	authenticatedGroupMember.DELETE("/links/:linkID", handlers.DeleteLink)
+	authenticatedGroupMember.POST("/links/:linkID/preview", handlers.RefreshLinkPreview)
+
+	// Trash Routes
+	authenticatedGroupMember.GET("/trash", handlers.GetTrash)
+	authenticatedGroupMember.POST("/trash/:type/:id/restore", handlers.RestoreTrashItem)
 }
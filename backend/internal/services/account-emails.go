@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// Purposes of emailed account tokens; they double as the user_tokens.purpose values
+const (
+	PasswordResetTokenPurpose     = "password_reset"
+	EmailVerificationTokenPurpose = "email_verification"
+)
+
+// SendVerificationEmail emails the user a link that confirms they own their current address
+func SendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := issueUserToken(ctx, user, EmailVerificationTokenPurpose, models.EmailVerificationTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", utils.AppURL(), url.QueryEscape(token.raw))
+	subject, body := utils.BuildEmailVerificationContent(user.Name, verifyURL, token.ExpiresAt)
+
+	return SendEmail(user.Email, user.Name, subject, body)
+}
+
+// SendPasswordResetEmail emails the user a single-use link to choose a new password
+func SendPasswordResetEmail(ctx context.Context, user *models.User) error {
+	token, err := issueUserToken(ctx, user, PasswordResetTokenPurpose, models.PasswordResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", utils.AppURL(), url.QueryEscape(token.raw))
+	subject, body := utils.BuildPasswordResetContent(user.Name, resetURL, token.ExpiresAt)
+
+	return SendEmail(user.Email, user.Name, subject, body)
+}
+
+// issuedToken is a stored user token together with the raw value that goes into the link
+type issuedToken struct {
+	models.UserToken
+	raw string
+}
+
+func issueUserToken(ctx context.Context, user *models.User, purpose string, ttl time.Duration) (*issuedToken, error) {
+	raw, tokenHash, err := utils.GenerateSignedToken(purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &issuedToken{
+		UserToken: models.UserToken{
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: tokenHash,
+			Email:     user.Email,
+			ExpiresAt: time.Now().Add(ttl),
+		},
+		raw: raw,
+	}
+
+	if err := token.Save(ctx); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// ConsumeAccountToken verifies an emailed token for the purpose and marks it used
+func ConsumeAccountToken(ctx context.Context, purpose string, raw string) (*models.UserToken, error) {
+	tokenHash, err := utils.VerifySignedToken(purpose, raw)
+	if err != nil {
+		return nil, fmt.Errorf("link is invalid or has expired")
+	}
+
+	return models.ConsumeUserToken(ctx, purpose, tokenHash)
+}
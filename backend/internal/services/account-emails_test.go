@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+type sentEmail struct {
+	to      string
+	name    string
+	subject string
+	body    string
+}
+
+// fakeEmailSender records emails instead of sending them
+type fakeEmailSender struct {
+	sent []sentEmail
+}
+
+func (f *fakeEmailSender) SendNotificationEmail(toEmail string, toName string, subject, content string) error {
+	f.sent = append(f.sent, sentEmail{to: toEmail, name: toName, subject: subject, body: content})
+	return nil
+}
+
+func TestSendEmailUsesEmailChannel(t *testing.T) {
+	sender := &fakeEmailSender{}
+	RegisterNotifier(NewEmailNotifier(sender))
+
+	if err := SendEmail("ada@example.com", "Ada", "Hello", "Body"); err != nil {
+		t.Fatalf("SendEmail: %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].to != "ada@example.com" || sender.sent[0].subject != "Hello" {
+		t.Fatalf("unexpected emails %+v", sender.sent)
+	}
+}
+
+// Tokens are checked against their signature before the database is consulted, so these run
+// without one
+func TestConsumeAccountTokenRejectsOtherPurposes(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	verification, _, err := utils.GenerateSignedToken(EmailVerificationTokenPurpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConsumeAccountToken(context.Background(), PasswordResetTokenPurpose, verification); err == nil {
+		t.Fatal("a verification token was accepted as a password reset token")
+	}
+}
+
+func TestConsumeAccountTokenRejectsTamperedTokens(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	reset, _, err := utils.GenerateSignedToken(PasswordResetTokenPurpose)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, token := range []string{"", "garbage", "x" + reset, reset + "x"} {
+		if _, err := ConsumeAccountToken(context.Background(), PasswordResetTokenPurpose, token); err == nil {
+			t.Errorf("token %q was accepted", token)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// InvitationTokenPurpose scopes signed invitation tokens so they can't be used as other tokens
+const InvitationTokenPurpose = "group_invitation"
+
+// SendInvitationEmail emails the invitee a link carrying the single-use accept token
+func SendInvitationEmail(ctx context.Context, invitation *models.GroupInvitation, token string) error {
+	group, err := models.GetGroupByID(invitation.GroupID)
+	if err != nil {
+		return fmt.Errorf("failed to load group %s: %w", invitation.GroupID, err)
+	}
+
+	inviter := &models.User{ID: invitation.InviterID}
+	if err := inviter.Get(); err != nil {
+		return fmt.Errorf("failed to load inviter %s: %w", invitation.InviterID, err)
+	}
+
+	expiresAt := time.Now().Add(models.InvitationTTL)
+	if invitation.ExpiresAt != nil {
+		expiresAt = *invitation.ExpiresAt
+	}
+
+	acceptURL := fmt.Sprintf("%s/invitations/accept?token=%s", utils.AppURL(), url.QueryEscape(token))
+	subject, body := utils.BuildInvitationContent(inviter.Name, group.Name, invitation.Role, acceptURL, expiresAt)
+
+	return SendEmail(invitation.InviteeEmail, "", subject, body)
+}
+
+// ExpireStaleInvitations moves pending invitations past their expiry to the expired status
+func ExpireStaleInvitations(ctx context.Context) error {
+	expired, err := models.ExpireInvitations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if expired > 0 {
+		log.Printf("Expired %d group invitations", expired)
+	}
+
+	return nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+)
+
+// Limits on what fetching a link preview downloads
+const (
+	linkFetchTimeout      = 10 * time.Second
+	maxLinkPageSize       = 1 << 20
+	maxOEmbedSize         = 64 << 10
+	maxPreviewImageSize   = 5 << 20
+	maxPreviewTitleLength = 300
+	maxPreviewTextLength  = 1000
+	linkPreviewUserAgent  = "Remindly-LinkPreview/1.0"
+)
+
+// previewImageExtensions are the image types kept as preview images, as sniffed from their content
+var previewImageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// LinkPreview is the metadata found for a URL
+type LinkPreview struct {
+	Title       string
+	Description string
+	ImageURL    string
+}
+
+// LinkUnfurler fetches link previews. The client must refuse non-public addresses when URLs come
+// from users (see utils.NewSafeHTTPClient); preview images are copied to storage when it is set.
+type LinkUnfurler struct {
+	client  *http.Client
+	storage utils.BlobStore
+}
+
+func NewLinkUnfurler(client *http.Client, storage utils.BlobStore) *LinkUnfurler {
+	return &LinkUnfurler{client: client, storage: storage}
+}
+
+var linkUnfurler = NewLinkUnfurler(utils.NewSafeHTTPClient(linkFetchTimeout), nil)
+
+// InitLinkPreviews makes link previews copy their images to storage
+func InitLinkPreviews(storage utils.BlobStore) {
+	linkUnfurler = NewLinkUnfurler(utils.NewSafeHTTPClient(linkFetchTimeout), storage)
+}
+
+// UnfurlLink fetches the link's preview and saves it on the link
+func UnfurlLink(ctx context.Context, link *models.Link, overwrite bool) error {
+	return linkUnfurler.Unfurl(ctx, link, overwrite)
+}
+
+// Unfurl fetches the link's preview and saves it. Without overwrite only the fields the link does
+// not have yet are filled, so what the user typed is kept; a title or description changed while
+// the preview was fetched is never overwritten. A failed fetch is saved as the link's
+// preview error and returned.
+func (u *LinkUnfurler) Unfurl(ctx context.Context, link *models.Link, overwrite bool) error {
+	loadedTitle, loadedDescription := link.Title, link.Description
+
+	preview, fetchErr := u.Fetch(ctx, link.URL)
+	if fetchErr != nil {
+		reason := fetchErr.Error()
+		link.PreviewStatus = "failed"
+		link.PreviewError = &reason
+		if err := link.SavePreview(ctx, loadedTitle, loadedDescription); err != nil {
+			return err
+		}
+		return fetchErr
+	}
+
+	link.PreviewStatus = "ready"
+	link.PreviewError = nil
+	setPreviewField(&link.Title, preview.Title, overwrite)
+	setPreviewField(&link.Description, preview.Description, overwrite)
+
+	previousKey := link.PreviewImageS3Key
+	if preview.ImageURL != "" && (overwrite || link.PreviewImageURL == nil) {
+		link.PreviewImageURL = &preview.ImageURL
+		link.PreviewImageS3Key = nil
+
+		if u.storage != nil && link.GroupID != nil {
+			key, err := u.storeImage(ctx, *link.GroupID, link.ID, preview.ImageURL)
+			if err != nil {
+				// The remote image URL is still usable
+				log.Printf("Error storing preview image of link %s: %v", link.ID, err)
+			} else {
+				link.PreviewImageS3Key = &key
+			}
+		}
+	}
+
+	if err := link.SavePreview(ctx, loadedTitle, loadedDescription); err != nil {
+		return err
+	}
+
+	if previousKey != nil && u.storage != nil && (link.PreviewImageS3Key == nil || *link.PreviewImageS3Key != *previousKey) {
+		if err := u.storage.Delete(ctx, *previousKey); err != nil {
+			log.Printf("Error deleting previous preview image %s: %v", *previousKey, err)
+		}
+	}
+
+	return nil
+}
+
+// Fetch downloads the page and reads its OpenGraph, Twitter card and oEmbed metadata, falling back
+// to <title> and the description meta tag. A URL pointing straight at an image is its own preview.
+func (u *LinkUnfurler) Fetch(ctx context.Context, rawURL string) (*LinkPreview, error) {
+	pageURL, err := utils.ValidateOutboundURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.get(ctx, pageURL.String(), "text/html,application/xhtml+xml;q=0.9,image/*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	finalURL := resp.Request.URL
+	switch contentType := mediaType(resp.Header); {
+	case strings.HasPrefix(contentType, "image/"):
+		return &LinkPreview{ImageURL: finalURL.String()}, nil
+	case contentType != "" && contentType != "text/html" && contentType != "application/xhtml+xml":
+		return nil, fmt.Errorf("no preview for content type %s", contentType)
+	}
+
+	preview, oEmbedURL := parseLinkPage(io.LimitReader(resp.Body, maxLinkPageSize), finalURL)
+	if oEmbedURL != "" && (preview.Title == "" || preview.ImageURL == "") {
+		if err := u.fillFromOEmbed(ctx, preview, oEmbedURL); err != nil {
+			log.Printf("Error fetching oEmbed metadata for %s: %v", finalURL, err)
+		}
+	}
+
+	return preview, nil
+}
+
+// fillFromOEmbed completes the preview with the page's oEmbed endpoint
+func (u *LinkUnfurler) fillFromOEmbed(ctx context.Context, preview *LinkPreview, oEmbedURL string) error {
+	if _, err := utils.ValidateOutboundURL(oEmbedURL); err != nil {
+		return err
+	}
+
+	resp, err := u.get(ctx, oEmbedURL, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var oEmbed struct {
+		Type         string `json:"type"`
+		Title        string `json:"title"`
+		AuthorName   string `json:"author_name"`
+		ThumbnailURL string `json:"thumbnail_url"`
+		URL          string `json:"url"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOEmbedSize)).Decode(&oEmbed); err != nil {
+		return errors.New("invalid oEmbed response: " + err.Error())
+	}
+
+	if preview.Title == "" {
+		preview.Title = cleanPreviewText(oEmbed.Title, maxPreviewTitleLength)
+	}
+	if preview.Description == "" && oEmbed.AuthorName != "" {
+		preview.Description = cleanPreviewText("by "+oEmbed.AuthorName, maxPreviewTextLength)
+	}
+	if preview.ImageURL == "" {
+		base, _ := url.Parse(oEmbedURL)
+		preview.ImageURL = resolvePreviewURL(base, oEmbed.ThumbnailURL)
+		if preview.ImageURL == "" && oEmbed.Type == "photo" {
+			preview.ImageURL = resolvePreviewURL(base, oEmbed.URL)
+		}
+	}
+	return nil
+}
+
+// storeImage copies the preview image to S3 and returns its key. Only actual JPEG, PNG, GIF and
+// WebP content is kept, whatever the remote server claims.
+func (u *LinkUnfurler) storeImage(ctx context.Context, groupID string, linkID string, imageURL string) (string, error) {
+	if _, err := utils.ValidateOutboundURL(imageURL); err != nil {
+		return "", err
+	}
+
+	resp, err := u.get(ctx, imageURL, "image/*")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPreviewImageSize+1))
+	if err != nil {
+		return "", errors.New("failed to read preview image: " + err.Error())
+	}
+	if len(data) > maxPreviewImageSize {
+		return "", fmt.Errorf("preview image is larger than %d bytes", maxPreviewImageSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := previewImageExtensions[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported preview image type %s", contentType)
+	}
+
+	key := fmt.Sprintf("link-previews/%s/%s/%s%s", groupID, linkID, uuid.New().String(), extension)
+	if err := u.storage.Put(ctx, key, bytes.NewReader(data), contentType); err != nil {
+		return "", errors.New("failed to upload preview image: " + err.Error())
+	}
+	return key, nil
+}
+
+// get sends a GET request and fails on error statuses
+func (u *LinkUnfurler) get(ctx context.Context, target string, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", linkPreviewUserAgent)
+	req.Header.Set("Accept", accept)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", target, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s returned status %d", target, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// parseLinkPage reads the metadata in the page's head and returns the preview and the page's
+// oEmbed endpoint, if it advertises one
+func parseLinkPage(body io.Reader, pageURL *url.URL) (*LinkPreview, string) {
+	meta := map[string]string{}
+	title := ""
+	oEmbedURL := ""
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+parse:
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			break parse
+
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(tokenizer.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				break parse
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttributes := tokenizer.TagName()
+			attributes := map[string]string{}
+			for hasAttributes {
+				var key, value []byte
+				key, value, hasAttributes = tokenizer.TagAttr()
+				attributes[strings.ToLower(string(key))] = string(value)
+			}
+
+			switch string(name) {
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "body":
+				break parse
+			case "meta":
+				key := attributes["property"]
+				if key == "" {
+					key = attributes["name"]
+				}
+				key = strings.ToLower(key)
+				if _, seen := meta[key]; key != "" && !seen {
+					meta[key] = attributes["content"]
+				}
+			case "link":
+				if oEmbedURL == "" && strings.EqualFold(attributes["type"], "application/json+oembed") {
+					oEmbedURL = resolvePreviewURL(pageURL, attributes["href"])
+				}
+			}
+		}
+	}
+
+	preview := &LinkPreview{
+		Title:       cleanPreviewText(firstNonEmpty(meta["og:title"], meta["twitter:title"], title), maxPreviewTitleLength),
+		Description: cleanPreviewText(firstNonEmpty(meta["og:description"], meta["twitter:description"], meta["description"]), maxPreviewTextLength),
+		ImageURL: resolvePreviewURL(pageURL, firstNonEmpty(
+			meta["og:image:secure_url"], meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"],
+		)),
+	}
+	return preview, oEmbedURL
+}
+
+// resolvePreviewURL resolves a possibly relative URL found in a page, keeping only http(s) results
+func resolvePreviewURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	return resolved.String()
+}
+
+// cleanPreviewText collapses whitespace and truncates to maxLength characters
+func cleanPreviewText(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:maxLength-1])) + "…"
+}
+
+func setPreviewField(field **string, value string, overwrite bool) {
+	if value == "" || (*field != nil && **field != "" && !overwrite) {
+		return
+	}
+	*field = &value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func mediaType(header http.Header) string {
+	contentType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(contentType)
+}
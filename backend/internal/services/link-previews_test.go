@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// testUnfurler reaches httptest servers, which the safe client used in production refuses
+func testUnfurler() *LinkUnfurler {
+	return NewLinkUnfurler(&http.Client{Timeout: 5 * time.Second}, nil)
+}
+
+func servePage(contentType string, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		fmt.Fprint(w, body)
+	}))
+}
+
+func TestFetchReadsOpenGraphTags(t *testing.T) {
+	server := servePage("text/html; charset=utf-8", `<html><head>
+		<title>Page title</title>
+		<meta property="og:title" content="  OG   title ">
+		<meta property="og:description" content="OG description">
+		<meta name="description" content="Plain description">
+		<meta property="og:image" content="/images/cover.png">
+		</head><body><meta property="og:title" content="Not in head"></body></html>`)
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "OG title" {
+		t.Errorf("Title = %q, want %q", preview.Title, "OG title")
+	}
+	if preview.Description != "OG description" {
+		t.Errorf("Description = %q, want %q", preview.Description, "OG description")
+	}
+	if want := server.URL + "/images/cover.png"; preview.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", preview.ImageURL, want)
+	}
+}
+
+func TestFetchFallsBackToTitleAndDescription(t *testing.T) {
+	server := servePage("text/html", `<html><head>
+		<title>Page title</title>
+		<meta name="description" content="Plain description">
+		<meta name="twitter:image" content="javascript:alert(1)">
+		</head></html>`)
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "Page title" || preview.Description != "Plain description" {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+	if preview.ImageURL != "" {
+		t.Errorf("ImageURL = %q, want non-http URLs dropped", preview.ImageURL)
+	}
+}
+
+func TestFetchTruncatesLongText(t *testing.T) {
+	server := servePage("text/html", `<html><head><title>`+strings.Repeat("a", 2*maxPreviewTitleLength)+`</title></head></html>`)
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if got := len([]rune(preview.Title)); got != maxPreviewTitleLength {
+		t.Errorf("title is %d characters, want %d", got, maxPreviewTitleLength)
+	}
+}
+
+func TestFetchStopsAtPageSizeLimit(t *testing.T) {
+	padding := "<!--" + strings.Repeat("x", maxLinkPageSize) + "-->"
+	server := servePage("text/html", `<html><head>`+padding+`<title>Too far</title></head></html>`)
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "" {
+		t.Errorf("Title = %q, want nothing read past %d bytes", preview.Title, maxLinkPageSize)
+	}
+}
+
+func TestFetchRefusesNonHTML(t *testing.T) {
+	server := servePage("application/pdf", "%PDF-1.4")
+	defer server.Close()
+
+	if _, err := testUnfurler().Fetch(context.Background(), server.URL); err == nil {
+		t.Fatal("Fetch returned a preview for a PDF")
+	}
+}
+
+func TestFetchImageIsItsOwnPreview(t *testing.T) {
+	server := servePage("image/png", "\x89PNG\r\n\x1a\n")
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL+"/photo.png")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if want := server.URL + "/photo.png"; preview.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", preview.ImageURL, want)
+	}
+}
+
+func TestFetchUsesOEmbed(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oembed" {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"type": "video", "title": "Video title", "author_name": "Ada", "thumbnail_url": "/thumb.jpg"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><link rel="alternate" type="application/json+oembed" href="%s/oembed"></head></html>`, server.URL)
+	}))
+	defer server.Close()
+
+	preview, err := testUnfurler().Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if preview.Title != "Video title" || preview.Description != "by Ada" || preview.ImageURL != server.URL+"/thumb.jpg" {
+		t.Errorf("unexpected preview %+v", preview)
+	}
+}
+
+func TestFetchErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	if _, err := testUnfurler().Fetch(context.Background(), server.URL); err == nil {
+		t.Fatal("Fetch succeeded on a 404")
+	}
+}
+
+func TestFetchRefusesPrivateAddresses(t *testing.T) {
+	server := servePage("text/html", "<title>Internal</title>")
+	defer server.Close()
+
+	unfurler := NewLinkUnfurler(utils.NewSafeHTTPClient(5*time.Second), nil)
+	if _, err := unfurler.Fetch(context.Background(), server.URL); !errors.Is(err, utils.ErrNonPublicAddress) {
+		t.Fatalf("Fetch %s: got %v, want ErrNonPublicAddress", server.URL, err)
+	}
+}
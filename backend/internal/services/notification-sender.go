@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -11,12 +12,20 @@ import (
 	"github.com/KoiralaSam/Remindly/backend/internal/utils"
 )
 
-func SendPendingNotifications(ctx context.Context) error {
-	emailService := utils.GetEmailService()
-	if emailService == nil {
-		return fmt.Errorf("email service not initialized")
-	}
+const (
+	// maxNotificationAttempts is how often delivery of a notification is tried before it counts as failed
+	maxNotificationAttempts = 3
+	// notificationRetryDelay is the wait before the first retry; it doubles with every further attempt
+	notificationRetryDelay = time.Minute
+)
 
+// retryDelay is how long a notification waits after its retryCount-th failed attempt
+func retryDelay(retryCount int) time.Duration {
+	return notificationRetryDelay << retryCount
+}
+
+// SendPendingNotifications delivers every pending notification that is due
+func SendPendingNotifications(ctx context.Context) error {
 	// Get all pending notifications scheduled for now or earlier
 	now := time.Now()
 	notifications, err := models.GetPendingNotifications(ctx, now)
@@ -26,104 +35,198 @@ func SendPendingNotifications(ctx context.Context) error {
 
 	log.Printf("Found %d pending notifications to send", len(notifications))
 
-	for _, notification := range notifications {
-		// Skip if max retries exceeded
-		if notification.RetryCount >= 3 {
-			log.Printf("Skipping notification %s: max retries exceeded", notification.ID)
-			notification.Status = "failed"
-			notification.Update(ctx)
-			continue
+	for i := range notifications {
+		DeliverNotification(ctx, &notifications[i])
+	}
+
+	return nil
+}
+
+// DeliverNotification sends one pending notification over the channels the user picked for its
+// type. It counts as sent once at least one channel delivered it. Handlers call this right after
+// creating a notification so it arrives immediately instead of on the next scheduler run.
+// Deliveries that fail for a reason that may pass (a timeout, a 5xx) are not retried here but put
+// back as pending with a backoff, so one slow channel never holds up the caller or the scheduler.
+func DeliverNotification(ctx context.Context, notification *models.TaskNotification) {
+	// Skip if max retries exceeded
+	if notification.RetryCount >= maxNotificationAttempts {
+		log.Printf("Skipping notification %s: max retries exceeded", notification.ID)
+		notification.Status = "failed"
+		notification.Update(ctx)
+		return
+	}
+
+	// Get task details
+	task, err := models.GetTaskByID(ctx, notification.TaskID)
+	if err != nil {
+		log.Printf("Error fetching task %s: %v", notification.TaskID, err)
+		notification.MarkAsFailed(ctx)
+		return
+	}
+
+	// Get user details
+	user := &models.User{ID: notification.UserID}
+	err = user.Get()
+	if err != nil {
+		log.Printf("Error fetching user %s: %v", notification.UserID, err)
+		notification.MarkAsFailed(ctx)
+		return
+	}
+
+	preferences := &models.UserPreferences{UserID: notification.UserID}
+	err = preferences.Get(ctx)
+	if err != nil {
+		log.Printf("Error fetching preferences for user %s: %v", notification.UserID, err)
+		notification.MarkAsFailed(ctx)
+		return
+	}
+
+	// The user muted this notification type
+	channels := preferences.ChannelsFor(notification.NotificationType)
+	if len(channels) == 0 {
+		notification.Status = "cancelled"
+		if err := notification.Update(ctx); err != nil {
+			log.Printf("Error cancelling muted notification %s: %v", notification.ID, err)
 		}
+		return
+	}
 
-		// Get task details
-		task, err := models.GetTaskByID(ctx, notification.TaskID)
-		if err != nil {
-			log.Printf("Error fetching task %s: %v", notification.TaskID, err)
-			notification.MarkAsFailed(ctx)
+	// Another worker is already delivering this notification
+	claimed, err := notification.Claim(ctx)
+	if err != nil {
+		log.Printf("Error claiming notification %s: %v", notification.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	// Build content with full task data
+	taskData := utils.NotificationTaskData{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Status:      task.Status,
+		GroupID:     task.GroupID,
+	}
+	subject, body := utils.BuildNotificationContent(
+		notification.NotificationType,
+		taskData,
+		user.Name,
+	)
+
+	message := &NotificationMessage{
+		Notification: *notification,
+		Task:         task,
+		User:         user,
+		Preferences:  preferences,
+		Subject:      subject,
+		Body:         body,
+	}
+
+	delivered := 0
+	retryable := false
+	for _, channel := range channels {
+		notifier, ok := GetNotifier(channel)
+		if !ok {
+			log.Printf("Skipping %s delivery of notification %s: channel not configured", channel, notification.ID)
 			continue
 		}
 
-		// Get user details
-		user := &models.User{ID: notification.UserID}
-		err = user.Get()
-		if err != nil {
-			log.Printf("Error fetching user %s: %v", notification.UserID, err)
-			notification.MarkAsFailed(ctx)
+		if err := notifier.Send(ctx, message); err != nil {
+			log.Printf("Failed to send notification %s over %s: %v", notification.ID, channel, err)
+			if !errors.Is(err, errPermanentFailure) {
+				retryable = true
+			}
 			continue
 		}
+		delivered++
+	}
 
-		// Build email content with full task data
-		taskData := utils.NotificationTaskData{
-			ID:          task.ID,
-			Title:       task.Title,
-			Description: task.Description,
-			DueDate:     task.DueDate,
-			Status:      task.Status,
-			GroupID:     task.GroupID,
-		}
-		subject, body := emailService.BuildNotificationContent(
-			notification.NotificationType,
-			taskData,
-			user.Name,
-		)
-
-		// Send email
-		err = emailService.SendNotificationEmail(
-			user.Email,
-			user.Name,
-			subject,
-			body,
-		)
-
-		// Update notification status
-		if err != nil {
-			log.Printf("Failed to send notification %s: %v", notification.ID, err)
-			notification.MarkAsFailed(ctx)
-		} else {
-			log.Printf("Successfully sent notification %s to %s", notification.ID, user.Email)
-			notification.MarkAsSent(ctx)
+	// Update notification status
+	if delivered == 0 {
+		if retryable && notification.RetryCount+1 < maxNotificationAttempts {
+			retryAt := time.Now().Add(retryDelay(notification.RetryCount))
+			log.Printf("Failed to send notification %s on any channel, retrying at %v", notification.ID, retryAt)
+			if err := notification.ScheduleRetry(ctx, retryAt); err != nil {
+				log.Printf("Error scheduling retry of notification %s: %v", notification.ID, err)
+			}
+			return
 		}
+		log.Printf("Failed to send notification %s on any channel", notification.ID)
+		notification.MarkAsFailed(ctx)
+		return
 	}
 
-	return nil
+	if err := notification.MarkAsSent(ctx); err != nil {
+		log.Printf("Error marking notification %s as sent: %v", notification.ID, err)
+		return
+	}
+	log.Printf("Successfully sent notification %s to user %s over %d channel(s)", notification.ID, user.ID, delivered)
+}
+
+// notifyTaskUser creates a notification of the given type for one user and delivers it right away
+func notifyTaskUser(ctx context.Context, taskID string, userID string, notificationType string) {
+	notification := models.TaskNotification{
+		TaskID:           taskID,
+		UserID:           userID,
+		NotificationType: notificationType,
+		ScheduledAt:      time.Now(),
+		Status:           "pending",
+	}
+	if err := notification.Save(ctx); err != nil {
+		log.Printf("Error creating %s notification for task %s, user %s: %v", notificationType, taskID, userID, err)
+		return
+	}
+	DeliverNotification(ctx, &notification)
 }
 
 // CheckDueDatesAndReminders checks for tasks with due dates coming up and creates pending notifications
 func CheckDueDatesAndReminders(ctx context.Context) error {
 	now := time.Now()
 
-	// Query for tasks that need reminder notifications (24 hours before due date)
-	// Tasks where due_date is between now+23 hours and now+25 hours (to account for cron running every minute)
-	reminderStart := now.Add(23 * time.Hour)
-	reminderEnd := now.Add(25 * time.Hour)
-
-	reminderQuery := `
-		SELECT DISTINCT t.id, t.due_date
-		FROM tasks t
-		JOIN task_assignments ta ON t.id = ta.task_id
-		WHERE t.due_date >= $1 AND t.due_date <= $2
-		  AND t.status NOT IN ('completed', 'cancelled')
-	`
+	// Drop pending reminders whose task, due date or rules changed since they were created
+	cancelled, err := models.CancelStaleReminders(ctx)
+	if err != nil {
+		log.Printf("Error cancelling stale reminders: %v", err)
+	} else if cancelled > 0 {
+		log.Printf("Cancelled %d stale reminder notifications", cancelled)
+	}
 
-	reminderRows, err := db.GetDB().Query(ctx, reminderQuery, reminderStart, reminderEnd)
+	// Expand every reminder rule (task override, else assignee preferences, else 24 hours before) and
+	// create the ones scheduled within the next hour. Reminders whose time passed while the job was not
+	// running are skipped, like before.
+	candidates, err := models.GetReminderCandidates(ctx, now.Add(-1*time.Minute), now.Add(time.Hour))
 	if err != nil {
 		return fmt.Errorf("failed to query reminder tasks: %w", err)
 	}
-	defer reminderRows.Close()
 
-	type TaskDueInfo struct {
-		TaskID  string
-		DueDate time.Time
-	}
+	for _, candidate := range candidates {
+		offset := candidate.Offset
+		notification := models.TaskNotification{
+			TaskID:           candidate.TaskID,
+			UserID:           candidate.UserID,
+			NotificationType: "reminder",
+			ScheduledAt:      candidate.ScheduledAt,
+			Status:           "pending",
+			ReminderOffset:   &offset,
+		}
 
-	var reminderTasks []TaskDueInfo
-	for reminderRows.Next() {
-		var taskInfo TaskDueInfo
-		if err := reminderRows.Scan(&taskInfo.TaskID, &taskInfo.DueDate); err != nil {
-			log.Printf("Error scanning reminder task: %v", err)
+		// De-duplicated per rule: the same offset for the same due date is only created once
+		created, err := notification.SaveReminder(ctx)
+		if err != nil {
+			log.Printf("Error creating reminder notification for task %s, user %s: %v", candidate.TaskID, candidate.UserID, err)
 			continue
 		}
-		reminderTasks = append(reminderTasks, taskInfo)
+		if created {
+			log.Printf("Created reminder notification for task %s, user %s (%d minutes before), scheduled for %v", candidate.TaskID, candidate.UserID, offset, candidate.ScheduledAt)
+		}
+	}
+
+	type TaskDueInfo struct {
+		TaskID  string
+		DueDate time.Time
 	}
 
 	// Query for tasks that need due_date notifications (on the due date)
@@ -137,6 +240,7 @@ func CheckDueDatesAndReminders(ctx context.Context) error {
 		JOIN task_assignments ta ON t.id = ta.task_id
 		WHERE t.due_date >= $1 AND t.due_date < $2
 		  AND t.status NOT IN ('completed', 'cancelled')
+		  AND t.deleted_at IS NULL
 	`
 
 	dueDateRows, err := db.GetDB().Query(ctx, dueDateQuery, startOfDay, endOfDay)
@@ -155,50 +259,6 @@ func CheckDueDatesAndReminders(ctx context.Context) error {
 		dueDateTasks = append(dueDateTasks, taskInfo)
 	}
 
-	// Create reminder notifications (scheduled for 24 hours before due date)
-	for _, taskInfo := range reminderTasks {
-		// Get all assignees for this task
-		assignments, err := models.GetTaskAssignments(ctx, taskInfo.TaskID)
-		if err != nil {
-			log.Printf("Error getting assignments for task %s: %v", taskInfo.TaskID, err)
-			continue
-		}
-
-		// Schedule reminder for 24 hours before due date
-		reminderTime := taskInfo.DueDate.Add(-24 * time.Hour)
-
-		for _, assignment := range assignments {
-			// Check if notification already exists
-			exists, err := models.NotificationExists(ctx, taskInfo.TaskID, assignment.UserID, "reminder")
-			if err != nil {
-				log.Printf("Error checking notification existence: %v", err)
-				continue
-			}
-			if exists {
-				continue // Skip if notification already exists
-			}
-
-			// Only create notification if reminder time is in the future (or very recent past)
-			if reminderTime.Before(now.Add(-1 * time.Minute)) {
-				continue
-			}
-
-			notification := models.TaskNotification{
-				TaskID:           taskInfo.TaskID,
-				UserID:           assignment.UserID,
-				NotificationType: "reminder",
-				ScheduledAt:      reminderTime,
-				Status:           "pending",
-			}
-
-			if err := notification.Save(ctx); err != nil {
-				log.Printf("Error creating reminder notification for task %s, user %s: %v", taskInfo.TaskID, assignment.UserID, err)
-				continue
-			}
-			log.Printf("Created reminder notification for task %s, user %s, scheduled for %v", taskInfo.TaskID, assignment.UserID, reminderTime)
-		}
-	}
-
 	// Create due_date notifications (scheduled for the due date itself, at start of day)
 	for _, taskInfo := range dueDateTasks {
 		// Get all assignees for this task
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// EmailSender is implemented by both utils.EmailService (SendGrid) and utils.SMTPService.
+// Tests can register a fake with RegisterNotifier(NewEmailNotifier(fake)).
+type EmailSender interface {
+	SendNotificationEmail(toEmail string, toName string, subject, content string) error
+}
+
+// EmailNotifier delivers notifications to the user's email address
+type EmailNotifier struct {
+	sender EmailSender
+}
+
+func NewEmailNotifier(sender EmailSender) *EmailNotifier {
+	return &EmailNotifier{sender: sender}
+}
+
+func NewSendGridNotifier(service *utils.EmailService) *EmailNotifier {
+	return &EmailNotifier{sender: service}
+}
+
+func NewSMTPNotifier(service *utils.SMTPService) *EmailNotifier {
+	return &EmailNotifier{sender: service}
+}
+
+func (n *EmailNotifier) Channel() string {
+	return "email"
+}
+
+func (n *EmailNotifier) Send(ctx context.Context, message *NotificationMessage) error {
+	if message.User.Email == "" {
+		return permanentFailure(fmt.Errorf("user %s has no email address", message.User.ID))
+	}
+
+	return n.sender.SendNotificationEmail(message.User.Email, message.User.Name, message.Subject, message.Body)
+}
+
+// SendEmail sends a one-off email (invitations, account emails) through the configured email backend
+func SendEmail(toEmail string, toName string, subject, body string) error {
+	notifier, ok := GetNotifier("email")
+	if !ok {
+		return fmt.Errorf("no email backend configured")
+	}
+
+	emailNotifier, ok := notifier.(*EmailNotifier)
+	if !ok {
+		return fmt.Errorf("email channel does not support direct sending")
+	}
+
+	return emailNotifier.sender.SendNotificationEmail(toEmail, toName, subject, body)
+}
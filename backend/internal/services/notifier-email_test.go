@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+func TestEmailNotifierSendsToUser(t *testing.T) {
+	sender := &fakeEmailSender{}
+	message := &NotificationMessage{
+		Notification: models.TaskNotification{ID: "notification-1", NotificationType: "reminder"},
+		User:         &models.User{ID: "user-1", Name: "Ada", Email: "ada@example.com"},
+		Subject:      "Task due soon",
+		Body:         "Your task is due tomorrow",
+	}
+
+	if err := NewEmailNotifier(sender).Send(context.Background(), message); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	want := sentEmail{to: "ada@example.com", name: "Ada", subject: "Task due soon", body: "Your task is due tomorrow"}
+	if len(sender.sent) != 1 || sender.sent[0] != want {
+		t.Fatalf("sent %+v, want %+v", sender.sent, want)
+	}
+}
+
+func TestEmailNotifierRequiresAddress(t *testing.T) {
+	sender := &fakeEmailSender{}
+	message := &NotificationMessage{User: &models.User{ID: "user-1"}, Subject: "Subject", Body: "Body"}
+
+	err := NewEmailNotifier(sender).Send(context.Background(), message)
+	if !errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send without an address: got %v, want a permanent failure", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent %+v to a user without an address", sender.sent)
+	}
+}
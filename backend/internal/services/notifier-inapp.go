@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// InAppNotifier pushes notifications to the user's notification stream (/api/ws/notifications)
+// together with the new unread count. Notifications stay listed under GET /api/notifications,
+// so users who are offline see them later.
+type InAppNotifier struct {
+	hub         *WS.Hub
+	unreadCount func(ctx context.Context, userID string) (int, error)
+}
+
+// userHub carries pushes to notification streams that are not task notifications (invitations,
+// unread counts); set by InitNotifiers
+var userHub *WS.Hub
+
+// PushToUser sends an event to the user's notification streams. Without a hub it does nothing.
+func PushToUser(userID string, eventType string, data any) {
+	if userHub != nil {
+		userHub.NotifyUser(userID, eventType, data)
+	}
+}
+
+// UnreadCounts returns the badge counts shown next to the notification bell
+func UnreadCounts(ctx context.Context, userID string, email string) (map[string]any, error) {
+	unreadCount, err := models.CountUnreadNotifications(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pendingInvitations, err := models.CountPendingInvitations(ctx, userID, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"unread_count": unreadCount, "pending_invitations": pendingInvitations}, nil
+}
+
+// PushUnreadCounts sends the current counts to the user's notification streams in the background
+func PushUnreadCounts(userID string, email string) {
+	if userHub == nil {
+		return
+	}
+
+	go func() {
+		countCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		counts, err := UnreadCounts(countCtx, userID, email)
+		if err != nil {
+			return
+		}
+		userHub.NotifyUser(userID, "unread_count", counts)
+	}()
+}
+
+func NewInAppNotifier(hub *WS.Hub) *InAppNotifier {
+	return &InAppNotifier{hub: hub, unreadCount: models.CountUnreadNotifications}
+}
+
+func (n *InAppNotifier) Channel() string {
+	return "in_app"
+}
+
+func (n *InAppNotifier) Send(ctx context.Context, message *NotificationMessage) error {
+	if n.hub == nil {
+		return permanentFailure(fmt.Errorf("websocket hub not initialized"))
+	}
+
+	unreadCount, err := n.unreadCount(ctx, message.User.ID)
+	if err != nil {
+		return err
+	}
+	// The notification being delivered only counts as unread once it is marked sent afterwards
+	unreadCount++
+
+	n.hub.NotifyUser(message.User.ID, "notification", map[string]any{
+		"notification": message.Notification,
+		"task":         message.Task,
+		"subject":      message.Subject,
+		"unread_count": unreadCount,
+	})
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+func inAppMessage() *NotificationMessage {
+	return &NotificationMessage{
+		Notification: models.TaskNotification{ID: "notification-1", NotificationType: "assignment"},
+		Task:         &models.Task{ID: "task-1", Title: "Write report"},
+		User:         &models.User{ID: "user-1"},
+		Subject:      "New task",
+	}
+}
+
+func TestInAppNotifierPushesToUserStream(t *testing.T) {
+	hub := WS.NewHub()
+	notifier := &InAppNotifier{hub: hub, unreadCount: func(ctx context.Context, userID string) (int, error) {
+		return 4, nil
+	}}
+
+	// Stand in for Hub.Run and take the push off the user channel
+	pushed := make(chan *WS.Message, 1)
+	go func() { pushed <- <-hub.SendToUser }()
+
+	if err := notifier.Send(context.Background(), inAppMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case message := <-pushed:
+		if message.UserID != "user-1" || message.Type != "notification" {
+			t.Fatalf("pushed %+v, want a notification for user-1", message)
+		}
+		data, ok := message.Data.(map[string]any)
+		if !ok {
+			t.Fatalf("unexpected data %T", message.Data)
+		}
+		if data["unread_count"] != 5 || data["subject"] != "New task" {
+			t.Errorf("unexpected data %+v", data)
+		}
+		if notification, _ := data["notification"].(models.TaskNotification); notification.ID != "notification-1" {
+			t.Errorf("pushed notification %+v, want notification-1", data["notification"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("nothing was pushed to the user")
+	}
+}
+
+func TestInAppNotifierRetriesUnreadCountErrors(t *testing.T) {
+	hub := WS.NewHub()
+	notifier := &InAppNotifier{hub: hub, unreadCount: func(ctx context.Context, userID string) (int, error) {
+		return 0, errors.New("connection reset")
+	}}
+
+	err := notifier.Send(context.Background(), inAppMessage())
+	if err == nil || errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send: got %v, want a retryable error", err)
+	}
+}
+
+func TestInAppNotifierRequiresHub(t *testing.T) {
+	err := NewInAppNotifier(nil).Send(context.Background(), inAppMessage())
+	if !errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send without a hub: got %v, want a permanent failure", err)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// WebhookNotifier POSTs notifications as JSON to the webhook URL in the user's preferences.
+// When WEBHOOK_SIGNING_SECRET is set, the body is signed with HMAC-SHA256 in the
+// X-Remindly-Signature header so receivers can verify it came from us.
+// Webhook URLs are user-supplied, so they are called through utils.NewSafeHTTPClient and cannot
+// reach internal hosts. Each Send makes one attempt; network errors, 429 and 5xx responses are
+// retried later by the scheduler (see DeliverNotification), everything else fails for good.
+type WebhookNotifier struct {
+	client *http.Client
+	secret string
+}
+
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{
+		client: utils.NewSafeHTTPClient(10 * time.Second),
+		secret: os.Getenv("WEBHOOK_SIGNING_SECRET"),
+	}
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return "webhook"
+}
+
+// webhookPayload is the JSON body sent to webhook receivers
+type webhookPayload struct {
+	ID               string    `json:"id"`
+	NotificationType string    `json:"notification_type"`
+	UserID           string    `json:"user_id"`
+	Subject          string    `json:"subject"`
+	Body             string    `json:"body"`
+	Task             any       `json:"task"`
+	CommentID        *string   `json:"comment_id,omitempty"`
+	SentAt           time.Time `json:"sent_at"`
+}
+
+func (n *WebhookNotifier) Send(ctx context.Context, message *NotificationMessage) error {
+	if message.Preferences == nil || message.Preferences.WebhookURL == nil || *message.Preferences.WebhookURL == "" {
+		return permanentFailure(fmt.Errorf("user %s has no webhook URL configured", message.User.ID))
+	}
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:               message.Notification.ID,
+		NotificationType: message.Notification.NotificationType,
+		UserID:           message.User.ID,
+		Subject:          message.Subject,
+		Body:             message.Body,
+		Task:             message.Task,
+		CommentID:        message.Notification.CommentID,
+		SentAt:           time.Now(),
+	})
+	if err != nil {
+		return permanentFailure(fmt.Errorf("failed to encode webhook payload: %w", err))
+	}
+
+	retry, err := n.post(ctx, *message.Preferences.WebhookURL, message.Notification.NotificationType, payload)
+	if err != nil && !retry {
+		return permanentFailure(err)
+	}
+	return err
+}
+
+// post makes one delivery attempt. It reports whether a failure is worth retrying: refused
+// addresses and 4xx responses other than 429 will not get better.
+func (n *WebhookNotifier) post(ctx context.Context, webhookURL string, eventType string, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Remindly-Webhook/1.0")
+	req.Header.Set("X-Remindly-Event", eventType)
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(payload)
+		req.Header.Set("X-Remindly-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, utils.ErrNonPublicAddress), fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return false, nil
+}
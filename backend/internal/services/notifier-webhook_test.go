@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+func webhookMessage(url string) *NotificationMessage {
+	return &NotificationMessage{
+		Notification: models.TaskNotification{ID: "notification-1", NotificationType: "assigned"},
+		User:         &models.User{ID: "user-1"},
+		Preferences:  &models.UserPreferences{UserID: "user-1", WebhookURL: &url},
+		Subject:      "New task",
+		Body:         "You were assigned a task",
+	}
+}
+
+// testWebhookNotifier calls httptest servers, which listen on loopback and are refused by the
+// client NewWebhookNotifier uses
+func testWebhookNotifier(secret string) *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{Timeout: 5 * time.Second}, secret: secret}
+}
+
+func TestWebhookNotifierDelivers(t *testing.T) {
+	var body []byte
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := testWebhookNotifier("secret").Send(context.Background(), webhookMessage(server.URL)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	if payload.ID != "notification-1" || payload.UserID != "user-1" || payload.Subject != "New task" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	if got := header.Get("X-Remindly-Event"); got != "assigned" {
+		t.Errorf("X-Remindly-Event = %q, want assigned", got)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if got, want := header.Get("X-Remindly-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("X-Remindly-Signature = %q, want %q", got, want)
+	}
+}
+
+// Retries are left to the scheduler: Send makes one attempt and says whether another may help
+func TestWebhookNotifierLeavesServerErrorsRetryable(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := testWebhookNotifier("").Send(context.Background(), webhookMessage(server.URL))
+	if err == nil {
+		t.Fatal("Send succeeded against a failing webhook")
+	}
+	if errors.Is(err, errPermanentFailure) {
+		t.Errorf("a 503 was reported as permanent: %v", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("webhook called %d times, want 1", got)
+	}
+}
+
+func TestWebhookNotifierDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := testWebhookNotifier("").Send(context.Background(), webhookMessage(server.URL))
+	if !errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send against a 404: got %v, want a permanent failure", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("webhook called %d times, want 1", got)
+	}
+}
+
+func TestWebhookNotifierRefusesPrivateAddresses(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier().Send(context.Background(), webhookMessage(server.URL))
+	if !errors.Is(err, utils.ErrNonPublicAddress) || !errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send to %s: got %v, want a permanent ErrNonPublicAddress", server.URL, err)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("loopback webhook was called %d times", got)
+	}
+}
+
+func TestWebhookNotifierRequiresURL(t *testing.T) {
+	message := webhookMessage("")
+	if err := testWebhookNotifier("").Send(context.Background(), message); !errors.Is(err, errPermanentFailure) {
+		t.Fatalf("Send without a webhook URL: got %v, want a permanent failure", err)
+	}
+}
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for retryCount, delay := range want {
+		if got := retryDelay(retryCount); got != delay {
+			t.Errorf("retryDelay(%d) = %v, want %v", retryCount, got, delay)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// Notifier delivers a notification over one channel. Channel names match the ones users pick in
+// their preferences (see models.NotificationChannelNames).
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, message *NotificationMessage) error
+}
+
+// errPermanentFailure marks delivery errors that retrying will not fix, such as a missing address
+// or a rejected request. Other errors are retried (see DeliverNotification).
+var errPermanentFailure = errors.New("delivery cannot succeed")
+
+func permanentFailure(err error) error {
+	return fmt.Errorf("%w: %w", errPermanentFailure, err)
+}
+
+// NotificationMessage is everything a notifier needs to deliver one task notification
+type NotificationMessage struct {
+	Notification models.TaskNotification
+	Task         *models.Task
+	User         *models.User
+	Preferences  *models.UserPreferences
+	Subject      string
+	Body         string
+}
+
+var (
+	notifiersMu sync.RWMutex
+	notifiers   = map[string]Notifier{}
+)
+
+// RegisterNotifier makes a channel available for delivery, replacing any notifier with the same channel
+func RegisterNotifier(notifier Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers[notifier.Channel()] = notifier
+}
+
+// GetNotifier returns the notifier registered for a channel
+func GetNotifier(channel string) (Notifier, bool) {
+	notifiersMu.RLock()
+	defer notifiersMu.RUnlock()
+	notifier, ok := notifiers[channel]
+	return notifier, ok
+}
+
+// InitNotifiers registers every channel that is configured. Email goes through SendGrid when it
+// is set up and falls back to SMTP otherwise; in-app and webhook delivery are always available.
+func InitNotifiers(hub *WS.Hub) {
+	if emailService := utils.GetEmailService(); emailService != nil {
+		RegisterNotifier(NewSendGridNotifier(emailService))
+		log.Println("Email notifications delivered through SendGrid")
+	} else if smtpService := utils.GetSMTPService(); smtpService != nil {
+		RegisterNotifier(NewSMTPNotifier(smtpService))
+		log.Println("Email notifications delivered through SMTP")
+	} else {
+		log.Printf("Warning: no email backend configured (email notifications will not be sent)")
+	}
+
+	userHub = hub
+	RegisterNotifier(NewInAppNotifier(hub))
+	RegisterNotifier(NewWebhookNotifier())
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// CleanupExpiredSessions removes sessions whose refresh token has expired
+func CleanupExpiredSessions(ctx context.Context) error {
+	deleted, err := models.DeleteExpiredAuths(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deleted > 0 {
+		log.Printf("Removed %d expired sessions", deleted)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// quotaHub pushes storage warnings to owners who are online; they are only emailed without it
+var quotaHub *WS.Hub
+
+// InitStorageQuotas gives storage warnings access to the notification hub
+func InitStorageQuotas(hub *WS.Hub) {
+	quotaHub = hub
+}
+
+// GroupStorageQuota returns the quota that applies to a group: its own, or the default. Nil means unlimited.
+func GroupStorageQuota(ctx context.Context, groupID string) (*int64, error) {
+	quota, err := models.GetGroupStorageQuota(ctx, groupID)
+	if err != nil || quota != nil {
+		return quota, err
+	}
+	return utils.DefaultGroupStorageQuota(), nil
+}
+
+// MemberStorageQuota returns the quota that applies to a member of a group: their own, or the default.
+// Nil means unlimited.
+func MemberStorageQuota(ctx context.Context, groupID string, userID string) (*int64, error) {
+	quota, err := models.GetMemberStorageQuota(ctx, groupID, userID)
+	if err != nil || quota != nil {
+		return quota, err
+	}
+	return utils.DefaultUserStorageQuota(), nil
+}
+
+// ReserveStorage holds size bytes of a group's storage for a member's upload, counting the uploads
+// already in progress. It returns the reservation to release once the upload is saved or failed,
+// or why the upload is refused.
+func ReserveStorage(ctx context.Context, groupID string, userID string, size int64) (*models.StorageReservation, string, error) {
+	groupQuota, err := GroupStorageQuota(ctx, groupID)
+	if err != nil {
+		return nil, "", err
+	}
+	memberQuota, err := MemberStorageQuota(ctx, groupID, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reservation := &models.StorageReservation{GroupID: groupID, UserID: userID, Size: size}
+	reason, err := reservation.Reserve(ctx, func(group *models.StorageUsage, member *models.StorageUsage) string {
+		if groupQuota != nil && group.UsedBytes+group.ReservedBytes+size > *groupQuota {
+			return fmt.Sprintf("The group has %s of storage left and the upload needs %s",
+				utils.FormatBytes(max(*groupQuota-group.UsedBytes-group.ReservedBytes, 0)), utils.FormatBytes(size))
+		}
+		if memberQuota != nil && member.UsedBytes+member.ReservedBytes+size > *memberQuota {
+			return fmt.Sprintf("You have %s of storage left in this group and the upload needs %s",
+				utils.FormatBytes(max(*memberQuota-member.UsedBytes-member.ReservedBytes, 0)), utils.FormatBytes(size))
+		}
+		return ""
+	})
+	if err != nil || reason != "" {
+		return nil, reason, err
+	}
+	return reservation, "", nil
+}
+
+// CheckStorageThreshold warns the owners of a group once when its storage passes
+// utils.StorageWarningPercent of the quota, and re-arms the warning when it drops back below
+func CheckStorageThreshold(ctx context.Context, groupID string) {
+	quota, err := GroupStorageQuota(ctx, groupID)
+	if err != nil {
+		log.Printf("Error getting storage quota of group %s: %v", groupID, err)
+		return
+	}
+	if quota == nil || *quota == 0 {
+		return
+	}
+
+	usage, err := models.GetGroupStorageUsage(ctx, groupID)
+	if err != nil {
+		log.Printf("Error getting storage usage of group %s: %v", groupID, err)
+		return
+	}
+
+	if usage.UsedBytes*100 < *quota*int64(utils.StorageWarningPercent()) {
+		if err := models.ClearStorageWarning(ctx, groupID); err != nil {
+			log.Printf("Error clearing storage warning of group %s: %v", groupID, err)
+		}
+		return
+	}
+
+	marked, err := models.MarkStorageWarningSent(ctx, groupID)
+	if err != nil {
+		log.Printf("Error marking storage warning of group %s: %v", groupID, err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	notifyStorageWarning(ctx, groupID, usage.UsedBytes, *quota)
+}
+
+// notifyStorageWarning pushes the warning to the group's owners and emails them
+func notifyStorageWarning(ctx context.Context, groupID string, usedBytes int64, quotaBytes int64) {
+	group, err := models.GetGroupByID(groupID)
+	if err != nil {
+		log.Printf("Error loading group %s for storage warning: %v", groupID, err)
+		return
+	}
+
+	ownerIDs, err := models.GetGroupMemberIDs(ctx, groupID, "owner")
+	if err != nil {
+		log.Printf("Error getting owners of group %s for storage warning: %v", groupID, err)
+		return
+	}
+
+	storageURL := fmt.Sprintf("%s/groups/%s/files", utils.AppURL(), groupID)
+	for _, ownerID := range ownerIDs {
+		if quotaHub != nil {
+			quotaHub.NotifyUser(ownerID, "storage_warning", map[string]any{
+				"group_id":    groupID,
+				"group_name":  group.Name,
+				"used_bytes":  usedBytes,
+				"quota_bytes": quotaBytes,
+			})
+		}
+
+		owner := &models.User{ID: ownerID}
+		if err := owner.Get(); err != nil {
+			log.Printf("Error loading owner %s for storage warning: %v", ownerID, err)
+			continue
+		}
+		subject, body := utils.BuildStorageWarningContent(owner.Name, group.Name, usedBytes, quotaBytes, storageURL)
+		if err := SendEmail(owner.Email, owner.Name, subject, body); err != nil {
+			log.Printf("Error emailing storage warning to %s: %v", ownerID, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// RecordTaskActivity adds an entry to the task's activity feed. The feed is informational,
+// so failures are logged instead of failing the change that triggered them.
+func RecordTaskActivity(ctx context.Context, taskID string, actorID *string, activityType string, oldValue *string, newValue *string) {
+	activity := models.TaskActivity{
+		TaskID:       taskID,
+		ActorID:      actorID,
+		ActivityType: activityType,
+		OldValue:     oldValue,
+		NewValue:     newValue,
+	}
+	if err := activity.Save(ctx); err != nil {
+		log.Printf("Error recording %s activity for task %s: %v", activityType, taskID, err)
+	}
+}
+
+// NotifyMentions creates and delivers a mention notification for each user mentioned in the comment
+func NotifyMentions(ctx context.Context, comment *models.TaskComment, userIDs []string) {
+	for _, userID := range userIDs {
+		notification := models.TaskNotification{
+			TaskID:           comment.TaskID,
+			UserID:           userID,
+			NotificationType: "mention",
+			ScheduledAt:      time.Now(),
+			Status:           "pending",
+			CommentID:        &comment.ID,
+		}
+		if err := notification.Save(ctx); err != nil {
+			log.Printf("Error creating mention notification for comment %s, user %s: %v", comment.ID, userID, err)
+			continue
+		}
+		DeliverNotification(ctx, &notification)
+	}
+}
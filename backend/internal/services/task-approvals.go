@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// NotifyApprovers sends an "approval_request" notification to every owner and admin of the group
+// except the requester
+func NotifyApprovers(ctx context.Context, groupID string, approval *models.TaskApproval) {
+	adminIDs, err := models.GetGroupMemberIDs(ctx, groupID, "owner", "admin")
+	if err != nil {
+		log.Printf("Error getting approvers for task %s: %v", approval.TaskID, err)
+		return
+	}
+
+	for _, adminID := range adminIDs {
+		if adminID == approval.RequestedBy {
+			continue
+		}
+		notifyTaskUser(ctx, approval.TaskID, adminID, "approval_request")
+	}
+}
+
+// NotifyApprovalDecision tells the requester that their request was decided, unless they decided it themselves
+func NotifyApprovalDecision(ctx context.Context, approval *models.TaskApproval) {
+	if approval.DecidedBy != nil && *approval.DecidedBy == approval.RequestedBy {
+		return
+	}
+	notifyTaskUser(ctx, approval.TaskID, approval.RequestedBy, "approval_decision")
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// NotifyUnblockedDependents sends an "unblocked" notification to the assignees of every task
+// whose last open blocker was the given task
+func NotifyUnblockedDependents(ctx context.Context, taskID string) {
+	dependents, err := models.GetUnblockedDependents(ctx, taskID)
+	if err != nil {
+		log.Printf("Error getting unblocked dependents of task %s: %v", taskID, err)
+		return
+	}
+
+	for _, dependent := range dependents {
+		assignments, err := models.GetTaskAssignments(ctx, dependent.ID)
+		if err != nil {
+			log.Printf("Error getting assignments for task %s: %v", dependent.ID, err)
+			continue
+		}
+
+		for _, assignment := range assignments {
+			notification := models.TaskNotification{
+				TaskID:           dependent.ID,
+				UserID:           assignment.UserID,
+				NotificationType: "unblocked",
+				ScheduledAt:      time.Now(),
+				Status:           "pending",
+			}
+			if err := notification.Save(ctx); err != nil {
+				log.Printf("Error creating unblocked notification for task %s, user %s: %v", dependent.ID, assignment.UserID, err)
+				continue
+			}
+			DeliverNotification(ctx, &notification)
+		}
+	}
+}
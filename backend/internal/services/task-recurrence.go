@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// GenerateNextOccurrence materializes the occurrence that follows the latest one in a series.
+// It returns nil without an error when nothing needs to be generated (series ended, latest
+// occurrence not approved yet, or the occurrence was already created concurrently).
+func GenerateNextOccurrence(ctx context.Context, seriesID string) (*models.Task, error) {
+	series := &models.TaskSeries{ID: seriesID}
+	if err := series.Get(ctx); err != nil {
+		return nil, err
+	}
+	if series.EndedAt != nil {
+		return nil, nil
+	}
+
+	// The latest occurrence is the template for the next one (title, description, assignees)
+	source, err := models.GetLatestSeriesTask(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Series whose latest occurrence still awaits approval (or was rejected) do not advance
+	if source.Status == "pending" || source.Status == "rejected" {
+		return nil, nil
+	}
+
+	next, err := utils.NextOccurrence(series.RecurrenceRule, series.RecurrenceTimezone, series.DTStart, series.LastOccurrenceAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute next occurrence for series %s: %w", seriesID, err)
+	}
+
+	// Rule exhausted (COUNT reached or past UNTIL)
+	if next.IsZero() {
+		if err := series.End(ctx); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	task := &models.Task{
+		GroupID:      source.GroupID,
+		Title:        source.Title,
+		Description:  source.Description,
+		DueDate:      next,
+		Status:       "active",
+		CreatedBy:    source.CreatedBy,
+		SeriesID:     &seriesID,
+		OccurrenceAt: &next,
+		// Reminder overrides carry over like the rest of the template
+		ReminderOffsets: source.ReminderOffsets,
+	}
+
+	created, err := task.CreateOccurrence(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := series.AdvanceCursor(ctx, next); err != nil {
+		return nil, err
+	}
+
+	if !created {
+		return nil, nil
+	}
+
+	// Carry the assignees over from the previous occurrence
+	assignments, err := models.GetTaskAssignments(ctx, source.ID)
+	if err != nil {
+		log.Printf("Error getting assignments for task %s: %v", source.ID, err)
+		return task, nil
+	}
+
+	for _, assignment := range assignments {
+		newAssignment := models.TaskAssignment{
+			TaskID:     task.ID,
+			UserID:     assignment.UserID,
+			AssignedBy: assignment.AssignedBy,
+		}
+		// The membership trigger rejects users who have since left the group
+		if err := newAssignment.Save(ctx); err != nil {
+			log.Printf("Skipping assignee %s for task %s: %v", assignment.UserID, task.ID, err)
+			continue
+		}
+
+		notification := models.TaskNotification{
+			TaskID:           task.ID,
+			UserID:           assignment.UserID,
+			NotificationType: "assignment",
+			ScheduledAt:      time.Now(),
+			Status:           "pending",
+		}
+		if err := notification.Save(ctx); err != nil {
+			log.Printf("Error creating assignment notification for task %s, user %s: %v", task.ID, assignment.UserID, err)
+			continue
+		}
+		DeliverNotification(ctx, &notification)
+	}
+
+	return task, nil
+}
+
+// maxCatchUpOccurrences bounds how many overdue occurrences one series generates per run, so a
+// long-stalled series with a short interval cannot hold up the scheduler
+const maxCatchUpOccurrences = 100
+
+// MaterializeRecurringTasks creates the next occurrence for every series whose latest
+// occurrence has been completed, cancelled, or has passed its due date. A series that has
+// fallen behind gets every occurrence that is already due, not just the next one.
+func MaterializeRecurringTasks(ctx context.Context) error {
+	now := time.Now()
+	series, err := models.GetSeriesDueForGeneration(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to fetch recurring task series: %w", err)
+	}
+
+	for _, s := range series {
+		for range maxCatchUpOccurrences {
+			task, err := GenerateNextOccurrence(ctx, s.ID)
+			if err != nil {
+				log.Printf("Error generating next occurrence for series %s: %v", s.ID, err)
+				break
+			}
+			if task == nil {
+				break
+			}
+			log.Printf("Created occurrence %s of series %s due %v", task.ID, s.ID, task.DueDate)
+
+			// The next occurrence is only due now if this one already was
+			if task.DueDate.After(now) {
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// PurgeExpiredTrash deletes for good what has been in the trash longer than utils.TrashRetention,
+// including the stored objects of purged files and link previews. Without file storage files and
+// links stay in the trash, so no object is left behind.
+func PurgeExpiredTrash(ctx context.Context) error {
+	purge, err := models.PurgeTrash(ctx, time.Now().Add(-utils.TrashRetention()), uploadStorage != nil)
+	if err != nil {
+		return err
+	}
+
+	// The rows are gone already; objects that fail to delete are only logged
+	for _, key := range purge.ObjectKeys {
+		if err := uploadStorage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting object %s of purged trash: %v", key, err)
+		}
+	}
+	for _, groupID := range purge.GroupIDs {
+		CheckStorageThreshold(ctx, groupID)
+	}
+
+	if purge.Tasks+purge.Files+purge.Folders+purge.Links > 0 {
+		log.Printf("Purged trash: %d tasks, %d files, %d folders, %d links, %d objects",
+			purge.Tasks, purge.Files, purge.Folders, purge.Links, len(purge.ObjectKeys))
+	}
+
+	return nil
+}
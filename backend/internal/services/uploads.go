@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+	"github.com/KoiralaSam/Remindly/backend/internal/utils"
+)
+
+// expiredUploadBatch bounds how many expired upload sessions one cleanup run handles
+const expiredUploadBatch = 100
+
+// uploadStorage is where upload sessions put their content and where purged files are deleted
+// from. Without it upload cleanup is skipped and the trash purge keeps files and links.
+var uploadStorage utils.BlobStore
+
+// InitUploads gives upload session cleanup and the trash purge access to file storage
+func InitUploads(storage utils.BlobStore) {
+	uploadStorage = storage
+}
+
+// DiscardUpload removes what the client uploaded for a session: the parts of a multipart upload
+// or the object of a single PUT
+func DiscardUpload(ctx context.Context, session *models.UploadSession) error {
+	if uploadStorage == nil {
+		return errors.New("file storage not initialized")
+	}
+	if multipart, ok := uploadStorage.(utils.MultipartBlobStore); ok && session.MultipartUploadID != nil {
+		if err := multipart.AbortMultipartUpload(ctx, session.S3Key, *session.MultipartUploadID); err != nil {
+			// The upload may have been completed before the session failed; drop the object too
+			log.Printf("Error aborting multipart upload of session %s: %v", session.ID, err)
+		}
+	}
+	return uploadStorage.Delete(ctx, session.S3Key)
+}
+
+// CleanupExpiredUploads discards the content of upload sessions that were never completed and
+// marks them expired, and drops the storage reservations of uploads that never finished
+func CleanupExpiredUploads(ctx context.Context) error {
+	released, err := models.DeleteExpiredStorageReservations(ctx)
+	if err != nil {
+		return err
+	}
+	if released > 0 {
+		log.Printf("Dropped %d expired storage reservations", released)
+	}
+
+	if uploadStorage == nil {
+		return nil
+	}
+
+	sessions, err := models.GetExpiredUploadSessions(ctx, expiredUploadBatch)
+	if err != nil {
+		return err
+	}
+
+	expired := 0
+	for i := range sessions {
+		if err := DiscardUpload(ctx, &sessions[i]); err != nil {
+			log.Printf("Error discarding upload session %s: %v", sessions[i].ID, err)
+			continue
+		}
+		if err := sessions[i].Finish(ctx, "expired", nil); err != nil {
+			log.Printf("Error expiring upload session %s: %v", sessions[i].ID, err)
+			continue
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		log.Printf("Cleaned up %d expired upload sessions", expired)
+	}
+
+	return nil
+}
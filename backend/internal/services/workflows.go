@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/WS"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+// defaultWorkflowTaskDue is the due date of tasks created by workflows without due_in_minutes
+const defaultWorkflowTaskDue = 24 * time.Hour
+
+// workflowHub is where post_message actions broadcast; messages are only persisted without it
+var workflowHub *WS.Hub
+
+// InitWorkflows gives the workflow runner access to the chat hub
+func InitWorkflows(hub *WS.Hub) {
+	workflowHub = hub
+}
+
+// TaskEvent is a change to a task that workflows can react to. Type is a workflow trigger type;
+// FromStatus and ToStatus are set for task_status_changed.
+type TaskEvent struct {
+	Type       string
+	TaskID     string
+	FromStatus string
+	ToStatus   string
+}
+
+// RunTaskWorkflows runs the group's enabled workflows triggered by the event. Changes made by
+// workflow actions do not emit events themselves, so workflows cannot trigger each other in a loop.
+func RunTaskWorkflows(ctx context.Context, event TaskEvent) {
+	task, err := models.GetTaskByID(ctx, event.TaskID)
+	if err != nil {
+		log.Printf("Error loading task %s for workflows: %v", event.TaskID, err)
+		return
+	}
+
+	workflows, err := models.GetTriggeredWorkflows(ctx, task.GroupID, event.Type)
+	if err != nil {
+		log.Printf("Error getting %s workflows for group %s: %v", event.Type, task.GroupID, err)
+		return
+	}
+
+	for i := range workflows {
+		trigger := workflows[i].Definition.Trigger
+		if trigger.FromStatus != "" && trigger.FromStatus != event.FromStatus {
+			continue
+		}
+		if trigger.ToStatus != "" && trigger.ToStatus != event.ToStatus {
+			continue
+		}
+		runWorkflow(ctx, &workflows[i], task)
+	}
+}
+
+// RunOverdueWorkflows runs every task_overdue workflow for the open tasks of its group that have
+// been overdue long enough. Each workflow runs at most once per task.
+func RunOverdueWorkflows(ctx context.Context) error {
+	workflows, err := models.GetTriggeredWorkflows(ctx, "", "task_overdue")
+	if err != nil {
+		return fmt.Errorf("failed to fetch overdue workflows: %w", err)
+	}
+
+	for i := range workflows {
+		overdueFor := time.Duration(workflows[i].Definition.Trigger.OverdueMinutes) * time.Minute
+		tasks, err := models.GetOverdueTasksForWorkflow(ctx, &workflows[i], overdueFor)
+		if err != nil {
+			log.Printf("Error getting overdue tasks for workflow %s: %v", workflows[i].ID, err)
+			continue
+		}
+
+		for j := range tasks {
+			runWorkflow(ctx, &workflows[i], &tasks[j])
+		}
+	}
+
+	return nil
+}
+
+// runWorkflow checks the workflow's conditions against the task and runs its actions in order,
+// stopping at the first failure. Every run is recorded in the workflow's execution log; runs of a
+// workflow whose creator lost their owner or admin role fail without running any action.
+func runWorkflow(ctx context.Context, workflow *models.Workflow, task *models.Task) {
+	if !workflow.Definition.Matches(task) {
+		return
+	}
+
+	run := models.WorkflowRun{
+		WorkflowID:  workflow.ID,
+		TaskID:      &task.ID,
+		TriggerType: workflow.Definition.Trigger.Type,
+	}
+	started, err := run.Start(ctx)
+	if err != nil {
+		log.Printf("Error starting workflow %s for task %s: %v", workflow.ID, task.ID, err)
+		return
+	}
+	if !started {
+		return
+	}
+
+	run.Actions = []models.WorkflowRunAction{}
+	if err := checkWorkflowCreator(ctx, workflow); err != nil {
+		log.Printf("Workflow %s not run for task %s: %v", workflow.ID, task.ID, err)
+		if err := run.Finish(ctx, err); err != nil {
+			log.Printf("Error recording run %s of workflow %s: %v", run.ID, workflow.ID, err)
+		}
+		return
+	}
+
+	var runErr error
+	for i, action := range workflow.Definition.Actions {
+		result := models.WorkflowRunAction{Type: action.Type, Status: "succeeded"}
+
+		createdTaskID, err := executeWorkflowAction(ctx, workflow, task, action)
+		result.TaskID = createdTaskID
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			run.Actions = append(run.Actions, result)
+			runErr = fmt.Errorf("action %d (%s) failed: %w", i+1, action.Type, err)
+			break
+		}
+		run.Actions = append(run.Actions, result)
+	}
+
+	if err := run.Finish(ctx, runErr); err != nil {
+		log.Printf("Error recording run %s of workflow %s: %v", run.ID, workflow.ID, err)
+	}
+}
+
+// checkWorkflowCreator makes sure the workflow's creator is still an owner or admin of the group.
+// Actions run on their behalf, so a workflow must not outlive its creator's permissions.
+func checkWorkflowCreator(ctx context.Context, workflow *models.Workflow) error {
+	adminIDs, err := models.GetGroupMemberIDs(ctx, workflow.GroupID, "owner", "admin")
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(adminIDs, workflow.CreatedBy) {
+		return errors.New("workflow creator is no longer an owner or admin of the group")
+	}
+	return nil
+}
+
+// executeWorkflowAction performs one action for the triggering task. It returns the ID of the
+// task created by create_task actions.
+func executeWorkflowAction(ctx context.Context, workflow *models.Workflow, task *models.Task, action models.WorkflowAction) (string, error) {
+	switch action.Type {
+	case "create_task":
+		return createWorkflowTask(ctx, workflow, task, action)
+
+	case "post_message":
+		return "", postWorkflowMessage(ctx, workflow, renderWorkflowTemplate(action.Content, task))
+
+	case "reassign":
+		userIDs, err := resolveWorkflowUsers(ctx, task, action.AssignTo)
+		if err != nil {
+			return "", err
+		}
+		if len(userIDs) == 0 {
+			return "", errors.New("nobody to assign the task to")
+		}
+		added, err := models.ReplaceTaskAssignments(ctx, task.ID, userIDs, workflow.CreatedBy)
+		if err != nil {
+			return "", err
+		}
+		for _, userID := range added {
+			notifyTaskUser(ctx, task.ID, userID, "assignment")
+		}
+		return "", nil
+
+	case "set_priority":
+		return "", models.UpdateTaskPriority(ctx, task.ID, action.Priority)
+
+	case "add_label":
+		return "", models.AddTaskLabel(ctx, task.ID, task.GroupID, action.LabelID)
+	}
+
+	return "", errors.New("unknown action type " + action.Type)
+}
+
+// createWorkflowTask creates the follow-up task of a create_task action on behalf of the workflow's creator
+func createWorkflowTask(ctx context.Context, workflow *models.Workflow, task *models.Task, action models.WorkflowAction) (string, error) {
+	due := defaultWorkflowTaskDue
+	if action.DueInMinutes > 0 {
+		due = time.Duration(action.DueInMinutes) * time.Minute
+	}
+
+	followUp := models.Task{
+		GroupID:     task.GroupID,
+		Title:       renderWorkflowTemplate(action.Title, task),
+		Description: renderWorkflowTemplate(action.Description, task),
+		DueDate:     time.Now().Add(due).Truncate(time.Minute),
+		Status:      "active",
+		CreatedBy:   workflow.CreatedBy,
+		Priority:    action.Priority,
+	}
+	if action.AsSubtask {
+		followUp.ParentID = &task.ID
+	}
+
+	if err := followUp.CreateTask(ctx); err != nil {
+		return "", err
+	}
+
+	if action.AssignTo == "" {
+		return followUp.ID, nil
+	}
+
+	userIDs, err := resolveWorkflowUsers(ctx, task, action.AssignTo)
+	if err != nil {
+		return followUp.ID, err
+	}
+	for _, userID := range userIDs {
+		assignment := models.TaskAssignment{
+			TaskID:     followUp.ID,
+			UserID:     userID,
+			AssignedBy: workflow.CreatedBy,
+		}
+		if err := assignment.Save(ctx); err != nil {
+			return followUp.ID, err
+		}
+		notifyTaskUser(ctx, followUp.ID, userID, "assignment")
+	}
+
+	return followUp.ID, nil
+}
+
+// postWorkflowMessage persists a chat message in the group room under the workflow's name and
+// broadcasts it to connected members
+func postWorkflowMessage(ctx context.Context, workflow *models.Workflow, content string) error {
+	message := models.Message{
+		RoomID:   workflow.GroupID,
+		UserID:   workflow.CreatedBy,
+		Username: workflow.Name,
+		Content:  content,
+	}
+	if err := message.Save(ctx); err != nil {
+		return err
+	}
+
+	if workflowHub != nil {
+		workflowHub.Broadcast <- WS.NewMessageCreated(&message)
+	}
+
+	return nil
+}
+
+// resolveWorkflowUsers turns an assign_to target into user IDs: the task's assignees, its creator,
+// the group's owners, or a single user who must still be a member of the group
+func resolveWorkflowUsers(ctx context.Context, task *models.Task, target string) ([]string, error) {
+	switch target {
+	case "assignees":
+		assignments, err := models.GetTaskAssignments(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		userIDs := []string{}
+		for _, assignment := range assignments {
+			userIDs = append(userIDs, assignment.UserID)
+		}
+		return userIDs, nil
+	case "creator":
+		return []string{task.CreatedBy}, nil
+	case "owner":
+		return models.GetGroupMemberIDs(ctx, task.GroupID, "owner")
+	}
+
+	groupMember := &models.GroupMember{GroupID: task.GroupID, UserID: target}
+	isMember, err := groupMember.IsMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, errors.New("user " + target + " is no longer a member of the group")
+	}
+	return []string{target}, nil
+}
+
+// renderWorkflowTemplate fills in the {{task.*}} placeholders with the triggering task
+func renderWorkflowTemplate(text string, task *models.Task) string {
+	return strings.NewReplacer(
+		"{{task.title}}", task.Title,
+		"{{task.status}}", task.Status,
+		"{{task.priority}}", task.Priority,
+		"{{task.due_date}}", task.DueDate.UTC().Format("Jan 2, 2006"),
+	).Replace(text)
+}
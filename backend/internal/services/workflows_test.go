@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KoiralaSam/Remindly/backend/internal/db/dbtest"
+	"github.com/KoiralaSam/Remindly/backend/internal/models"
+)
+
+func TestRunWorkflowRequiresAdminCreator(t *testing.T) {
+	dbtest.Setup(t)
+	ctx := context.Background()
+
+	creator := &models.User{Name: "Ada", Email: fmt.Sprintf("ada-%d@example.com", time.Now().UnixNano()), Password: "password"}
+	if err := creator.Save(); err != nil {
+		t.Fatal(err)
+	}
+	group := &models.Group{Name: "Workflows", CreatedBy: creator.ID}
+	if err := group.Create(); err != nil {
+		t.Fatal(err)
+	}
+	member := &models.GroupMember{GroupID: group.ID, UserID: creator.ID, Role: "admin"}
+	if err := member.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	workflow := &models.Workflow{
+		GroupID: group.ID,
+		Name:    "Follow up",
+		Definition: models.WorkflowDefinition{
+			Trigger: models.WorkflowTrigger{Type: "task_created"},
+			Actions: []models.WorkflowAction{{Type: "set_priority", Priority: "urgent"}},
+		},
+		Enabled:   true,
+		CreatedBy: creator.ID,
+	}
+	if err := workflow.Definition.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := workflow.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(role string) (*models.Task, models.WorkflowRun) {
+		t.Helper()
+		if err := member.UpdateRole(role); err != nil {
+			t.Fatal(err)
+		}
+		task := &models.Task{
+			GroupID:   group.ID,
+			Title:     "Triggering task",
+			DueDate:   time.Now().Add(time.Hour),
+			Status:    "active",
+			CreatedBy: creator.ID,
+		}
+		if err := task.CreateTask(ctx); err != nil {
+			t.Fatal(err)
+		}
+		runWorkflow(ctx, workflow, task)
+
+		runs, _, err := models.GetWorkflowRuns(ctx, workflow.ID, 1, 0)
+		if err != nil || len(runs) == 0 {
+			t.Fatalf("no run recorded (err %v)", err)
+		}
+		updated, err := models.GetTaskByID(ctx, task.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return updated, runs[0]
+	}
+
+	task, latest := run("member")
+	if latest.Status != "failed" || len(latest.Actions) != 0 {
+		t.Errorf("run of a demoted creator's workflow = %+v, want failed without actions", latest)
+	}
+	if task.Priority == "urgent" {
+		t.Error("workflow of a demoted creator changed the task")
+	}
+
+	task, latest = run("admin")
+	if latest.Status != "succeeded" || task.Priority != "urgent" {
+		t.Errorf("run of an admin's workflow = %+v, task priority %q", latest, task.Priority)
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BlobStore keeps file contents by key. Keys are slash-separated paths such as the ones
+// GenerateFileVersionKey returns.
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get opens a stored object for reading; the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	Head(ctx context.Context, key string) (*BlobInfo, error)
+	// PresignGet returns a URL anyone can download the object from until it expires
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// PresignPut returns a URL the content of the object can be PUT to directly until it expires.
+	// Only content of the given size and SHA-256 is accepted.
+	PresignPut(ctx context.Context, key string, content UploadContent, expires time.Duration) (*PresignedUpload, error)
+	// Bucket names where the objects live, as stored in files.s3_bucket
+	Bucket() string
+}
+
+// MultipartBlobStore is a BlobStore that can also receive large objects in parts uploaded directly by clients
+type MultipartBlobStore interface {
+	BlobStore
+	CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error)
+	// PresignPart returns the URL one part is PUT to; the ETag of the response completes the upload.
+	// Only a part of the given size and SHA-256 is accepted.
+	PresignPart(ctx context.Context, key string, uploadID string, partNumber int32, content UploadContent, expires time.Duration) (*PresignedUpload, error)
+	CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []UploadedPart) error
+	AbortMultipartUpload(ctx context.Context, key string, uploadID string) error
+}
+
+// BlobInfo describes a stored object
+type BlobInfo struct {
+	Size        int64
+	ContentType string
+	// ChecksumSHA256 is the checksum the store verified the content against, in the format of
+	// ChecksumSHA256 or CompositeChecksumSHA256; empty when the store has none
+	ChecksumSHA256 string
+}
+
+// UploadContent is what a presigned upload URL accepts
+type UploadContent struct {
+	Size        int64
+	SHA256      string // Hex digest
+	ContentType string
+}
+
+// PresignedUpload is a URL content can be PUT to, with the headers the request has to carry
+type PresignedUpload struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// UploadedPart is a part of a multipart upload as reported by the client
+type UploadedPart struct {
+	PartNumber int32  `json:"part_number" binding:"required,min=1"`
+	ETag       string `json:"etag" binding:"required"`
+	SHA256     string `json:"-"` // Hex digest the part was uploaded with, set by the server
+}
+
+// ChecksumSHA256 converts a hex SHA-256 digest to the base64 form S3 uses in checksum headers
+func ChecksumSHA256(hexDigest string) (string, error) {
+	digest, err := hex.DecodeString(hexDigest)
+	if err != nil || len(digest) != sha256.Size {
+		return "", fmt.Errorf("invalid SHA-256 digest %q", hexDigest)
+	}
+	return base64.StdEncoding.EncodeToString(digest), nil
+}
+
+// CompositeChecksumSHA256 returns the checksum S3 reports for an object assembled from parts with
+// the given hex SHA-256 digests: the digest of the concatenated part digests, followed by the
+// number of parts
+func CompositeChecksumSHA256(partDigests []string) (string, error) {
+	hash := sha256.New()
+	for _, hexDigest := range partDigests {
+		digest, err := hex.DecodeString(hexDigest)
+		if err != nil || len(digest) != sha256.Size {
+			return "", fmt.Errorf("invalid SHA-256 digest %q", hexDigest)
+		}
+		hash.Write(digest)
+	}
+	return fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(hash.Sum(nil)), len(partDigests)), nil
+}
+
+// NewBlobStore creates the backend selected by STORAGE_BACKEND: "s3" or "local". When it is not
+// set, S3 is used if AWS_S3_BUCKET is configured and the local disk otherwise.
+func NewBlobStore() (BlobStore, error) {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "local"
+		if os.Getenv("AWS_S3_BUCKET") != "" {
+			backend = "s3"
+		}
+	}
+
+	switch backend {
+	case "s3":
+		return NewS3Service()
+	case "local":
+		return NewLocalBlobStore()
+	}
+	return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (expected s3 or local)", backend)
+}
+
+// GenerateFileVersionKey generates the key of one version of a file, keeping all the versions
+// of a file under the same prefix
+// Format: groupID/files/fileID/uuid/filename
+func GenerateFileVersionKey(groupID, fileID, filename string) string {
+	return fmt.Sprintf("%s/files/%s/%s/%s", groupID, fileID, uuid.New().String(), filepath.Base(filename))
+}
+
+// FileVersionRetention is how many versions of a file are kept (FILE_VERSION_RETENTION, default 10).
+// Older versions are pruned when a new one is added.
+func FileVersionRetention() int {
+	if value, err := strconv.Atoi(os.Getenv("FILE_VERSION_RETENTION")); err == nil && value > 0 {
+		return value
+	}
+	return 10
+}
+
+// DefaultGroupStorageQuota is how many bytes a group may store unless the group sets its own
+// quota (GROUP_STORAGE_QUOTA). Nil means unlimited.
+func DefaultGroupStorageQuota() *int64 {
+	return storageQuotaFromEnv("GROUP_STORAGE_QUOTA")
+}
+
+// DefaultUserStorageQuota is how many bytes a member may upload to a group unless the member has
+// their own quota (USER_STORAGE_QUOTA). Nil means unlimited.
+func DefaultUserStorageQuota() *int64 {
+	return storageQuotaFromEnv("USER_STORAGE_QUOTA")
+}
+
+// MaxStorageQuota is the most group admins can set as the quota of their group or of a member
+// (MAX_STORAGE_QUOTA, default 1 TiB), so a group cannot lift itself out of limits even when the
+// default quotas are unlimited
+func MaxStorageQuota() int64 {
+	if quota := storageQuotaFromEnv("MAX_STORAGE_QUOTA"); quota != nil {
+		return *quota
+	}
+	return 1 << 40
+}
+
+// StorageWarningPercent is the share of its quota past which the owners of a group are warned
+// (STORAGE_WARNING_PERCENT, default 90)
+func StorageWarningPercent() int {
+	if value, err := strconv.Atoi(os.Getenv("STORAGE_WARNING_PERCENT")); err == nil && value > 0 && value <= 100 {
+		return value
+	}
+	return 90
+}
+
+func storageQuotaFromEnv(name string) *int64 {
+	value, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil || value <= 0 {
+		return nil
+	}
+	return &value
+}
+
+// FormatBytes renders a size for people, e.g. 1.5 GB
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
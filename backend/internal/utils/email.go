@@ -79,6 +79,12 @@ type NotificationTaskData struct {
 }
 
 func (es *EmailService) BuildNotificationContent(notificationType string, taskData NotificationTaskData, userName string) (subject, body string) {
+	return BuildNotificationContent(notificationType, taskData, userName)
+}
+
+// BuildNotificationContent renders the subject and plain-text body of a task notification.
+// It does not depend on the delivery channel, so every notifier can reuse it.
+func BuildNotificationContent(notificationType string, taskData NotificationTaskData, userName string) (subject, body string) {
 	baseURL := os.Getenv("APP_URL")
 	if baseURL == "" {
 		baseURL = "http://localhost:5173"
@@ -154,6 +160,14 @@ func buildSubject(notificationType, taskTitle, status, timeUntilDue string) stri
 		return fmt.Sprintf("🔄 Task Status Changed: %s is now %s", taskTitle, status)
 	case "update":
 		return fmt.Sprintf("✏️ Task Updated: %s", taskTitle)
+	case "mention":
+		return fmt.Sprintf("💬 You were mentioned on: %s", taskTitle)
+	case "unblocked":
+		return fmt.Sprintf("🟢 Ready to Start: %s", taskTitle)
+	case "approval_request":
+		return fmt.Sprintf("🙋 Approval Requested: %s", taskTitle)
+	case "approval_decision":
+		return fmt.Sprintf("📝 Approval Decision: %s is now %s", taskTitle, status)
 	default:
 		return fmt.Sprintf("Remindly Notification: %s", taskTitle)
 	}
@@ -203,7 +217,82 @@ func buildNotificationMessage(notificationType, taskTitle, statusFormatted, time
 		return fmt.Sprintf("The status of your task \"%s\" has been updated to: %s", taskTitle, statusFormatted)
 	case "update":
 		return fmt.Sprintf("Your task \"%s\" has been updated. Please review the changes below.", taskTitle)
+	case "mention":
+		return fmt.Sprintf("You were mentioned in a comment on the task \"%s\".", taskTitle)
+	case "unblocked":
+		return fmt.Sprintf("Everything your task \"%s\" was waiting on is done. You can start on it now.", taskTitle)
+	case "approval_request":
+		return fmt.Sprintf("The task \"%s\" is waiting for your approval. Approve or reject it from the group's approval queue.", taskTitle)
+	case "approval_decision":
+		return fmt.Sprintf("Your request for the task \"%s\" has been reviewed. The task is now: %s", taskTitle, statusFormatted)
 	default:
 		return fmt.Sprintf("You have a notification about: %s", taskTitle)
 	}
 }
+
+// AppURL returns the frontend base URL used in links inside emails
+func AppURL() string {
+	baseURL := os.Getenv("APP_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:5173"
+	}
+	return baseURL
+}
+
+// BuildInvitationContent renders the email sent to someone invited to a group
+func BuildInvitationContent(inviterName, groupName, role, acceptURL string, expiresAt time.Time) (subject, body string) {
+	subject = fmt.Sprintf("📨 %s invited you to join %s on Remindly", inviterName, groupName)
+
+	body = "Hi,\n\n"
+	body += fmt.Sprintf("%s has invited you to join the group \"%s\" as %s.\n\n", inviterName, groupName, role)
+	body += fmt.Sprintf("Accept the invitation: %s\n\n", acceptURL)
+	body += "If you don't have a Remindly account yet, the link lets you sign up and join the group in one step.\n"
+	body += fmt.Sprintf("The link can be used once and expires on %s.\n\n", expiresAt.Format("January 2, 2006 at 3:04 PM MST"))
+	body += "---\n"
+	body += "If you weren't expecting this invitation, you can ignore this email."
+
+	return subject, body
+}
+
+// BuildEmailVerificationContent renders the email asking a user to confirm their address
+func BuildEmailVerificationContent(userName, verifyURL string, expiresAt time.Time) (subject, body string) {
+	subject = "✅ Confirm your email address for Remindly"
+
+	body = fmt.Sprintf("Hi %s,\n\n", userName)
+	body += "Please confirm that this is your email address so you can accept group invitations sent to it.\n\n"
+	body += fmt.Sprintf("Confirm your email: %s\n\n", verifyURL)
+	body += fmt.Sprintf("The link expires on %s.\n\n", expiresAt.Format("January 2, 2006 at 3:04 PM MST"))
+	body += "---\n"
+	body += "If you didn't create a Remindly account, you can ignore this email."
+
+	return subject, body
+}
+
+// BuildPasswordResetContent renders the email with a password reset link
+func BuildPasswordResetContent(userName, resetURL string, expiresAt time.Time) (subject, body string) {
+	subject = "🔑 Reset your Remindly password"
+
+	body = fmt.Sprintf("Hi %s,\n\n", userName)
+	body += "We received a request to reset your password.\n\n"
+	body += fmt.Sprintf("Choose a new password: %s\n\n", resetURL)
+	body += fmt.Sprintf("The link can be used once and expires on %s. Resetting your password signs you out on all devices.\n\n", expiresAt.Format("January 2, 2006 at 3:04 PM MST"))
+	body += "---\n"
+	body += "If you didn't ask to reset your password, you can ignore this email; your password stays the same."
+
+	return subject, body
+}
+
+// BuildStorageWarningContent renders the email telling a group owner the group is running out of storage
+func BuildStorageWarningContent(userName, groupName string, usedBytes, quotaBytes int64, storageURL string) (subject, body string) {
+	percent := usedBytes * 100 / quotaBytes
+	subject = fmt.Sprintf("💾 %s has used %d%% of its storage on Remindly", groupName, percent)
+
+	body = fmt.Sprintf("Hi %s,\n\n", userName)
+	body += fmt.Sprintf("The group \"%s\" stores %s of its %s quota (%d%%).\n\n", groupName, FormatBytes(usedBytes), FormatBytes(quotaBytes), percent)
+	body += "Uploads that would go over the quota are refused. Delete files or old versions you no longer need to free up space.\n\n"
+	body += fmt.Sprintf("See what uses the storage: %s\n\n", storageURL)
+	body += "---\n"
+	body += "You receive this email because you own the group."
+
+	return subject, body
+}
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalBlobStore keeps objects as files under a directory, for self-hosting and local development.
+// The SHA-256 of every object is recorded under .checksums. Presigned URLs point at the API's
+// storage endpoint and carry an HMAC signature of the method, key and expiry instead of S3
+// credentials; upload URLs also sign the size and SHA-256 the content must have.
+type LocalBlobStore struct {
+	root    string
+	baseURL string
+	secret  []byte
+}
+
+// NewLocalBlobStore stores objects in LOCAL_STORAGE_PATH (default ./data/files) and signs URLs
+// under LOCAL_STORAGE_URL (default /api/storage) with STORAGE_SIGNING_SECRET, or JWT_SECRET
+func NewLocalBlobStore() (*LocalBlobStore, error) {
+	root := os.Getenv("LOCAL_STORAGE_PATH")
+	if root == "" {
+		root = filepath.Join("data", "files")
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOCAL_STORAGE_PATH: %w", err)
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	secret := os.Getenv("STORAGE_SIGNING_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	if secret == "" {
+		return nil, errors.New("STORAGE_SIGNING_SECRET or JWT_SECRET must be set to sign storage URLs")
+	}
+
+	baseURL := os.Getenv("LOCAL_STORAGE_URL")
+	if baseURL == "" {
+		baseURL = "/api/storage"
+	}
+
+	return &LocalBlobStore{
+		root:    root,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  []byte(secret),
+	}, nil
+}
+
+// ErrContentMismatch is returned by PutVerified when the body is not the announced content
+var ErrContentMismatch = errors.New("content does not match the announced size and SHA-256")
+
+func (s *LocalBlobStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	return s.write(key, body, nil)
+}
+
+// PutVerified stores the body only when it has the size and SHA-256 of the content, as uploads to
+// presigned URLs must. A body that does not match leaves the stored object untouched.
+func (s *LocalBlobStore) PutVerified(ctx context.Context, key string, body io.Reader, content UploadContent) error {
+	return s.write(key, body, &content)
+}
+
+func (s *LocalBlobStore) write(key string, body io.Reader, expected *UploadContent) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// Write next to the destination and rename, so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), body)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if expected != nil && (written != expected.Size || hex.EncodeToString(hash.Sum(nil)) != expected.SHA256) {
+		return ErrContentMismatch
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return s.writeChecksum(key, base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+}
+
+func (s *LocalBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(s.checksumPath(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Head returns the size and recorded checksum of the object; the type is guessed from the key's extension
+func (s *LocalBlobStore) Head(ctx context.Context, key string) (*BlobInfo, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is not an object", key)
+	}
+	// Objects stored before checksums were recorded have none
+	checksum, err := os.ReadFile(s.checksumPath(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return &BlobInfo{
+		Size:           info.Size(),
+		ContentType:    mime.TypeByExtension(filepath.Ext(key)),
+		ChecksumSHA256: string(checksum),
+	}, nil
+}
+
+func (s *LocalBlobStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return s.signedURL("GET", key, nil, expires)
+}
+
+func (s *LocalBlobStore) PresignPut(ctx context.Context, key string, content UploadContent, expires time.Duration) (*PresignedUpload, error) {
+	url, err := s.signedURL("PUT", key, &content, expires)
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedUpload{URL: url}, nil
+}
+
+func (s *LocalBlobStore) Bucket() string {
+	return "local"
+}
+
+// VerifySignedRequest checks the expiry and signature of a presigned URL for the method and key.
+// For uploads it returns the content the URL was signed for.
+func (s *LocalBlobStore) VerifySignedRequest(method string, key string, query url.Values) (*UploadContent, error) {
+	expiresAt, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid expiry")
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, errors.New("link has expired")
+	}
+
+	var content *UploadContent
+	if method == "PUT" {
+		size, err := strconv.ParseInt(query.Get("size"), 10, 64)
+		if err != nil {
+			return nil, errors.New("invalid size")
+		}
+		content = &UploadContent{Size: size, SHA256: query.Get("sha256")}
+	}
+	if !hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(method, key, expiresAt, content))) {
+		return nil, errors.New("invalid signature")
+	}
+	return content, nil
+}
+
+func (s *LocalBlobStore) signedURL(method string, key string, content *UploadContent, expires time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expiresAt := time.Now().Add(expires).Unix()
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	if content != nil {
+		query.Set("size", strconv.FormatInt(content.Size, 10))
+		query.Set("sha256", content.SHA256)
+	}
+	query.Set("signature", s.sign(method, key, expiresAt, content))
+	return s.baseURL + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+func (s *LocalBlobStore) sign(method string, key string, expiresAt int64, content *UploadContent) string {
+	message := method + "\n" + key + "\n" + strconv.FormatInt(expiresAt, 10)
+	if content != nil {
+		message += "\n" + strconv.FormatInt(content.Size, 10) + "\n" + content.SHA256
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checksumDir holds the checksums of the objects, mirroring their paths
+const checksumDir = ".checksums"
+
+// writeChecksum records the base64 SHA-256 of an object
+func (s *LocalBlobStore) writeChecksum(key string, checksum string) error {
+	path := s.checksumPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checksum-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(checksum); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// checksumPath is where the checksum of a key is recorded; the key must have passed path
+func (s *LocalBlobStore) checksumPath(key string) string {
+	return filepath.Join(s.root, checksumDir, filepath.FromSlash(key))
+}
+
+// path maps a key to its file, refusing keys that would escape the storage directory or reach
+// the checksums
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || strings.Split(key, "/")[0] == checksumDir {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
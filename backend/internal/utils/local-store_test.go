@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStore(t *testing.T) *LocalBlobStore {
+	t.Helper()
+	t.Setenv("LOCAL_STORAGE_PATH", t.TempDir())
+	t.Setenv("STORAGE_SIGNING_SECRET", "test-secret")
+	store, err := NewLocalBlobStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// presignedQuery returns the path key and query of a presigned local storage URL
+func presignedQuery(t *testing.T, rawURL string) (string, url.Values) {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(parsed.Path, "/api/storage/"), parsed.Query()
+}
+
+func TestLocalPresignPutSignsContent(t *testing.T) {
+	store := newTestLocalStore(t)
+	data := []byte("announced content")
+	content := UploadContent{Size: int64(len(data)), SHA256: hexSHA256(data)}
+
+	upload, err := store.PresignPut(context.Background(), "group/file", content, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, query := presignedQuery(t, upload.URL)
+
+	signed, err := store.VerifySignedRequest("PUT", key, query)
+	if err != nil {
+		t.Fatalf("VerifySignedRequest: %v", err)
+	}
+	if *signed != content {
+		t.Errorf("signed content = %+v, want %+v", *signed, content)
+	}
+
+	tampered := url.Values{}
+	for name, values := range query {
+		tampered[name] = values
+	}
+	tampered.Set("size", "1000")
+	if _, err := store.VerifySignedRequest("PUT", key, tampered); err == nil {
+		t.Error("URL with a changed size was accepted")
+	}
+	tampered.Set("size", query.Get("size"))
+	tampered.Set("sha256", hexSHA256([]byte("other content")))
+	if _, err := store.VerifySignedRequest("PUT", key, tampered); err == nil {
+		t.Error("URL with a changed SHA-256 was accepted")
+	}
+	if _, err := store.VerifySignedRequest("GET", key, query); err == nil {
+		t.Error("upload URL was accepted for downloads")
+	}
+}
+
+func TestLocalPutVerified(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	data := "announced content"
+	content := UploadContent{Size: int64(len(data)), SHA256: hexSHA256([]byte(data))}
+
+	for _, body := range []string{"different content", "announced content and more", "announced"} {
+		err := store.PutVerified(ctx, "group/file", strings.NewReader(body), content)
+		if !errors.Is(err, ErrContentMismatch) {
+			t.Errorf("PutVerified(%q): got %v, want ErrContentMismatch", body, err)
+		}
+	}
+	if _, err := store.Head(ctx, "group/file"); err == nil {
+		t.Fatal("mismatched content was stored")
+	}
+
+	if err := store.PutVerified(ctx, "group/file", strings.NewReader(data), content); err != nil {
+		t.Fatalf("PutVerified: %v", err)
+	}
+	info, err := store.Head(ctx, "group/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != content.Size || info.ChecksumSHA256 != sha256Base64([]byte(data)) {
+		t.Errorf("Head = %+v, want size %d and checksum %q", info, content.Size, sha256Base64([]byte(data)))
+	}
+
+	object, err := store.Get(ctx, "group/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Close()
+	if stored, _ := io.ReadAll(object); string(stored) != data {
+		t.Errorf("stored %q, want %q", stored, data)
+	}
+}
+
+func TestLocalStoreRefusesChecksumKeys(t *testing.T) {
+	store := newTestLocalStore(t)
+	if err := store.Put(context.Background(), checksumDir+"/group/file", strings.NewReader("x"), ""); err == nil {
+		t.Fatal("Put wrote into the checksum directory")
+	}
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rankDigits are the digits of fractional ranks in ascending byte order, so ranks sort
+// correctly with a plain byte comparison (COLLATE "C" in Postgres)
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// RankBetween returns a rank that sorts strictly between before and after. An empty before means
+// "at the start" and an empty after "at the end". Ranks are base-62 fractions without trailing
+// zeros, so there is always room for another rank between two existing ones.
+func RankBetween(before string, after string) (string, error) {
+	if err := validateRank(before); err != nil {
+		return "", err
+	}
+	if err := validateRank(after); err != nil {
+		return "", err
+	}
+	if after != "" && before >= after {
+		return "", fmt.Errorf("rank %q does not sort before %q", before, after)
+	}
+	return rankMidpoint(before, after), nil
+}
+
+func validateRank(rank string) error {
+	for i := 0; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) < 0 {
+			return fmt.Errorf("invalid rank %q", rank)
+		}
+	}
+	if strings.HasSuffix(rank, "0") {
+		return fmt.Errorf("invalid rank %q", rank)
+	}
+	return nil
+}
+
+// rankMidpoint assumes before < after (an empty after is +infinity) and neither ends with a zero digit
+func rankMidpoint(before string, after string) string {
+	if after != "" {
+		// Keep the common prefix, padding before with zeros
+		n := 0
+		for n < len(after) && rankDigitAt(before, n) == after[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(before) {
+				rest = before[n:]
+			}
+			return after[:n] + rankMidpoint(rest, after[n:])
+		}
+	}
+
+	low := 0
+	if before != "" {
+		low = strings.IndexByte(rankDigits, before[0])
+	}
+	high := len(rankDigits)
+	if after != "" {
+		high = strings.IndexByte(rankDigits, after[0])
+	}
+
+	if high-low > 1 {
+		return string(rankDigits[(low+high)/2])
+	}
+
+	// Consecutive digits: a longer after can be cut to its first digit, otherwise extend before
+	if len(after) > 1 {
+		return after[:1]
+	}
+	rest := ""
+	if len(before) > 1 {
+		rest = before[1:]
+	}
+	return string(rankDigits[low]) + rankMidpoint(rest, "")
+}
+
+func rankDigitAt(rank string, i int) byte {
+	if i < len(rank) {
+		return rank[i]
+	}
+	return rankDigits[0]
+}
+
+// EvenRanks returns n ascending ranks spread evenly over the rank space. It is used to rebalance a
+// list whose ranks grew long after many inserts at the same spot.
+func EvenRanks(n int) []string {
+	width := 1
+	for space := len(rankDigits); space <= n+1; space *= len(rankDigits) {
+		width++
+	}
+	space := 1
+	for i := 0; i < width; i++ {
+		space *= len(rankDigits)
+	}
+
+	ranks := make([]string, n)
+	for i := range ranks {
+		value := (i + 1) * space / (n + 1)
+		digits := make([]byte, width)
+		for j := width - 1; j >= 0; j-- {
+			digits[j] = rankDigits[value%len(rankDigits)]
+			value /= len(rankDigits)
+		}
+		ranks[i] = strings.TrimRight(string(digits), rankDigits[:1])
+	}
+	return ranks
+}
@@ -0,0 +1,111 @@
+package utils
+
+import "testing"
+
+func TestRankBetween(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"empty list", "", ""},
+		{"at the start", "", "V"},
+		{"before the smallest digit", "", "1"},
+		{"before a rank starting with zero", "", "01"},
+		{"at the end", "V", ""},
+		{"after the largest digit", "z", ""},
+		{"after a long rank", "zzz", ""},
+		{"adjacent digits", "V", "W"},
+		{"adjacent with longer before", "Vz", "W"},
+		{"adjacent with longer after", "V", "W1"},
+		{"prefix of after", "V", "V1"},
+		{"shared prefix", "ab1", "ab2"},
+		{"zeros between", "V0001", "V001"},
+	}
+	for _, test := range tests {
+		rank, err := RankBetween(test.before, test.after)
+		if err != nil {
+			t.Errorf("%s: RankBetween(%q, %q): %v", test.name, test.before, test.after, err)
+			continue
+		}
+		if err := validateRank(rank); err != nil || rank == "" {
+			t.Errorf("%s: RankBetween(%q, %q) = %q, not a valid rank", test.name, test.before, test.after, rank)
+		}
+		if rank <= test.before || (test.after != "" && rank >= test.after) {
+			t.Errorf("%s: RankBetween(%q, %q) = %q, not between", test.name, test.before, test.after, rank)
+		}
+	}
+}
+
+func TestRankBetweenRejectsBadNeighbours(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+	}{
+		{"equal ranks", "V", "V"},
+		{"wrong order", "W", "V"},
+		{"trailing zero", "V0", ""},
+		{"invalid digit", "", "V-"},
+	}
+	for _, test := range tests {
+		if rank, err := RankBetween(test.before, test.after); err == nil {
+			t.Errorf("%s: RankBetween(%q, %q) = %q, want an error", test.name, test.before, test.after, rank)
+		}
+	}
+}
+
+func TestRankBetweenRepeatedInserts(t *testing.T) {
+	tests := []struct {
+		name string
+		// next returns the neighbours of the next insert given the first, last and previous rank
+		next func(first string, last string, previous string) (string, string)
+	}{
+		{"always at the start", func(first, last, previous string) (string, string) { return "", previous }},
+		{"always at the end", func(first, last, previous string) (string, string) { return previous, "" }},
+		{"always after the first", func(first, last, previous string) (string, string) { return first, previous }},
+		{"always before the last", func(first, last, previous string) (string, string) { return previous, last }},
+	}
+	for _, test := range tests {
+		first, last := "1", "y"
+		previous := "V"
+		for i := range 500 {
+			before, after := test.next(first, last, previous)
+			rank, err := RankBetween(before, after)
+			if err != nil {
+				t.Fatalf("%s: insert %d: RankBetween(%q, %q): %v", test.name, i, before, after, err)
+			}
+			if rank <= before || (after != "" && rank >= after) {
+				t.Fatalf("%s: insert %d: RankBetween(%q, %q) = %q, not between", test.name, i, before, after, rank)
+			}
+			previous = rank
+		}
+	}
+}
+
+func TestEvenRanks(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 61, 62, 63, 1000, 5000} {
+		ranks := EvenRanks(n)
+		if len(ranks) != n {
+			t.Errorf("EvenRanks(%d) returned %d ranks", n, len(ranks))
+			continue
+		}
+		for i, rank := range ranks {
+			if err := validateRank(rank); err != nil || rank == "" {
+				t.Errorf("EvenRanks(%d)[%d] = %q, not a valid rank", n, i, rank)
+			}
+			if i > 0 && rank <= ranks[i-1] {
+				t.Errorf("EvenRanks(%d)[%d] = %q, not after %q", n, i, rank, ranks[i-1])
+			}
+		}
+		// The rebalanced list must still leave room at both ends and between neighbours
+		if n > 0 {
+			if _, err := RankBetween("", ranks[0]); err != nil {
+				t.Errorf("EvenRanks(%d): no room before the first rank: %v", n, err)
+			}
+			if _, err := RankBetween(ranks[n-1], ""); err != nil {
+				t.Errorf("EvenRanks(%d): no room after the last rank: %v", n, err)
+			}
+		}
+	}
+}
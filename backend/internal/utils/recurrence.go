@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Alpine images ship without a zoneinfo database
+
+	"github.com/teambition/rrule-go"
+)
+
+// ParseRecurrence validates an RFC 5545 RRULE and builds a rule anchored at dtstart
+// in the given IANA timezone. An empty timezone defaults to UTC.
+func ParseRecurrence(rule string, timezone string, dtstart time.Time) (*rrule.RRule, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence timezone %q", timezone)
+	}
+
+	// Accept both "RRULE:FREQ=..." and bare "FREQ=..." forms
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("recurrence rule is empty")
+	}
+
+	option, err := rrule.StrToROptionInLocation(rule, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	// Occurrences are computed in the series timezone so that "every Monday at 9:00"
+	// stays at 9:00 local time across DST changes
+	option.Dtstart = dtstart.In(loc).Truncate(time.Second)
+
+	r, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// NextOccurrence returns the first occurrence strictly after the given time,
+// or the zero time if the rule has no further occurrences
+func NextOccurrence(rule string, timezone string, dtstart time.Time, after time.Time) (time.Time, error) {
+	r, err := ParseRecurrence(rule, timezone, dtstart)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return r.After(after.Truncate(time.Second), false), nil
+}
+
+// NormalizeRecurrenceRule strips the optional "RRULE:" prefix so rules are stored in one form
+func NormalizeRecurrenceRule(rule string) string {
+	return strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+}
@@ -8,8 +8,11 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/google/uuid"
 )
 
@@ -37,7 +40,13 @@ func NewS3Service() (*S3Service, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
-	client := s3.NewFromConfig(cfg)
+	// AWS_S3_ENDPOINT points the client at an S3-compatible store such as MinIO
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := os.Getenv("AWS_S3_ENDPOINT"); endpoint != "" {
+			o.BaseEndpoint = &endpoint
+			o.UsePathStyle = true
+		}
+	})
 	return &S3Service{
 		client: client,
 		bucket: AWS_S3_BUCKET,
@@ -46,7 +55,7 @@ func NewS3Service() (*S3Service, error) {
 
 }
 
-func (s *S3Service) UploadFile(ctx context.Context, key string, fileReader io.Reader, contentType string) error {
+func (s *S3Service) Put(ctx context.Context, key string, fileReader io.Reader, contentType string) error {
 	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      &s.bucket,
 		Key:         &key,
@@ -59,7 +68,7 @@ func (s *S3Service) UploadFile(ctx context.Context, key string, fileReader io.Re
 	return nil
 }
 
-func (s *S3Service) GetPresignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+func (s *S3Service) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
 	presignClient := s3.NewPresignClient(s.client)
 	presign, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: &s.bucket,
@@ -73,7 +82,7 @@ func (s *S3Service) GetPresignedURL(ctx context.Context, key string, expires tim
 	return presign.URL, nil
 }
 
-func (s *S3Service) DeleteFile(ctx context.Context, key string) error {
+func (s *S3Service) Delete(ctx context.Context, key string) error {
 	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
 		Bucket: &s.bucket,
 		Key:    &key,
@@ -84,7 +93,147 @@ func (s *S3Service) DeleteFile(ctx context.Context, key string) error {
 	return nil
 }
 
-func (s *S3Service) GetBucket() string {
+// PresignPut returns a URL the client can PUT the object's content to directly. The size and
+// checksum are part of the signature, so S3 refuses any other content.
+func (s *S3Service) PresignPut(ctx context.Context, key string, content UploadContent, expires time.Duration) (*PresignedUpload, error) {
+	checksum, err := ChecksumSHA256(content.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	presignClient := s3.NewPresignClient(s.client)
+	presign, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:         &s.bucket,
+		Key:            &key,
+		ContentType:    &content.ContentType,
+		ContentLength:  &content.Size,
+		ChecksumSHA256: &checksum,
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expires
+	})
+	if err != nil {
+		return nil, err
+	}
+	return presignedUpload(presign), nil
+}
+
+// CreateMultipartUpload starts a multipart upload and returns its upload ID
+func (s *S3Service) CreateMultipartUpload(ctx context.Context, key string, contentType string) (string, error) {
+	output, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            &s.bucket,
+		Key:               &key,
+		ContentType:       &contentType,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return "", err
+	}
+	return *output.UploadId, nil
+}
+
+// PresignPart returns a URL the client can PUT one part of a multipart upload to, for a part of
+// the given size and checksum only. The ETag header of the response is needed to complete the upload.
+func (s *S3Service) PresignPart(ctx context.Context, key string, uploadID string, partNumber int32, content UploadContent, expires time.Duration) (*PresignedUpload, error) {
+	checksum, err := ChecksumSHA256(content.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	presignClient := s3.NewPresignClient(s.client)
+	presign, err := presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:            &s.bucket,
+		Key:               &key,
+		UploadId:          &uploadID,
+		PartNumber:        &partNumber,
+		ContentLength:     &content.Size,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		ChecksumSHA256:    &checksum,
+	}, func(opts *s3.PresignOptions) {
+		opts.Expires = expires
+	})
+	if err != nil {
+		return nil, err
+	}
+	return presignedUpload(presign), nil
+}
+
+// presignedUpload lists the signed headers the client has to send. Host and Content-Length are
+// left out: HTTP clients set them from the URL and the body, and browsers refuse to set them.
+func presignedUpload(presign *v4.PresignedHTTPRequest) *PresignedUpload {
+	headers := map[string]string{}
+	for name, values := range presign.SignedHeader {
+		if name == "Host" || name == "Content-Length" || len(values) == 0 {
+			continue
+		}
+		headers[name] = values[0]
+	}
+	return &PresignedUpload{URL: presign.URL, Headers: headers}
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the object
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, key string, uploadID string, parts []UploadedPart) error {
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		checksum, err := ChecksumSHA256(part.SHA256)
+		if err != nil {
+			return err
+		}
+		completed = append(completed, types.CompletedPart{
+			PartNumber:     aws.Int32(part.PartNumber),
+			ETag:           aws.String(part.ETag),
+			ChecksumSHA256: aws.String(checksum),
+		})
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &s.bucket,
+		Key:             &key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts uploaded so far
+func (s *S3Service) AbortMultipartUpload(ctx context.Context, key string, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	return err
+}
+
+// Head returns the size, type and SHA-256 checksum of a stored object
+func (s *S3Service) Head(ctx context.Context, key string) (*BlobInfo, error) {
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       &s.bucket,
+		Key:          &key,
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &BlobInfo{
+		Size:           aws.ToInt64(output.ContentLength),
+		ContentType:    aws.ToString(output.ContentType),
+		ChecksumSHA256: aws.ToString(output.ChecksumSHA256),
+	}, nil
+}
+
+// Get opens a stored object for reading; the caller closes it
+func (s *S3Service) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (s *S3Service) Bucket() string {
 	return s.bucket
 }
 
@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is enough of S3 for presigned uploads: single and multipart PUTs checked against their
+// SHA-256 checksum, and HEAD with checksum mode. Signatures are not checked.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	uploads map[string]map[int32]fakeObject
+}
+
+type fakeObject struct {
+	data     []byte
+	checksum string
+}
+
+func newFakeS3(t *testing.T) *httptest.Server {
+	t.Helper()
+	fake := &fakeS3{objects: map[string]fakeObject{}, uploads: map[string]map[int32]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_S3_BUCKET", "bucket")
+	t.Setenv("AWS_S3_ENDPOINT", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	return server
+}
+
+func sha256Base64(data []byte) string {
+	sum := sha256.Sum256(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if length := r.Header.Get("Content-Length"); length != strconv.Itoa(len(data)) {
+			http.Error(w, "content length mismatch", http.StatusBadRequest)
+			return
+		}
+		checksum := query.Get("X-Amz-Checksum-Sha256")
+		if checksum == "" {
+			checksum = r.Header.Get("X-Amz-Checksum-Sha256")
+		}
+		if checksum != "" && checksum != sha256Base64(data) {
+			http.Error(w, "BadDigest", http.StatusBadRequest)
+			return
+		}
+		object := fakeObject{data: data, checksum: checksum}
+		if uploadID != "" {
+			partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+			f.uploads[uploadID][int32(partNumber)] = object
+		} else {
+			f.objects[key] = object
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"%x"`, md5.Sum(data)))
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[uploadID] = map[int32]fakeObject{}
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, key, uploadID)
+
+	case r.Method == http.MethodPost && uploadID != "":
+		var request struct {
+			Parts []struct {
+				PartNumber     int32
+				ETag           string
+				ChecksumSHA256 string
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var data []byte
+		digests := sha256.New()
+		for _, part := range request.Parts {
+			uploaded, ok := f.uploads[uploadID][part.PartNumber]
+			if !ok || part.ChecksumSHA256 != uploaded.checksum {
+				http.Error(w, "InvalidPart", http.StatusBadRequest)
+				return
+			}
+			data = append(data, uploaded.data...)
+			digest, _ := base64.StdEncoding.DecodeString(uploaded.checksum)
+			digests.Write(digest)
+		}
+		checksum := fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(digests.Sum(nil)), len(request.Parts))
+		f.objects[key] = fakeObject{data: data, checksum: checksum}
+		delete(f.uploads, uploadID)
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>%s</Key></CompleteMultipartUploadResult>`, key)
+
+	case r.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		if r.Header.Get("X-Amz-Checksum-Mode") == "ENABLED" && object.checksum != "" {
+			w.Header().Set("X-Amz-Checksum-Sha256", object.checksum)
+		}
+
+	default:
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}
+}
+
+// putPresigned uploads data the way a client would: to the URL, with the headers it came with
+func putPresigned(t *testing.T, upload *PresignedUpload, data []byte) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, upload.URL, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range upload.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChecksumSHA256(t *testing.T) {
+	data := []byte("hello")
+	checksum, err := ChecksumSHA256(hexSHA256(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum != sha256Base64(data) {
+		t.Errorf("ChecksumSHA256 = %q, want %q", checksum, sha256Base64(data))
+	}
+	if _, err := ChecksumSHA256("not-a-digest"); err == nil {
+		t.Error("ChecksumSHA256 accepted an invalid digest")
+	}
+}
+
+func TestS3PresignPutBindsSizeAndChecksum(t *testing.T) {
+	newFakeS3(t)
+	store, err := NewS3Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	data := []byte("announced content")
+
+	upload, err := store.PresignPut(ctx, "group/file", UploadContent{Size: int64(len(data)), SHA256: hexSHA256(data), ContentType: "text/plain"}, time.Hour)
+	if err != nil {
+		t.Fatalf("PresignPut: %v", err)
+	}
+	signed, err := url.Parse(upload.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := signed.Query().Get("X-Amz-Checksum-Sha256"); got != sha256Base64(data) {
+		t.Errorf("URL checksum = %q, want %q", got, sha256Base64(data))
+	}
+	if got := signed.Query().Get("X-Amz-SignedHeaders"); !strings.Contains(got, "content-length") {
+		t.Errorf("signed headers %q do not include content-length", got)
+	}
+	if got := upload.Headers["Content-Type"]; got != "text/plain" {
+		t.Errorf("Content-Type header = %q, want text/plain", got)
+	}
+
+	resp, err := putPresigned(t, upload, []byte("different content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		t.Fatal("presigned URL accepted other content")
+	}
+
+	resp, err = putPresigned(t, upload, data)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT: %v %v", resp, err)
+	}
+	info, err := store.Head(ctx, "group/file")
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	if info.Size != int64(len(data)) || info.ChecksumSHA256 != sha256Base64(data) {
+		t.Errorf("Head = %+v, want size %d and checksum %q", info, len(data), sha256Base64(data))
+	}
+}
+
+func TestS3MultipartUploadChecksum(t *testing.T) {
+	newFakeS3(t)
+	store, err := NewS3Service()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	parts := [][]byte{[]byte("first part "), []byte("second part")}
+
+	uploadID, err := store.CreateMultipartUpload(ctx, "group/large", "text/plain")
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+
+	uploaded := []UploadedPart{}
+	digests := []string{}
+	for i, data := range parts {
+		digests = append(digests, hexSHA256(data))
+		partNumber := int32(i + 1)
+		upload, err := store.PresignPart(ctx, "group/large", uploadID, partNumber, UploadContent{Size: int64(len(data)), SHA256: hexSHA256(data)}, time.Hour)
+		if err != nil {
+			t.Fatalf("PresignPart: %v", err)
+		}
+
+		if resp, err := putPresigned(t, upload, parts[len(parts)-1-i]); err != nil || resp.StatusCode == http.StatusOK {
+			t.Fatalf("part URL %d accepted another part", partNumber)
+		}
+		resp, err := putPresigned(t, upload, data)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("PUT part %d: %v %v", partNumber, resp, err)
+		}
+		uploaded = append(uploaded, UploadedPart{PartNumber: partNumber, ETag: resp.Header.Get("ETag"), SHA256: hexSHA256(data)})
+	}
+
+	if err := store.CompleteMultipartUpload(ctx, "group/large", uploadID, uploaded); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+
+	info, err := store.Head(ctx, "group/large")
+	if err != nil {
+		t.Fatalf("Head: %v", err)
+	}
+	expected, err := CompositeChecksumSHA256(digests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ChecksumSHA256 != expected {
+		t.Errorf("Head checksum = %q, want %q", info.ChecksumSHA256, expected)
+	}
+	if !strings.HasSuffix(expected, "-2") {
+		t.Errorf("composite checksum %q does not end with the part count", expected)
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is wrapped by the errors of NewSafeHTTPClient requests that were refused
+// because they would have connected to a loopback, private or otherwise non-public address
+var ErrNonPublicAddress = errors.New("refusing to connect to non-public address")
+
+// maxSafeRedirects bounds the redirects followed by NewSafeHTTPClient
+const maxSafeRedirects = 5
+
+// nonPublicPrefixes are the ranges not covered by the netip helpers that must not be reached from
+// user-supplied URLs (shared address space, benchmarking, documentation, reserved...)
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublicIP reports whether the address is routable on the public internet, i.e. not loopback,
+// private, link-local (cloud metadata endpoints live there), multicast or reserved
+func IsPublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateOutboundURL checks a user-supplied URL can be fetched: http or https, with a host and
+// without credentials. Where the host resolves to is checked when connecting.
+func ValidateOutboundURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("invalid URL: " + err.Error())
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, errors.New("only http and https URLs can be fetched")
+	}
+	if parsed.Hostname() == "" {
+		return nil, errors.New("URL has no host")
+	}
+	if parsed.User != nil {
+		return nil, errors.New("URLs with credentials cannot be fetched")
+	}
+	return parsed, nil
+}
+
+// NewSafeHTTPClient returns a client for fetching user-supplied URLs. Every connection, including
+// the ones made for redirects, is checked after DNS resolution and refused unless it goes to a
+// public address, so hostnames resolving (or rebinding) to internal services cannot be reached.
+// Environment proxies are ignored for the same reason.
+func NewSafeHTTPClient(timeout time.Duration) *http.Client {
+	return newSafeHTTPClient(timeout, func(addrPort netip.AddrPort) bool {
+		return IsPublicIP(addrPort.Addr())
+	})
+}
+
+// newSafeHTTPClient builds a client that only connects where allowed says it may
+func newSafeHTTPClient(timeout time.Duration, allowed func(netip.AddrPort) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("refusing to connect to %s: %w", address, err)
+			}
+			if !allowed(addrPort) {
+				return fmt.Errorf("%w %s", ErrNonPublicAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxSafeRedirects {
+				return errors.New("too many redirects")
+			}
+			_, err := ValidateOutboundURL(req.URL.String())
+			return err
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+)
+
+// GenerateSignedToken creates an opaque single-use token for emailed links (invitations, password
+// resets, ...). The token is random bytes signed with JWT_SECRET for the given purpose, so a token
+// issued for one purpose is rejected for another. Only the returned hash should be stored; it is
+// what VerifySignedToken gives back for the lookup.
+func GenerateSignedToken(purpose string) (token string, tokenHash string, err error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", errors.New("failed to generate token: " + err.Error())
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(nonce)
+	token = payload + "." + signTokenPayload(purpose, payload)
+
+	return token, HashToken(token), nil
+}
+
+// VerifySignedToken checks the token signature for the purpose and returns the hash to look it up by
+func VerifySignedToken(purpose string, token string) (string, error) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok || payload == "" || signature == "" {
+		return "", errors.New("invalid token")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signTokenPayload(purpose, payload))) {
+		return "", errors.New("invalid token")
+	}
+
+	return HashToken(token), nil
+}
+
+// HashToken returns the SHA-256 hex digest stored in place of a token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func signTokenPayload(purpose string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+	mac.Write([]byte(purpose + ":" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTPService sends email through a plain SMTP server. It is used when SendGrid is not configured,
+// e.g. with a self-hosted mail relay or a local stand-in such as MailHog during development.
+type SMTPService struct {
+	addr      string
+	auth      smtp.Auth
+	fromEmail string
+	fromName  string
+}
+
+var smtpService *SMTPService
+
+func InitSMTPService() error {
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+	username := os.Getenv("SMTP_USERNAME")
+	password := os.Getenv("SMTP_PASSWORD")
+	fromEmail := os.Getenv("SMTP_FROM_EMAIL")
+	fromName := os.Getenv("SMTP_FROM_NAME")
+	if host == "" {
+		return fmt.Errorf("SMTP_HOST is not set")
+	}
+
+	if fromEmail == "" {
+		return fmt.Errorf("SMTP_FROM_EMAIL is not set")
+	}
+
+	if port == "" {
+		port = "587"
+	}
+
+	if fromName == "" {
+		fromName = "Remindly"
+	}
+
+	// Servers without authentication (local relays) are allowed
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	smtpService = &SMTPService{
+		addr:      net.JoinHostPort(host, port),
+		auth:      auth,
+		fromEmail: fromEmail,
+		fromName:  fromName,
+	}
+	return nil
+}
+
+func GetSMTPService() *SMTPService {
+	return smtpService
+}
+
+func (ss *SMTPService) SendNotificationEmail(toEmail string, toName string, subject, content string) error {
+	if ss == nil {
+		return fmt.Errorf("smtp service not initialized")
+	}
+
+	from := (&mail.Address{Name: ss.fromName, Address: ss.fromEmail}).String()
+	to := (&mail.Address{Name: toName, Address: toEmail}).String()
+
+	var message strings.Builder
+	message.WriteString("From: " + from + "\r\n")
+	message.WriteString("To: " + to + "\r\n")
+	message.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	message.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	message.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n"))
+
+	// smtp.SendMail upgrades to TLS with STARTTLS when the server supports it
+	err := smtp.SendMail(ss.addr, ss.auth, ss.fromEmail, []string{toEmail}, []byte(message.String()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpStandIn accepts one mail over plain SMTP and hands back the envelope and DATA section
+type smtpStandIn struct {
+	addr     string
+	received chan smtpMail
+}
+
+type smtpMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &smtpStandIn{addr: listener.Addr().String(), received: make(chan smtpMail, 1)}
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		server.serve(textproto.NewConn(conn))
+	}()
+	return server
+}
+
+func (s *smtpStandIn) serve(conn *textproto.Conn) {
+	var mail smtpMail
+	conn.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 localhost")
+		case "MAIL":
+			mail.from = strings.TrimPrefix(line, "MAIL FROM:")
+			conn.PrintfLine("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, strings.TrimPrefix(line, "RCPT TO:"))
+			conn.PrintfLine("250 OK")
+		case "DATA":
+			conn.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.received <- mail
+			conn.PrintfLine("250 OK")
+		case "QUIT":
+			conn.PrintfLine("221 Bye")
+			return
+		default:
+			conn.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPServiceSendsNotificationEmail(t *testing.T) {
+	server := newSMTPStandIn(t)
+	host, port, _ := net.SplitHostPort(server.addr)
+	t.Setenv("SMTP_HOST", host)
+	t.Setenv("SMTP_PORT", port)
+	t.Setenv("SMTP_USERNAME", "")
+	t.Setenv("SMTP_FROM_EMAIL", "noreply@example.com")
+	t.Setenv("SMTP_FROM_NAME", "")
+	if err := InitSMTPService(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { smtpService = nil })
+
+	err := GetSMTPService().SendNotificationEmail("ada@example.com", "Ada", "Fällig: Bericht", "Line one\nLine two")
+	if err != nil {
+		t.Fatalf("SendNotificationEmail: %v", err)
+	}
+
+	mail := <-server.received
+	if mail.from != "<noreply@example.com>" || len(mail.to) != 1 || mail.to[0] != "<ada@example.com>" {
+		t.Errorf("envelope from %q to %q", mail.from, mail.to)
+	}
+
+	message, err := textproto.NewReader(bufio.NewReader(strings.NewReader(mail.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("message headers: %v", err)
+	}
+	if got := message.Get("From"); got != `"Remindly" <noreply@example.com>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := message.Get("To"); got != `"Ada" <ada@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+	if got := message.Get("Subject"); got != "=?utf-8?q?F=C3=A4llig:_Bericht?=" {
+		t.Errorf("Subject = %q, want it Q-encoded", got)
+	}
+	if !strings.HasSuffix(mail.data, "\n\nLine one\nLine two\n") {
+		t.Errorf("body not found at the end of %q", mail.data)
+	}
+}
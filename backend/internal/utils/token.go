@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -10,13 +11,42 @@ import (
 type AuthDetails struct {
 	UserID   string `json:"userId"`
 	AuthUuid string `json:"auth_uuid"`
+	// Set by VerifyToken for tokens issued before access tokens expired. They are only accepted
+	// for sessions from that time, which have no refresh token.
+	Legacy bool `json:"-"`
 }
 
+// RefreshTokenPurpose scopes signed refresh tokens (see GenerateSignedToken)
+const RefreshTokenPurpose = "refresh_token"
+
+// AccessTokenTTL is the lifetime of access tokens (ACCESS_TOKEN_TTL, default 15 minutes)
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+}
+
+// RefreshTokenTTL is how long a session survives without being refreshed (REFRESH_TOKEN_TTL, default 30 days)
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return fallback
+}
+
+// GenerateToken issues a short-lived access token for a session
 func GenerateToken(claims AuthDetails) (string, error) {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"authorized": true,
 		"auth_uuid":  claims.AuthUuid,
 		"user_id":    claims.UserID,
+		"iat":        now.Unix(),
+		"exp":        now.Add(AccessTokenTTL()).Unix(),
 	})
 
 	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
@@ -35,6 +65,9 @@ func VerifyToken(token string) (*AuthDetails, error) {
 	})
 
 	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errors.New("token expired")
+		}
 		return nil, errors.New("invalid token")
 	}
 
@@ -58,9 +91,12 @@ func VerifyToken(token string) (*AuthDetails, error) {
 		return nil, errors.New("could not extract uuid from token")
 	}
 
+	_, expiring := claims["exp"]
+
 	authDetails := &AuthDetails{
 		UserID:   userId,
 		AuthUuid: authUuid,
+		Legacy:   !expiring,
 	}
 
 	return authDetails, nil
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// TrashRetention is how long deleted tasks, files, folders and links stay in a group's trash before
+// they are purged (TRASH_RETENTION_DAYS, default 30)
+func TrashRetention() time.Duration {
+	days := 30
+	if value, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && value > 0 {
+		days = value
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A task series holds the recurrence rule shared by every occurrence of a recurring task
+CREATE TABLE task_series (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    recurrence_rule TEXT NOT NULL, -- RFC 5545 RRULE without the "RRULE:" prefix
+    recurrence_timezone TEXT NOT NULL DEFAULT 'UTC', -- IANA timezone the rule is evaluated in
+    dtstart TIMESTAMPTZ NOT NULL, -- Anchor of the rule (due date of the first occurrence)
+    last_occurrence_at TIMESTAMPTZ NOT NULL, -- Latest occurrence that has been materialized
+    ended_at TIMESTAMPTZ, -- Set when the rule is exhausted or the series is cut short
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE tasks ADD COLUMN series_id UUID REFERENCES task_series(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN occurrence_at TIMESTAMPTZ; -- Scheduled occurrence this task was generated for
+
+-- Prevent the generator from materializing the same occurrence twice
+CREATE UNIQUE INDEX idx_tasks_series_occurrence
+    ON tasks(series_id, occurrence_at)
+    WHERE series_id IS NOT NULL;
+
+CREATE INDEX idx_task_series_active
+    ON task_series(last_occurrence_at)
+    WHERE ended_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_series_active;
+DROP INDEX IF EXISTS idx_tasks_series_occurrence;
+ALTER TABLE tasks DROP COLUMN IF EXISTS occurrence_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS task_series;
+-- +goose StatementEnd
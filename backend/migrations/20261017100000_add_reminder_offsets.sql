@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Per-user preferences; reminder_offsets are minutes before the due date (default: 24 hours)
+CREATE TABLE user_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    reminder_offsets INT[] NOT NULL DEFAULT ARRAY[1440],
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Per-task override of the assignees' default reminder offsets (NULL = use each assignee's preferences)
+ALTER TABLE tasks ADD COLUMN reminder_offsets INT[];
+
+-- The reminder rule (minutes before due date) a notification was generated from
+ALTER TABLE task_notifications ADD COLUMN reminder_offset INT;
+
+-- One reminder per rule and due date; a moved due date produces a new row
+CREATE UNIQUE INDEX idx_task_notifications_reminder_rule
+    ON task_notifications(task_id, user_id, reminder_offset, scheduled_at)
+    WHERE reminder_offset IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_notifications_reminder_rule;
+ALTER TABLE task_notifications DROP COLUMN IF EXISTS reminder_offset;
+ALTER TABLE tasks DROP COLUMN IF EXISTS reminder_offsets;
+DROP TABLE IF EXISTS user_preferences;
+-- +goose StatementEnd
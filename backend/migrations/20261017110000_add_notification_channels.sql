@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Channels per notification type, e.g. {"reminder": ["email", "in_app"], "update": ["in_app"]}.
+-- Types that are not listed are delivered over the default channels.
+ALTER TABLE user_preferences ADD COLUMN notification_channels JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- Target of the "webhook" channel
+ALTER TABLE user_preferences ADD COLUMN webhook_url TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS webhook_url;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS notification_channels;
+-- +goose StatementEnd
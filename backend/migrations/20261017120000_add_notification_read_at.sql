@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Claimed by a worker and being delivered; sent_at is only set once delivery succeeded
+INSERT INTO notification_statuses (status, description) VALUES
+    ('sending', 'Notification is being delivered');
+
+-- Set when the user reads the notification in the app; unread = sent and read_at IS NULL
+ALTER TABLE task_notifications ADD COLUMN read_at TIMESTAMPTZ;
+
+CREATE INDEX idx_task_notifications_unread
+    ON task_notifications(user_id)
+    WHERE status = 'sent' AND read_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_notifications_unread;
+ALTER TABLE task_notifications DROP COLUMN IF EXISTS read_at;
+UPDATE task_notifications SET status = 'pending' WHERE status = 'sending';
+DELETE FROM notification_statuses WHERE status = 'sending';
+-- +goose StatementEnd
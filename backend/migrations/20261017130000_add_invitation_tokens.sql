@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SHA-256 of the emailed accept token; cleared once the invitation leaves the pending state
+ALTER TABLE group_invitations ADD COLUMN token_hash TEXT;
+
+CREATE UNIQUE INDEX idx_group_invitations_token_hash
+    ON group_invitations(token_hash)
+    WHERE token_hash IS NOT NULL;
+
+-- Invitations created before expiry was enforced get the default 7 day lifetime
+UPDATE group_invitations
+SET expires_at = created_at + INTERVAL '7 days'
+WHERE status = 'pending' AND expires_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_group_invitations_token_hash;
+ALTER TABLE group_invitations DROP COLUMN IF EXISTS token_hash;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each auths row is one login session (device). Access tokens reference it by auth_uuid and are
+-- rejected once the row is gone; the refresh token rotates on every use.
+ALTER TABLE auths ADD COLUMN refresh_token_hash TEXT; -- SHA-256 of the current refresh token
+ALTER TABLE auths ADD COLUMN previous_refresh_token_hash TEXT; -- Last rotated-out token, used to detect reuse
+ALTER TABLE auths ADD COLUMN user_agent TEXT;
+ALTER TABLE auths ADD COLUMN ip_address TEXT;
+ALTER TABLE auths ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE auths ADD COLUMN last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE auths ADD COLUMN expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '30 days';
+
+-- Sessions from before refresh tokens keep a NULL refresh_token_hash. Their non-expiring access
+-- tokens stay valid until expires_at (30 days from now), after which those users log in again.
+
+CREATE UNIQUE INDEX idx_auths_auth_uuid ON auths(auth_uuid);
+CREATE UNIQUE INDEX idx_auths_refresh_token_hash ON auths(refresh_token_hash);
+CREATE INDEX idx_auths_user_id ON auths(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_auths_user_id;
+DROP INDEX IF EXISTS idx_auths_refresh_token_hash;
+DROP INDEX IF EXISTS idx_auths_auth_uuid;
+ALTER TABLE auths DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE auths DROP COLUMN IF EXISTS last_used_at;
+ALTER TABLE auths DROP COLUMN IF EXISTS created_at;
+ALTER TABLE auths DROP COLUMN IF EXISTS ip_address;
+ALTER TABLE auths DROP COLUMN IF EXISTS user_agent;
+ALTER TABLE auths DROP COLUMN IF EXISTS previous_refresh_token_hash;
+ALTER TABLE auths DROP COLUMN IF EXISTS refresh_token_hash;
+-- +goose StatementEnd
@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    edited_at TIMESTAMPTZ, -- Set on every edit; the previous bodies live in task_comment_edits
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_comments_task ON task_comments(task_id, created_at);
+
+-- One row per edit holding the body as it was before the edit
+CREATE TABLE task_comment_edits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    comment_id UUID NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    previous_body TEXT NOT NULL,
+    edited_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_comment_edits_comment ON task_comment_edits(comment_id, created_at);
+
+CREATE TABLE task_comment_mentions (
+    comment_id UUID NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (comment_id, user_id)
+);
+
+-- Non-comment entries of a task's activity feed
+CREATE TABLE task_activities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL when the change was made by the system
+    activity_type TEXT NOT NULL CHECK (activity_type IN ('status_change', 'assigned', 'unassigned', 'due_date_change')),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_activities_task ON task_activities(task_id, created_at);
+
+INSERT INTO notification_types (type, description) VALUES
+    ('mention', 'User was mentioned in a task comment');
+
+-- Links mention notifications to the comment they came from
+ALTER TABLE task_notifications ADD COLUMN comment_id UUID REFERENCES task_comments(id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_notifications DROP COLUMN IF EXISTS comment_id;
+DELETE FROM task_notifications WHERE notification_type = 'mention';
+DELETE FROM notification_types WHERE type = 'mention';
+DROP INDEX IF EXISTS idx_task_activities_task;
+DROP TABLE IF EXISTS task_activities;
+DROP TABLE IF EXISTS task_comment_mentions;
+DROP INDEX IF EXISTS idx_task_comment_edits_comment;
+DROP TABLE IF EXISTS task_comment_edits;
+DROP INDEX IF EXISTS idx_task_comments_task;
+DROP TABLE IF EXISTS task_comments;
+-- +goose StatementEnd
@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tasks ADD COLUMN parent_id UUID REFERENCES tasks(id) ON DELETE CASCADE;
+-- When set, the task cannot be completed while any of its subtasks is still open
+ALTER TABLE tasks ADD COLUMN require_subtasks_complete BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_tasks_parent ON tasks(parent_id) WHERE parent_id IS NOT NULL;
+
+-- A subtask must live in its parent's group and must not end up as its own ancestor
+CREATE OR REPLACE FUNCTION check_task_parent()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.parent_id IS NULL THEN
+        RETURN NEW;
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM tasks WHERE id = NEW.parent_id AND group_id = NEW.group_id
+    ) THEN
+        RAISE EXCEPTION 'Parent task % does not belong to group %', NEW.parent_id, NEW.group_id;
+    END IF;
+
+    IF EXISTS (
+        WITH RECURSIVE ancestors AS (
+            SELECT id, parent_id FROM tasks WHERE id = NEW.parent_id
+            UNION
+            SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+        )
+        SELECT 1 FROM ancestors WHERE id = NEW.id
+    ) THEN
+        RAISE EXCEPTION 'Task % cannot be a subtask of its own subtask', NEW.id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER task_parent_check
+    BEFORE INSERT OR UPDATE OF parent_id, group_id ON tasks
+    FOR EACH ROW
+    EXECUTE FUNCTION check_task_parent();
+
+CREATE TABLE task_checklist_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMPTZ,
+    completed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_task_checklist_items_task ON task_checklist_items(task_id, position);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_checklist_items_task;
+DROP TABLE IF EXISTS task_checklist_items;
+DROP TRIGGER IF EXISTS task_parent_check ON tasks;
+DROP FUNCTION IF EXISTS check_task_parent();
+DROP INDEX IF EXISTS idx_tasks_parent;
+ALTER TABLE tasks DROP COLUMN IF EXISTS require_subtasks_complete;
+ALTER TABLE tasks DROP COLUMN IF EXISTS parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- task_id cannot start until depends_on_id is done
+CREATE TABLE task_dependencies (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, depends_on_id),
+    CHECK (task_id <> depends_on_id)
+);
+
+CREATE INDEX idx_task_dependencies_depends_on ON task_dependencies(depends_on_id);
+
+INSERT INTO notification_types (type, description) VALUES
+    ('unblocked', 'All tasks blocking the task are done');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM task_notifications WHERE notification_type = 'unblocked';
+DELETE FROM notification_types WHERE type = 'unblocked';
+DROP INDEX IF EXISTS idx_task_dependencies_depends_on;
+DROP TABLE IF EXISTS task_dependencies;
+-- +goose StatementEnd
@@ -0,0 +1,80 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_priorities (
+    priority TEXT PRIMARY KEY,
+    rank INT NOT NULL UNIQUE, -- Higher is more urgent; used for sorting
+    description TEXT NOT NULL
+);
+
+INSERT INTO task_priorities (priority, rank, description) VALUES
+    ('none', 0, 'No priority set'),
+    ('low', 1, 'Can wait'),
+    ('medium', 2, 'Should be done soon'),
+    ('high', 3, 'Important'),
+    ('urgent', 4, 'Needs attention right away');
+
+ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT 'none' REFERENCES task_priorities(priority);
+
+CREATE INDEX idx_tasks_group_priority ON tasks(group_id, priority);
+
+CREATE TABLE group_labels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    color TEXT NOT NULL CHECK (color ~ '^#[0-9a-fA-F]{6}$'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_group_labels_name ON group_labels(group_id, LOWER(name));
+
+CREATE TABLE task_labels (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    label_id UUID NOT NULL REFERENCES group_labels(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX idx_task_labels_label ON task_labels(label_id);
+
+CREATE TABLE group_custom_fields (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    field_type TEXT NOT NULL CHECK (field_type IN ('text', 'number', 'date', 'select')),
+    options TEXT[] NOT NULL DEFAULT '{}', -- Allowed values of select fields
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_group_custom_fields_name ON group_custom_fields(group_id, LOWER(name));
+
+-- Values are stored in the column matching the field type so they can be compared and sorted natively
+CREATE TABLE task_custom_field_values (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_id UUID NOT NULL REFERENCES group_custom_fields(id) ON DELETE CASCADE,
+    value_text TEXT, -- text and select fields
+    value_number NUMERIC,
+    value_date TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (task_id, field_id)
+);
+
+CREATE INDEX idx_task_custom_field_values_field ON task_custom_field_values(field_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_custom_field_values_field;
+DROP TABLE IF EXISTS task_custom_field_values;
+DROP INDEX IF EXISTS idx_group_custom_fields_name;
+DROP TABLE IF EXISTS group_custom_fields;
+DROP INDEX IF EXISTS idx_task_labels_label;
+DROP TABLE IF EXISTS task_labels;
+DROP INDEX IF EXISTS idx_group_labels_name;
+DROP TABLE IF EXISTS group_labels;
+DROP INDEX IF EXISTS idx_tasks_group_priority;
+ALTER TABLE tasks DROP COLUMN IF EXISTS priority;
+DROP TABLE IF EXISTS task_priorities;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE boards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_boards_group ON boards(group_id);
+
+-- Columns are user-defined; each maps onto one of the task statuses and several columns may share a status
+CREATE TABLE board_columns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    status TEXT NOT NULL REFERENCES task_statuses(status),
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_board_columns_board ON board_columns(board_id, position);
+
+-- Where a task sits on a board. Tasks without a row (or whose status no longer matches the column)
+-- are shown in the first column mapped to their status, after the ranked ones.
+CREATE TABLE task_board_positions (
+    board_id UUID NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    column_id UUID NOT NULL REFERENCES board_columns(id) ON DELETE CASCADE,
+    rank TEXT COLLATE "C" NOT NULL, -- Fractional rank within the column, compared bytewise
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (board_id, task_id)
+);
+
+CREATE INDEX idx_task_board_positions_column ON task_board_positions(column_id, rank);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_task_board_positions_column;
+DROP TABLE IF EXISTS task_board_positions;
+DROP INDEX IF EXISTS idx_board_columns_board;
+DROP TABLE IF EXISTS board_columns;
+DROP INDEX IF EXISTS idx_boards_group;
+DROP TABLE IF EXISTS boards;
+-- +goose StatementEnd
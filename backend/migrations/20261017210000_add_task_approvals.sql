@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One row per request to have a pending task approved; decided rows are the task's approval history
+CREATE TABLE task_approvals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    requested_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    decided_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    decision_comment TEXT,
+    decided_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A task has at most one open request at a time
+CREATE UNIQUE INDEX idx_task_approvals_open
+    ON task_approvals(task_id)
+    WHERE status = 'pending';
+
+CREATE INDEX idx_task_approvals_task ON task_approvals(task_id, created_at);
+
+-- Tasks already waiting for approval enter the queue
+INSERT INTO task_approvals (task_id, requested_by, created_at, updated_at)
+SELECT id, created_by, created_at, created_at FROM tasks WHERE status = 'pending';
+
+INSERT INTO notification_types (type, description) VALUES
+    ('approval_request', 'A task is waiting for approval'),
+    ('approval_decision', 'An approval request was approved or rejected');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM task_notifications WHERE notification_type IN ('approval_request', 'approval_decision');
+DELETE FROM notification_types WHERE type IN ('approval_request', 'approval_decision');
+DROP INDEX IF EXISTS idx_task_approvals_task;
+DROP INDEX IF EXISTS idx_task_approvals_open;
+DROP TABLE IF EXISTS task_approvals;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- workflow_data holds the trigger, conditions and actions (see models.WorkflowDefinition)
+ALTER TABLE workflows ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Execution log: one row per time a workflow's trigger and conditions matched a task
+CREATE TABLE workflow_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workflow_id UUID NOT NULL REFERENCES workflows(id) ON DELETE CASCADE,
+    task_id UUID REFERENCES tasks(id) ON DELETE SET NULL,
+    trigger_type TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    actions JSONB NOT NULL DEFAULT '[]', -- Outcome of each action, in order
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_workflow_runs_workflow ON workflow_runs(workflow_id, created_at DESC);
+
+-- Overdue workflows fire once per task
+CREATE UNIQUE INDEX idx_workflow_runs_overdue_once
+    ON workflow_runs(workflow_id, task_id)
+    WHERE trigger_type = 'task_overdue';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workflow_runs_overdue_once;
+DROP INDEX IF EXISTS idx_workflow_runs_workflow;
+DROP TABLE IF EXISTS workflow_runs;
+ALTER TABLE workflows DROP COLUMN IF EXISTS enabled;
+-- +goose StatementEnd
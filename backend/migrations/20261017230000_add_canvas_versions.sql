@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Number of operations applied to canvas_data; collaborative edits are ordered by it
+ALTER TABLE canvases ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE canvases DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
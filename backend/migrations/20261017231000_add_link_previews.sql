@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Link previews are fetched in the background after a link is saved
+ALTER TABLE links
+    ADD COLUMN preview_status TEXT NOT NULL DEFAULT 'pending' CHECK (preview_status IN ('pending', 'ready', 'failed')),
+    ADD COLUMN preview_error TEXT,
+    ADD COLUMN preview_fetched_at TIMESTAMPTZ;
+
+-- Links saved before previews existed keep whatever the client sent
+UPDATE links SET preview_status = 'ready';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE links
+    DROP COLUMN IF EXISTS preview_fetched_at,
+    DROP COLUMN IF EXISTS preview_error,
+    DROP COLUMN IF EXISTS preview_status;
+-- +goose StatementEnd
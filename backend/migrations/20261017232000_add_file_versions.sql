@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every upload of a file is a version; the files row mirrors the current one. Restoring a version
+-- appends a new version pointing at the same S3 object, so objects can be shared between versions.
+CREATE TABLE file_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    version_number INT NOT NULL,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    mime_type TEXT NOT NULL,
+    s3_bucket TEXT NOT NULL,
+    s3_key TEXT NOT NULL,
+    restored_from INT, -- Version this one restored
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(file_id, version_number)
+);
+
+CREATE INDEX idx_file_versions_s3_key ON file_versions(s3_bucket, s3_key);
+
+ALTER TABLE files ADD COLUMN current_version INT NOT NULL DEFAULT 1;
+
+-- Existing files become their own first version
+INSERT INTO file_versions (file_id, version_number, name, size, mime_type, s3_bucket, s3_key, created_by, created_at)
+SELECT id, 1, name, size, mime_type, s3_bucket, s3_key, created_by, created_at FROM files;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE files DROP COLUMN IF EXISTS current_version;
+DROP TABLE IF EXISTS file_versions;
+-- +goose StatementEnd
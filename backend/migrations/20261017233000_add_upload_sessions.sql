@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Direct-to-S3 uploads. The client PUTs the content to presigned URLs (one, or one per part for
+-- multipart uploads) and completes the session, which verifies the object and creates the file,
+-- or a new version of file_id.
+CREATE TABLE upload_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    folder_id UUID REFERENCES folders(id) ON DELETE SET NULL,
+    file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL CHECK (size > 0),
+    mime_type TEXT NOT NULL,
+    sha256 TEXT NOT NULL, -- Hex digest the uploaded content must match
+    s3_bucket TEXT NOT NULL,
+    s3_key TEXT NOT NULL,
+    multipart_upload_id TEXT, -- Set for multipart uploads
+    part_size BIGINT,
+    part_count INT,
+    part_sha256s TEXT[], -- Hex digests of the parts, bound into their upload URLs
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completing', 'completed', 'failed', 'aborted', 'expired')),
+    error TEXT,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_upload_sessions_group ON upload_sessions(group_id);
+CREATE INDEX idx_upload_sessions_key ON upload_sessions(s3_key) WHERE status = 'pending';
+CREATE INDEX idx_upload_sessions_expiry ON upload_sessions(expires_at) WHERE status IN ('pending', 'completing');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS upload_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Quotas are in bytes; NULL falls back to GROUP_STORAGE_QUOTA / USER_STORAGE_QUOTA
+ALTER TABLE groups ADD COLUMN storage_quota BIGINT CHECK (storage_quota >= 0);
+ALTER TABLE groups ADD COLUMN storage_warning_sent_at TIMESTAMPTZ; -- Owners were told the group passed the warning threshold
+ALTER TABLE group_members ADD COLUMN storage_quota BIGINT CHECK (storage_quota >= 0);
+
+CREATE INDEX idx_file_versions_created_by ON file_versions(created_by);
+
+-- Storage held for uploads sent through the API between the quota check and the file being saved
+CREATE TABLE storage_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_storage_reservations_group ON storage_reservations(group_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS storage_reservations;
+DROP INDEX IF EXISTS idx_file_versions_created_by;
+ALTER TABLE group_members DROP COLUMN IF EXISTS storage_quota;
+ALTER TABLE groups DROP COLUMN IF EXISTS storage_warning_sent_at;
+ALTER TABLE groups DROP COLUMN IF EXISTS storage_quota;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleting a task, file, folder or link moves it to the group's trash. Everything deleted along
+-- with it (subtasks, a folder's contents) gets the same deleted_at so restoring brings it back too.
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE tasks ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE files ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE files ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE folders ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE folders ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE links ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE links ADD COLUMN deleted_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Trashed folders keep their path, so only live folders need unique paths
+ALTER TABLE folders DROP CONSTRAINT IF EXISTS folders_path_group_id_key;
+CREATE UNIQUE INDEX idx_folders_live_path ON folders(path, group_id) WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_tasks_trash ON tasks(group_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_files_trash ON files(group_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_folders_trash ON folders(group_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_links_trash ON links(group_id, deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Trashed rows would come back to life without the columns; they are purged instead
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+DELETE FROM files WHERE deleted_at IS NOT NULL;
+DELETE FROM folders WHERE deleted_at IS NOT NULL;
+DELETE FROM links WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_links_trash;
+DROP INDEX IF EXISTS idx_folders_trash;
+DROP INDEX IF EXISTS idx_files_trash;
+DROP INDEX IF EXISTS idx_tasks_trash;
+DROP INDEX IF EXISTS idx_folders_live_path;
+ALTER TABLE folders ADD CONSTRAINT folders_path_group_id_key UNIQUE (path, group_id);
+
+ALTER TABLE links DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE links DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE folders DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE folders DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE files DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE files DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Full-text search vectors, kept up to date by Postgres. File names and folder paths have their
+-- punctuation replaced so "q3_report.pdf" and "/docs/specs" match on each of their words.
+ALTER TABLE tasks ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', description), 'B')
+) STORED;
+
+ALTER TABLE messages ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('english', content)
+) STORED;
+
+ALTER TABLE files ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('english', regexp_replace(name, '[[:punct:]]+', ' ', 'g'))
+) STORED;
+
+ALTER TABLE folders ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    to_tsvector('english', regexp_replace(path, '[[:punct:]]+', ' ', 'g'))
+) STORED;
+
+ALTER TABLE links ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_tasks_search ON tasks USING GIN (search_vector);
+CREATE INDEX idx_messages_search ON messages USING GIN (search_vector);
+CREATE INDEX idx_files_search ON files USING GIN (search_vector);
+CREATE INDEX idx_folders_search ON folders USING GIN (search_vector);
+CREATE INDEX idx_links_search ON links USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_links_search;
+DROP INDEX IF EXISTS idx_folders_search;
+DROP INDEX IF EXISTS idx_files_search;
+DROP INDEX IF EXISTS idx_messages_search;
+DROP INDEX IF EXISTS idx_tasks_search;
+
+ALTER TABLE links DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE folders DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE files DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE messages DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
+-- +goose StatementEnd
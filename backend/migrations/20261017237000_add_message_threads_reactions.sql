@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE messages ADD COLUMN edited_at TIMESTAMPTZ;
+-- Set when a message with replies is deleted: it stays, emptied, so the replies keep their thread
+ALTER TABLE messages ADD COLUMN deleted_at TIMESTAMPTZ;
+-- Replies point at the first message of their thread; threads are one level deep
+ALTER TABLE messages ADD COLUMN parent_id UUID REFERENCES messages(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_messages_parent_created ON messages(parent_id, created_at) WHERE parent_id IS NOT NULL;
+
+CREATE TABLE message_reactions (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS message_reactions;
+DROP INDEX IF EXISTS idx_messages_parent_created;
+ALTER TABLE messages DROP COLUMN IF EXISTS parent_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
+-- +goose StatementEnd
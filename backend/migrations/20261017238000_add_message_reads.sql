@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- How far each member has read a group's chat. last_read_at is the creation time of the last read
+-- message; messages after it from other members are unread.
+CREATE TABLE message_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    last_read_message_id UUID REFERENCES messages(id) ON DELETE SET NULL,
+    last_read_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, room_id)
+);
+
+-- Existing members start with everything read rather than the whole history unread
+INSERT INTO message_reads (user_id, room_id, last_read_message_id, last_read_at)
+SELECT gm.user_id, gm.group_id, latest.id, latest.created_at
+FROM group_members gm
+JOIN LATERAL (
+    SELECT id, created_at FROM messages WHERE room_id = gm.group_id ORDER BY created_at DESC LIMIT 1
+) latest ON true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS message_reads;
+-- +goose StatementEnd
@@ -1,6 +1,7 @@
 import { useState, FormEvent } from "react";
 import { useUser } from "../../context/UserContext";
 import { apiConfig } from "../../config/api";
+import { storeSession, type SessionTokens } from "../../lib/auth";
 
 interface LoginFormProps {
   onSuccess?: () => void;
@@ -12,8 +13,7 @@ interface LoginFormData {
   password: string;
 }
 
-interface LoginResponse {
-  token: string;
+interface LoginResponse extends SessionTokens {
   id: string;
   name: string;
   email: string;
@@ -71,6 +71,7 @@ export default function LoginForm({
         email: userData.email,
         token: userData.token,
       });
+      storeSession(userData);
 
       // Fetch user data including role permissions
       await fetchUserData();
@@ -1,6 +1,7 @@
 import { useState, FormEvent } from "react";
 import { useUser } from "@/context/UserContext";
 import { apiConfig } from "@/config/api";
+import { storeSession, type SessionTokens } from "@/lib/auth";
 
 interface SignupFormProps {
   onSuccess?: () => void;
@@ -14,8 +15,7 @@ interface SignupFormData {
   password: string;
 }
 
-interface SignupResponse {
-  token: string;
+interface SignupResponse extends SessionTokens {
   id: string;
   name: string;
   email: string;
@@ -88,6 +88,7 @@ export default function SignupForm({
         email: userData.email,
         token: userData.token,
       });
+      storeSession(userData);
 
       setLoading(false);
       onSuccess?.();
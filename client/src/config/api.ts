@@ -25,6 +25,7 @@ export const apiConfig = {
     base: `${API_BASE_URL}${API_AUTH_URL}`,
     login: `${API_BASE_URL}${API_AUTH_URL}/login`,
     register: `${API_BASE_URL}${API_AUTH_URL}/register`,
+    refresh: `${API_BASE_URL}${API_AUTH_URL}/refresh`,
   },
   groups: {
     base: `${API_BASE_URL}${API_GROUP_URL}`,
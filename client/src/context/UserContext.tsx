@@ -1,5 +1,18 @@
-import React, { createContext, useContext, useState, ReactNode } from "react";
+import React, {
+  createContext,
+  useContext,
+  useEffect,
+  useState,
+  ReactNode,
+} from "react";
 import { apiConfig } from "@/config/api";
+import {
+  hasRefreshToken,
+  refreshSession,
+  tokenExpiresAt,
+  SESSION_EXPIRED_EVENT,
+  TOKEN_REFRESHED_EVENT,
+} from "@/lib/auth";
 
 export interface RolePermissions {
   [role: string]: string[]; // Maps role to array of roles it can modify
@@ -60,6 +73,48 @@ export const UserProvider: React.FC<UserProviderProps> = ({ children }) => {
     }
   };
 
+  // Keep the access token fresh: refresh shortly before it expires (so WebSocket reconnects get a
+  // valid token too) and pick up tokens refreshed by API calls. The session ending logs out.
+  useEffect(() => {
+    if (!userState) return;
+
+    let timer: number | undefined;
+    const scheduleRefresh = () => {
+      window.clearTimeout(timer);
+      const expiresAt = tokenExpiresAt();
+      if (expiresAt === null || !hasRefreshToken()) return;
+      timer = window.setTimeout(
+        () => refreshSession(),
+        Math.max(expiresAt - Date.now() - 60 * 1000, 0)
+      );
+    };
+
+    const handleRefreshed = (event: Event) => {
+      const token = (event as CustomEvent<string>).detail;
+      setUserState((current) => {
+        if (!current) return current;
+        const updatedUser = { ...current, token };
+        localStorage.setItem("user", JSON.stringify(updatedUser));
+        return updatedUser;
+      });
+      scheduleRefresh();
+    };
+
+    const handleExpired = () => {
+      setUser(null);
+      localStorage.clear();
+    };
+
+    scheduleRefresh();
+    window.addEventListener(TOKEN_REFRESHED_EVENT, handleRefreshed);
+    window.addEventListener(SESSION_EXPIRED_EVENT, handleExpired);
+    return () => {
+      window.clearTimeout(timer);
+      window.removeEventListener(TOKEN_REFRESHED_EVENT, handleRefreshed);
+      window.removeEventListener(SESSION_EXPIRED_EVENT, handleExpired);
+    };
+  }, [userState?.id]);
+
   const logout = async () => {
     const token = localStorage.getItem("token");
 
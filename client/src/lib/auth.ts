@@ -0,0 +1,136 @@
+import { apiConfig } from "@/config/api";
+
+// Access tokens expire after a few minutes; the refresh token (which rotates on every use) gets a
+// new one. Tokens live in localStorage next to "token", which the rest of the app already reads.
+const REFRESH_TOKEN_KEY = "refresh_token";
+const TOKEN_EXPIRES_AT_KEY = "token_expires_at";
+
+// Refresh this long before the access token expires
+const REFRESH_MARGIN_MS = 60 * 1000;
+
+// Fired on window when the access token changed (detail: the new token) or the session is gone
+export const TOKEN_REFRESHED_EVENT = "auth:token-refreshed";
+export const SESSION_EXPIRED_EVENT = "auth:session-expired";
+
+export interface SessionTokens {
+  token: string;
+  expires_in: number; // Seconds
+  refresh_token: string;
+}
+
+// storeSession saves the tokens returned by login, register and refresh
+export function storeSession(tokens: SessionTokens) {
+  localStorage.setItem("token", tokens.token);
+  localStorage.setItem(REFRESH_TOKEN_KEY, tokens.refresh_token);
+  localStorage.setItem(
+    TOKEN_EXPIRES_AT_KEY,
+    String(Date.now() + tokens.expires_in * 1000)
+  );
+}
+
+// tokenExpiresAt returns when the access token expires (ms since epoch), or null when unknown
+export function tokenExpiresAt(): number | null {
+  const value = Number(localStorage.getItem(TOKEN_EXPIRES_AT_KEY));
+  return value > 0 ? value : null;
+}
+
+export function hasRefreshToken(): boolean {
+  return !!localStorage.getItem(REFRESH_TOKEN_KEY);
+}
+
+const originalFetch = window.fetch.bind(window);
+let pendingRefresh: Promise<string | null> | null = null;
+
+// refreshSession trades the refresh token for new tokens. Concurrent callers share one request,
+// since a refresh token can only be used once. Resolves to the new access token, or null when the
+// session is over (the user has to log in again).
+export function refreshSession(): Promise<string | null> {
+  if (!pendingRefresh) {
+    pendingRefresh = doRefresh().finally(() => {
+      pendingRefresh = null;
+    });
+  }
+  return pendingRefresh;
+}
+
+async function doRefresh(): Promise<string | null> {
+  const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+  if (!refreshToken) return null;
+
+  let response: Response;
+  try {
+    response = await originalFetch(apiConfig.auth.refresh, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ refresh_token: refreshToken }),
+    });
+  } catch (err) {
+    // Offline: keep the session, the next request tries again
+    console.error("Token refresh failed:", err);
+    return null;
+  }
+
+  if (!response.ok) {
+    if (response.status === 401) {
+      window.dispatchEvent(new Event(SESSION_EXPIRED_EVENT));
+    }
+    return null;
+  }
+
+  const tokens: SessionTokens = await response.json();
+  storeSession(tokens);
+  window.dispatchEvent(
+    new CustomEvent(TOKEN_REFRESHED_EVENT, { detail: tokens.token })
+  );
+  return tokens.token;
+}
+
+function isApiRequest(url: string): boolean {
+  if (url.startsWith(apiConfig.auth.base)) return false; // Login, register and refresh itself
+  if (apiConfig.baseURL) return url.startsWith(apiConfig.baseURL);
+  return (
+    url.startsWith("/api/") ||
+    url.startsWith(`${window.location.origin}/api/`)
+  );
+}
+
+// withToken returns the request options with the Authorization header swapped for the token
+function withToken(init: RequestInit | undefined, token: string): RequestInit {
+  const headers = new Headers(init?.headers);
+  headers.set("Authorization", `Bearer ${token}`);
+  return { ...init, headers };
+}
+
+// installAuthFetch wraps window.fetch so API calls keep working when the access token expires:
+// tokens about to expire are refreshed first, and a 401 is retried once after refreshing.
+export function installAuthFetch() {
+  window.fetch = async (input: RequestInfo | URL, init?: RequestInit) => {
+    // Components call fetch with a URL and headers in init; Request objects are passed through
+    if (input instanceof Request) return originalFetch(input, init);
+
+    const url = input instanceof URL ? input.href : input;
+    const sentToken = new Headers(init?.headers)
+      .get("Authorization")
+      ?.replace(/^Bearer\s+/i, "");
+    if (!sentToken || !isApiRequest(url) || !hasRefreshToken()) {
+      return originalFetch(input, init);
+    }
+
+    const expiresAt = tokenExpiresAt();
+    if (expiresAt !== null && expiresAt - Date.now() < REFRESH_MARGIN_MS) {
+      const token = await refreshSession();
+      if (token) init = withToken(init, token);
+    }
+
+    const response = await originalFetch(input, init);
+    if (response.status !== 401) return response;
+
+    // Another request may have refreshed while this one was built with the old token
+    const currentToken = localStorage.getItem("token");
+    const token =
+      currentToken && currentToken !== sentToken
+        ? currentToken
+        : await refreshSession();
+    return token ? originalFetch(input, withToken(init, token)) : response;
+  };
+}
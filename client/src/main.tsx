@@ -1,4 +1,7 @@
 import { createRoot } from "react-dom/client";
 import App from "./App.tsx";
+import { installAuthFetch } from "./lib/auth";
+
+installAuthFetch();
 
 createRoot(document.getElementById("root")!).render(<App />);